@@ -43,6 +43,7 @@ import (
 	cmdcom "github.com/imZhuFei/zeepin/cmd/common"
 	"github.com/imZhuFei/zeepin/cmd/utils"
 	"github.com/imZhuFei/zeepin/common/config"
+	httpcom "github.com/imZhuFei/zeepin/http/base/common"
 	nutils "github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
 	"github.com/urfave/cli"
 )
@@ -55,13 +56,16 @@ var AssetCommand = cli.Command{
 		{
 			Action:      transfer,
 			Name:        "transfer",
-			Usage:       "Transfer zpt or gala to another account",
+			Usage:       "Transfer zpt, gala or a standard token to another account",
 			ArgsUsage:   " ",
-			Description: "Transfer zpt or gala to another account. If from address does not specified, using default account",
+			Description: "Transfer zpt, gala or a standard token to another account. A token is specified by its contract address. If from address does not specified, using default account",
 			Flags: []cli.Flag{
 				utils.RPCPortFlag,
 				utils.TransactionGasPriceFlag,
 				utils.TransactionGasLimitFlag,
+				utils.TransactionNotBeforeFlag,
+				utils.TransactionExpireHeightFlag,
+				utils.TransactionValidForFlag,
 				utils.TransactionAssetFlag,
 				utils.TransactionFromFlag,
 				utils.TransactionToFlag,
@@ -78,6 +82,9 @@ var AssetCommand = cli.Command{
 				utils.RPCPortFlag,
 				utils.TransactionGasPriceFlag,
 				utils.TransactionGasLimitFlag,
+				utils.TransactionNotBeforeFlag,
+				utils.TransactionExpireHeightFlag,
+				utils.TransactionValidForFlag,
 				utils.ApproveAssetFlag,
 				utils.ApproveAssetFromFlag,
 				utils.ApproveAssetToFlag,
@@ -94,6 +101,9 @@ var AssetCommand = cli.Command{
 				utils.RPCPortFlag,
 				utils.TransactionGasPriceFlag,
 				utils.TransactionGasLimitFlag,
+				utils.TransactionNotBeforeFlag,
+				utils.TransactionExpireHeightFlag,
+				utils.TransactionValidForFlag,
 				utils.ApproveAssetFlag,
 				utils.TransferFromSenderFlag,
 				utils.ApproveAssetFromFlag,
@@ -143,6 +153,9 @@ var AssetCommand = cli.Command{
 				utils.RPCPortFlag,
 				utils.TransactionGasPriceFlag,
 				utils.TransactionGasLimitFlag,
+				utils.TransactionNotBeforeFlag,
+				utils.TransactionExpireHeightFlag,
+				utils.TransactionValidForFlag,
 				utils.WalletFileFlag,
 			},
 		},
@@ -151,6 +164,9 @@ var AssetCommand = cli.Command{
 
 func transfer(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	if !ctx.IsSet(utils.GetFlagName(utils.TransactionToFlag)) ||
 		!ctx.IsSet(utils.GetFlagName(utils.TransactionFromFlag)) ||
 		!ctx.IsSet(utils.GetFlagName(utils.TransactionAmountFlag)) {
@@ -175,19 +191,26 @@ func transfer(ctx *cli.Context) error {
 	}
 
 	var amount uint64
+	var token *httpcom.TokenInfo
 	amountStr := ctx.String(utils.TransactionAmountFlag.Name)
 	switch strings.ToLower(asset) {
 	case "zpt":
 		amount = utils.ParseZpt(amountStr)
 		amountStr = utils.FormatZpt(amount)
+		err = utils.CheckAssetAmount(asset, amount)
 	case "gala":
 		amount = utils.ParseGala(amountStr)
 		amountStr = utils.FormatGala(amount)
+		err = utils.CheckAssetAmount(asset, amount)
 	default:
-		return fmt.Errorf("unsupport asset:%s", asset)
+		token, err = utils.GetTokenInfo(asset)
+		if err != nil {
+			return fmt.Errorf("unsupport asset:%s, %s", asset, err)
+		}
+		amount = utils.ParseAssetAmount(amountStr, byte(token.Decimals))
+		amountStr = utils.FormatAssetAmount(amount, int(token.Decimals))
+		err = utils.CheckTokenAmount(token, amount)
 	}
-
-	err = utils.CheckAssetAmount(asset, amount)
 	if err != nil {
 		return err
 	}
@@ -208,7 +231,13 @@ func transfer(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("GetAccount error:%s", err)
 	}
-	txHash, err := utils.Transfer(gasPrice, gasLimit, signer, asset, fromAddr, toAddr, amount)
+	var txHash string
+	if token != nil {
+		txHash, err = utils.TransferToken(gasPrice, gasLimit, signer, token, toAddr, amount)
+		asset = token.Symbol
+	} else {
+		txHash, err = utils.Transfer(gasPrice, gasLimit, signer, asset, fromAddr, toAddr, amount)
+	}
 	if err != nil {
 		return fmt.Errorf("Transfer error:%s", err)
 	}
@@ -304,6 +333,9 @@ func getAllowance(ctx *cli.Context) error {
 
 func approve(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	asset := ctx.String(utils.GetFlagName(utils.ApproveAssetFlag))
 	from := ctx.String(utils.GetFlagName(utils.ApproveAssetFromFlag))
 	to := ctx.String(utils.GetFlagName(utils.ApproveAssetToFlag))
@@ -375,6 +407,9 @@ func approve(ctx *cli.Context) error {
 
 func transferFrom(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	asset := ctx.String(utils.GetFlagName(utils.ApproveAssetFlag))
 	from := ctx.String(utils.GetFlagName(utils.ApproveAssetFromFlag))
 	to := ctx.String(utils.GetFlagName(utils.ApproveAssetToFlag))
@@ -487,6 +522,9 @@ func unboundGala(ctx *cli.Context) error {
 
 func withdrawGala(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	if ctx.NArg() < 1 {
 		fmt.Println("Missing argument. Account address, label or index expected.\n")
 		cli.ShowSubcommandHelp(ctx)
@@ -36,6 +36,7 @@ package cmd
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/imZhuFei/zeepin/cmd/utils"
 	"github.com/imZhuFei/zeepin/common"
@@ -45,11 +46,52 @@ import (
 	"github.com/urfave/cli"
 )
 
+var reloadLock sync.Mutex
+
 func SetZeepinChainConfig(ctx *cli.Context) (*config.ZeepinChainConfig, error) {
 	cfg := config.DefConfig
+	err := setZeepinChainConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+//ReloadZeepinChainConfig rebuilds the config from the node config file and the command line flags
+//and applies the settings which can change on the running node to config.DefConfig
+func ReloadZeepinChainConfig(ctx *cli.Context) (*config.ReloadResult, error) {
+	if !ctx.GlobalIsSet(utils.GetFlagName(utils.NodeConfigFlag)) {
+		return nil, fmt.Errorf("no node config file to reload, start with --%s", utils.GetFlagName(utils.NodeConfigFlag))
+	}
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	newCfg := config.NewZeepinChainConfig()
+	err := setZeepinChainConfig(ctx, newCfg)
+	if err != nil {
+		return nil, err
+	}
+	return config.DefConfig.Reload(newCfg), nil
+}
+
+//useFlag reports whether a flag sets its config value, with a node config file only the
+//flags given on the command line override the file
+func useFlag(ctx *cli.Context, flag cli.Flag) bool {
+	return !ctx.GlobalIsSet(utils.GetFlagName(utils.NodeConfigFlag)) || ctx.GlobalIsSet(utils.GetFlagName(flag))
+}
+
+func setZeepinChainConfig(ctx *cli.Context, cfg *config.ZeepinChainConfig) error {
+	if ctx.GlobalIsSet(utils.GetFlagName(utils.NodeConfigFlag)) {
+		nodeCfgFile := ctx.GlobalString(utils.GetFlagName(utils.NodeConfigFlag))
+		err := config.LoadNodeConfigFile(nodeCfgFile, cfg)
+		if err != nil {
+			return fmt.Errorf("LoadNodeConfigFile error:%s", err)
+		}
+		log.Infof("Load node config:%s", nodeCfgFile)
+	}
 	err := setGenesis(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("setGenesis error:%s", err)
+		return fmt.Errorf("setGenesis error:%s", err)
 	}
 	setCommonConfig(ctx, cfg.Common)
 	setConsensusConfig(ctx, cfg.Consensus)
@@ -57,6 +99,13 @@ func SetZeepinChainConfig(ctx *cli.Context) (*config.ZeepinChainConfig, error) {
 	setRpcConfig(ctx, cfg.Rpc)
 	setRestfulConfig(ctx, cfg.Restful)
 	setWebSocketConfig(ctx, cfg.Ws)
+	setMetricsConfig(ctx, cfg.Metrics)
+	if _, _, err := log.ParseModuleLevels(cfg.Common.LogModules); err != nil {
+		return fmt.Errorf("LogModules error:%s", err)
+	}
+	if cfg.Common.LogFormat != log.FORMAT_TEXT && cfg.Common.LogFormat != log.FORMAT_JSON {
+		return fmt.Errorf("invalid LogFormat %s", cfg.Common.LogFormat)
+	}
 	if cfg.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		cfg.Ws.EnableHttpWs = true
 		cfg.Restful.EnableHttpRestful = true
@@ -70,7 +119,7 @@ func SetZeepinChainConfig(ctx *cli.Context) (*config.ZeepinChainConfig, error) {
 		cfg.P2PNode.NetworkId == config.NETWORK_ID_POLARIS_NET {
 		defNetworkId, err := cfg.GetDefaultNetworkId()
 		if err != nil {
-			return nil, fmt.Errorf("GetDefaultNetworkId error:%s", err)
+			return fmt.Errorf("GetDefaultNetworkId error:%s", err)
 		}
 		if defNetworkId != cfg.P2PNode.NetworkId {
 			cfg.P2PNode.NetworkId = defNetworkId
@@ -78,11 +127,14 @@ func SetZeepinChainConfig(ctx *cli.Context) (*config.ZeepinChainConfig, error) {
 			cfg.P2PNode.NetworkName = config.GetNetworkName(defNetworkId)
 		}
 	}
-	return cfg, nil
+	return nil
 }
 
 func setGenesis(ctx *cli.Context, cfg *config.ZeepinChainConfig) error {
 	netWorkId := ctx.GlobalInt(utils.GetFlagName(utils.NetworkIdFlag))
+	if !useFlag(ctx, utils.NetworkIdFlag) {
+		netWorkId = int(cfg.P2PNode.NetworkId)
+	}
 	switch netWorkId {
 	case config.NETWORK_ID_MAIN_NET:
 		cfg.Genesis = config.MainNetConfig
@@ -132,6 +184,10 @@ func setGenesis(ctx *cli.Context, cfg *config.ZeepinChainConfig) error {
 		if len(cfg.Genesis.GBFT.Peers) < config.VBFT_MIN_NODE_NUM {
 			return fmt.Errorf("GBFT consensus at least need %d peers in config", config.VBFT_MIN_NODE_NUM)
 		}
+	case config.CONSENSUS_TYPE_SOLO:
+		if cfg.Genesis.SOLO.GenBlockTime < config.MIN_GEN_BLOCK_TIME {
+			cfg.Genesis.SOLO.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
+		}
 	default:
 		return fmt.Errorf("Unknow consensus:%s", cfg.Genesis.ConsensusType)
 	}
@@ -140,32 +196,84 @@ func setGenesis(ctx *cli.Context, cfg *config.ZeepinChainConfig) error {
 }
 
 func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
-	cfg.LogLevel = ctx.GlobalUint(utils.GetFlagName(utils.LogLevelFlag))
-	cfg.EnableEventLog = !ctx.GlobalBool(utils.GetFlagName(utils.DisableEventLogFlag))
-	cfg.GasLimit = ctx.GlobalUint64(utils.GetFlagName(utils.GasLimitFlag))
-	cfg.GasPrice = ctx.GlobalUint64(utils.GetFlagName(utils.GasPriceFlag))
-	cfg.DataDir = ctx.GlobalString(utils.GetFlagName(utils.DataDirFlag))
+	if useFlag(ctx, utils.LogLevelFlag) {
+		cfg.LogLevel = ctx.GlobalUint(utils.GetFlagName(utils.LogLevelFlag))
+	}
+	if useFlag(ctx, utils.LogModulesFlag) {
+		cfg.LogModules = ctx.GlobalString(utils.GetFlagName(utils.LogModulesFlag))
+	}
+	if useFlag(ctx, utils.LogFormatFlag) {
+		cfg.LogFormat = ctx.GlobalString(utils.GetFlagName(utils.LogFormatFlag))
+	}
+	if useFlag(ctx, utils.LogMaxSizeFlag) {
+		cfg.MaxLogSize = ctx.GlobalUint(utils.GetFlagName(utils.LogMaxSizeFlag))
+	}
+	if useFlag(ctx, utils.LogMaxAgeFlag) {
+		cfg.MaxLogAge = ctx.GlobalUint(utils.GetFlagName(utils.LogMaxAgeFlag))
+	}
+	if useFlag(ctx, utils.DisableEventLogFlag) {
+		cfg.EnableEventLog = !ctx.GlobalBool(utils.GetFlagName(utils.DisableEventLogFlag))
+	}
+	if useFlag(ctx, utils.GasLimitFlag) {
+		cfg.GasLimit = ctx.GlobalUint64(utils.GetFlagName(utils.GasLimitFlag))
+	}
+	if useFlag(ctx, utils.GasPriceFlag) {
+		cfg.GasPrice = ctx.GlobalUint64(utils.GetFlagName(utils.GasPriceFlag))
+	}
+	if useFlag(ctx, utils.DataDirFlag) {
+		cfg.DataDir = ctx.GlobalString(utils.GetFlagName(utils.DataDirFlag))
+	}
 }
 
 func setConsensusConfig(ctx *cli.Context, cfg *config.ConsensusConfig) {
-	cfg.EnableConsensus = ctx.GlobalBool(utils.GetFlagName(utils.EnableConsensusFlag))
-	cfg.MaxTxInBlock = ctx.GlobalUint(utils.GetFlagName(utils.MaxTxInBlockFlag))
+	if useFlag(ctx, utils.EnableConsensusFlag) {
+		cfg.EnableConsensus = ctx.GlobalBool(utils.GetFlagName(utils.EnableConsensusFlag))
+	}
+	if useFlag(ctx, utils.MaxTxInBlockFlag) {
+		cfg.MaxTxInBlock = ctx.GlobalUint(utils.GetFlagName(utils.MaxTxInBlockFlag))
+	}
 }
 
 func setP2PNodeConfig(ctx *cli.Context, cfg *config.P2PNodeConfig) {
-	cfg.NetworkId = uint32(ctx.GlobalUint(utils.GetFlagName(utils.NetworkIdFlag)))
+	if useFlag(ctx, utils.NetworkIdFlag) {
+		cfg.NetworkId = uint32(ctx.GlobalUint(utils.GetFlagName(utils.NetworkIdFlag)))
+	}
 	cfg.NetworkMagic = config.GetNetworkMagic(cfg.NetworkId)
 	cfg.NetworkName = config.GetNetworkName(cfg.NetworkId)
-	cfg.NodePort = ctx.GlobalUint(utils.GetFlagName(utils.NodePortFlag))
-	cfg.NodeConsensusPort = ctx.GlobalUint(utils.GetFlagName(utils.ConsensusPortFlag))
-	cfg.DualPortSupport = ctx.GlobalBool(utils.GetFlagName(utils.DualPortSupportFlag))
-	cfg.ReservedPeersOnly = ctx.GlobalBool(utils.GetFlagName(utils.ReservedPeersOnlyFlag))
-	cfg.MaxConnInBound = ctx.GlobalUint(utils.GetFlagName(utils.MaxConnInBoundFlag))
-	cfg.MaxConnOutBound = ctx.GlobalUint(utils.GetFlagName(utils.MaxConnOutBoundFlag))
-	cfg.MaxConnInBoundForSingleIP = ctx.GlobalUint(utils.GetFlagName(utils.MaxConnInBoundForSingleIPFlag))
+	if useFlag(ctx, utils.NodePortFlag) {
+		cfg.NodePort = ctx.GlobalUint(utils.GetFlagName(utils.NodePortFlag))
+	}
+	if useFlag(ctx, utils.ConsensusPortFlag) {
+		cfg.NodeConsensusPort = ctx.GlobalUint(utils.GetFlagName(utils.ConsensusPortFlag))
+	}
+	if useFlag(ctx, utils.DualPortSupportFlag) {
+		cfg.DualPortSupport = ctx.GlobalBool(utils.GetFlagName(utils.DualPortSupportFlag))
+	}
+	if useFlag(ctx, utils.DisableP2PCompressionFlag) {
+		cfg.DisableCompression = ctx.GlobalBool(utils.GetFlagName(utils.DisableP2PCompressionFlag))
+	}
+	if useFlag(ctx, utils.EnableP2PEncryptionFlag) {
+		cfg.EnableEncryption = ctx.GlobalBool(utils.GetFlagName(utils.EnableP2PEncryptionFlag))
+	}
+	if useFlag(ctx, utils.DisableCompactBlockFlag) {
+		cfg.DisableCompactBlock = ctx.GlobalBool(utils.GetFlagName(utils.DisableCompactBlockFlag))
+	}
+	if useFlag(ctx, utils.ReservedPeersOnlyFlag) {
+		cfg.ReservedPeersOnly = ctx.GlobalBool(utils.GetFlagName(utils.ReservedPeersOnlyFlag))
+	}
+	if useFlag(ctx, utils.MaxConnInBoundFlag) {
+		cfg.MaxConnInBound = ctx.GlobalUint(utils.GetFlagName(utils.MaxConnInBoundFlag))
+	}
+	if useFlag(ctx, utils.MaxConnOutBoundFlag) {
+		cfg.MaxConnOutBound = ctx.GlobalUint(utils.GetFlagName(utils.MaxConnOutBoundFlag))
+	}
+	if useFlag(ctx, utils.MaxConnInBoundForSingleIPFlag) {
+		cfg.MaxConnInBoundForSingleIP = ctx.GlobalUint(utils.GetFlagName(utils.MaxConnInBoundForSingleIPFlag))
+	}
 
+	//the reserved peers of a node config file are kept unless the reserved peers file is given
 	rsvfile := ctx.GlobalString(utils.GetFlagName(utils.ReservedPeersFileFlag))
-	if cfg.ReservedPeersOnly {
+	if cfg.ReservedPeersOnly && useFlag(ctx, utils.ReservedPeersFileFlag) {
 		if !common.FileExisted(rsvfile) {
 			log.Infof("file %s not exist\n", rsvfile)
 			return
@@ -186,19 +294,45 @@ func setP2PNodeConfig(ctx *cli.Context, cfg *config.P2PNodeConfig) {
 }
 
 func setRpcConfig(ctx *cli.Context, cfg *config.RpcConfig) {
-	cfg.EnableHttpJsonRpc = !ctx.Bool(utils.GetFlagName(utils.RPCDisabledFlag))
-	cfg.HttpJsonPort = ctx.GlobalUint(utils.GetFlagName(utils.RPCPortFlag))
-	cfg.HttpLocalPort = ctx.GlobalUint(utils.GetFlagName(utils.RPCLocalProtFlag))
+	if useFlag(ctx, utils.RPCDisabledFlag) {
+		cfg.EnableHttpJsonRpc = !ctx.Bool(utils.GetFlagName(utils.RPCDisabledFlag))
+	}
+	if useFlag(ctx, utils.RPCPortFlag) {
+		cfg.HttpJsonPort = ctx.GlobalUint(utils.GetFlagName(utils.RPCPortFlag))
+	}
+	if useFlag(ctx, utils.RPCLocalProtFlag) {
+		cfg.HttpLocalPort = ctx.GlobalUint(utils.GetFlagName(utils.RPCLocalProtFlag))
+	}
+	if useFlag(ctx, utils.RPCDebugTraceFlag) {
+		cfg.EnableDebugTrace = ctx.GlobalBool(utils.GetFlagName(utils.RPCDebugTraceFlag))
+	}
 }
 
 func setRestfulConfig(ctx *cli.Context, cfg *config.RestfulConfig) {
-	cfg.EnableHttpRestful = ctx.GlobalBool(utils.GetFlagName(utils.RestfulEnableFlag))
-	cfg.HttpRestPort = ctx.GlobalUint(utils.GetFlagName(utils.RestfulPortFlag))
+	if useFlag(ctx, utils.RestfulEnableFlag) {
+		cfg.EnableHttpRestful = ctx.GlobalBool(utils.GetFlagName(utils.RestfulEnableFlag))
+	}
+	if useFlag(ctx, utils.RestfulPortFlag) {
+		cfg.HttpRestPort = ctx.GlobalUint(utils.GetFlagName(utils.RestfulPortFlag))
+	}
 }
 
 func setWebSocketConfig(ctx *cli.Context, cfg *config.WebSocketConfig) {
-	cfg.EnableHttpWs = ctx.GlobalBool(utils.GetFlagName(utils.WsEnabledFlag))
-	cfg.HttpWsPort = ctx.GlobalUint(utils.GetFlagName(utils.WsPortFlag))
+	if useFlag(ctx, utils.WsEnabledFlag) {
+		cfg.EnableHttpWs = ctx.GlobalBool(utils.GetFlagName(utils.WsEnabledFlag))
+	}
+	if useFlag(ctx, utils.WsPortFlag) {
+		cfg.HttpWsPort = ctx.GlobalUint(utils.GetFlagName(utils.WsPortFlag))
+	}
+}
+
+func setMetricsConfig(ctx *cli.Context, cfg *config.MetricsConfig) {
+	if useFlag(ctx, utils.MetricsEnableFlag) {
+		cfg.EnableMetrics = ctx.GlobalBool(utils.GetFlagName(utils.MetricsEnableFlag))
+	}
+	if useFlag(ctx, utils.MetricsPortFlag) {
+		cfg.MetricsPort = ctx.GlobalUint(utils.GetFlagName(utils.MetricsPortFlag))
+	}
 }
 
 func SetRpcPort(ctx *cli.Context) {
@@ -206,3 +340,39 @@ func SetRpcPort(ctx *cli.Context) {
 		config.DefConfig.Rpc.HttpJsonPort = ctx.Uint(utils.GetFlagName(utils.RPCPortFlag))
 	}
 }
+
+//SetTxValidityWindow sets the validity window of the transactions built by the command,
+//validfor is resolved against the current block count so it must run after SetRpcPort
+func SetTxValidityWindow(ctx *cli.Context) error {
+	expireSet := ctx.IsSet(utils.GetFlagName(utils.TransactionExpireHeightFlag))
+	validForSet := ctx.IsSet(utils.GetFlagName(utils.TransactionValidForFlag))
+	notBefore := uint32(ctx.Uint(utils.GetFlagName(utils.TransactionNotBeforeFlag)))
+	if !expireSet && !validForSet {
+		if notBefore != 0 {
+			return fmt.Errorf("notbefore requires expireheight or validfor")
+		}
+		return nil
+	}
+	if expireSet && validForSet {
+		return fmt.Errorf("expireheight and validfor can not be used together")
+	}
+	expireHeight := uint32(ctx.Uint(utils.GetFlagName(utils.TransactionExpireHeightFlag)))
+	if validForSet {
+		validFor := uint32(ctx.Uint(utils.GetFlagName(utils.TransactionValidForFlag)))
+		if validFor == 0 {
+			return fmt.Errorf("validfor must be greater than 0")
+		}
+		//the next block has the height of the current block count
+		count, err := utils.GetBlockCount()
+		if err != nil {
+			return fmt.Errorf("GetBlockCount error:%s", err)
+		}
+		expireHeight = count + validFor - 1
+	}
+	if expireHeight == 0 || notBefore > expireHeight {
+		return fmt.Errorf("invalid validity window [%d, %d]", notBefore, expireHeight)
+	}
+	utils.TxValidityWindow.NotBefore = notBefore
+	utils.TxValidityWindow.ExpireHeight = expireHeight
+	return nil
+}
@@ -46,6 +46,7 @@ import (
 	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/core/types"
 	httpcom "github.com/imZhuFei/zeepin/http/base/common"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
 	"github.com/imZhuFei/zeepin/smartcontract/service/wasmvm"
 	cstates "github.com/imZhuFei/zeepin/smartcontract/states"
 	"github.com/urfave/cli"
@@ -68,6 +69,9 @@ var (
 					utils.RPCPortFlag,
 					utils.TransactionGasPriceFlag,
 					utils.TransactionGasLimitFlag,
+					utils.TransactionNotBeforeFlag,
+					utils.TransactionExpireHeightFlag,
+					utils.TransactionValidForFlag,
 					utils.ContractStorageFlag,
 					utils.ContractCodeFileFlag,
 					utils.ContractNameFlag,
@@ -75,6 +79,7 @@ var (
 					utils.ContractAuthorFlag,
 					utils.ContractEmailFlag,
 					utils.ContractDescFlag,
+					utils.ContractEventAbiFlag,
 					utils.ContractPrepareDeployFlag,
 					utils.WalletFileFlag,
 					utils.AccountAddressFlag,
@@ -90,6 +95,9 @@ var (
 					utils.RPCPortFlag,
 					utils.TransactionGasPriceFlag,
 					utils.TransactionGasLimitFlag,
+					utils.TransactionNotBeforeFlag,
+					utils.TransactionExpireHeightFlag,
+					utils.TransactionValidForFlag,
 					utils.ContractAddrFlag,
 					utils.ContractParamsFlag,
 					utils.ContractAttrFlag,
@@ -112,6 +120,9 @@ var (
 					utils.ContractCodeFileFlag,
 					utils.TransactionGasPriceFlag,
 					utils.TransactionGasLimitFlag,
+					utils.TransactionNotBeforeFlag,
+					utils.TransactionExpireHeightFlag,
+					utils.TransactionValidForFlag,
 					utils.WalletFileFlag,
 					utils.ContractPrepareInvokeFlag,
 					utils.AccountAddressFlag,
@@ -123,6 +134,9 @@ var (
 
 func deployContract(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	if !ctx.IsSet(utils.GetFlagName(utils.ContractCodeFileFlag)) ||
 		!ctx.IsSet(utils.GetFlagName(utils.ContractNameFlag)) {
 		fmt.Errorf("Missing code or name argument\n")
@@ -159,8 +173,19 @@ func deployContract(ctx *cli.Context) error {
 
 	cversion := fmt.Sprintf("%s", version)
 
+	var eventAbi []byte
+	if abiFile := ctx.String(utils.GetFlagName(utils.ContractEventAbiFlag)); abiFile != "" {
+		eventAbi, err = ioutil.ReadFile(abiFile)
+		if err != nil {
+			return fmt.Errorf("Read event abi:%s error:%s", abiFile, err)
+		}
+		if _, err = event.ParseEventAbi(eventAbi); err != nil {
+			return fmt.Errorf("Event abi:%s error:%s", abiFile, err)
+		}
+	}
+
 	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareDeployFlag)) {
-		preResult, err := utils.PrepareDeployContract(store, code, name, cversion, author, email, desc, cattr)
+		preResult, err := utils.PrepareDeployContract(store, code, name, cversion, author, email, desc, cattr, eventAbi)
 		if err != nil {
 			return fmt.Errorf("PrepareDeployContract error:%s", err)
 		}
@@ -177,7 +202,7 @@ func deployContract(ctx *cli.Context) error {
 		return fmt.Errorf("Get signer account error:%s", err)
 	}
 
-	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, store, code, name, cversion, author, email, desc, cattr)
+	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, store, code, name, cversion, author, email, desc, cattr, eventAbi)
 	if err != nil {
 		return fmt.Errorf("DeployContract error:%s", err)
 	}
@@ -198,6 +223,9 @@ func deployContract(ctx *cli.Context) error {
 
 func invokeCodeContract(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	if !ctx.IsSet(utils.GetFlagName(utils.ContractCodeFileFlag)) {
 		fmt.Printf("Missing code or name argument\n")
 		cli.ShowSubcommandHelp(ctx)
@@ -298,6 +326,9 @@ func invokeCodeContract(ctx *cli.Context) error {
 
 func invokeContract(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if err := SetTxValidityWindow(ctx); err != nil {
+		return err
+	}
 	if !ctx.IsSet(utils.GetFlagName(utils.ContractAddrFlag)) {
 		fmt.Printf("Missing contract address argument.\n")
 		cli.ShowSubcommandHelp(ctx)
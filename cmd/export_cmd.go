@@ -43,7 +43,6 @@ import (
 	"github.com/gosuri/uiprogress"
 	"github.com/imZhuFei/zeepin/cmd/utils"
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/urfave/cli"
 )
 
@@ -56,6 +55,9 @@ var ExportCommand = cli.Command{
 		utils.RPCPortFlag,
 		utils.ExportFileFlag,
 		utils.ExportHeightFlag,
+		utils.ExportStartHeightFlag,
+		utils.ExportEndHeightFlag,
+		utils.ExportCompressFlag,
 		utils.ExportSpeedFlag,
 	},
 	Description: "",
@@ -73,6 +75,14 @@ func exportBlocks(ctx *cli.Context) error {
 		return fmt.Errorf("File:%s has already exist", exportFile)
 	}
 	endHeight := ctx.Uint(utils.GetFlagName(utils.ExportHeightFlag))
+	if ctx.IsSet(utils.GetFlagName(utils.ExportEndHeightFlag)) {
+		endHeight = ctx.Uint(utils.GetFlagName(utils.ExportEndHeightFlag))
+	}
+	startHeight := ctx.Uint(utils.GetFlagName(utils.ExportStartHeightFlag))
+	compressType, err := utils.ParseCompressType(ctx.String(utils.GetFlagName(utils.ExportCompressFlag)))
+	if err != nil {
+		return err
+	}
 	blockCount, err := utils.GetBlockCount()
 	if err != nil {
 		return fmt.Errorf("GetBlockCount error:%s", err)
@@ -80,6 +90,9 @@ func exportBlocks(ctx *cli.Context) error {
 	if endHeight == 0 || endHeight >= uint(blockCount) {
 		endHeight = uint(blockCount) - 1
 	}
+	if startHeight > endHeight {
+		return fmt.Errorf("start height:%d is above end height:%d", startHeight, endHeight)
+	}
 	speed := ctx.String(utils.GetFlagName(utils.ExportSpeedFlag))
 	var sleepTime time.Duration
 	switch speed {
@@ -99,36 +112,32 @@ func exportBlocks(ctx *cli.Context) error {
 	fWriter := bufio.NewWriter(ef)
 
 	metadata := utils.NewExportBlockMetadata()
+	metadata.CompressType = compressType
+	metadata.StartHeight = uint32(startHeight)
 	metadata.BlockHeight = uint32(endHeight)
 	err = metadata.Serialize(fWriter)
 	if err != nil {
 		return fmt.Errorf("Write export metadata error:%s", err)
 	}
+	chunkWriter := utils.NewExportChunkWriter(fWriter, metadata)
+	total := int(endHeight - startHeight + 1)
 
 	//progress bar
 	uiprogress.Start()
-	bar := uiprogress.AddBar(int(endHeight)).
+	bar := uiprogress.AddBar(total).
 		AppendCompleted().
 		AppendElapsed().
 		PrependFunc(func(b *uiprogress.Bar) string {
-			return fmt.Sprintf("Block(%d/%d)", b.Current(), int(endHeight))
+			return fmt.Sprintf("Block(%d/%d)", b.Current(), total)
 		})
 
 	fmt.Printf("Start export.\n")
-	for i := uint32(0); i <= uint32(endHeight); i++ {
+	for i := uint32(startHeight); i <= uint32(endHeight); i++ {
 		blockData, err := utils.GetBlockData(i)
 		if err != nil {
 			return fmt.Errorf("Get block:%d error:%s", i, err)
 		}
-		data, err := utils.CompressBlockData(blockData, metadata.CompressType)
-		if err != nil {
-			return fmt.Errorf("Compress block height:%d error:%s", i, err)
-		}
-		err = serialization.WriteUint32(fWriter, uint32(len(data)))
-		if err != nil {
-			return fmt.Errorf("write block data height:%d len:%d error:%s", i, uint32(len(data)), err)
-		}
-		_, err = fWriter.Write(data)
+		err = chunkWriter.WriteBlock(blockData)
 		if err != nil {
 			return fmt.Errorf("write block data height:%d error:%s", i, err)
 		}
@@ -139,12 +148,16 @@ func exportBlocks(ctx *cli.Context) error {
 	}
 	uiprogress.Stop()
 
+	err = chunkWriter.Flush()
+	if err != nil {
+		return fmt.Errorf("write block data error:%s", err)
+	}
 	err = fWriter.Flush()
 	if err != nil {
 		return fmt.Errorf("Export flush file error:%s", err)
 	}
 	fmt.Printf("Export blocks successfully.\n")
-	fmt.Printf("Total blocks:%d\n", endHeight+1)
+	fmt.Printf("Total blocks:%d\n", total)
 	fmt.Printf("Export file:%s\n", exportFile)
 	return nil
 }
@@ -47,10 +47,12 @@ import (
 )
 
 type SigEmbededInvokeTxReq struct {
-	GasPrice uint64        `json:"gas_price"`
-	GasLimit uint64        `json:"gas_limit"`
-	Address  string        `json:"address"`
-	Params   []interface{} `json:"params"`
+	GasPrice     uint64        `json:"gas_price"`
+	GasLimit     uint64        `json:"gas_limit"`
+	Address      string        `json:"address"`
+	Params       []interface{} `json:"params"`
+	NotBefore    uint32        `json:"not_before,omitempty"`
+	ExpireHeight uint32        `json:"expire_height,omitempty"`
 }
 
 type SigEmbededInvokeTxRsp struct {
@@ -92,6 +94,14 @@ func SigEmbededInvokeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResp
 		}
 		mutable.Payer = payerAddress
 	}
+	if rawReq.NotBefore != 0 || rawReq.ExpireHeight != 0 {
+		err = cliutil.SetValidityWindow(mutable, rawReq.NotBefore, rawReq.ExpireHeight)
+		if err != nil {
+			log.Infof("Cli Qid:%s SigEmbededInvokeTx SetValidityWindow error:%s", req.Qid, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			return
+		}
+	}
 	signer := clisvrcom.DefAccount
 	err = cliutil.SignTransaction(signer, mutable)
 	if err != nil {
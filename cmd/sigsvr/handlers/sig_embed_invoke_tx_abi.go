@@ -46,12 +46,14 @@ import (
 )
 
 type SigEmbededInvokeTxAbiReq struct {
-	GasPrice    uint64          `json:"gas_price"`
-	GasLimit    uint64          `json:"gas_limit"`
-	Address     string          `json:"address"`
-	Method      string          `json:"method"`
-	Params      []string        `json:"params"`
-	ContractAbi json.RawMessage `json:"contract_abi"`
+	GasPrice     uint64          `json:"gas_price"`
+	GasLimit     uint64          `json:"gas_limit"`
+	Address      string          `json:"address"`
+	Method       string          `json:"method"`
+	Params       []string        `json:"params"`
+	ContractAbi  json.RawMessage `json:"contract_abi"`
+	NotBefore    uint32          `json:"not_before,omitempty"`
+	ExpireHeight uint32          `json:"expire_height,omitempty"`
 }
 
 type SigEmbededInvokeTxAbiRsp struct {
@@ -104,6 +106,14 @@ func SigEmbededInvokeAbiTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcR
 		}
 		mutable.Payer = payerAddress
 	}
+	if rawReq.NotBefore != 0 || rawReq.ExpireHeight != 0 {
+		err = cliutil.SetValidityWindow(mutable, rawReq.NotBefore, rawReq.ExpireHeight)
+		if err != nil {
+			log.Infof("Cli Qid:%s SigEmbededInvokeAbiTx SetValidityWindow error:%s", req.Qid, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			return
+		}
+	}
 	signer := clisvrcom.DefAccount
 	err = cliutil.SignTransaction(signer, mutable)
 	if err != nil {
@@ -47,12 +47,14 @@ import (
 )
 
 type SigNativeInvokeTxReq struct {
-	GasPrice uint64        `json:"gas_price"`
-	GasLimit uint64        `json:"gas_limit"`
-	Address  string        `json:"address"`
-	Method   string        `json:"method"`
-	Params   []interface{} `json:"params"`
-	Version  byte          `json:"version"`
+	GasPrice     uint64        `json:"gas_price"`
+	GasLimit     uint64        `json:"gas_limit"`
+	Address      string        `json:"address"`
+	Method       string        `json:"method"`
+	Params       []interface{} `json:"params"`
+	Version      byte          `json:"version"`
+	NotBefore    uint32        `json:"not_before,omitempty"`
+	ExpireHeight uint32        `json:"expire_height,omitempty"`
 }
 
 type SigNativeInvokeTxRsp struct {
@@ -94,6 +96,14 @@ func SigNativeInvokeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcRespo
 		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
 		return
 	}
+	if rawReq.NotBefore != 0 || rawReq.ExpireHeight != 0 {
+		err = cliutil.SetValidityWindow(tx, rawReq.NotBefore, rawReq.ExpireHeight)
+		if err != nil {
+			log.Infof("Cli Qid:%s SigNativeInvokeTx SetValidityWindow error:%s", req.Qid, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			return
+		}
+	}
 	signer := clisvrcom.DefAccount
 	err = cliutil.SignTransaction(signer, tx)
 	if err != nil {
@@ -45,12 +45,14 @@ import (
 )
 
 type SigTransferTransactionReq struct {
-	GasPrice uint64 `json:"gas_price"`
-	GasLimit uint64 `json:"gas_limit"`
-	Asset    string `json:"asset"`
-	From     string `json:"from"`
-	To       string `json:"to"`
-	Amount   uint64 `json:"amount"`
+	GasPrice     uint64 `json:"gas_price"`
+	GasLimit     uint64 `json:"gas_limit"`
+	Asset        string `json:"asset"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Amount       uint64 `json:"amount"`
+	NotBefore    uint32 `json:"not_before,omitempty"`
+	ExpireHeight uint32 `json:"expire_height,omitempty"`
 }
 
 type SinTransferTransactionRsp struct {
@@ -69,6 +71,14 @@ func SigTransferTransaction(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpc
 		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
 		return
 	}
+	if rawReq.NotBefore != 0 || rawReq.ExpireHeight != 0 {
+		err = cliutil.SetValidityWindow(transferTx, rawReq.NotBefore, rawReq.ExpireHeight)
+		if err != nil {
+			log.Infof("Cli Qid:%s SigTransferTransaction SetValidityWindow error:%s", req.Qid, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			return
+		}
+	}
 	signer := clisvrcom.DefAccount
 	if signer == nil {
 		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
@@ -0,0 +1,328 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/imZhuFei/zeepin/account"
+	cmdcom "github.com/imZhuFei/zeepin/cmd/common"
+	"github.com/imZhuFei/zeepin/cmd/utils"
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/common/password"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/governance"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/vrf"
+	"github.com/urfave/cli"
+)
+
+const (
+	TESTNET_WALLET_FILE    = "wallet.dat"
+	TESTNET_GENESIS_FILE   = "config.json"
+	TESTNET_NODE_FILE      = "nodeconfig.json"
+	TESTNET_START_FILE     = "start.sh"
+	TESTNET_PORT_STEP      = 10          //ports reserved for each node
+	TESTNET_MIN_GBFT_NODES = 9           //gbft needs K >= 9 peers in the genesis
+	TESTNET_INIT_POS       = 10000000000 //init stake of each gbft peer
+)
+
+var TestnetCommand = cli.Command{
+	Action:    cli.ShowSubcommandHelp,
+	Name:      "testnet",
+	Usage:     "Bootstrap a private test network",
+	ArgsUsage: "[arguments...]",
+	Description: `Test network commands generate everything needed to run a private network of zeepin nodes on one machine.
+You can use ./zeepin testnet --help command to view help information of test network command.`,
+	Subcommands: []cli.Command{
+		{
+			Action:    testnetInit,
+			Name:      "init",
+			Usage:     "Generate the wallets, genesis config and node configs of a test network",
+			ArgsUsage: "[sub-command options]",
+			Flags: []cli.Flag{
+				utils.TestnetNodesFlag,
+				utils.TestnetDirFlag,
+				utils.TestnetConsensusFlag,
+				utils.TestnetNetworkIdFlag,
+				utils.TestnetBasePortFlag,
+				utils.TestModeGenBlockTimeFlag,
+				utils.AccountPassFlag,
+			},
+			Description: ` Generate a test network of --nodes nodes in --dir. Each node gets a directory with
+   wallet.dat       a new account of the node, all wallets use the same password
+   config.json      the genesis config, passed with --config, its seed list holds the other nodes
+   nodeconfig.json  the node config, passed with --nodeconfig, with distinct ports for each node
+   The wallet of node1 also holds the admin GID of a gbft network.
+   start.sh in --dir starts all nodes in the background, ZEEPIN sets the node binary and ZEEPIN_PASSWORD the wallet password.`,
+		},
+	},
+}
+
+func testnetInit(ctx *cli.Context) error {
+	nodes := int(ctx.Uint(utils.GetFlagName(utils.TestnetNodesFlag)))
+	dir := ctx.String(utils.GetFlagName(utils.TestnetDirFlag))
+	consensus := ctx.String(utils.GetFlagName(utils.TestnetConsensusFlag))
+	networkId := uint32(ctx.Uint(utils.GetFlagName(utils.TestnetNetworkIdFlag)))
+	basePort := ctx.Uint(utils.GetFlagName(utils.TestnetBasePortFlag))
+
+	switch consensus {
+	case config.CONSENSUS_TYPE_VBFT:
+		if nodes < TESTNET_MIN_GBFT_NODES {
+			return fmt.Errorf("gbft consensus needs at least %d nodes", TESTNET_MIN_GBFT_NODES)
+		}
+	case config.CONSENSUS_TYPE_SOLO:
+		if nodes != config.SOLO_MIN_NODE_NUM {
+			return fmt.Errorf("solo consensus runs a single node")
+		}
+		networkId = config.NETWORK_ID_SOLO_NET
+	default:
+		return fmt.Errorf("unsupported consensus:%s", consensus)
+	}
+	if networkId == config.NETWORK_ID_MAIN_NET || networkId == config.NETWORK_ID_POLARIS_NET {
+		return fmt.Errorf("networkid %d is reserved for %s", networkId, config.GetNetworkName(networkId))
+	}
+	if basePort == 0 || basePort+uint(nodes*TESTNET_PORT_STEP) > 65535 {
+		return fmt.Errorf("baseport %d leaves no room for the ports of %d nodes", basePort, nodes)
+	}
+	if common.FileExisted(dir) {
+		return fmt.Errorf("%s has already exist", dir)
+	}
+
+	var pass []byte
+	var err error
+	if ctx.IsSet(utils.GetFlagName(utils.AccountPassFlag)) {
+		pass = []byte(ctx.String(utils.GetFlagName(utils.AccountPassFlag)))
+	} else {
+		pass, err = password.GetConfirmedPassword()
+		if err != nil {
+			return fmt.Errorf("input password error:%s", err)
+		}
+	}
+	defer cmdcom.ClearPasswd(pass)
+
+	accounts := make([]*account.Account, 0, nodes)
+	for i := 0; i < nodes; i++ {
+		nodeDir := testnetNodeDir(dir, i)
+		err = os.MkdirAll(nodeDir, 0755)
+		if err != nil {
+			return fmt.Errorf("create %s error:%s", nodeDir, err)
+		}
+		wallet, err := account.Open(filepath.Join(nodeDir, TESTNET_WALLET_FILE))
+		if err != nil {
+			return fmt.Errorf("open wallet error:%s", err)
+		}
+		acc, err := wallet.NewAccount(fmt.Sprintf("node%d", i+1), keyTypeMap[""].code, curveMap[""].code, schemeMap[""].code, pass)
+		if err != nil {
+			return fmt.Errorf("new account error:%s", err)
+		}
+		accounts = append(accounts, acc)
+	}
+
+	genesis := config.NewGenesisConfig()
+	genesis.ConsensusType = consensus
+	switch consensus {
+	case config.CONSENSUS_TYPE_VBFT:
+		adminGID, err := newTestnetAdmin(filepath.Join(testnetNodeDir(dir, 0), TESTNET_WALLET_FILE), pass)
+		if err != nil {
+			return err
+		}
+		genesis.GBFT, err = newTestnetGBFTConfig(accounts, adminGID)
+		if err != nil {
+			return err
+		}
+	case config.CONSENSUS_TYPE_SOLO:
+		//the governance contract is initialized from the gbft config whatever the consensus,
+		//solo uses the one of the main net like the test mode
+		genesis.GBFT = config.MainNetConfig.GBFT
+		genesis.SOLO.GenBlockTime = ctx.Uint(utils.GetFlagName(utils.TestModeGenBlockTimeFlag))
+		genesis.SOLO.Bookkeepers = []string{hex.EncodeToString(keypair.SerializePublicKey(accounts[0].PublicKey))}
+	}
+	err = governance.CheckVBFTConfig(genesis.GBFT)
+	if err != nil {
+		return fmt.Errorf("gbft config error:%s", err)
+	}
+
+	for i := 0; i < nodes; i++ {
+		genesis.SeedList = make([]string, 0, nodes-1)
+		for j := 0; j < nodes; j++ {
+			if j != i {
+				genesis.SeedList = append(genesis.SeedList, fmt.Sprintf("127.0.0.1:%d", testnetNodeConfig(networkId, basePort, j, nodes).P2PNode.NodePort))
+			}
+		}
+		err = writeTestnetGenesis(filepath.Join(testnetNodeDir(dir, i), TESTNET_GENESIS_FILE), genesis)
+		if err != nil {
+			return err
+		}
+		err = config.SaveNodeConfigFile(filepath.Join(testnetNodeDir(dir, i), TESTNET_NODE_FILE), testnetNodeConfig(networkId, basePort, i, nodes))
+		if err != nil {
+			return fmt.Errorf("save node config error:%s", err)
+		}
+	}
+	err = writeTestnetStartScript(dir, nodes)
+	if err != nil {
+		return err
+	}
+
+	for i, acc := range accounts {
+		nodeCfg := testnetNodeConfig(networkId, basePort, i, nodes)
+		fmt.Printf("\nnode%d\n", i+1)
+		fmt.Println("Address:", acc.Address.ToBase58())
+		fmt.Println("Public key:", hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)))
+		fmt.Println("P2P port:", nodeCfg.P2PNode.NodePort)
+		fmt.Println("Json rpc port:", nodeCfg.Rpc.HttpJsonPort)
+	}
+	if consensus == config.CONSENSUS_TYPE_VBFT {
+		fmt.Println("\nAdmin GID:", genesis.GBFT.AdminGID)
+	}
+	fmt.Printf("\nCreate %s test network of %d nodes in %s successfully.\n", consensus, nodes, dir)
+	fmt.Printf("Start it with: ZEEPIN=<path of zeepin> sh %s\n", filepath.Join(dir, TESTNET_START_FILE))
+	return nil
+}
+
+func testnetNodeDir(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("node%d", index+1))
+}
+
+//testnetNodeConfig returns the config of the node at index, the ports start at
+//basePort+TESTNET_PORT_STEP*index in the order of the default ports
+func testnetNodeConfig(networkId uint32, basePort uint, index, nodes int) *config.ZeepinChainConfig {
+	port := basePort + uint(index*TESTNET_PORT_STEP)
+	cfg := config.NewZeepinChainConfig()
+	cfg.Restful.HttpRestPort = port
+	cfg.Ws.HttpWsPort = port + 1
+	cfg.Rpc.HttpJsonPort = port + 2
+	cfg.Rpc.HttpLocalPort = port + 3
+	cfg.P2PNode.NodePort = port + 4
+	cfg.P2PNode.NodeConsensusPort = port + 5
+	cfg.Metrics.MetricsPort = port + 6
+	cfg.P2PNode.NetworkId = networkId
+	cfg.P2PNode.NetworkMagic = config.GetNetworkMagic(networkId)
+	cfg.P2PNode.NetworkName = config.GetNetworkName(networkId)
+	//all nodes connect from the same ip, each on both ports
+	if cfg.P2PNode.MaxConnInBoundForSingleIP < uint(2*nodes) {
+		cfg.P2PNode.MaxConnInBoundForSingleIP = uint(2 * nodes)
+	}
+	return cfg
+}
+
+//newTestnetAdmin adds the admin identity of the network to the wallet
+func newTestnetAdmin(walletFile string, pass []byte) (string, error) {
+	wallet, err := account.Open(walletFile)
+	if err != nil {
+		return "", fmt.Errorf("open wallet error:%s", err)
+	}
+	id, err := account.NewIdentity("admin", keyTypeMap[""].code, curveMap[""].code, pass)
+	if err != nil {
+		return "", fmt.Errorf("create GID error:%s", err)
+	}
+	wd := wallet.GetWalletData()
+	wd.AddIdentity(id)
+	err = wd.Save(walletFile)
+	if err != nil {
+		return "", fmt.Errorf("save to %s error:%s", walletFile, err)
+	}
+	return id.ID, nil
+}
+
+func newTestnetGBFTConfig(accounts []*account.Account, adminGID string) (*config.VBFTConfig, error) {
+	//the genesis vrf only seeds the first participant selection, any fresh value of a peer works
+	vrfValue, vrfProof, err := vrf.Vrf(accounts[0].PrivateKey, []byte(adminGID))
+	if err != nil {
+		return nil, fmt.Errorf("compute genesis vrf error:%s", err)
+	}
+	k := uint32(len(accounts))
+	peers := make([]*config.VBFTPeerStakeInfo, 0, len(accounts))
+	for i, acc := range accounts {
+		peers = append(peers, &config.VBFTPeerStakeInfo{
+			Index:      uint32(i + 1),
+			PeerPubkey: hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)),
+			Address:    acc.Address.ToBase58(),
+			InitPos:    TESTNET_INIT_POS,
+		})
+	}
+	return &config.VBFTConfig{
+		N:                    k,
+		C:                    (k - 1) / 3,
+		K:                    k,
+		L:                    16 * k,
+		BlockMsgDelay:        10000,
+		HashMsgDelay:         10000,
+		PeerHandshakeTimeout: 10,
+		MaxBlockChangeView:   3000,
+		MinInitStake:         TESTNET_INIT_POS,
+		AdminGID:             adminGID,
+		VrfValue:             hex.EncodeToString(vrfValue),
+		VrfProof:             hex.EncodeToString(vrfProof),
+		Peers:                peers,
+	}, nil
+}
+
+func writeTestnetGenesis(file string, genesis *config.GenesisConfig) error {
+	data, err := json.MarshalIndent(genesis, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshal genesis config error:%s", err)
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+func writeTestnetStartScript(dir string, nodes int) error {
+	script := new(bytes.Buffer)
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("#starts the nodes of the test network in the background, each logs to node.log in its directory\n")
+	script.WriteString("ZEEPIN=${ZEEPIN:-zeepin}\n")
+	script.WriteString("case $ZEEPIN in /*) ;; */*) ZEEPIN=$(pwd)/$ZEEPIN ;; esac\n")
+	script.WriteString("if [ -z \"$ZEEPIN_PASSWORD\" ]; then\n")
+	script.WriteString("\tprintf \"Password: \"; stty -echo; read ZEEPIN_PASSWORD; stty echo; echo\n")
+	script.WriteString("fi\n")
+	script.WriteString("cd \"$(dirname \"$0\")\" || exit 1\n")
+	for i := 0; i < nodes; i++ {
+		fmt.Fprintf(script, "(cd node%d && nohup \"$ZEEPIN\" --config %s --nodeconfig %s --wallet %s --password \"$ZEEPIN_PASSWORD\" > node.log 2>&1 &)\n",
+			i+1, TESTNET_GENESIS_FILE, TESTNET_NODE_FILE, TESTNET_WALLET_FILE)
+	}
+	file := filepath.Join(dir, TESTNET_START_FILE)
+	err := ioutil.WriteFile(file, script.Bytes(), 0755)
+	if err != nil {
+		return fmt.Errorf("write %s error:%s", file, err)
+	}
+	return nil
+}
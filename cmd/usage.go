@@ -96,7 +96,12 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "ZEEPINCHAIN",
 		Flags: []cli.Flag{
 			utils.ConfigFlag,
+			utils.NodeConfigFlag,
 			utils.LogLevelFlag,
+			utils.LogModulesFlag,
+			utils.LogFormatFlag,
+			utils.LogMaxSizeFlag,
+			utils.LogMaxAgeFlag,
 			utils.DisableEventLogFlag,
 			utils.DataDirFlag,
 			utils.ImportEnableFlag,
@@ -152,6 +157,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.NetworkIdFlag,
 			utils.NodePortFlag,
 			utils.DualPortSupportFlag,
+			utils.DisableP2PCompressionFlag,
+			utils.EnableP2PEncryptionFlag,
+			utils.DisableCompactBlockFlag,
 			utils.ConsensusPortFlag,
 			utils.MaxConnInBoundFlag,
 			utils.MaxConnOutBoundFlag,
@@ -165,6 +173,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCPortFlag,
 			utils.RPCLocalEnableFlag,
 			utils.RPCLocalProtFlag,
+			utils.RPCDebugTraceFlag,
 		},
 	},
 	{
@@ -181,6 +190,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WsPortFlag,
 		},
 	},
+	{
+		Name: "METRICS",
+		Flags: []cli.Flag{
+			utils.MetricsEnableFlag,
+			utils.MetricsPortFlag,
+		},
+	},
 	{
 		Name: "TEST MODE",
 		Flags: []cli.Flag{
@@ -214,6 +230,9 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.TransactionGasLimitFlag,
 			utils.TransactionGasPriceFlag,
+			utils.TransactionNotBeforeFlag,
+			utils.TransactionExpireHeightFlag,
+			utils.TransactionValidForFlag,
 			utils.TransactionAssetFlag,
 			utils.TransactionFromFlag,
 			utils.TransactionToFlag,
@@ -239,6 +258,19 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ExportFileFlag,
 			utils.ExportSpeedFlag,
 			utils.ExportHeightFlag,
+			utils.ExportStartHeightFlag,
+			utils.ExportEndHeightFlag,
+			utils.ExportCompressFlag,
+		},
+	},
+	{
+		Name: "TESTNET",
+		Flags: []cli.Flag{
+			utils.TestnetNodesFlag,
+			utils.TestnetDirFlag,
+			utils.TestnetConsensusFlag,
+			utils.TestnetNetworkIdFlag,
+			utils.TestnetBasePortFlag,
 		},
 	},
 	{
@@ -38,32 +38,67 @@ import (
 	"bytes"
 	"compress/zlib"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
+	"strings"
 
+	"github.com/golang/snappy"
+	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/core/types"
+	"github.com/klauspost/compress/zstd"
 )
 
 const (
 	COMPRESS_TYPE_ZLIB = iota
+	COMPRESS_TYPE_SNAPPY
+	COMPRESS_TYPE_ZSTD
 )
 
 const (
 	DEFAULT_COMPRESS_TYPE         = COMPRESS_TYPE_ZLIB
 	EXPORT_BLOCK_METADATA_LEN     = 256
-	EXPORT_BLOCK_METADATA_VERSION = 1
+	EXPORT_BLOCK_METADATA_VERSION = 2
+	EXPORT_BLOCK_METADATA_V1      = 1 //sequential blocks from genesis, no chunks
+	DEFAULT_EXPORT_CHUNK_SIZE     = 1000
+	MAX_EXPORT_CHUNK_DATA_LEN     = 256 * 1024 * 1024 //chunks are flushed before they grow beyond it
+	EXPORT_CHUNK_HEADER_LEN       = 4 + 4 + common.UINT256_SIZE + 4 + 4
 )
 
+var compressTypeNames = map[string]byte{
+	"zlib":   COMPRESS_TYPE_ZLIB,
+	"snappy": COMPRESS_TYPE_SNAPPY,
+	"zstd":   COMPRESS_TYPE_ZSTD,
+}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+//ParseCompressType return the compress type of name
+func ParseCompressType(name string) (byte, error) {
+	compressType, ok := compressTypeNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown compress type:%s", name)
+	}
+	return compressType, nil
+}
+
 type ExportBlockMetadata struct {
 	Version      byte
 	CompressType byte
-	BlockHeight  uint32
+	BlockHeight  uint32 //height of the last exported block
+	StartHeight  uint32 //height of the first exported block, version 2 only
+	ChunkSize    uint32 //max blocks per chunk, version 2 only
 }
 
 func NewExportBlockMetadata() *ExportBlockMetadata {
 	return &ExportBlockMetadata{
 		Version:      EXPORT_BLOCK_METADATA_VERSION,
 		CompressType: DEFAULT_COMPRESS_TYPE,
+		ChunkSize:    DEFAULT_EXPORT_CHUNK_SIZE,
 	}
 }
 
@@ -79,6 +114,19 @@ func (this *ExportBlockMetadata) Serialize(w io.Writer) error {
 		return err
 	}
 	err = serialization.WriteUint32(buf, this.BlockHeight)
+	if err != nil {
+		return err
+	}
+	if this.Version >= EXPORT_BLOCK_METADATA_VERSION {
+		err = serialization.WriteUint32(buf, this.StartHeight)
+		if err != nil {
+			return err
+		}
+		err = serialization.WriteUint32(buf, this.ChunkSize)
+		if err != nil {
+			return err
+		}
+	}
 	data := buf.Bytes()
 	if len(data) > EXPORT_BLOCK_METADATA_LEN {
 		return fmt.Errorf("metata len size larger than %d", EXPORT_BLOCK_METADATA_LEN)
@@ -94,7 +142,7 @@ func (this *ExportBlockMetadata) Deserialize(r io.Reader) error {
 	if err != nil {
 		return err
 	}
-	if metadata[0] != EXPORT_BLOCK_METADATA_VERSION {
+	if metadata[0] != EXPORT_BLOCK_METADATA_V1 && metadata[0] != EXPORT_BLOCK_METADATA_VERSION {
 		return fmt.Errorf("version unmatch")
 	}
 	reader := bytes.NewBuffer(metadata)
@@ -113,6 +161,22 @@ func (this *ExportBlockMetadata) Deserialize(r io.Reader) error {
 		return err
 	}
 	this.BlockHeight = height
+	if this.Version == EXPORT_BLOCK_METADATA_V1 {
+		this.StartHeight = 0
+		this.ChunkSize = 0
+		return nil
+	}
+	this.StartHeight, err = serialization.ReadUint32(reader)
+	if err != nil {
+		return err
+	}
+	this.ChunkSize, err = serialization.ReadUint32(reader)
+	if err != nil {
+		return err
+	}
+	if this.StartHeight > this.BlockHeight || this.ChunkSize == 0 {
+		return fmt.Errorf("invalid block range [%d, %d] chunk size %d", this.StartHeight, this.BlockHeight, this.ChunkSize)
+	}
 	return nil
 }
 
@@ -120,6 +184,10 @@ func CompressBlockData(data []byte, compressType byte) ([]byte, error) {
 	switch compressType {
 	case COMPRESS_TYPE_ZLIB:
 		return ZLibCompress(data)
+	case COMPRESS_TYPE_SNAPPY:
+		return snappy.Encode(nil, data), nil
+	case COMPRESS_TYPE_ZSTD:
+		return zstdEncoder.EncodeAll(data, nil), nil
 	default:
 		return nil, fmt.Errorf("unknown compress type")
 	}
@@ -129,6 +197,10 @@ func DecompressBlockData(data []byte, compressType byte) ([]byte, error) {
 	switch compressType {
 	case COMPRESS_TYPE_ZLIB:
 		return ZLibDecompress(data)
+	case COMPRESS_TYPE_SNAPPY:
+		return snappy.Decode(nil, data)
+	case COMPRESS_TYPE_ZSTD:
+		return zstdDecoder.DecodeAll(data, nil)
 	default:
 		return nil, fmt.Errorf("unknown compress type")
 	}
@@ -155,3 +227,222 @@ func ZLibDecompress(data []byte) ([]byte, error) {
 
 	return ioutil.ReadAll(zlibReader)
 }
+
+//ExportChunkHeader precedes the data of every chunk of a version 2 export file.
+//The chunk data is the sequence of [len uint32][compressed block] of BlockCount
+//blocks from StartHeight, Checksum is its crc32 and LastBlockHash the hash of
+//the last block as checkpoint
+type ExportChunkHeader struct {
+	StartHeight   uint32
+	BlockCount    uint32
+	LastBlockHash common.Uint256
+	Checksum      uint32
+	DataLen       uint32
+}
+
+func (this *ExportChunkHeader) EndHeight() uint32 {
+	return this.StartHeight + this.BlockCount - 1
+}
+
+func (this *ExportChunkHeader) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.StartHeight); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.BlockCount); err != nil {
+		return err
+	}
+	if err := this.LastBlockHash.Serialize(w); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.Checksum); err != nil {
+		return err
+	}
+	return serialization.WriteUint32(w, this.DataLen)
+}
+
+//Deserialize returns io.EOF only if r is at its end
+func (this *ExportChunkHeader) Deserialize(r io.Reader) error {
+	data := make([]byte, EXPORT_CHUNK_HEADER_LEN)
+	_, err := io.ReadFull(r, data)
+	if err != nil {
+		return err
+	}
+	r = bytes.NewReader(data)
+	if this.StartHeight, err = serialization.ReadUint32(r); err != nil {
+		return err
+	}
+	if this.BlockCount, err = serialization.ReadUint32(r); err != nil {
+		return err
+	}
+	if err = this.LastBlockHash.Deserialize(r); err != nil {
+		return err
+	}
+	if this.Checksum, err = serialization.ReadUint32(r); err != nil {
+		return err
+	}
+	if this.DataLen, err = serialization.ReadUint32(r); err != nil {
+		return err
+	}
+	if this.BlockCount == 0 || this.DataLen > MAX_EXPORT_CHUNK_DATA_LEN {
+		return fmt.Errorf("invalid chunk at height:%d blocks:%d len:%d", this.StartHeight, this.BlockCount, this.DataLen)
+	}
+	return nil
+}
+
+//ExportChunk is a chunk read from an export file
+type ExportChunk struct {
+	Header ExportChunkHeader
+	Data   []byte
+}
+
+//ExportChunkWriter groups the exported blocks into checksummed chunks
+type ExportChunkWriter struct {
+	w            io.Writer
+	compressType byte
+	chunkSize    uint32
+	header       ExportChunkHeader
+	buf          *bytes.Buffer
+}
+
+func NewExportChunkWriter(w io.Writer, metadata *ExportBlockMetadata) *ExportChunkWriter {
+	return &ExportChunkWriter{
+		w:            w,
+		compressType: metadata.CompressType,
+		chunkSize:    metadata.ChunkSize,
+		buf:          bytes.NewBuffer(nil),
+	}
+}
+
+//WriteBlock add the serialized block to the current chunk, blocks must be written in height order
+func (this *ExportChunkWriter) WriteBlock(blockData []byte) error {
+	block := &types.Block{}
+	err := block.Deserialize(bytes.NewReader(blockData))
+	if err != nil {
+		return fmt.Errorf("block deserialize error:%s", err)
+	}
+	height := block.Header.Height
+	if this.header.BlockCount == 0 {
+		this.header.StartHeight = height
+	} else if height != this.header.StartHeight+this.header.BlockCount {
+		return fmt.Errorf("discontinuous block height:%d expect:%d", height, this.header.StartHeight+this.header.BlockCount)
+	}
+	data, err := CompressBlockData(blockData, this.compressType)
+	if err != nil {
+		return fmt.Errorf("compress block height:%d error:%s", height, err)
+	}
+	if 4+len(data) > MAX_EXPORT_CHUNK_DATA_LEN {
+		return fmt.Errorf("block height:%d size:%d exceeds the chunk limit", height, len(data))
+	}
+	if this.header.BlockCount > 0 && this.buf.Len()+4+len(data) > MAX_EXPORT_CHUNK_DATA_LEN {
+		if err := this.Flush(); err != nil {
+			return err
+		}
+		this.header.StartHeight = height
+	}
+	serialization.WriteUint32(this.buf, uint32(len(data)))
+	this.buf.Write(data)
+	this.header.BlockCount++
+	this.header.LastBlockHash = block.Hash()
+	if this.header.BlockCount >= this.chunkSize {
+		return this.Flush()
+	}
+	return nil
+}
+
+//Flush writes the pending blocks as a chunk
+func (this *ExportChunkWriter) Flush() error {
+	if this.header.BlockCount == 0 {
+		return nil
+	}
+	data := this.buf.Bytes()
+	this.header.Checksum = crc32.ChecksumIEEE(data)
+	this.header.DataLen = uint32(len(data))
+	err := this.header.Serialize(this.w)
+	if err != nil {
+		return err
+	}
+	_, err = this.w.Write(data)
+	if err != nil {
+		return err
+	}
+	this.header = ExportChunkHeader{}
+	this.buf.Reset()
+	return nil
+}
+
+//ReadExportChunk reads the next chunk and verifies its checksum, io.EOF is returned at the end of file
+func ReadExportChunk(r io.Reader) (*ExportChunk, error) {
+	chunk := &ExportChunk{}
+	err := chunk.Header.Deserialize(r)
+	if err != nil {
+		return nil, err
+	}
+	if chunk.Header.DataLen > MAX_EXPORT_CHUNK_DATA_LEN {
+		return nil, fmt.Errorf("chunk at height:%d len:%d exceeds the limit", chunk.Header.StartHeight, chunk.Header.DataLen)
+	}
+	//the buffer grows with the data actually read, a truncated file does not allocate DataLen
+	buf := bytes.NewBuffer(nil)
+	_, err = io.CopyN(buf, r, int64(chunk.Header.DataLen))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("read chunk at height:%d error:%s", chunk.Header.StartHeight, err)
+	}
+	chunk.Data = buf.Bytes()
+	if crc32.ChecksumIEEE(chunk.Data) != chunk.Header.Checksum {
+		return nil, fmt.Errorf("chunk at height:%d checksum mismatch", chunk.Header.StartHeight)
+	}
+	return chunk, nil
+}
+
+//DecodeExportChunk decompresses the blocks of chunk and verifies them against the chunk checkpoint
+func DecodeExportChunk(chunk *ExportChunk, compressType byte) ([]*types.Block, error) {
+	if chunk.Header.BlockCount == 0 {
+		return nil, fmt.Errorf("chunk at height:%d has no block", chunk.Header.StartHeight)
+	}
+	//every block takes at least its length prefix
+	if uint64(chunk.Header.BlockCount)*4 > uint64(len(chunk.Data)) {
+		return nil, fmt.Errorf("chunk at height:%d is too short for %d blocks", chunk.Header.StartHeight, chunk.Header.BlockCount)
+	}
+	reader := bytes.NewReader(chunk.Data)
+	blocks := make([]*types.Block, 0, chunk.Header.BlockCount)
+	for i := uint32(0); i < chunk.Header.BlockCount; i++ {
+		height := chunk.Header.StartHeight + i
+		size, err := serialization.ReadUint32(reader)
+		if err != nil {
+			return nil, fmt.Errorf("read block height:%d error:%s", height, err)
+		}
+		if int64(size) > int64(reader.Len()) {
+			return nil, fmt.Errorf("block height:%d size:%d out of chunk", height, size)
+		}
+		compressData := make([]byte, size)
+		_, err = io.ReadFull(reader, compressData)
+		if err != nil {
+			return nil, fmt.Errorf("read block data height:%d error:%s", height, err)
+		}
+		blockData, err := DecompressBlockData(compressData, compressType)
+		if err != nil {
+			return nil, fmt.Errorf("block height:%d decompress error:%s", height, err)
+		}
+		block := &types.Block{}
+		err = block.Deserialize(bytes.NewReader(blockData))
+		if err != nil {
+			return nil, fmt.Errorf("block height:%d deserialize error:%s", height, err)
+		}
+		if block.Header.Height != height {
+			return nil, fmt.Errorf("unexpected block height:%d expect:%d", block.Header.Height, height)
+		}
+		if i > 0 && block.Header.PrevBlockHash != blocks[i-1].Hash() {
+			return nil, fmt.Errorf("block height:%d does not link to previous block", height)
+		}
+		blocks = append(blocks, block)
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("chunk at height:%d has %d trailing bytes", chunk.Header.StartHeight, reader.Len())
+	}
+	if blocks[len(blocks)-1].Hash() != chunk.Header.LastBlockHash {
+		return nil, fmt.Errorf("chunk at height:%d checkpoint hash mismatch", chunk.Header.StartHeight)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBlocks(start, count uint32) [][]byte {
+	blocks := make([][]byte, 0, count)
+	prevHash := common.Uint256{}
+	for i := uint32(0); i < count; i++ {
+		block := &types.Block{
+			Header: &types.Header{
+				Height:        start + i,
+				PrevBlockHash: prevHash,
+				Bookkeepers:   make([]keypair.PublicKey, 0),
+				SigData:       make([][]byte, 0),
+			},
+		}
+		prevHash = block.Hash()
+		blocks = append(blocks, block.ToArray())
+	}
+	return blocks
+}
+
+func TestCompressBlockData(t *testing.T) {
+	data := bytes.Repeat([]byte("zeepin block data"), 100)
+	for _, name := range []string{"zlib", "snappy", "ZSTD"} {
+		compressType, err := ParseCompressType(name)
+		assert.Nil(t, err)
+		compressed, err := CompressBlockData(data, compressType)
+		assert.Nil(t, err)
+		assert.True(t, len(compressed) < len(data))
+		decompressed, err := DecompressBlockData(compressed, compressType)
+		assert.Nil(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+	_, err := ParseCompressType("lz4")
+	assert.NotNil(t, err)
+}
+
+func TestExportBlockMetadata(t *testing.T) {
+	metadata := NewExportBlockMetadata()
+	metadata.CompressType = COMPRESS_TYPE_ZSTD
+	metadata.StartHeight = 10
+	metadata.BlockHeight = 20
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, metadata.Serialize(buf))
+	assert.Equal(t, EXPORT_BLOCK_METADATA_LEN, buf.Len())
+
+	metadata2 := &ExportBlockMetadata{}
+	assert.Nil(t, metadata2.Deserialize(buf))
+	assert.Equal(t, metadata, metadata2)
+
+	v1 := &ExportBlockMetadata{Version: EXPORT_BLOCK_METADATA_V1, BlockHeight: 20}
+	buf.Reset()
+	assert.Nil(t, v1.Serialize(buf))
+	v1Read := &ExportBlockMetadata{}
+	assert.Nil(t, v1Read.Deserialize(buf))
+	assert.Equal(t, v1, v1Read)
+}
+
+func TestExportChunks(t *testing.T) {
+	metadata := NewExportBlockMetadata()
+	metadata.CompressType = COMPRESS_TYPE_SNAPPY
+	metadata.ChunkSize = 4
+	blocks := newTestBlocks(5, 10)
+
+	buf := bytes.NewBuffer(nil)
+	writer := NewExportChunkWriter(buf, metadata)
+	for _, data := range blocks {
+		assert.Nil(t, writer.WriteBlock(data))
+	}
+	assert.Nil(t, writer.Flush())
+	raw := buf.Bytes()
+
+	reader := bytes.NewReader(raw)
+	height := uint32(5)
+	for _, count := range []uint32{4, 4, 2} {
+		chunk, err := ReadExportChunk(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, height, chunk.Header.StartHeight)
+		assert.Equal(t, count, chunk.Header.BlockCount)
+		decoded, err := DecodeExportChunk(chunk, metadata.CompressType)
+		assert.Nil(t, err)
+		for i, block := range decoded {
+			assert.Equal(t, blocks[height-5+uint32(i)], block.ToArray())
+		}
+		height += count
+	}
+	_, err := ReadExportChunk(reader)
+	assert.Equal(t, io.EOF, err)
+
+	//corrupted chunk data fails the checksum
+	corrupted := append([]byte{}, raw...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	reader = bytes.NewReader(corrupted)
+	for i := 0; i < 2; i++ {
+		_, err = ReadExportChunk(reader)
+		assert.Nil(t, err)
+	}
+	_, err = ReadExportChunk(reader)
+	assert.NotNil(t, err)
+
+	//a block out of order breaks the chunk
+	writer = NewExportChunkWriter(bytes.NewBuffer(nil), metadata)
+	assert.Nil(t, writer.WriteBlock(blocks[0]))
+	assert.NotNil(t, writer.WriteBlock(blocks[2]))
+
+	//chunks not read by ReadExportChunk are checked as well
+	_, err = DecodeExportChunk(&ExportChunk{}, metadata.CompressType)
+	assert.NotNil(t, err)
+	_, err = DecodeExportChunk(&ExportChunk{Header: ExportChunkHeader{BlockCount: 2}, Data: make([]byte, 7)},
+		metadata.CompressType)
+	assert.NotNil(t, err)
+
+	//a header announcing more data than the limit is rejected before reading it
+	header := &ExportChunkHeader{StartHeight: 5, BlockCount: 1, DataLen: MAX_EXPORT_CHUNK_DATA_LEN + 1}
+	buf.Reset()
+	assert.Nil(t, header.Serialize(buf))
+	_, err = ReadExportChunk(buf)
+	assert.NotNil(t, err)
+
+	//a truncated chunk is reported
+	buf.Reset()
+	header.DataLen = 1024
+	assert.Nil(t, header.Serialize(buf))
+	buf.Write(make([]byte, 10))
+	_, err = ReadExportChunk(buf)
+	assert.NotNil(t, err)
+}
@@ -45,8 +45,12 @@ import (
 )
 
 const (
-	DEFAULT_EXPORT_FILE = "./blocks.dat"
-	DEFAULT_ABI_PATH    = "./abi"
+	DEFAULT_EXPORT_FILE      = "./blocks.dat"
+	DEFAULT_ABI_PATH         = "./abi"
+	DEFAULT_TESTNET_DIR      = "./testnet"
+	DEFAULT_TESTNET_NODES    = 9
+	DEFAULT_TESTNET_NET_ID   = 299
+	DEFAULT_TESTNET_BASEPORT = 30334
 )
 
 var (
@@ -55,11 +59,33 @@ var (
 		Name:  "config",
 		Usage: "Use `<filename>` to specifies the genesis block config file. If doesn't specifies the genesis block config, zeepin will use Polaris config with GBFT consensus as default.",
 	}
+	NodeConfigFlag = cli.StringFlag{
+		Name:  "nodeconfig",
+		Usage: "Use `<filename>` to specifies the json node config file with the Common, Consensus, P2PNode, Rpc, Restful, Ws and Metrics settings. Flags given on the command line override the file. The file is reloaded on SIGHUP or the reloadconfig local rpc",
+	}
 	LogLevelFlag = cli.UintFlag{
 		Name:  "loglevel",
 		Usage: "Set the log level to `<level>` (0~6). 0:Debug 1:Info 2:Warn 3:Error 4:Fatal 5:Trace 6:MaxLevel",
 		Value: config.DEFAULT_LOG_LEVEL,
 	}
+	LogModulesFlag = cli.StringFlag{
+		Name:  "logmodules",
+		Usage: "Set per-module log levels as `<modules>`, e.g. vbft=debug,p2p=warn. Modules: consensus, vbft, dbft, solo, p2p, txnpool, ledger",
+	}
+	LogFormatFlag = cli.StringFlag{
+		Name:  "logformat",
+		Usage: "Log output `<format>`, text or json",
+		Value: config.DEFAULT_LOG_FORMAT,
+	}
+	LogMaxSizeFlag = cli.UintFlag{
+		Name:  "logmaxsize",
+		Usage: "Start a new log file when the current one grows over `<size>` MByte",
+		Value: config.DEFAULT_MAX_LOG_SIZE,
+	}
+	LogMaxAgeFlag = cli.UintFlag{
+		Name:  "logmaxage",
+		Usage: "Start a new log file when the current one is older than `<hours>`, 0 for no age limit",
+	}
 	DisableEventLogFlag = cli.BoolFlag{
 		Name:  "disableeventlog",
 		Usage: "If set disableeventlog flag, zeepin will not record event log output by smart contract",
@@ -148,6 +174,18 @@ var (
 		Name:  "dualport",
 		Usage: "Using to initiates a dual network, i.e. a P2P network for processing transaction messages and a consensus network for consensus messages. ",
 	}
+	DisableP2PCompressionFlag = cli.BoolFlag{
+		Name:  "disablep2pcompression",
+		Usage: "Using to disable the compressed framing of block, headers and inv messages with peers supporting it",
+	}
+	EnableP2PEncryptionFlag = cli.BoolFlag{
+		Name:  "enablep2pencryption",
+		Usage: "Using to offer peers an encrypted link authenticated by the node key pairs. Requires the consensus account, peers not supporting it keep the plain framing",
+	}
+	DisableCompactBlockFlag = cli.BoolFlag{
+		Name:  "disablecompactblock",
+		Usage: "Using to disable the compact block relay, which sends block headers with the transaction hashes and lets peers rebuild blocks from their transaction pool",
+	}
 	ConsensusPortFlag = cli.UintFlag{
 		Name:  "consensusport",
 		Usage: "Using to specifies the consensus network port number. By default, the consensus network reuses the P2P network, so it is not necessary to specify a consensus network port. After the dual network is enabled with the --dualport parameter, the consensus network port number must be set separately.",
@@ -187,6 +225,10 @@ var (
 		Usage: "Json rpc local server listening port",
 		Value: config.DEFAULT_RPC_LOCAL_PORT,
 	}
+	RPCDebugTraceFlag = cli.BoolFlag{
+		Name:  "debugtrace",
+		Usage: "Enable the debug_traceTransaction rpc. The node keeps the state changes of the recent blocks to trace their transactions",
+	}
 
 	//Websocket setting
 	WsEnabledFlag = cli.BoolFlag{
@@ -199,6 +241,24 @@ var (
 		Value: config.DEFAULT_WS_PORT,
 	}
 
+	//Metrics setting
+	MetricsEnableFlag = cli.BoolFlag{
+		Name:  "metrics",
+		Usage: "Enable prometheus metrics server",
+	}
+	MetricsPortFlag = cli.UintFlag{
+		Name:  "metricsport",
+		Usage: "Metrics server listening port",
+		Value: config.DEFAULT_METRICS_PORT,
+	}
+
+	//Event abi setting
+	NativeAbiPathFlag = cli.StringFlag{
+		Name:  "nativeabi",
+		Usage: "Directory `<path>` of the native contract abi json files used to decode contract events",
+		Value: DEFAULT_ABI_PATH,
+	}
+
 	//Restful setting
 	RestfulEnableFlag = cli.BoolFlag{
 		Name:  "rest",
@@ -322,6 +382,10 @@ var (
 		Usage: "Set `<text>` as the description of the contract",
 		Value: "",
 	}
+	ContractEventAbiFlag = cli.StringFlag{
+		Name:  "eventabi",
+		Usage: "File path of the abi json `<path>` whose events are decoded in notifications",
+	}
 	ContractParamsFlag = cli.StringFlag{
 		Name:  "params",
 		Usage: "Invoke contract parameters list. use comma ',' to split params, and must add type prefix to params. Param type support bytearray(hexstring), string, integer, boolean,For example: string:foo,int:0,bool:true; If parameter is an object array, enclose array with '[]'. For example:  string:foo,[int:0,bool:true]",
@@ -366,7 +430,7 @@ var (
 	//Transfer setting
 	TransactionAssetFlag = cli.StringFlag{
 		Name:  "asset",
-		Usage: "Using to specifies the transfer asset `<zpt|gala>`",
+		Usage: "Using to specifies the transfer asset `<zpt|gala|token contract address>`",
 		Value: ASSET_ZPT,
 	}
 	TransactionFromFlag = cli.StringFlag{
@@ -395,6 +459,18 @@ var (
 		Usage: "Using to specifies the gas limit of the transaction. The gas limit of the transaction cannot be less than the minimum gas limit set by the node's transaction pool, otherwise the transaction will be rejected. Gasprice * gaslimit is actual GALA costs.",
 		Value: embed.MIN_TRANSACTION_GAS,
 	}
+	TransactionNotBeforeFlag = cli.UintFlag{
+		Name:  "notbefore",
+		Usage: "Using to specifies the first block `<height>` the transaction can be included in. Requires expireheight or validfor",
+	}
+	TransactionExpireHeightFlag = cli.UintFlag{
+		Name:  "expireheight",
+		Usage: "Using to specifies the last block `<height>` the transaction can be included in. Once the chain passes it the transaction can not be replayed",
+	}
+	TransactionValidForFlag = cli.UintFlag{
+		Name:  "validfor",
+		Usage: "Using to specifies the `<number>` of blocks from the next one the transaction can be included in, instead of an absolute expireheight",
+	}
 
 	//Asset setting
 	ApproveAssetFromFlag = cli.StringFlag{
@@ -451,6 +527,48 @@ var (
 		Usage: "Export block speed, `<h|m|l>` h for high speed, m for middle speed and l for low speed",
 		Value: "m",
 	}
+	ExportStartHeightFlag = cli.UintFlag{
+		Name:  "start",
+		Usage: "Using to specifies the height of the first exported block. The default value is 0, which means exporting from the genesis block",
+		Value: 0,
+	}
+	ExportEndHeightFlag = cli.UintFlag{
+		Name:  "end",
+		Usage: "Using to specifies the height of the last exported block, same as height. The default value is 0, which means exporting to the current block",
+		Value: 0,
+	}
+	ExportCompressFlag = cli.StringFlag{
+		Name:  "compress",
+		Usage: "Compress type of the exported blocks `<zlib|snappy|zstd>`",
+		Value: "zlib",
+	}
+
+	//Testnet setting
+	TestnetNodesFlag = cli.UintFlag{
+		Name:  "nodes",
+		Usage: "Number of nodes of the test network, gbft consensus needs at least 9 nodes and solo consensus runs a single node",
+		Value: DEFAULT_TESTNET_NODES,
+	}
+	TestnetDirFlag = cli.StringFlag{
+		Name:  "dir",
+		Usage: "Output `<path>` of the test network, each node gets its own directory in it",
+		Value: DEFAULT_TESTNET_DIR,
+	}
+	TestnetConsensusFlag = cli.StringFlag{
+		Name:  "consensus",
+		Usage: "Consensus of the test network `<gbft|solo>`",
+		Value: config.CONSENSUS_TYPE_VBFT,
+	}
+	TestnetNetworkIdFlag = cli.UintFlag{
+		Name:  "networkid",
+		Usage: "Network ID of the test network, must not be the ID of the zeepin main net or the polaris test net",
+		Value: DEFAULT_TESTNET_NET_ID,
+	}
+	TestnetBasePortFlag = cli.UintFlag{
+		Name:  "baseport",
+		Usage: "First `<port>` of the test network. Each node uses 7 ports from baseport+10*index in the order restful, websocket, json rpc, local rpc, p2p, consensus and metrics",
+		Value: DEFAULT_TESTNET_BASEPORT,
+	}
 
 	//PreExecute switcher
 	TxpoolPreExecDisableFlag = cli.BoolFlag{
@@ -40,6 +40,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 
 	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/common/serialization"
@@ -71,6 +72,9 @@ func ImportBlocks(importFile string, targetHeight uint32) error {
 		log.Infof("No blocks to import.\n")
 		return nil
 	}
+	if metadata.StartHeight > currBlockHeight+1 {
+		return fmt.Errorf("export file starts at block height:%d, current block height:%d", metadata.StartHeight, currBlockHeight)
+	}
 	if targetHeight == 0 {
 		targetHeight = endBlockHeight
 	}
@@ -81,6 +85,19 @@ func ImportBlocks(importFile string, targetHeight uint32) error {
 	log.Infof("Start import blocks")
 	log.Infof("Current block height:%d TotalBlocks:%d", currBlockHeight, endBlockHeight-currBlockHeight)
 
+	if metadata.Version == EXPORT_BLOCK_METADATA_V1 {
+		err = importBlocksV1(fReader, metadata, currBlockHeight, endBlockHeight)
+	} else {
+		err = importBlockChunks(fReader, metadata, currBlockHeight, endBlockHeight)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Import block complete, current block height:%d", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
+
+func importBlocksV1(fReader io.Reader, metadata *ExportBlockMetadata, currBlockHeight, endBlockHeight uint32) error {
 	for i := uint32(0); i <= endBlockHeight; i++ {
 		size, err := serialization.ReadUint32(fReader)
 		if err != nil {
@@ -112,6 +129,77 @@ func ImportBlocks(importFile string, targetHeight uint32) error {
 			return fmt.Errorf("add block height:%d error:%s", i, err)
 		}
 	}
-	log.Infof("Import block complete, current block height:%d", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
+
+type decodedChunk struct {
+	header *ExportChunkHeader
+	blocks []*types.Block
+	err    error
+}
+
+//importBlockChunks decompresses chunks in parallel and adds their blocks to the ledger in order.
+//Chunks already in the ledger are skipped, so an interrupted import resumes from the last
+//chunk it did not complete, and an import stops at the last good chunk on a corrupted one
+func importBlockChunks(fReader io.Reader, metadata *ExportBlockMetadata, currBlockHeight, endBlockHeight uint32) error {
+	workers := runtime.NumCPU()
+	pending := make(chan chan *decodedChunk, workers)
+	quit := make(chan struct{})
+	defer close(quit)
+
+	go func() {
+		defer close(pending)
+		for {
+			result := make(chan *decodedChunk, 1)
+			chunk, err := ReadExportChunk(fReader)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				result <- &decodedChunk{err: err}
+			} else if chunk.Header.EndHeight() <= currBlockHeight {
+				continue
+			} else {
+				go func() {
+					blocks, err := DecodeExportChunk(chunk, metadata.CompressType)
+					result <- &decodedChunk{header: &chunk.Header, blocks: blocks, err: err}
+				}()
+			}
+			select {
+			case pending <- result:
+			case <-quit:
+				return
+			}
+			if err != nil || chunk.Header.EndHeight() >= endBlockHeight {
+				return
+			}
+		}
+	}()
+
+	lastGood := currBlockHeight
+	for result := range pending {
+		chunk := <-result
+		if chunk.err != nil {
+			return fmt.Errorf("import stopped after block height:%d error:%s", lastGood, chunk.err)
+		}
+		for _, block := range chunk.blocks {
+			height := block.Header.Height
+			if height <= currBlockHeight {
+				continue
+			}
+			if height > endBlockHeight {
+				return nil
+			}
+			err := ledger.DefLedger.AddBlock(block)
+			if err != nil {
+				return fmt.Errorf("add block height:%d error:%s", height, err)
+			}
+		}
+		lastGood = chunk.header.EndHeight()
+		log.Infof("Import chunk [%d, %d] done", chunk.header.StartHeight, lastGood)
+	}
+	if lastGood < endBlockHeight {
+		return fmt.Errorf("export file ends at block height:%d, expect:%d", lastGood, endBlockHeight)
+	}
 	return nil
 }
@@ -44,6 +44,7 @@ import (
 	"strings"
 
 	"github.com/imZhuFei/zeepin/common/constants"
+	httpcom "github.com/imZhuFei/zeepin/http/base/common"
 )
 
 const (
@@ -113,6 +114,18 @@ func CheckAssetAmount(asset string, amount uint64) error {
 	return nil
 }
 
+//CheckTokenAmount checks amount against the total supply of a standard token
+func CheckTokenAmount(token *httpcom.TokenInfo, amount uint64) error {
+	supply, ok := new(big.Int).SetString(token.TotalSupply, 10)
+	if !ok {
+		return fmt.Errorf("invalid total supply:%s", token.TotalSupply)
+	}
+	if new(big.Int).SetUint64(amount).Cmp(supply) > 0 {
+		return fmt.Errorf("Amount:%d larger than %s total supply:%s", amount, token.Symbol, token.TotalSupply)
+	}
+	return nil
+}
+
 func GetJsonObjectFromFile(filePath string, jsonObject interface{}) error {
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
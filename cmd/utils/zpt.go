@@ -95,6 +95,37 @@ func GetAllowance(asset, from, to string) (string, error) {
 	return balance, nil
 }
 
+//GetTokenInfo return the info of a contract conforming to the token standard
+func GetTokenInfo(contract string) (*httpcom.TokenInfo, error) {
+	result, err := sendRpcRequest("gettokeninfo", []interface{}{contract})
+	if err != nil {
+		return nil, fmt.Errorf("sendRpcRequest error:%s", err)
+	}
+	info := &httpcom.TokenInfo{}
+	err = json.Unmarshal(result, info)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error:%s", err)
+	}
+	return info, nil
+}
+
+//TransferToken transfer a standard token from account to another account
+func TransferToken(gasPrice, gasLimit uint64, signer *account.Account, token *httpcom.TokenInfo, to string, amount uint64) (string, error) {
+	contractAddr, err := common.AddressFromHexString(token.Contract)
+	if err != nil {
+		return "", fmt.Errorf("token contract:%s invalid:%s", token.Contract, err)
+	}
+	toAddr, err := common.AddressFromBase58(to)
+	if err != nil {
+		return "", fmt.Errorf("To address:%s invalid:%s", to, err)
+	}
+	mutable, err := httpcom.NewTokenTransferTransaction(gasPrice, gasLimit, token.VmType, contractAddr, signer.Address, toAddr, amount)
+	if err != nil {
+		return "", err
+	}
+	return InvokeSmartContract(signer, mutable)
+}
+
 //Transfer zpt|gala from account to another account
 func Transfer(gasPrice, gasLimit uint64, signer *account.Account, asset, from, to string, amount uint64) (string, error) {
 	mutable, err := TransferTx(gasPrice, gasLimit, asset, signer.Address.ToBase58(), to, amount)
@@ -294,7 +325,35 @@ func TransferFromTx(gasPrice, gasLimit uint64, asset, sender, from, to string, a
 	return tx, nil
 }
 
+//TxValidityWindow is applied by SignTransaction to default version transactions,
+//a zero ExpireHeight leaves them without window
+var TxValidityWindow struct {
+	NotBefore    uint32
+	ExpireHeight uint32
+}
+
+//SetValidityWindow makes tx a version 1 transaction valid in the blocks [notBefore, expireHeight],
+//it must be called before the transaction is signed
+func SetValidityWindow(tx *types.MutableTransaction, notBefore, expireHeight uint32) error {
+	if expireHeight == 0 {
+		return fmt.Errorf("expire height not set")
+	}
+	if notBefore > expireHeight {
+		return fmt.Errorf("not before height %d is above expire height %d", notBefore, expireHeight)
+	}
+	tx.Version = types.TX_VERSION_VALIDITY_WINDOW
+	tx.NotBefore = notBefore
+	tx.ExpireHeight = expireHeight
+	return nil
+}
+
 func SignTransaction(signer *account.Account, tx *types.MutableTransaction) error {
+	if tx.Version == types.TX_VERSION_DEFAULT && TxValidityWindow.ExpireHeight != 0 {
+		err := SetValidityWindow(tx, TxValidityWindow.NotBefore, TxValidityWindow.ExpireHeight)
+		if err != nil {
+			return err
+		}
+	}
 	tx.Payer = signer.Address
 	txHash := tx.Hash()
 	sigData, err := Sign(txHash.ToArray(), signer)
@@ -422,13 +481,13 @@ func DeployContract(
 	cversion,
 	cauthor,
 	cemail,
-	cdesc string, attr uint64) (string, error) {
+	cdesc string, attr uint64, eventAbi []byte) (string, error) {
 
 	//c, err := hex.DecodeString(code)
 	//if err != nil {
 	//	return "", fmt.Errorf("hex.DecodeString error:%s", err)
 	//}
-	mutable := NewDeployCodeTransaction(gasPrice, gasLimit, []byte(code), needStorage, cname, cversion, cauthor, cemail, cdesc, attr)
+	mutable := NewDeployCodeTransaction(gasPrice, gasLimit, []byte(code), needStorage, cname, cversion, cauthor, cemail, cdesc, attr, eventAbi)
 
 	err := SignTransaction(signer, mutable)
 	if err != nil {
@@ -453,12 +512,13 @@ func PrepareDeployContract(
 	cauthor,
 	cemail,
 	cdesc string,
-	attr uint64) (*cstates.PreExecResult, error) {
+	attr uint64,
+	eventAbi []byte) (*cstates.PreExecResult, error) {
 	//c, err := hex.DecodeString(code)
 	//if err != nil {
 	//	return nil, fmt.Errorf("hex.DecodeString error:%s", err)
 	//}
-	mutable := NewDeployCodeTransaction(0, 0, []byte(code), needStorage, cname, cversion, cauthor, cemail, cdesc, attr)
+	mutable := NewDeployCodeTransaction(0, 0, []byte(code), needStorage, cname, cversion, cauthor, cemail, cdesc, attr, eventAbi)
 	tx, _ := mutable.IntoImmutable()
 	var buffer bytes.Buffer
 	err := tx.Serialize(&buffer)
@@ -698,9 +758,10 @@ func PrepareInvokeWASMVMContract(
 	return preResult, nil
 }
 
-//NewDeployCodeTransaction return a smart contract deploy transaction instance
+//NewDeployCodeTransaction return a smart contract deploy transaction instance,
+//a contract deployed with an event abi records its vm type
 func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorage bool,
-	cname, cversion, cauthor, cemail, cdesc string, attr uint64) *types.MutableTransaction {
+	cname, cversion, cauthor, cemail, cdesc string, attr uint64, eventAbi []byte) *types.MutableTransaction {
 
 	deployPayload := &payload.DeployCode{
 		Code:        code,
@@ -711,6 +772,13 @@ func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorag
 		Email:       cemail,
 		Description: cdesc,
 	}
+	if len(eventAbi) != 0 {
+		deployPayload.EventAbi = eventAbi
+		deployPayload.VmType = payload.WasmVm
+		if attr == 0 {
+			deployPayload.VmType = payload.EmbedVm
+		}
+	}
 	tx := &types.MutableTransaction{
 		Version:    VERSION_TRANSACTION,
 		TxType:     types.Deploy,
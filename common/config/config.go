@@ -65,6 +65,7 @@ const (
 	CONSENSUS_TYPE_VBFT = "gbft"
 
 	DEFAULT_LOG_LEVEL                       = 1
+	DEFAULT_LOG_FORMAT                      = "text"
 	DEFAULT_MAX_LOG_SIZE                    = 100 //MByte
 	DEFAULT_NODE_PORT                       = uint(20338)
 	DEFAULT_CONSENSUS_PORT                  = uint(20339)
@@ -72,6 +73,7 @@ const (
 	DEFAULT_RPC_LOCAL_PORT                  = uint(20337)
 	DEFAULT_REST_PORT                       = uint(20334)
 	DEFAULT_WS_PORT                         = uint(20335)
+	DEFAULT_METRICS_PORT                    = uint(20340)
 	DEFAULT_MAX_CONN_IN_BOUND               = uint(1024)
 	DEFAULT_MAX_CONN_OUT_BOUND              = uint(1024)
 	DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP = uint(16)
@@ -117,6 +119,166 @@ func GetNetworkMagic(id uint32) uint32 {
 	return id
 }
 
+var VM_TYPE_CHECK_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.VM_TYPE_CHECK_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.VM_TYPE_CHECK_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetVmTypeCheckHeight return the height from which deployed contracts record
+//their vm type and invocations are dispatched by it, private networks start at genesis
+func GetVmTypeCheckHeight(id uint32) uint32 {
+	height, ok := VM_TYPE_CHECK_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var CHECKSIG_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.CHECKSIG_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.CHECKSIG_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetCheckSigHeight return the height from which embed contracts may use
+//the CHECKSIG and CHECKMULTISIG opcodes, private networks start at genesis
+func GetCheckSigHeight(id uint32) uint32 {
+	height, ok := CHECKSIG_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var WASM_ALLOCATOR_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.WASM_ALLOCATOR_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.WASM_ALLOCATOR_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetWasmAllocatorHeight return the height from which wasm contract memory is
+//managed by the free list allocator, private networks start at genesis
+func GetWasmAllocatorHeight(id uint32) uint32 {
+	height, ok := WASM_ALLOCATOR_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var NFT_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.NFT_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.NFT_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetNftHeight return the height from which the nft native contract can be
+//invoked, private networks start at genesis
+func GetNftHeight(id uint32) uint32 {
+	height, ok := NFT_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var CLAIM_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.CLAIM_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.CLAIM_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetClaimHeight return the height from which the claim native contract can be
+//invoked, private networks start at genesis
+func GetClaimHeight(id uint32) uint32 {
+	height, ok := CLAIM_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var GID_POLICY_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.GID_POLICY_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.GID_POLICY_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetGidPolicyHeight return the height from which gids may be controlled by
+//M-of-N key policies and guardians, private networks start at genesis
+func GetGidPolicyHeight(id uint32) uint32 {
+	height, ok := GID_POLICY_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var AUTH_AUDIT_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.AUTH_AUDIT_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.AUTH_AUDIT_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetAuthAuditHeight return the height from which roles can be revoked and
+//audited through the auth contract, private networks start at genesis
+func GetAuthAuditHeight(id uint32) uint32 {
+	height, ok := AUTH_AUDIT_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var PROTECTED_METHODS_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.PROTECTED_METHODS_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.PROTECTED_METHODS_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetProtectedMethodsHeight return the height from which contract methods can
+//be protected by the auth contract, private networks start at genesis
+func GetProtectedMethodsHeight(id uint32) uint32 {
+	height, ok := PROTECTED_METHODS_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var EVENT_ABI_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.EVENT_ABI_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.EVENT_ABI_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetEventAbiHeight return the height from which contracts may be deployed
+//with an event abi, private networks start at genesis
+func GetEventAbiHeight(id uint32) uint32 {
+	height, ok := EVENT_ABI_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
+var TX_VALIDITY_WINDOW_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.TX_VALIDITY_WINDOW_HEIGHT_MAINNET,
+	NETWORK_ID_POLARIS_NET: constants.TX_VALIDITY_WINDOW_HEIGHT_POLARIS,
+	NETWORK_ID_SOLO_NET:    0,
+}
+
+//GetTxValidityWindowHeight return the height from which transactions carrying a
+//validity window are accepted, private networks start at genesis
+func GetTxValidityWindowHeight(id uint32) uint32 {
+	height, ok := TX_VALIDITY_WINDOW_HEIGHT[id]
+	if ok {
+		return height
+	}
+	return 0
+}
+
 func GetNetworkName(id uint32) string {
 	name, ok := NETWORK_NAME[id]
 	if ok {
@@ -508,6 +670,10 @@ type SOLOConfig struct {
 
 type CommonConfig struct {
 	LogLevel       uint
+	LogModules     string //per-module level overrides, e.g. vbft=debug,p2p=warn
+	LogFormat      string //text or json
+	MaxLogSize     uint   //MByte
+	MaxLogAge      uint   //hours, no age limit if 0
 	NodeType       string
 	EnableEventLog bool
 	SystemFee      map[string]int64
@@ -544,12 +710,16 @@ type P2PNodeConfig struct {
 	MaxConnInBound            uint
 	MaxConnOutBound           uint
 	MaxConnInBoundForSingleIP uint
+	DisableCompression        bool //do not offer compressed framing to peers
+	EnableEncryption          bool //offer the node key authenticated encryption, needs the node account
+	DisableCompactBlock       bool //relay full blocks only, neither serve nor request compact blocks
 }
 
 type RpcConfig struct {
 	EnableHttpJsonRpc bool
 	HttpJsonPort      uint
 	HttpLocalPort     uint
+	EnableDebugTrace  bool //serve debug_traceTransaction and keep the state changes of the recent blocks for it
 }
 
 type RestfulConfig struct {
@@ -566,6 +736,11 @@ type WebSocketConfig struct {
 	HttpKeyPath  string
 }
 
+type MetricsConfig struct {
+	EnableMetrics bool
+	MetricsPort   uint
+}
+
 type ZeepinChainConfig struct {
 	Genesis   *GenesisConfig
 	Common    *CommonConfig
@@ -574,6 +749,7 @@ type ZeepinChainConfig struct {
 	Rpc       *RpcConfig
 	Restful   *RestfulConfig
 	Ws        *WebSocketConfig
+	Metrics   *MetricsConfig
 }
 
 func NewZeepinChainConfig() *ZeepinChainConfig {
@@ -581,6 +757,8 @@ func NewZeepinChainConfig() *ZeepinChainConfig {
 		Genesis: MainNetConfig,
 		Common: &CommonConfig{
 			LogLevel:       DEFAULT_LOG_LEVEL,
+			LogFormat:      DEFAULT_LOG_FORMAT,
+			MaxLogSize:     DEFAULT_MAX_LOG_SIZE,
 			EnableEventLog: DEFAULT_ENABLE_EVENT_LOG,
 			SystemFee:      make(map[string]int64),
 			GasLimit:       DEFAULT_GAS_LIMIT,
@@ -622,6 +800,10 @@ func NewZeepinChainConfig() *ZeepinChainConfig {
 			EnableHttpWs: true,
 			HttpWsPort:   DEFAULT_WS_PORT,
 		},
+		Metrics: &MetricsConfig{
+			EnableMetrics: false,
+			MetricsPort:   DEFAULT_METRICS_PORT,
+		},
 	}
 }
 
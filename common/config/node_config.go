@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+)
+
+//liveLock guards the LIVE_CONFIG settings, a reload writes them while the
+//running node reads them through the getters below
+var liveLock sync.RWMutex
+
+//LIVE_CONFIG lists the settings a reload applies to the running node, the
+//others only take effect after a restart
+var LIVE_CONFIG = map[string]bool{
+	"Common.LogLevel":                   true,
+	"Common.LogModules":                 true,
+	"Common.LogFormat":                  true,
+	"Common.MaxLogSize":                 true,
+	"Common.MaxLogAge":                  true,
+	"Common.GasLimit":                   true,
+	"Common.GasPrice":                   true,
+	"P2PNode.MaxConnInBound":            true,
+	"P2PNode.MaxConnOutBound":           true,
+	"P2PNode.MaxConnInBoundForSingleIP": true,
+	"P2PNode.ReservedPeersOnly":         true,
+	"P2PNode.ReservedCfg":               true,
+	"Rpc.EnableHttpJsonRpc":             true,
+}
+
+//nodeConfigFile is the layout of the node config file, the genesis is not part of it
+type nodeConfigFile struct {
+	Common    *CommonConfig
+	Consensus *ConsensusConfig
+	P2PNode   *P2PNodeConfig
+	Rpc       *RpcConfig
+	Restful   *RestfulConfig
+	Ws        *WebSocketConfig
+	Metrics   *MetricsConfig
+}
+
+// LoadNodeConfigFile reads the json node config file into cfg, the settings
+// missing in the file keep their current value
+func LoadNodeConfigFile(file string, cfg *ZeepinChainConfig) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&nodeConfigFile{
+		Common:    cfg.Common,
+		Consensus: cfg.Consensus,
+		P2PNode:   cfg.P2PNode,
+		Rpc:       cfg.Rpc,
+		Restful:   cfg.Restful,
+		Ws:        cfg.Ws,
+		Metrics:   cfg.Metrics,
+	})
+	if err != nil {
+		return fmt.Errorf("decode %s error:%s", file, err)
+	}
+	if cfg.P2PNode.ReservedCfg == nil {
+		cfg.P2PNode.ReservedCfg = &P2PRsvConfig{}
+	}
+	return nil
+}
+
+// SaveNodeConfigFile writes the node settings of cfg to a json node config file
+func SaveNodeConfigFile(file string, cfg *ZeepinChainConfig) error {
+	data, err := json.MarshalIndent(&nodeConfigFile{
+		Common:    cfg.Common,
+		Consensus: cfg.Consensus,
+		P2PNode:   cfg.P2PNode,
+		Rpc:       cfg.Rpc,
+		Restful:   cfg.Restful,
+		Ws:        cfg.Ws,
+		Metrics:   cfg.Metrics,
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// ReloadResult reports the settings changed by a reload
+type ReloadResult struct {
+	Applied         []string //changed and applied to the running node
+	RequiresRestart []string //changed but only effective after a restart
+}
+
+// Reload applies the live settings of newCfg which differ from this config,
+// changed settings outside LIVE_CONFIG are reported but left as they are
+func (this *ZeepinChainConfig) Reload(newCfg *ZeepinChainConfig) *ReloadResult {
+	result := &ReloadResult{
+		Applied:         make([]string, 0),
+		RequiresRestart: make([]string, 0),
+	}
+	liveLock.Lock()
+	defer liveLock.Unlock()
+
+	curSections := reflect.ValueOf(this).Elem()
+	newSections := reflect.ValueOf(newCfg).Elem()
+	for i := 0; i < curSections.NumField(); i++ {
+		section := curSections.Type().Field(i).Name
+		if section == "Genesis" {
+			continue
+		}
+		cur := curSections.Field(i).Elem()
+		next := newSections.Field(i).Elem()
+		for j := 0; j < cur.NumField(); j++ {
+			if reflect.DeepEqual(cur.Field(j).Interface(), next.Field(j).Interface()) {
+				continue
+			}
+			name := section + "." + cur.Type().Field(j).Name
+			if LIVE_CONFIG[name] {
+				cur.Field(j).Set(next.Field(j))
+				result.Applied = append(result.Applied, name)
+			} else {
+				result.RequiresRestart = append(result.RequiresRestart, name)
+			}
+		}
+	}
+	return result
+}
+
+// GetCommonConfig returns a copy of the common settings
+func (this *ZeepinChainConfig) GetCommonConfig() CommonConfig {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return *this.Common
+}
+
+// GetGasLimit returns the minimum gas limit of the txs accepted by the tx pool
+func (this *ZeepinChainConfig) GetGasLimit() uint64 {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.Common.GasLimit
+}
+
+// GetGasPrice returns the minimum gas price configured for the tx pool
+func (this *ZeepinChainConfig) GetGasPrice() uint64 {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.Common.GasPrice
+}
+
+// GetMaxConnInBound returns the max number of inbound connections
+func (this *ZeepinChainConfig) GetMaxConnInBound() uint {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.P2PNode.MaxConnInBound
+}
+
+// GetMaxConnOutBound returns the max number of outbound connections
+func (this *ZeepinChainConfig) GetMaxConnOutBound() uint {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.P2PNode.MaxConnOutBound
+}
+
+// GetMaxConnInBoundForSingleIP returns the max number of inbound connections from one ip
+func (this *ZeepinChainConfig) GetMaxConnInBoundForSingleIP() uint {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.P2PNode.MaxConnInBoundForSingleIP
+}
+
+// GetReservedCfg reports whether only the reserved peers are allowed and returns
+// the reserved peers config. A reload replaces the config instead of changing it,
+// so the returned one can be read without the lock
+func (this *ZeepinChainConfig) GetReservedCfg() (bool, *P2PRsvConfig) {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.P2PNode.ReservedPeersOnly, this.P2PNode.ReservedCfg
+}
+
+// GetEnableHttpJsonRpc reports whether the json rpc server is enabled
+func (this *ZeepinChainConfig) GetEnableHttpJsonRpc() bool {
+	liveLock.RLock()
+	defer liveLock.RUnlock()
+	return this.Rpc.EnableHttpJsonRpc
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeNodeConfigFile(t *testing.T, content string) string {
+	file, err := ioutil.TempFile("", "nodeconfig")
+	assert.Nil(t, err)
+	_, err = file.WriteString(content)
+	assert.Nil(t, err)
+	file.Close()
+	return file.Name()
+}
+
+func TestLoadNodeConfigFile(t *testing.T) {
+	file := writeNodeConfigFile(t, `{
+		"Common": {"GasPrice": 500, "LogModules": "vbft=debug"},
+		"P2PNode": {"MaxConnInBound": 10, "ReservedCfg": {"reserved": ["1.2.3.4"]}},
+		"Rpc": {"EnableHttpJsonRpc": false}
+	}`)
+	defer os.Remove(file)
+
+	cfg := NewZeepinChainConfig()
+	assert.Nil(t, LoadNodeConfigFile(file, cfg))
+	assert.Equal(t, uint64(500), cfg.Common.GasPrice)
+	assert.Equal(t, "vbft=debug", cfg.Common.LogModules)
+	assert.Equal(t, uint(10), cfg.P2PNode.MaxConnInBound)
+	assert.Equal(t, []string{"1.2.3.4"}, cfg.P2PNode.ReservedCfg.ReservedPeers)
+	assert.False(t, cfg.Rpc.EnableHttpJsonRpc)
+	//settings missing in the file keep their value
+	assert.Equal(t, DEFAULT_NODE_PORT, cfg.P2PNode.NodePort)
+	assert.Equal(t, DEFAULT_RPC_PORT, cfg.Rpc.HttpJsonPort)
+
+	genesis := writeNodeConfigFile(t, `{"Genesis": {}}`)
+	defer os.Remove(genesis)
+	assert.NotNil(t, LoadNodeConfigFile(genesis, NewZeepinChainConfig()))
+}
+
+func TestReload(t *testing.T) {
+	cur := NewZeepinChainConfig()
+	next := NewZeepinChainConfig()
+	next.Common.GasPrice = 1000
+	next.P2PNode.MaxConnOutBound = 8
+	next.P2PNode.ReservedCfg = &P2PRsvConfig{ReservedPeers: []string{"1.2.3.4"}}
+	next.P2PNode.NodePort = 30338
+	next.Rpc.EnableHttpJsonRpc = false
+	next.Genesis = PolarisConfig
+
+	result := cur.Reload(next)
+	assert.Equal(t, []string{"Common.GasPrice", "P2PNode.ReservedCfg", "P2PNode.MaxConnOutBound", "Rpc.EnableHttpJsonRpc"}, result.Applied)
+	assert.Equal(t, []string{"P2PNode.NodePort"}, result.RequiresRestart)
+
+	assert.Equal(t, uint64(1000), cur.Common.GasPrice)
+	assert.Equal(t, uint(8), cur.P2PNode.MaxConnOutBound)
+	assert.Equal(t, []string{"1.2.3.4"}, cur.P2PNode.ReservedCfg.ReservedPeers)
+	assert.False(t, cur.Rpc.EnableHttpJsonRpc)
+	assert.Equal(t, DEFAULT_NODE_PORT, cur.P2PNode.NodePort)
+	assert.Equal(t, MainNetConfig, cur.Genesis)
+
+	result = cur.Reload(next)
+	assert.Equal(t, 0, len(result.Applied))
+	assert.Equal(t, []string{"P2PNode.NodePort"}, result.RequiresRestart)
+}
+
+func TestReloadConcurrentRead(t *testing.T) {
+	cur := NewZeepinChainConfig()
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			cur.GetGasPrice()
+			cur.GetMaxConnInBound()
+			cur.GetEnableHttpJsonRpc()
+			_, reservedCfg := cur.GetReservedCfg()
+			_ = len(reservedCfg.ReservedPeers)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		next := NewZeepinChainConfig()
+		next.Common.GasPrice = uint64(i)
+		next.P2PNode.MaxConnInBound = uint(i)
+		next.P2PNode.ReservedCfg = &P2PRsvConfig{ReservedPeers: []string{"1.2.3.4"}}
+		next.Rpc.EnableHttpJsonRpc = i%2 == 0
+		cur.Reload(next)
+	}
+	close(done)
+	wg.Wait()
+
+	assert.Equal(t, uint64(99), cur.GetGasPrice())
+	reservedOnly, reservedCfg := cur.GetReservedCfg()
+	assert.False(t, reservedOnly)
+	assert.Equal(t, []string{"1.2.3.4"}, reservedCfg.ReservedPeers)
+}
+
+func TestSaveNodeConfigFile(t *testing.T) {
+	file := writeNodeConfigFile(t, "")
+	defer os.Remove(file)
+
+	cfg := NewZeepinChainConfig()
+	cfg.P2PNode.NodePort = 30338
+	cfg.P2PNode.NetworkId = 299
+	cfg.Metrics.MetricsPort = 30340
+	assert.Nil(t, SaveNodeConfigFile(file, cfg))
+
+	loaded := NewZeepinChainConfig()
+	assert.Nil(t, LoadNodeConfigFile(file, loaded))
+	assert.Equal(t, uint(30338), loaded.P2PNode.NodePort)
+	assert.Equal(t, uint32(299), loaded.P2PNode.NetworkId)
+	assert.Equal(t, uint(30340), loaded.Metrics.MetricsPort)
+	assert.Equal(t, 0, len(cfg.Reload(loaded).RequiresRestart))
+}
@@ -35,6 +35,7 @@
 package constants
 
 import (
+	"math"
 	"time"
 )
 
@@ -44,6 +45,49 @@ var (
 	GENESIS_BLOCK_TIMESTAMP = uint32(time.Date(2018, time.August, 31, 0, 0, 0, 0, time.UTC).Unix())
 )
 
+// fork heights
+const (
+	//TODO: schedule the vm type check on public networks
+	VM_TYPE_CHECK_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	VM_TYPE_CHECK_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule the embed vm signature opcodes on public networks
+	CHECKSIG_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	CHECKSIG_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule the wasm memory allocator on public networks
+	WASM_ALLOCATOR_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	WASM_ALLOCATOR_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule the nft native contract on public networks
+	NFT_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	NFT_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule the claim native contract on public networks
+	CLAIM_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	CLAIM_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule gid control policies and guardians on public networks
+	GID_POLICY_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	GID_POLICY_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule role revocation and audit queries of the auth contract on public networks
+	AUTH_AUDIT_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	AUTH_AUDIT_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule auth-protected contract methods on public networks
+	PROTECTED_METHODS_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	PROTECTED_METHODS_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule contract event abis on public networks
+	EVENT_ABI_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	EVENT_ABI_HEIGHT_POLARIS = uint32(math.MaxUint32)
+
+	//TODO: schedule transaction validity windows on public networks
+	TX_VALIDITY_WINDOW_HEIGHT_MAINNET = uint32(math.MaxUint32)
+	TX_VALIDITY_WINDOW_HEIGHT_POLARIS = uint32(math.MaxUint32)
+)
+
 // zpt constants
 const (
 	ZPT_NAME         = "ZPT Token"
@@ -42,10 +42,10 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -124,16 +124,28 @@ func NameLevel(name string) int {
 }
 
 type Logger struct {
-	level   int
-	logger  *log.Logger
-	logFile *os.File
+	level    int
+	logger   *log.Logger
+	logFile  *os.File
+	outputs  []interface{} //log locations the logger was initialized with, reused by Rotate
+	openTime time.Time
+
+	lock    sync.RWMutex
+	modules map[string]int //per-module level overrides
+	flag    int
+	json    bool
+	maxSize int64         //MByte, DEFAULT_MAX_LOG_SIZE if 0
+	maxAge  time.Duration //no age limit if 0
 }
 
 func New(out io.Writer, prefix string, flag, level int, file *os.File) *Logger {
 	return &Logger{
-		level:   level,
-		logger:  log.New(out, prefix, flag),
-		logFile: file,
+		level:    level,
+		logger:   log.New(out, prefix, flag),
+		logFile:  file,
+		openTime: time.Now(),
+		modules:  make(map[string]int),
+		flag:     flag,
 	}
 }
 
@@ -146,28 +158,111 @@ func (l *Logger) SetDebugLevel(level int) error {
 	return nil
 }
 
-func (l *Logger) Output(level int, a ...interface{}) error {
-	if level >= l.level {
-		gid := GetGID()
-		gidStr := strconv.FormatUint(gid, 10)
-
-		a = append([]interface{}{LevelName(level), "GID",
-			gidStr + ","}, a...)
+// SetModuleLevels applies a level spec like "info,vbft=debug,p2p=warn", the module
+// overrides replace the current ones and a term without module sets the default level
+func (l *Logger) SetModuleLevels(spec string) error {
+	level, modules, err := ParseModuleLevels(spec)
+	if err != nil {
+		return err
+	}
+	if level >= 0 {
+		l.level = level
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.modules = modules
+	return nil
+}
 
-		return l.logger.Output(CALL_DEPTH, fmt.Sprintln(a...))
+// GetModuleLevels returns a copy of the per-module level overrides
+func (l *Logger) GetModuleLevels() map[string]int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	modules := make(map[string]int, len(l.modules))
+	for module, level := range l.modules {
+		modules[module] = level
+	}
+	return modules
+}
+
+// SetFormat switches the output between FORMAT_TEXT and FORMAT_JSON records
+func (l *Logger) SetFormat(format string) error {
+	var json bool
+	switch format {
+	case FORMAT_TEXT:
+	case FORMAT_JSON:
+		json = true
+	default:
+		return fmt.Errorf("invalid log format %s", format)
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.json = json
+	if json {
+		//json records carry their own timestamp
+		l.logger.SetFlags(0)
+	} else {
+		l.logger.SetFlags(l.flag)
 	}
 	return nil
 }
 
-func (l *Logger) Outputf(level int, format string, v ...interface{}) error {
-	if level >= l.level {
-		gid := GetGID()
-		v = append([]interface{}{LevelName(level), "GID",
-			gid}, v...)
+// SetRotation sets the size in MByte and the age after which CheckIfNeedNewFile asks for a new log file
+func (l *Logger) SetRotation(maxSize int64, maxAge time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.maxSize = maxSize
+	l.maxAge = maxAge
+}
 
-		return l.logger.Output(CALL_DEPTH, fmt.Sprintf("%s %s %d, "+format+"\n", v...))
+func (l *Logger) moduleLevel(module string) int {
+	if module != "" {
+		l.lock.RLock()
+		level, ok := l.modules[module]
+		l.lock.RUnlock()
+		if ok {
+			return level
+		}
 	}
-	return nil
+	return l.level
+}
+
+func (l *Logger) isJSON() bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.json
+}
+
+//output writes a record tagged with module, caller is the position of debug and trace records
+//and fields the key/value pairs of structured records
+func (l *Logger) output(level int, module, caller, msg string, fields []interface{}) error {
+	if level < l.moduleLevel(module) {
+		return nil
+	}
+	gid := GetGID()
+	if l.isJSON() {
+		return l.logger.Output(CALL_DEPTH, jsonRecord(level, module, gid, caller, msg, fields))
+	}
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "%s GID %d, ", LevelName(level), gid)
+	if module != "" {
+		buf.WriteString("[" + module + "] ")
+	}
+	if caller != "" {
+		buf.WriteString(caller + " ")
+	}
+	buf.WriteString(msg)
+	writeTextFields(buf, fields)
+	buf.WriteByte('\n')
+	return l.logger.Output(CALL_DEPTH, buf.String())
+}
+
+func (l *Logger) Output(level int, a ...interface{}) error {
+	return l.output(level, "", "", sprintln(a...), nil)
+}
+
+func (l *Logger) Outputf(level int, format string, v ...interface{}) error {
+	return l.output(level, "", "", fmt.Sprintf(format, v...), nil)
 }
 
 func (l *Logger) Trace(a ...interface{}) {
@@ -222,72 +317,51 @@ func Trace(a ...interface{}) {
 	if TraceLog < Log.level {
 		return
 	}
-
-	pc := make([]uintptr, 10)
-	runtime.Callers(2, pc)
-	f := runtime.FuncForPC(pc[0])
-	file, line := f.FileLine(pc[0])
-	fileName := filepath.Base(file)
-
-	nameFull := f.Name()
-	nameEnd := filepath.Ext(nameFull)
-	funcName := strings.TrimPrefix(nameEnd, ".")
-
-	a = append([]interface{}{funcName + "()", fileName + ":" + strconv.Itoa(line)}, a...)
-
-	Log.Trace(a...)
+	Log.output(TraceLog, "", traceCaller(1), sprintln(a...), nil)
 }
 
 func Tracef(format string, a ...interface{}) {
 	if TraceLog < Log.level {
 		return
 	}
-
-	pc := make([]uintptr, 10)
-	runtime.Callers(2, pc)
-	f := runtime.FuncForPC(pc[0])
-	file, line := f.FileLine(pc[0])
-	fileName := filepath.Base(file)
-
-	nameFull := f.Name()
-	nameEnd := filepath.Ext(nameFull)
-	funcName := strings.TrimPrefix(nameEnd, ".")
-
-	a = append([]interface{}{funcName, fileName, line}, a...)
-
-	Log.Tracef("%s() %s:%d "+format, a...)
+	Log.output(TraceLog, "", traceCaller(1), fmt.Sprintf(format, a...), nil)
 }
 
 func Debug(a ...interface{}) {
 	if DebugLog < Log.level {
 		return
 	}
-
-	pc := make([]uintptr, 10)
-	runtime.Callers(2, pc)
-	f := runtime.FuncForPC(pc[0])
-	file, line := f.FileLine(pc[0])
-	fileName := filepath.Base(file)
-
-	a = append([]interface{}{f.Name(), fileName + ":" + strconv.Itoa(line)}, a...)
-
-	Log.Debug(a...)
+	Log.output(DebugLog, "", debugCaller(1), sprintln(a...), nil)
 }
 
 func Debugf(format string, a ...interface{}) {
 	if DebugLog < Log.level {
 		return
 	}
+	Log.output(DebugLog, "", debugCaller(1), fmt.Sprintf(format, a...), nil)
+}
+
+// Debugw writes msg with the key/value pairs kv as a structured debug record
+func Debugw(msg string, kv ...interface{}) {
+	if DebugLog < Log.level {
+		return
+	}
+	Log.output(DebugLog, "", debugCaller(1), msg, kv)
+}
 
-	pc := make([]uintptr, 10)
-	runtime.Callers(2, pc)
-	f := runtime.FuncForPC(pc[0])
-	file, line := f.FileLine(pc[0])
-	fileName := filepath.Base(file)
+// Infow writes msg with the key/value pairs kv as a structured info record
+func Infow(msg string, kv ...interface{}) {
+	Log.output(InfoLog, "", "", msg, kv)
+}
 
-	a = append([]interface{}{f.Name(), fileName, line}, a...)
+// Warnw writes msg with the key/value pairs kv as a structured warn record
+func Warnw(msg string, kv ...interface{}) {
+	Log.output(WarnLog, "", "", msg, kv)
+}
 
-	Log.Debugf("%s %s:%d "+format, a...)
+// Errorw writes msg with the key/value pairs kv as a structured error record
+func Errorw(msg string, kv ...interface{}) {
+	Log.output(ErrorLog, "", "", msg, kv)
 }
 
 func Info(a ...interface{}) {
@@ -376,6 +450,23 @@ func InitLog(logLevel int, a ...interface{}) {
 	}
 	fileAndStdoutWrite := io.MultiWriter(writers...)
 	Log = New(fileAndStdoutWrite, "", log.Ldate|log.Lmicroseconds, logLevel, logFile)
+	Log.outputs = a
+}
+
+// Rotate closes the log file and reopens the log locations, keeping the levels and settings of the logger
+func Rotate() {
+	old := Log
+	ClosePrintLog()
+	InitLog(old.level, old.outputs...)
+
+	old.lock.RLock()
+	defer old.lock.RUnlock()
+	Log.modules = old.modules
+	Log.maxSize = old.maxSize
+	Log.maxAge = old.maxAge
+	if old.json {
+		Log.SetFormat(FORMAT_JSON)
+	}
 }
 
 func GetLogFileSize() (int64, error) {
@@ -395,8 +486,14 @@ func GetMaxLogChangeInterval(maxLogSize int64) int64 {
 }
 
 func CheckIfNeedNewFile() bool {
+	Log.lock.RLock()
+	maxSize, maxAge := Log.maxSize, Log.maxAge
+	Log.lock.RUnlock()
+	if maxAge > 0 && Log.logFile != nil && time.Since(Log.openTime) > maxAge {
+		return true
+	}
 	logFileSize, err := GetLogFileSize()
-	maxLogFileSize := GetMaxLogChangeInterval(0)
+	maxLogFileSize := GetMaxLogChangeInterval(maxSize)
 	if err != nil {
 		return false
 	}
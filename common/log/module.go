@@ -0,0 +1,350 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
+
+	JSON_TIME_FORMAT = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+//modules tagging their records, usable as keys of the level spec
+const (
+	MODULE_CONSENSUS = "consensus"
+	MODULE_VBFT      = "vbft"
+	MODULE_DBFT      = "dbft"
+	MODULE_SOLO      = "solo"
+	MODULE_P2P       = "p2p"
+	MODULE_TXNPOOL   = "txnpool"
+	MODULE_LEDGER    = "ledger"
+)
+
+var levelNames = map[int]string{
+	DebugLog: "debug",
+	InfoLog:  "info",
+	WarnLog:  "warn",
+	ErrorLog: "error",
+	FatalLog: "fatal",
+	TraceLog: "trace",
+}
+
+// ParseLevel parses a level name like "debug" or a level number
+func ParseLevel(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level, n := range levelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	level, err := strconv.Atoi(name)
+	if err != nil || level < 0 || level > MaxLevelLog {
+		return 0, fmt.Errorf("invalid log level %s", name)
+	}
+	return level, nil
+}
+
+// ParseModuleLevels parses a level spec like "info,vbft=debug,p2p=warn", the returned
+// default level is -1 if no term of the spec is without module
+func ParseModuleLevels(spec string) (int, map[string]int, error) {
+	def := -1
+	modules := make(map[string]int)
+	for _, term := range strings.Split(spec, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		idx := strings.Index(term, "=")
+		if idx < 0 {
+			level, err := ParseLevel(term)
+			if err != nil {
+				return 0, nil, err
+			}
+			def = level
+			continue
+		}
+		module := strings.TrimSpace(term[:idx])
+		if module == "" {
+			return 0, nil, fmt.Errorf("missing module in %s", term)
+		}
+		level, err := ParseLevel(term[idx+1:])
+		if err != nil {
+			return 0, nil, err
+		}
+		modules[module] = level
+	}
+	return def, modules, nil
+}
+
+// ModuleLogger writes the records of a module, tagged with the module name and
+// filtered by its level override if one is set
+type ModuleLogger struct {
+	module string
+	fields []interface{}
+}
+
+// Module returns the logger of the named module
+func Module(name string) *ModuleLogger {
+	return &ModuleLogger{module: name}
+}
+
+// With returns a logger adding the key/value pairs to every record
+func (m *ModuleLogger) With(kv ...interface{}) *ModuleLogger {
+	fields := make([]interface{}, 0, len(m.fields)+len(kv))
+	fields = append(fields, m.fields...)
+	fields = append(fields, kv...)
+	return &ModuleLogger{module: m.module, fields: fields}
+}
+
+func (m *ModuleLogger) enabled(level int) bool {
+	return Log != nil && level >= Log.moduleLevel(m.module)
+}
+
+func (m *ModuleLogger) output(level int, caller, msg string, kv []interface{}) {
+	fields := m.fields
+	if len(kv) > 0 {
+		fields = make([]interface{}, 0, len(m.fields)+len(kv))
+		fields = append(fields, m.fields...)
+		fields = append(fields, kv...)
+	}
+	Log.output(level, m.module, caller, msg, fields)
+}
+
+func (m *ModuleLogger) Trace(a ...interface{}) {
+	if m.enabled(TraceLog) {
+		m.output(TraceLog, traceCaller(1), sprintln(a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Tracef(format string, a ...interface{}) {
+	if m.enabled(TraceLog) {
+		m.output(TraceLog, traceCaller(1), fmt.Sprintf(format, a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Debug(a ...interface{}) {
+	if m.enabled(DebugLog) {
+		m.output(DebugLog, debugCaller(1), sprintln(a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Debugf(format string, a ...interface{}) {
+	if m.enabled(DebugLog) {
+		m.output(DebugLog, debugCaller(1), fmt.Sprintf(format, a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Info(a ...interface{}) {
+	if m.enabled(InfoLog) {
+		m.output(InfoLog, "", sprintln(a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Infof(format string, a ...interface{}) {
+	if m.enabled(InfoLog) {
+		m.output(InfoLog, "", fmt.Sprintf(format, a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Warn(a ...interface{}) {
+	if m.enabled(WarnLog) {
+		m.output(WarnLog, "", sprintln(a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Warnf(format string, a ...interface{}) {
+	if m.enabled(WarnLog) {
+		m.output(WarnLog, "", fmt.Sprintf(format, a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Error(a ...interface{}) {
+	if m.enabled(ErrorLog) {
+		m.output(ErrorLog, "", sprintln(a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Errorf(format string, a ...interface{}) {
+	if m.enabled(ErrorLog) {
+		m.output(ErrorLog, "", fmt.Sprintf(format, a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Fatal(a ...interface{}) {
+	if m.enabled(FatalLog) {
+		m.output(FatalLog, "", sprintln(a...), nil)
+	}
+}
+
+func (m *ModuleLogger) Fatalf(format string, a ...interface{}) {
+	if m.enabled(FatalLog) {
+		m.output(FatalLog, "", fmt.Sprintf(format, a...), nil)
+	}
+}
+
+// Debugw writes msg with the key/value pairs kv as a structured debug record
+func (m *ModuleLogger) Debugw(msg string, kv ...interface{}) {
+	if m.enabled(DebugLog) {
+		m.output(DebugLog, debugCaller(1), msg, kv)
+	}
+}
+
+// Infow writes msg with the key/value pairs kv as a structured info record
+func (m *ModuleLogger) Infow(msg string, kv ...interface{}) {
+	if m.enabled(InfoLog) {
+		m.output(InfoLog, "", msg, kv)
+	}
+}
+
+// Warnw writes msg with the key/value pairs kv as a structured warn record
+func (m *ModuleLogger) Warnw(msg string, kv ...interface{}) {
+	if m.enabled(WarnLog) {
+		m.output(WarnLog, "", msg, kv)
+	}
+}
+
+// Errorw writes msg with the key/value pairs kv as a structured error record
+func (m *ModuleLogger) Errorw(msg string, kv ...interface{}) {
+	if m.enabled(ErrorLog) {
+		m.output(ErrorLog, "", msg, kv)
+	}
+}
+
+//sprintln formats like the records of Output without the trailing newline
+func sprintln(a ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(a...), "\n")
+}
+
+//traceCaller returns the function and position skip frames above its caller
+func traceCaller(skip int) string {
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	funcName := strings.TrimPrefix(filepath.Ext(runtime.FuncForPC(pc).Name()), ".")
+	return funcName + "() " + filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+//debugCaller is traceCaller with the full function name
+func debugCaller(skip int) string {
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return runtime.FuncForPC(pc).Name() + " " + filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+//fieldValue returns the printable value of a record field
+func fieldValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	}
+	return v
+}
+
+//fieldPairs calls fn with the key/value pairs of fields, a trailing key misses its value
+func fieldPairs(fields []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		if i+1 == len(fields) {
+			fn(key, "MISSING")
+			return
+		}
+		fn(key, fieldValue(fields[i+1]))
+	}
+}
+
+func writeTextFields(buf *bytes.Buffer, fields []interface{}) {
+	fieldPairs(fields, func(key string, value interface{}) {
+		text := fmt.Sprint(value)
+		if strings.ContainsAny(text, " \t\"=") {
+			text = strconv.Quote(text)
+		}
+		buf.WriteString(" " + key + "=" + text)
+	})
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(data)
+}
+
+//jsonRecord encodes a record as a single json line
+func jsonRecord(level int, module string, gid uint64, caller, msg string, fields []interface{}) string {
+	buf := bytes.NewBufferString("{")
+	writeJSONField(buf, "time", time.Now().Format(JSON_TIME_FORMAT))
+	name, ok := levelNames[level]
+	if !ok {
+		name = strconv.Itoa(level)
+	}
+	writeJSONField(buf, "level", name)
+	if module != "" {
+		writeJSONField(buf, "module", module)
+	}
+	writeJSONField(buf, "gid", gid)
+	if caller != "" {
+		writeJSONField(buf, "caller", caller)
+	}
+	writeJSONField(buf, "msg", msg)
+	fieldPairs(fields, func(key string, value interface{}) {
+		writeJSONField(buf, key, value)
+	})
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModuleLevels(t *testing.T) {
+	level, modules, err := ParseModuleLevels("vbft=debug, p2p=WARN,txnpool=3")
+	assert.Nil(t, err)
+	assert.Equal(t, -1, level)
+	assert.Equal(t, map[string]int{"vbft": DebugLog, "p2p": WarnLog, "txnpool": ErrorLog}, modules)
+
+	level, modules, err = ParseModuleLevels("error,ledger=info")
+	assert.Nil(t, err)
+	assert.Equal(t, ErrorLog, level)
+	assert.Equal(t, map[string]int{"ledger": InfoLog}, modules)
+
+	_, _, err = ParseModuleLevels("vbft=loud")
+	assert.NotNil(t, err)
+	_, _, err = ParseModuleLevels("=debug")
+	assert.NotNil(t, err)
+	_, _, err = ParseModuleLevels("vbft=7")
+	assert.NotNil(t, err)
+}
+
+func newBufferLog(buf *bytes.Buffer, level int) {
+	Log = New(buf, "", log.Ldate|log.Lmicroseconds, level, nil)
+}
+
+func TestModuleLevels(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	newBufferLog(buf, InfoLog)
+	vbft := Module(MODULE_VBFT)
+	p2p := Module(MODULE_P2P)
+
+	vbft.Debug("hidden")
+	p2p.Info("shown")
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), "[p2p] shown")
+
+	assert.Nil(t, Log.SetModuleLevels("vbft=debug,p2p=warn"))
+	buf.Reset()
+	vbft.Debugf("round %d", 3)
+	p2p.Info("hidden")
+	Info("default")
+	out := buf.String()
+	assert.Equal(t, 2, strings.Count(out, "\n"))
+	assert.Contains(t, out, "[vbft] github.com/imZhuFei/zeepin/common/log.TestModuleLevels module_test.go:")
+	assert.Contains(t, out, "round 3")
+	assert.Contains(t, out, "default")
+
+	assert.NotNil(t, Log.SetModuleLevels("vbft=loud"))
+	assert.Equal(t, map[string]int{MODULE_VBFT: DebugLog, MODULE_P2P: WarnLog}, Log.GetModuleLevels())
+	assert.Nil(t, Log.SetModuleLevels(""))
+	assert.Equal(t, 0, len(Log.GetModuleLevels()))
+}
+
+func TestTextFields(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	newBufferLog(buf, InfoLog)
+
+	Module(MODULE_TXNPOOL).With("worker", 2).Infow("tx rejected", "reason", "gas price", "err", errors.New("low"), "dangling")
+	assert.Contains(t, buf.String(), `[txnpool] tx rejected worker=2 reason="gas price" err=low dangling=MISSING`)
+}
+
+func TestJSONFormat(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	newBufferLog(buf, DebugLog)
+	assert.Nil(t, Log.SetFormat(FORMAT_JSON))
+	assert.NotNil(t, Log.SetFormat("xml"))
+
+	Module(MODULE_LEDGER).Infow("block saved", "height", 12, "txs", 3)
+	Debugf("untagged %s", "debug")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	record := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "info", record["level"])
+	assert.Equal(t, MODULE_LEDGER, record["module"])
+	assert.Equal(t, "block saved", record["msg"])
+	assert.Equal(t, float64(12), record["height"])
+	assert.Equal(t, float64(3), record["txs"])
+	assert.NotEmpty(t, record["time"])
+
+	record = make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "debug", record["level"])
+	assert.Nil(t, record["module"])
+	assert.Equal(t, "untagged debug", record["msg"])
+	assert.Contains(t, record["caller"], "module_test.go:")
+
+	assert.Nil(t, Log.SetFormat(FORMAT_TEXT))
+	buf.Reset()
+	Info("text again")
+	assert.False(t, strings.HasPrefix(buf.String(), "{"))
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+// Package metrics holds the prometheus collectors of the node internals
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const NAMESPACE = "zeepin"
+
+//rejection reasons of the transaction pool
+const (
+	REJECT_OVERSIZE  = "oversize"  //transaction over the max tx size
+	REJECT_DUPLICATE = "duplicate" //transaction already in the pool or being verified
+	REJECT_POOL_FULL = "pool_full" //transaction pool reached its capacity
+	REJECT_GAS       = "gas"       //gas limit or gas price not accepted
+	REJECT_PREEXEC   = "preexec"   //pre-execution check failed
+	REJECT_VERIFY    = "verify"    //signature or stateful verification failed
+	REJECT_EXPIRED   = "expired"   //validity window ended while in the pool
+)
+
+//message directions of the p2p counters
+const (
+	DIRECTION_IN  = "in"
+	DIRECTION_OUT = "out"
+)
+
+var (
+	BlockHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "ledger",
+		Name:      "block_height",
+		Help:      "Height of the current block.",
+	})
+	BlockTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "ledger",
+		Name:      "block_timestamp_seconds",
+		Help:      "Timestamp of the current block.",
+	})
+	BlockTxs = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "ledger",
+		Name:      "block_txs",
+		Help:      "Transactions per saved block.",
+		Buckets:   []float64{0, 1, 10, 50, 100, 500, 1000, 5000, 10000},
+	})
+	BlockExecTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "ledger",
+		Name:      "block_exec_seconds",
+		Help:      "Time spent executing and committing a block.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	})
+
+	TxPoolRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "txnpool",
+		Name:      "rejected_txs_total",
+		Help:      "Transactions rejected by the transaction pool by reason.",
+	}, []string{"reason"})
+
+	P2PMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "p2p",
+		Name:      "messages_total",
+		Help:      "P2P messages by command and direction.",
+	}, []string{"command", "direction"})
+	P2PBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "p2p",
+		Name:      "message_bytes_total",
+		Help:      "P2P message bytes on the wire by command and direction.",
+	}, []string{"command", "direction"})
+
+	VbftRounds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "vbft",
+		Name:      "rounds_total",
+		Help:      "Consensus rounds started.",
+	})
+	VbftViewChanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "vbft",
+		Name:      "view_changes_total",
+		Help:      "Chain config view changes applied by consensus.",
+	})
+	VbftView = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "vbft",
+		Name:      "view",
+		Help:      "Current chain config view.",
+	})
+
+	RpcLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "rpc",
+		Name:      "request_seconds",
+		Help:      "Json rpc request latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+var (
+	txPoolSize = &source{}
+	peerCount  = &source{}
+)
+
+func init() {
+	prometheus.MustRegister(
+		BlockHeight,
+		BlockTime,
+		BlockTxs,
+		BlockExecTime,
+		TxPoolRejected,
+		P2PMessages,
+		P2PBytes,
+		VbftRounds,
+		VbftViewChanges,
+		VbftView,
+		RpcLatency,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: NAMESPACE,
+			Subsystem: "txnpool",
+			Name:      "verified_txs",
+			Help:      "Verified transactions waiting in the pool.",
+		}, txPoolSize.value(0)),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: NAMESPACE,
+			Subsystem: "txnpool",
+			Name:      "pending_txs",
+			Help:      "Transactions being verified.",
+		}, txPoolSize.value(1)),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: NAMESPACE,
+			Subsystem: "p2p",
+			Name:      "peers",
+			Help:      "Connected neighbor peers.",
+		}, peerCount.value(0)),
+	)
+}
+
+//source reads gauge values from a running service when scraped
+type source struct {
+	lock sync.RWMutex
+	fn   func() []uint32
+}
+
+func (this *source) set(fn func() []uint32) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.fn = fn
+}
+
+func (this *source) value(idx int) func() float64 {
+	return func() float64 {
+		this.lock.RLock()
+		fn := this.fn
+		this.lock.RUnlock()
+		if fn == nil {
+			return 0
+		}
+		vals := fn()
+		if idx >= len(vals) {
+			return 0
+		}
+		return float64(vals[idx])
+	}
+}
+
+// SetTxPoolSource sets the function returning the verified and pending tx counts
+func SetTxPoolSource(fn func() []uint32) {
+	txPoolSize.set(fn)
+}
+
+// SetPeerSource sets the function returning the connected peer count
+func SetPeerSource(fn func() uint32) {
+	peerCount.set(func() []uint32 {
+		return []uint32{fn()}
+	})
+}
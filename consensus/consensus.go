@@ -43,6 +43,8 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_CONSENSUS)
+
 type ConsensusService interface {
 	Start() error
 	Halt() error
@@ -69,6 +71,6 @@ func NewConsensusService(consensusType string, account *account.Account, txpool
 	case CONSENSUS_VBFT:
 		consensus, err = vbft.NewVbftServer(account, txpool, p2p)
 	}
-	log.Infof("ConsensusType:%s", consensusType)
+	logger.Infof("ConsensusType:%s", consensusType)
 	return consensus, err
 }
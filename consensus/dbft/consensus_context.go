@@ -39,7 +39,6 @@ import (
 
 	"github.com/imZhuFei/zeepin/account"
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	ser "github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/core/ledger"
 	"github.com/imZhuFei/zeepin/core/types"
@@ -74,17 +73,17 @@ type ConsensusContext struct {
 }
 
 func (ctx *ConsensusContext) M() int {
-	log.Debug()
+	logger.Debug()
 	return len(ctx.Bookkeepers) - (len(ctx.Bookkeepers)-1)/3
 }
 
 func NewConsensusContext() *ConsensusContext {
-	log.Debug()
+	logger.Debug()
 	return &ConsensusContext{}
 }
 
 func (ctx *ConsensusContext) ChangeView(viewNum byte) {
-	log.Debug()
+	logger.Debug()
 	p := (ctx.Height - uint32(viewNum)) % uint32(len(ctx.Bookkeepers))
 	ctx.State &= SignatureSent
 	ctx.ViewNumber = viewNum
@@ -102,7 +101,7 @@ func (ctx *ConsensusContext) ChangeView(viewNum byte) {
 }
 
 func (ctx *ConsensusContext) MakeChangeView() *msg.ConsensusPayload {
-	log.Debug()
+	logger.Debug()
 	cv := &ChangeView{
 		NewViewNumber: ctx.ExpectedView[ctx.BookkeeperIndex],
 	}
@@ -111,7 +110,7 @@ func (ctx *ConsensusContext) MakeChangeView() *msg.ConsensusPayload {
 }
 
 func (ctx *ConsensusContext) MakeHeader() *types.Block {
-	log.Debug()
+	logger.Debug()
 	if ctx.Transactions == nil {
 		return nil
 	}
@@ -141,7 +140,7 @@ func (ctx *ConsensusContext) MakeHeader() *types.Block {
 }
 
 func (ctx *ConsensusContext) MakePayload(message ConsensusMessage) *msg.ConsensusPayload {
-	log.Debug()
+	logger.Debug()
 	message.ConsensusMessageData().ViewNumber = ctx.ViewNumber
 	return &msg.ConsensusPayload{
 		Version:         ContextVersion,
@@ -155,7 +154,7 @@ func (ctx *ConsensusContext) MakePayload(message ConsensusMessage) *msg.Consensu
 }
 
 func (ctx *ConsensusContext) MakePrepareRequest() *msg.ConsensusPayload {
-	log.Debug()
+	logger.Debug()
 	preReq := &PrepareRequest{
 		Nonce:          ctx.Nonce,
 		NextBookkeeper: ctx.NextBookkeeper,
@@ -167,7 +166,7 @@ func (ctx *ConsensusContext) MakePrepareRequest() *msg.ConsensusPayload {
 }
 
 func (ctx *ConsensusContext) MakePrepareResponse(signature []byte) *msg.ConsensusPayload {
-	log.Debug()
+	logger.Debug()
 	preRes := &PrepareResponse{
 		Signature: signature,
 	}
@@ -176,7 +175,7 @@ func (ctx *ConsensusContext) MakePrepareResponse(signature []byte) *msg.Consensu
 }
 
 func (ctx *ConsensusContext) MakeBlockSignatures(signatures []SignaturesData) *msg.ConsensusPayload {
-	log.Debug()
+	logger.Debug()
 	sigs := &BlockSignatures{
 		Signatures: signatures,
 	}
@@ -185,7 +184,7 @@ func (ctx *ConsensusContext) MakeBlockSignatures(signatures []SignaturesData) *m
 }
 
 func (ctx *ConsensusContext) GetSignaturesCount() (count int) {
-	log.Debug()
+	logger.Debug()
 	count = 0
 	for _, sig := range ctx.Signatures {
 		if sig != nil {
@@ -214,11 +213,11 @@ func (ctx *ConsensusContext) Reset(bkAccount *account.Account) {
 	header := ctx.MakeHeader()
 
 	if height != ctx.Height || header == nil || header.Hash() != preHash || len(ctx.NextBookkeepers) == 0 {
-		log.Info("[ConsensusContext] Calculate Bookkeepers from db")
+		logger.Info("[ConsensusContext] Calculate Bookkeepers from db")
 		var err error
 		ctx.Bookkeepers, err = vote.GetValidators([]*types.Transaction{})
 		if err != nil {
-			log.Error("[ConsensusContext] GetNextBookkeeper failed", err)
+			logger.Error("[ConsensusContext] GetNextBookkeeper failed", err)
 		}
 	} else {
 		ctx.Bookkeepers = ctx.NextBookkeepers
@@ -237,10 +236,10 @@ func (ctx *ConsensusContext) Reset(bkAccount *account.Account) {
 	ctx.Signatures = make([][]byte, bookkeeperLen)
 	ctx.ExpectedView = make([]byte, bookkeeperLen)
 
-	log.Debugf("bookkeepers number: %d", bookkeeperLen)
+	logger.Debugf("bookkeepers number: %d", bookkeeperLen)
 	for i := 0; i < bookkeeperLen; i++ {
 		if keypair.ComparePublicKey(bkAccount.PublicKey, ctx.Bookkeepers[i]) {
-			log.Debugf("this node is bookkeeper %d", i)
+			logger.Debugf("this node is bookkeeper %d", i)
 			ctx.BookkeeperIndex = i
 			ctx.Owner = ctx.Bookkeepers[i]
 			break
@@ -39,7 +39,6 @@ import (
 	"errors"
 	"io"
 
-	"github.com/imZhuFei/zeepin/common/log"
 	ser "github.com/imZhuFei/zeepin/common/serialization"
 )
 
@@ -56,7 +55,7 @@ type ConsensusMessageData struct {
 }
 
 func DeserializeMessage(data []byte) (ConsensusMessage, error) {
-	log.Debug()
+	logger.Debug()
 	msgType := ConsensusMessageType(data[0])
 
 	r := bytes.NewReader(data)
@@ -65,7 +64,7 @@ func DeserializeMessage(data []byte) (ConsensusMessage, error) {
 		prMsg := &PrepareRequest{}
 		err := prMsg.Deserialize(r)
 		if err != nil {
-			log.Error("[DeserializeMessage] PrepareRequestMsg Deserialize Error: ", err.Error())
+			logger.Error("[DeserializeMessage] PrepareRequestMsg Deserialize Error: ", err.Error())
 			return nil, err
 		}
 		return prMsg, nil
@@ -74,7 +73,7 @@ func DeserializeMessage(data []byte) (ConsensusMessage, error) {
 		presMsg := &PrepareResponse{}
 		err := presMsg.Deserialize(r)
 		if err != nil {
-			log.Error("[DeserializeMessage] PrepareResponseMsg Deserialize Error: ", err.Error())
+			logger.Error("[DeserializeMessage] PrepareResponseMsg Deserialize Error: ", err.Error())
 			return nil, err
 		}
 		return presMsg, nil
@@ -82,7 +81,7 @@ func DeserializeMessage(data []byte) (ConsensusMessage, error) {
 		cv := &ChangeView{}
 		err := cv.Deserialize(r)
 		if err != nil {
-			log.Error("[DeserializeMessage] ChangeViewMsg Deserialize Error: ", err.Error())
+			logger.Error("[DeserializeMessage] ChangeViewMsg Deserialize Error: ", err.Error())
 			return nil, err
 		}
 		return cv, nil
@@ -91,7 +90,7 @@ func DeserializeMessage(data []byte) (ConsensusMessage, error) {
 		blockSigs := &BlockSignatures{}
 		err := blockSigs.Deserialize(r)
 		if err != nil {
-			log.Error("[DeserializeMessage] BlockSignaturesMsg Deserialize Error: ", err.Error())
+			logger.Error("[DeserializeMessage] BlockSignaturesMsg Deserialize Error: ", err.Error())
 			return nil, err
 		}
 
@@ -112,7 +111,7 @@ func (cd *ConsensusMessageData) Serialize(w io.Writer) {
 
 //read data to reader
 func (cd *ConsensusMessageData) Deserialize(r io.Reader) error {
-	log.Debug()
+	logger.Debug()
 	//ConsensusMessageType
 	var msgType [1]byte
 	_, err := io.ReadFull(r, msgType[:])
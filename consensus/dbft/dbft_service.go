@@ -57,6 +57,8 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_DBFT)
+
 type DbftService struct {
 	context           ConsensusContext
 	Account           *account.Account
@@ -93,7 +95,7 @@ func NewDbftService(bkAccount *account.Account, txpool, p2p *actor.PID) (*DbftSe
 		for {
 			select {
 			case <-service.timer.C:
-				log.Debug("******Get a timeout notice")
+				logger.Debug("******Get a timeout notice")
 				service.pid.Tell(&actorTypes.TimeOut{})
 			}
 		}
@@ -117,25 +119,25 @@ func (this *DbftService) Receive(context actor.Context) {
 
 	switch msg := context.Message().(type) {
 	case *actor.Restarting:
-		log.Warn("dbft actor restarting")
+		logger.Warn("dbft actor restarting")
 	case *actor.Stopping:
-		log.Warn("dbft actor stopping")
+		logger.Warn("dbft actor stopping")
 	case *actor.Stopped:
-		log.Warn("dbft actor stopped")
+		logger.Warn("dbft actor stopped")
 	case *actor.Started:
-		log.Warn("dbft actor started")
+		logger.Warn("dbft actor started")
 	case *actor.Restart:
-		log.Warn("dbft actor restart")
+		logger.Warn("dbft actor restart")
 	case *actorTypes.StartConsensus:
 		this.start()
 	case *actorTypes.StopConsensus:
 		this.incrValidator.Clean()
 		this.halt()
 	case *actorTypes.TimeOut:
-		log.Info("dbft receive timeout")
+		logger.Info("dbft receive timeout")
 		this.Timeout()
 	case *message.SaveBlockCompleteMsg:
-		log.Infof("dbft actor receives block complete event. block height=%d, numtx=%d",
+		logger.Infof("dbft actor receives block complete event. block height=%d, numtx=%d",
 			msg.Block.Header.Height, len(msg.Block.Transactions))
 		this.incrValidator.AddBlock(msg.Block)
 		this.handleBlockPersistCompleted(msg.Block)
@@ -143,7 +145,7 @@ func (this *DbftService) Receive(context actor.Context) {
 		this.NewConsensusPayload(msg)
 
 	default:
-		log.Info("dbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+		logger.Info("dbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
 	}
 }
 
@@ -161,7 +163,7 @@ func (this *DbftService) Halt() error {
 }
 
 func (self *DbftService) handleBlockPersistCompleted(block *types.Block) {
-	log.Infof("persist block: %x", block.Hash())
+	logger.Infof("persist block: %x", block.Hash())
 	self.p2p.Broadcast(block.Hash())
 
 	self.InitializeConsensus(0)
@@ -169,7 +171,7 @@ func (self *DbftService) handleBlockPersistCompleted(block *types.Block) {
 
 func (ds *DbftService) BlockPersistCompleted(v interface{}) {
 	if block, ok := v.(*types.Block); ok {
-		log.Infof("persist block: %x", block.Hash())
+		logger.Infof("persist block: %x", block.Hash())
 
 		ds.p2p.Broadcast(block.Hash())
 	}
@@ -177,7 +179,7 @@ func (ds *DbftService) BlockPersistCompleted(v interface{}) {
 }
 
 func (ds *DbftService) CheckExpectedView(viewNumber byte) {
-	log.Debug()
+	logger.Debug()
 	if ds.context.State.HasFlag(BlockGenerated) {
 		return
 	}
@@ -195,7 +197,7 @@ func (ds *DbftService) CheckExpectedView(viewNumber byte) {
 
 	M := ds.context.M()
 	if count >= M {
-		log.Debug("[CheckExpectedView] Begin InitializeConsensus.")
+		logger.Debug("[CheckExpectedView] Begin InitializeConsensus.")
 		ds.InitializeConsensus(viewNumber)
 	}
 }
@@ -207,7 +209,7 @@ func (ds *DbftService) CheckPolicy(transaction *types.Transaction) error {
 }
 
 func (ds *DbftService) CheckSignatures() error {
-	log.Debug()
+	logger.Debug()
 
 	//check if get enough signatures
 	if ds.context.GetSignaturesCount() >= ds.context.M() {
@@ -233,7 +235,7 @@ func (ds *DbftService) CheckSignatures() error {
 		hash := block.Hash()
 		isExist, err := ds.ledger.IsContainBlock(hash)
 		if err != nil {
-			log.Errorf("DefLedger.IsContainBlock Hash:%x error:%s", hash, err)
+			logger.Errorf("DefLedger.IsContainBlock Hash:%x error:%s", hash, err)
 			return err
 		}
 		if !isExist {
@@ -252,8 +254,8 @@ func (ds *DbftService) CheckSignatures() error {
 }
 
 func (ds *DbftService) ChangeViewReceived(payload *p2pmsg.ConsensusPayload, message *ChangeView) {
-	log.Debug()
-	log.Info(fmt.Sprintf("Change View Received: height=%d View=%d index=%d nv=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex, message.NewViewNumber))
+	logger.Debug()
+	logger.Info(fmt.Sprintf("Change View Received: height=%d View=%d index=%d nv=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex, message.NewViewNumber))
 
 	if message.NewViewNumber <= ds.context.ExpectedView[payload.BookkeeperIndex] {
 		return
@@ -265,7 +267,7 @@ func (ds *DbftService) ChangeViewReceived(payload *p2pmsg.ConsensusPayload, mess
 }
 
 func (ds *DbftService) halt() error {
-	log.Info("DBFT Stop")
+	logger.Info("DBFT Stop")
 	if ds.timer != nil {
 		ds.timer.Stop()
 	}
@@ -277,8 +279,8 @@ func (ds *DbftService) halt() error {
 }
 
 func (ds *DbftService) InitializeConsensus(viewNum byte) error {
-	log.Debug("[InitializeConsensus] Start InitializeConsensus.")
-	log.Debug("[InitializeConsensus] viewNum: ", viewNum)
+	logger.Debug("[InitializeConsensus] Start InitializeConsensus.")
+	logger.Debug("[InitializeConsensus] viewNum: ", viewNum)
 
 	if viewNum == 0 {
 		ds.context.Reset(ds.Account)
@@ -290,7 +292,7 @@ func (ds *DbftService) InitializeConsensus(viewNum byte) error {
 	}
 
 	if ds.context.BookkeeperIndex < 0 {
-		log.Info("You aren't bookkeeper")
+		logger.Info("You aren't bookkeeper")
 		return nil
 	}
 
@@ -323,7 +325,7 @@ func (ds *DbftService) InitializeConsensus(viewNum byte) error {
 }
 
 func (ds *DbftService) LocalNodeNewInventory(v interface{}) {
-	log.Debug()
+	logger.Debug()
 	if inventory, ok := v.(common.Inventory); ok {
 		if inventory.Type() == common.CONSENSUS {
 			payload, ret := inventory.(*p2pmsg.ConsensusPayload)
@@ -342,23 +344,23 @@ func (ds *DbftService) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
 
 	//if payload is not same height with current contex, ignore it
 	if payload.Version != ContextVersion || payload.PrevHash != ds.context.PrevHash || payload.Height != ds.context.Height {
-		log.Debug("unmatched height")
+		logger.Debug("unmatched height")
 		return
 	}
 
 	if ds.context.State.HasFlag(BlockGenerated) {
-		log.Debug("has flag 'BlockGenerated'")
+		logger.Debug("has flag 'BlockGenerated'")
 		return
 	}
 
 	if int(payload.BookkeeperIndex) >= len(ds.context.Bookkeepers) {
-		log.Debug("bookkeeper index out of range")
+		logger.Debug("bookkeeper index out of range")
 		return
 	}
 
 	message, err := DeserializeMessage(payload.Data)
 	if err != nil {
-		log.Error(fmt.Sprintf("DeserializeMessage failed: %s\n", err))
+		logger.Error(fmt.Sprintf("DeserializeMessage failed: %s\n", err))
 		return
 	}
 
@@ -368,7 +370,7 @@ func (ds *DbftService) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
 
 	err = payload.Verify()
 	if err != nil {
-		log.Warn(err.Error())
+		logger.Warn(err.Error())
 		return
 	}
 
@@ -394,12 +396,12 @@ func (ds *DbftService) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
 		}
 		break
 	default:
-		log.Warn("unknown consensus message type")
+		logger.Warn("unknown consensus message type")
 	}
 }
 
 func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload, message *PrepareRequest) {
-	log.Info(fmt.Sprintf("Prepare Request Received: height=%d View=%d index=%d tx=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex, len(message.Transactions)))
+	logger.Info(fmt.Sprintf("Prepare Request Received: height=%d View=%d index=%d tx=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex, len(message.Transactions)))
 
 	if !ds.context.State.HasFlag(Backup) || ds.context.State.HasFlag(RequestReceived) {
 		return
@@ -411,18 +413,18 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 
 	header, err := ds.ledger.GetHeaderByHash(ds.context.PrevHash)
 	if err != nil {
-		log.Errorf("PrepareRequestReceived GetHeader failed with ds.context.PrevHash:%x", ds.context.PrevHash)
+		logger.Errorf("PrepareRequestReceived GetHeader failed with ds.context.PrevHash:%x", ds.context.PrevHash)
 		return
 	}
 	if header == nil {
-		log.Errorf("PrepareRequestReceived cannot GetHeaderByHash by PrevHash:%x", ds.context.PrevHash)
+		logger.Errorf("PrepareRequestReceived cannot GetHeaderByHash by PrevHash:%x", ds.context.PrevHash)
 		return
 	}
 
 	//TODO Add Error Catch
 	prevBlockTimestamp := header.Timestamp
 	if payload.Timestamp <= prevBlockTimestamp || payload.Timestamp > uint32(time.Now().Add(time.Minute*10).Unix()) {
-		log.Info(fmt.Sprintf("Prepare Reques tReceived: Timestamp incorrect: %d", payload.Timestamp))
+		logger.Info(fmt.Sprintf("Prepare Reques tReceived: Timestamp incorrect: %d", payload.Timestamp))
 		return
 	}
 
@@ -438,7 +440,7 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 	blockHash := ds.context.MakeHeader().Hash()
 	err = signature.Verify(ds.context.Bookkeepers[payload.BookkeeperIndex], blockHash[:], message.Signature)
 	if err != nil {
-		log.Warn("PrepareRequestReceived VerifySignature failed.", err)
+		logger.Warn("PrepareRequestReceived VerifySignature failed.", err)
 		ds.context = backupContext
 		ds.RequestChangeView()
 		return
@@ -456,11 +458,11 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 			validHeight = start
 		} else {
 			ds.incrValidator.Clean()
-			log.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
+			logger.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
 		}
 
 		if err := ds.poolActor.VerifyBlock(ds.context.Transactions, validHeight); err != nil {
-			log.Error("PrepareRequestReceived new transaction verification failed, will not sent Prepare Response", err)
+			logger.Error("PrepareRequestReceived new transaction verification failed, will not sent Prepare Response", err)
 			ds.context = backupContext
 			ds.RequestChangeView()
 
@@ -469,7 +471,7 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 
 		for _, tx := range ds.context.Transactions {
 			if err := ds.incrValidator.Verify(tx, validHeight); err != nil {
-				log.Error("PrepareRequestReceived new transaction increment verification failed, will not sent Prepare Response", err)
+				logger.Error("PrepareRequestReceived new transaction increment verification failed, will not sent Prepare Response", err)
 				ds.context = backupContext
 				ds.RequestChangeView()
 				return
@@ -480,34 +482,34 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 	ds.context.NextBookkeepers, err = vote.GetValidators(ds.context.Transactions)
 	if err != nil {
 		ds.context = backupContext
-		log.Error("[PrepareRequestReceived] GetValidators failed")
+		logger.Error("[PrepareRequestReceived] GetValidators failed")
 		return
 	}
 	ds.context.NextBookkeeper, err = types.AddressFromBookkeepers(ds.context.NextBookkeepers)
 	if err != nil {
 		ds.context = backupContext
-		log.Error("[PrepareRequestReceived] GetBookkeeperAddress failed")
+		logger.Error("[PrepareRequestReceived] GetBookkeeperAddress failed")
 		return
 	}
 
 	if ds.context.NextBookkeeper != message.NextBookkeeper {
 		ds.context = backupContext
 		ds.RequestChangeView()
-		log.Error("[PrepareRequestReceived] Unmatched NextBookkeeper")
+		logger.Error("[PrepareRequestReceived] Unmatched NextBookkeeper")
 		return
 	}
 
-	log.Info("send prepare response")
+	logger.Info("send prepare response")
 	ds.context.State |= SignatureSent
 
 	if ds.context.BookkeeperIndex == -1 {
-		log.Error("[DbftService] GetAccount failed")
+		logger.Error("[DbftService] GetAccount failed")
 		return
 	}
 
 	sig, err := signature.Sign(ds.Account, blockHash[:])
 	if err != nil {
-		log.Error("[DbftService] signing failed")
+		logger.Error("[DbftService] signing failed")
 		return
 	}
 	ds.context.Signatures[ds.context.BookkeeperIndex] = sig
@@ -517,11 +519,11 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 
 	ds.blockReceivedTime = time.Now()
 
-	log.Info("Prepare Request finished")
+	logger.Info("Prepare Request finished")
 }
 
 func (ds *DbftService) PrepareResponseReceived(payload *p2pmsg.ConsensusPayload, message *PrepareResponse) {
-	log.Info(fmt.Sprintf("Prepare Response Received: height=%d View=%d index=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex))
+	logger.Info(fmt.Sprintf("Prepare Response Received: height=%d View=%d index=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex))
 
 	if ds.context.State.HasFlag(BlockGenerated) {
 		return
@@ -545,14 +547,14 @@ func (ds *DbftService) PrepareResponseReceived(payload *p2pmsg.ConsensusPayload,
 	ds.context.Signatures[payload.BookkeeperIndex] = message.Signature
 	err = ds.CheckSignatures()
 	if err != nil {
-		log.Error("CheckSignatures failed", err)
+		logger.Error("CheckSignatures failed", err)
 		return
 	}
-	log.Info("Prepare Response finished")
+	logger.Info("Prepare Response finished")
 }
 
 func (ds *DbftService) BlockSignaturesReceived(payload *p2pmsg.ConsensusPayload, message *BlockSignatures) {
-	log.Info(fmt.Sprintf("BlockSignatures Received: height=%d View=%d index=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex))
+	logger.Info(fmt.Sprintf("BlockSignatures Received: height=%d View=%d index=%d", payload.Height, message.ViewNumber(), payload.BookkeeperIndex))
 
 	if ds.context.State.HasFlag(BlockGenerated) {
 		return
@@ -584,17 +586,17 @@ func (ds *DbftService) BlockSignaturesReceived(payload *p2pmsg.ConsensusPayload,
 
 		ds.context.Signatures[sigdata.Index] = sigdata.Signature
 		if ds.context.GetSignaturesCount() >= ds.context.M() {
-			log.Info("BlockSignatures got enough signatures")
+			logger.Info("BlockSignatures got enough signatures")
 			break
 		}
 	}
 
 	err := ds.CheckSignatures()
 	if err != nil {
-		log.Error("CheckSignatures failed")
+		logger.Error("CheckSignatures failed")
 		return
 	}
-	log.Info("BlockSignatures finished")
+	logger.Info("BlockSignatures finished")
 }
 
 func (ds *DbftService) RefreshPolicy() {
@@ -610,7 +612,7 @@ func (ds *DbftService) RequestChangeView() {
 	} else {
 		ds.context.ExpectedView[ds.context.BookkeeperIndex] += 1
 	}
-	log.Info(fmt.Sprintf("Request change view: height=%d View=%d nv=%d state=%s", ds.context.Height,
+	logger.Info(fmt.Sprintf("Request change view: height=%d View=%d nv=%d state=%s", ds.context.Height,
 		ds.context.ViewNumber, ds.context.ExpectedView[ds.context.BookkeeperIndex], ds.context.GetStateDetail()))
 
 	ds.timer.Stop()
@@ -629,13 +631,13 @@ func (ds *DbftService) SignAndRelay(payload *p2pmsg.ConsensusPayload) {
 }
 
 func (ds *DbftService) start() {
-	log.Debug()
+	logger.Debug()
 	ds.started = true
 
 	if config.DefConfig.Genesis.DBFT.GenBlockTime > config.MIN_GEN_BLOCK_TIME {
 		genesis.GenBlockTime = time.Duration(config.DefConfig.Genesis.DBFT.GenBlockTime) * time.Second
 	} else {
-		log.Warn("The Generate block time should be longer than 2 seconds, so set it to be default 6 seconds.")
+		logger.Warn("The Generate block time should be longer than 2 seconds, so set it to be default 6 seconds.")
 	}
 
 	ds.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
@@ -648,21 +650,21 @@ func (ds *DbftService) Timeout() {
 		return
 	}
 
-	log.Info("Timeout: height: ", ds.timerHeight, " View: ", ds.timeView, " State: ", ds.context.GetStateDetail())
+	logger.Info("Timeout: height: ", ds.timerHeight, " View: ", ds.timeView, " State: ", ds.context.GetStateDetail())
 
 	if ds.context.State.HasFlag(Primary) && !ds.context.State.HasFlag(RequestSent) {
 		//primary node send the prepare request
-		log.Info("Send prepare request: height: ", ds.timerHeight, " View: ", ds.timeView, " State: ", ds.context.GetStateDetail())
+		logger.Info("Send prepare request: height: ", ds.timerHeight, " View: ", ds.timeView, " State: ", ds.context.GetStateDetail())
 		ds.context.State |= RequestSent
 		if !ds.context.State.HasFlag(SignatureSent) {
 			now := uint32(time.Now().Unix())
 			header, err := ds.ledger.GetHeaderByHash(ds.context.PrevHash)
 			if err != nil {
-				log.Errorf("[Timeout] GetHeader PrevHash:%x error:%s", ds.context.PrevHash, err)
+				logger.Errorf("[Timeout] GetHeader PrevHash:%x error:%s", ds.context.PrevHash, err)
 				return
 			}
 			if header == nil {
-				log.Errorf("[Timeout] cannot GetHeaderByHash by PrevHash:%x", ds.context.PrevHash)
+				logger.Errorf("[Timeout] cannot GetHeaderByHash by PrevHash:%x", ds.context.PrevHash)
 				return
 			}
 			//set context Timestamp
@@ -684,10 +686,10 @@ func (ds *DbftService) Timeout() {
 				validHeight = start
 			} else {
 				ds.incrValidator.Clean()
-				log.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
+				logger.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
 			}
 
-			log.Infof("current block height %v, increment validator block cache range: [%d, %d)", height, start, end)
+			logger.Infof("current block height %v, increment validator block cache range: [%d, %d)", height, start, end)
 			txs := ds.poolActor.GetTxnPool(true, validHeight)
 
 			transactions := make([]*types.Transaction, 0, len(txs))
@@ -702,12 +704,12 @@ func (ds *DbftService) Timeout() {
 
 			ds.context.NextBookkeepers, err = vote.GetValidators(ds.context.Transactions)
 			if err != nil {
-				log.Error("[Timeout] GetValidators failed", err.Error())
+				logger.Error("[Timeout] GetValidators failed", err.Error())
 				return
 			}
 			ds.context.NextBookkeeper, err = types.AddressFromBookkeepers(ds.context.NextBookkeepers)
 			if err != nil {
-				log.Error("[Timeout] GetBookkeeperAddress failed")
+				logger.Error("[Timeout] GetBookkeeperAddress failed")
 				return
 			}
 			ds.context.header = nil
@@ -39,7 +39,6 @@ import (
 	"io"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	ser "github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/core/types"
 )
@@ -53,7 +52,7 @@ type PrepareRequest struct {
 }
 
 func (pr *PrepareRequest) Serialize(w io.Writer) error {
-	log.Debug()
+	logger.Debug()
 
 	pr.msgData.Serialize(w)
 	if err := ser.WriteVarUint(w, pr.Nonce); err != nil {
@@ -108,16 +107,16 @@ func (pr *PrepareRequest) Deserialize(r io.Reader) error {
 }
 
 func (pr *PrepareRequest) Type() ConsensusMessageType {
-	log.Debug()
+	logger.Debug()
 	return pr.ConsensusMessageData().Type
 }
 
 func (pr *PrepareRequest) ViewNumber() byte {
-	log.Debug()
+	logger.Debug()
 	return pr.msgData.ViewNumber
 }
 
 func (pr *PrepareRequest) ConsensusMessageData() *ConsensusMessageData {
-	log.Debug()
+	logger.Debug()
 	return &(pr.msgData)
 }
@@ -54,6 +54,8 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_SOLO)
+
 /*
 *Simple consensus for solo node in test environment.
  */
@@ -91,18 +93,18 @@ func NewSoloService(bkAccount *account.Account, txpool *actor.PID) (*SoloService
 func (self *SoloService) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Restarting:
-		log.Info("solo actor restarting")
+		logger.Info("solo actor restarting")
 	case *actor.Stopping:
-		log.Info("solo actor stopping")
+		logger.Info("solo actor stopping")
 	case *actor.Stopped:
-		log.Info("solo actor stopped")
+		logger.Info("solo actor stopped")
 	case *actor.Started:
-		log.Info("solo actor started")
+		logger.Info("solo actor started")
 	case *actor.Restart:
-		log.Info("solo actor restart")
+		logger.Info("solo actor restart")
 	case *actorTypes.StartConsensus:
 		if self.existCh != nil {
-			log.Info("consensus have started")
+			logger.Info("consensus have started")
 			return
 		}
 
@@ -130,16 +132,16 @@ func (self *SoloService) Receive(context actor.Context) {
 			self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
 		}
 	case *message.SaveBlockCompleteMsg:
-		log.Infof("solo actor receives block complete event. block height=%d txnum=%d", msg.Block.Header.Height, len(msg.Block.Transactions))
+		logger.Infof("solo actor receives block complete event. block height=%d txnum=%d", msg.Block.Header.Height, len(msg.Block.Transactions))
 		self.incrValidator.AddBlock(msg.Block)
 
 	case *actorTypes.TimeOut:
 		err := self.genBlock()
 		if err != nil {
-			log.Errorf("Solo genBlock error %s", err)
+			logger.Errorf("Solo genBlock error %s", err)
 		}
 	default:
-		log.Info("solo actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+		logger.Info("solo actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
 	}
 }
 
@@ -171,7 +173,7 @@ func (self *SoloService) genBlock() error {
 }
 
 func (self *SoloService) makeBlock() (*types.Block, error) {
-	log.Debug()
+	logger.Debug()
 	owner := self.Account.PublicKey
 	nextBookkeeper, err := types.AddressFromBookkeepers([]keypair.PublicKey{owner})
 	if err != nil {
@@ -188,10 +190,10 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 		validHeight = start
 	} else {
 		self.incrValidator.Clean()
-		log.Infof("increment validator block height %v != ledger block height %v", int(end)-1, height)
+		logger.Infof("increment validator block height %v != ledger block height %v", int(end)-1, height)
 	}
 
-	log.Infof("current block height %v, increment validator block cache range: [%d, %d)", height, start, end)
+	logger.Infof("current block height %v, increment validator block cache range: [%d, %d)", height, start, end)
 
 	txs := self.poolActor.GetTxnPool(true, validHeight)
 
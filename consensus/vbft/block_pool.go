@@ -42,7 +42,6 @@ import (
 	"sync"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/ontio/ontology-crypto/keypair"
 )
 
@@ -550,7 +549,7 @@ func (pool *BlockPool) commitDone(blkNum uint32, C uint32, N uint32) (uint32, bo
 				break
 			}
 		}
-		log.Infof("Check candidate block endorsersig, not commit Done")
+		logger.Infof("Check candidate block endorsersig, not commit Done")
 	}
 
 	if proposer != math.MaxUint32 {
@@ -691,13 +690,13 @@ func (pool *BlockPool) getSealedBlock(blockNum uint32) (*Block, common.Uint256)
 		if bytes.Compare(h[:], common.UINT256_EMPTY[:]) != 0 {
 			return c.SealedBlock, h
 		}
-		log.Errorf("empty hash founded in block pool sealed cache, blk: %d", blockNum)
+		logger.Errorf("empty hash founded in block pool sealed cache, blk: %d", blockNum)
 	}
 
 	// get from chainstore
 	blk, err := pool.chainStore.GetBlock(blockNum)
 	if err != nil {
-		log.Errorf("getSealedBlock %d err:%v", blockNum, err)
+		logger.Errorf("getSealedBlock %d err:%v", blockNum, err)
 		return nil, common.Uint256{}
 	}
 	return blk, blk.Block.Hash()
@@ -38,7 +38,6 @@ import (
 	"fmt"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/core/ledger"
 )
 
@@ -87,7 +86,7 @@ func (self *ChainStore) AddBlock(block *Block) error {
 	}
 
 	if block.getBlockNum() <= self.GetChainedBlockNum() {
-		log.Warnf("chain store adding chained block(%d, %d)", block.getBlockNum(), self.GetChainedBlockNum())
+		logger.Warnf("chain store adding chained block(%d, %d)", block.getBlockNum(), self.GetChainedBlockNum())
 		return nil
 	}
 
@@ -99,7 +98,7 @@ func (self *ChainStore) AddBlock(block *Block) error {
 	blkNum := self.GetChainedBlockNum() + 1
 	for {
 		if blk, present := self.pendingBlocks[blkNum]; blk != nil && present {
-			log.Infof("ledger adding chained block (%d, %d)", blkNum, self.GetChainedBlockNum())
+			logger.Infof("ledger adding chained block (%d, %d)", blkNum, self.GetChainedBlockNum())
 
 			err := self.db.AddBlock(blk.Block)
 			if err != nil && blkNum > self.GetChainedBlockNum() {
@@ -108,7 +107,7 @@ func (self *ChainStore) AddBlock(block *Block) error {
 
 			self.chainedBlockNum = blkNum
 			if blkNum != self.db.GetCurrentBlockHeight() {
-				log.Errorf("!!! chain store added chained block (%d, %d): %s",
+				logger.Errorf("!!! chain store added chained block (%d, %d): %s",
 					blkNum, self.db.GetCurrentBlockHeight(), err)
 			}
 
@@ -49,6 +49,8 @@ import (
 	"github.com/imZhuFei/zeepin/common/log"
 )
 
+var logger = log.Module(log.MODULE_VBFT)
+
 func shuffle_hash(txid common.Uint256, height uint32, id string, idx int) (uint64, error) {
 	data, err := json.Marshal(struct {
 		Txid   common.Uint256 `json:"txid"`
@@ -120,15 +122,15 @@ func GenesisChainConfig(config *config.VBFTConfig, peersinfo []*config.VBFTPeerS
 		}
 		return false
 	})
-	log.Debugf("sorted peers: %v", peers)
+	logger.Debugf("sorted peers: %v", peers)
 	// get stake sum of top-k peers
 	var sum uint64
 	for i := 0; i < int(config.K); i++ {
 		sum += peers[i].InitPos
-		log.Debugf("peer: %d, stack: %d", peers[i].Index, peers[i].InitPos)
+		logger.Debugf("peer: %d, stack: %d", peers[i].Index, peers[i].InitPos)
 	}
 
-	log.Debugf("sum of top K stakes: %d", sum)
+	logger.Debugf("sum of top K stakes: %d", sum)
 
 	// calculate peer ranks
 	scale := config.L/config.K - 1
@@ -145,7 +147,7 @@ func GenesisChainConfig(config *config.VBFTConfig, peersinfo []*config.VBFTPeerS
 		peerRanks = append(peerRanks, s)
 	}
 
-	log.Debugf("peers rank table: %v", peerRanks)
+	logger.Debugf("peers rank table: %v", peerRanks)
 
 	// calculate pos table
 	chainPeers := make(map[uint32]*PeerConfig, 0)
@@ -169,7 +171,7 @@ func GenesisChainConfig(config *config.VBFTConfig, peersinfo []*config.VBFTPeerS
 		j := h % uint64(i)
 		posTable[i], posTable[j] = posTable[j], posTable[i]
 	}
-	log.Debugf("init pos table: %v", posTable)
+	logger.Debugf("init pos table: %v", posTable)
 
 	// generate chain config, and save to ChainConfigFile
 	peerCfgs := make([]*PeerConfig, 0)
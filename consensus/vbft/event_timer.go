@@ -41,7 +41,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/imZhuFei/zeepin/common/log"
 )
 
 type TimerEventType int
@@ -141,7 +140,7 @@ func (self *EventTimer) StartTimer(Idx uint32, timeout time.Duration) error {
 
 	if t, present := self.normalTimers[Idx]; present {
 		t.Stop()
-		log.Infof("timer for %d got reset", Idx)
+		logger.Infof("timer for %d got reset", Idx)
 	}
 
 	self.normalTimers[Idx] = time.AfterFunc(timeout, func() {
@@ -211,7 +210,7 @@ func (self *EventTimer) startEventTimer(evtType TimerEventType, blockNum uint32)
 	if t, present := timers[blockNum]; present {
 		t.Stop()
 		delete(timers, blockNum)
-		log.Infof("timer (type: %d) for %d got reset", evtType, blockNum)
+		logger.Infof("timer (type: %d) for %d got reset", evtType, blockNum)
 	}
 
 	timeout := self.getEventTimeout(evtType)
@@ -246,7 +245,7 @@ func (self *EventTimer) StartProposalTimer(blockNum uint32) error {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
-	log.Infof("server %d started proposal timer for blk %d", self.server.Index, blockNum)
+	logger.Infof("server %d started proposal timer for blk %d", self.server.Index, blockNum)
 	return self.startEventTimer(EventProposeBlockTimeout, blockNum)
 }
 
@@ -261,7 +260,7 @@ func (self *EventTimer) StartEndorsingTimer(blockNum uint32) error {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
-	log.Infof("server %d started endorsing timer for blk %d", self.server.Index, blockNum)
+	logger.Infof("server %d started endorsing timer for blk %d", self.server.Index, blockNum)
 	return self.startEventTimer(EventEndorseBlockTimeout, blockNum)
 }
 
@@ -276,7 +275,7 @@ func (self *EventTimer) StartEndorseEmptyBlockTimer(blockNum uint32) error {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
-	log.Infof("server %d started empty endorsing timer for blk %d", self.server.Index, blockNum)
+	logger.Infof("server %d started empty endorsing timer for blk %d", self.server.Index, blockNum)
 	return self.startEventTimer(EventEndorseEmptyBlockTimeout, blockNum)
 }
 
@@ -291,7 +290,7 @@ func (self *EventTimer) StartCommitTimer(blockNum uint32) error {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
-	log.Infof("server %d started commit timer for blk %d", self.server.Index, blockNum)
+	logger.Infof("server %d started commit timer for blk %d", self.server.Index, blockNum)
 	return self.startEventTimer(EventCommitBlockTimeout, blockNum)
 }
 
@@ -351,7 +350,7 @@ func (self *EventTimer) onBlockSealed(blockNum uint32) {
 	// clear event timers
 	for i := 0; i < int(EventMax); i++ {
 		if err := self.cancelEventTimer(TimerEventType(i), blockNum); err != nil {
-			log.Errorf("server %d, failed to stop timer %d on sealing",
+			logger.Errorf("server %d, failed to stop timer %d on sealing",
 				self.server.Index, i)
 		}
 	}
@@ -377,7 +376,7 @@ func (self *EventTimer) startPeerTicker(peerIdx uint32) error {
 
 	if p, present := self.peerTickers[peerIdx]; present {
 		p.Stop()
-		log.Infof("ticker for %d got reset", peerIdx)
+		logger.Infof("ticker for %d got reset", peerIdx)
 	}
 
 	timeout := self.getEventTimeout(EventPeerHeartbeat)
@@ -40,9 +40,7 @@ import (
 	"time"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/consensus/vbft/config"
-	"github.com/imZhuFei/zeepin/core/ledger"
 	"github.com/imZhuFei/zeepin/core/signature"
 	"github.com/imZhuFei/zeepin/core/types"
 	"github.com/ontio/ontology-crypto/keypair"
@@ -177,7 +175,7 @@ func (self *Server) constructHeartbeatMsg() (*peerHeartbeatMsg, error) {
 			bookkeepers = append(bookkeepers, keypair.SerializePublicKey(endorsePks[i]))
 		}
 	} else {
-		log.Errorf("Invalid signature counts in block %d: %d vs %d", blkNum, len(endorsePks), len(sigData))
+		logger.Errorf("Invalid signature counts in block %d: %d vs %d", blkNum, len(endorsePks), len(sigData))
 		sigData = make([][]byte, 0)
 	}
 
@@ -199,7 +197,7 @@ func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, tx
 		txHash = append(txHash, t.Hash())
 	}
 	txRoot := common.ComputeMerkleRoot(txHash)
-	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoot(txRoot)
+	blockRoot := self.ledger.GetBlockRootWithNewTxRoot(txRoot)
 
 	blkHeader := &types.Header{
 		PrevBlockHash:    prevBlkHash,
@@ -39,7 +39,6 @@ import (
 	"sync"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 )
 
 var errDropFarFutureMsg = errors.New("msg pool dropped msg for far future")
@@ -135,7 +134,7 @@ func (pool *MsgPool) HasMsg(msg ConsensusMsg, msgHash common.Uint256) bool {
 		return false
 	} else {
 		if present, err := roundMsgs.hasMsg(msg, msgHash); err != nil {
-			log.Errorf("msgpool failed to check msg avail: %s", err)
+			logger.Errorf("msgpool failed to check msg avail: %s", err)
 			return false
 		} else {
 			return present
@@ -39,8 +39,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/imZhuFei/zeepin/common/log"
-	"github.com/imZhuFei/zeepin/core/ledger"
 )
 
 type SyncCheckReq struct {
@@ -138,18 +136,18 @@ func (self *Syncer) run() {
 				continue
 			}
 
-			log.Infof("server %d, got sync req(%d, %d) to %v",
+			logger.Infof("server %d, got sync req(%d, %d) to %v",
 				self.server.Index, req.startBlockNum, req.targetBlockNum, req.targetPeers)
 			if req.startBlockNum <= self.server.GetCommittedBlockNo() {
 				req.startBlockNum = self.server.GetCommittedBlockNo() + 1
-				log.Infof("server %d, sync req start change to %d",
+				logger.Infof("server %d, sync req start change to %d",
 					self.server.Index, req.startBlockNum)
 				if req.startBlockNum > req.targetBlockNum {
 					continue
 				}
 			}
 			if err := self.onNewBlockSyncReq(req); err != nil {
-				log.Errorf("server %d failed to handle new block sync req: %s", self.server.Index, err)
+				logger.Errorf("server %d failed to handle new block sync req: %s", self.server.Index, err)
 			}
 
 		case syncMsg := <-self.syncMsgC:
@@ -170,7 +168,7 @@ func (self *Syncer) run() {
 				continue
 			}
 
-			log.Infof("server %d, next: %d, target: %d,  from syncer %d, blk %d, proposer %d",
+			logger.Infof("server %d, next: %d, target: %d,  from syncer %d, blk %d, proposer %d",
 				self.server.Index, self.nextReqBlkNum, self.targetBlkNum, blkMsgFromPeer.fromPeer, blkNum, blkMsgFromPeer.block.getProposer())
 			if _, present := self.pendingBlocks[blkNum]; !present {
 				self.pendingBlocks[blkNum] = make(BlockFromPeers)
@@ -183,7 +181,7 @@ func (self *Syncer) run() {
 			for self.nextReqBlkNum <= self.targetBlkNum {
 				// FIXME: compete with ledger syncing
 				var blk *Block
-				if self.nextReqBlkNum <= ledger.DefLedger.GetCurrentBlockHeight() {
+				if self.nextReqBlkNum <= self.server.ledger.GetCurrentBlockHeight() {
 					blk, _ = self.server.chainStore.GetBlock(self.nextReqBlkNum)
 				}
 				if blk == nil {
@@ -194,10 +192,10 @@ func (self *Syncer) run() {
 					break
 				}
 				prevHash := blk.getPrevBlockHash()
-				log.Debugf("server %d syncer, sealed block %d, proposer %d, prevhash: %s",
+				logger.Debugf("server %d syncer, sealed block %d, proposer %d, prevhash: %s",
 					self.server.Index, self.nextReqBlkNum, blk.getProposer(), prevHash.ToHexString())
 				if err := self.server.fastForwardBlock(blk); err != nil {
-					log.Errorf("server %d syncer, fastforward block %d failed %s",
+					logger.Errorf("server %d syncer, fastforward block %d failed %s",
 						self.server.Index, self.nextReqBlkNum, err)
 					break
 				}
@@ -221,7 +219,7 @@ func (self *Syncer) run() {
 			}
 
 		case <-self.server.quitC:
-			log.Infof("server %d, syncer quit", self.server.Index)
+			logger.Infof("server %d, syncer quit", self.server.Index)
 			return
 		}
 	}
@@ -283,7 +281,7 @@ func (self *Syncer) cancelFetcherForPeer(peer *PeerSyncer) error {
 
 func (self *Syncer) onNewBlockSyncReq(req *BlockSyncReq) error {
 	if req.startBlockNum < self.nextReqBlkNum {
-		log.Errorf("server %d new blockSyncReq startblkNum %d vs %d",
+		logger.Errorf("server %d new blockSyncReq startblkNum %d vs %d",
 			self.server.Index, req.startBlockNum, self.nextReqBlkNum)
 	}
 	if req.targetBlockNum <= self.targetBlkNum {
@@ -304,7 +302,7 @@ func (self *Syncer) onNewBlockSyncReq(req *BlockSyncReq) error {
 		if p, present := self.peers[peerIdx]; !present || !p.active {
 			nextBlkNum := self.nextReqBlkNum
 			if p != nil && p.nextReqBlkNum > nextBlkNum {
-				log.Infof("server %d, syncer with peer %d start from %d, vs %d",
+				logger.Infof("server %d, syncer with peer %d start from %d, vs %d",
 					self.server.Index, peerIdx, p.nextReqBlkNum, self.nextReqBlkNum)
 				nextBlkNum = p.nextReqBlkNum
 			}
@@ -339,12 +337,12 @@ func (self *PeerSyncer) run() {
 	//				wait block fetch rsp from peer
 	//				notify syncer
 
-	log.Infof("server %d, syncer %d started, start %d, target %d",
+	logger.Infof("server %d, syncer %d started, start %d, target %d",
 		self.server.Index, self.peerIdx, self.nextReqBlkNum, self.targetBlkNum)
 
 	errQuit := true
 	defer func() {
-		log.Infof("server %d, syncer %d quit, start %d, target %d",
+		logger.Infof("server %d, syncer %d quit, start %d, target %d",
 			self.server.Index, self.peerIdx, self.nextReqBlkNum, self.targetBlkNum)
 		self.stop(errQuit)
 	}()
@@ -356,7 +354,7 @@ func (self *PeerSyncer) run() {
 		if _, present := blkProposers[blkNum]; !present {
 			blkInfos, err := self.requestBlockInfo(blkNum)
 			if err != nil {
-				log.Errorf("server %d failed to construct blockinfo fetch msg to peer %d: %s",
+				logger.Errorf("server %d failed to construct blockinfo fetch msg to peer %d: %s",
 					self.server.Index, self.peerIdx, err)
 				return
 			}
@@ -365,7 +363,7 @@ func (self *PeerSyncer) run() {
 			}
 		}
 		if _, present := blkProposers[blkNum]; !present {
-			log.Errorf("server %d failed to get block %d proposer from %d", self.server.Index,
+			logger.Errorf("server %d failed to get block %d proposer from %d", self.server.Index,
 				blkNum, self.peerIdx)
 			return
 		}
@@ -384,13 +382,13 @@ func (self *PeerSyncer) run() {
 
 		if proposalBlock == nil {
 			if proposalBlock, err = self.requestBlock(blkNum); err != nil {
-				log.Errorf("failed to get block %d from peer %d: %s", blkNum, self.peerIdx, err)
+				logger.Errorf("failed to get block %d from peer %d: %s", blkNum, self.peerIdx, err)
 				return
 			}
 		}
 
 		if err := self.fetchedBlock(blkNum, proposalBlock); err != nil {
-			log.Errorf("failed to commit block %d from peer syncer %d to syncer: %s",
+			logger.Errorf("failed to commit block %d from peer syncer %d to syncer: %s",
 				blkNum, self.peerIdx, err)
 		}
 		delete(blkProposers, blkNum)
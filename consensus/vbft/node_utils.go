@@ -39,7 +39,6 @@ import (
 	"fmt"
 	"math"
 
-	"github.com/imZhuFei/zeepin/common/log"
 	vconfig "github.com/imZhuFei/zeepin/consensus/vbft/config"
 	"github.com/imZhuFei/zeepin/core/signature"
 	msgpack "github.com/imZhuFei/zeepin/p2pserver/message/msg_pack"
@@ -171,7 +170,7 @@ func (self *Server) getProposerRankLocked(blockNum uint32, peerIdx uint32) int {
 			}
 		}
 	} else {
-		log.Errorf("todo: get proposer config for non-current blocknum:%d, current.BlockNum%d,peerIdx:%d", blockNum, self.currentParticipantConfig.BlockNum, peerIdx)
+		logger.Errorf("todo: get proposer config for non-current blocknum:%d, current.BlockNum%d,peerIdx:%d", blockNum, self.currentParticipantConfig.BlockNum, peerIdx)
 	}
 	return len(self.currentParticipantConfig.Proposers)
 }
@@ -184,7 +183,7 @@ func (self *Server) getHighestRankProposal(blockNum uint32, proposals []*blockPr
 	var proposal *blockProposalMsg
 	for _, p := range proposals {
 		if p.GetBlockNum() != blockNum {
-			log.Errorf("server %d, diff blockNum found when get highest rank proposal,blockNum:%d", self.Index, blockNum)
+			logger.Errorf("server %d, diff blockNum found when get highest rank proposal,blockNum:%d", self.Index, blockNum)
 			continue
 		}
 
@@ -196,7 +195,7 @@ func (self *Server) getHighestRankProposal(blockNum uint32, proposals []*blockPr
 
 	if proposal == nil && len(proposals) > 0 {
 		for _, p := range proposals {
-			log.Errorf("blk %d, proposer %d", p.Block.getBlockNum(), p.Block.getProposer())
+			logger.Errorf("blk %d, proposer %d", p.Block.getBlockNum(), p.Block.getProposer())
 		}
 		panic("ERR")
 	}
@@ -239,7 +238,7 @@ func (self *Server) buildParticipantConfig(blkNum uint32, block *Block, chainCfg
 	if uint32(len(cfg.Committers)) <= 2*chainCfg.C {
 		return nil, fmt.Errorf("cfg.Committers length less than double chainCfg.C:%d,%d", uint32(len(cfg.Committers)), chainCfg.C)
 	}
-	log.Infof("server %d, blkNum: %d, state: %d, participants config: %v, %v, %v", self.Index, blkNum,
+	logger.Infof("server %d, blkNum: %d, state: %d, participants config: %v, %v, %v", self.Index, blkNum,
 		self.getState(), cfg.Proposers, cfg.Endorsers, cfg.Committers)
 
 	return cfg, nil
@@ -356,7 +355,7 @@ func getCommitConsensus(commitMsgs []*blockCommitMsg, C int) (uint32, bool) {
 			}
 		}
 	}
-	log.Infof("Not Consensus Done")
+	logger.Infof("Not Consensus Done")
 	return math.MaxUint32, false
 }
 
@@ -387,7 +386,7 @@ func (self *Server) heartbeat() {
 	//	build heartbeat msg
 	msg, err := self.constructHeartbeatMsg()
 	if err != nil {
-		log.Errorf("failed to build heartbeat msg: %s", err)
+		logger.Errorf("failed to build heartbeat msg: %s", err)
 		return
 	}
 
@@ -435,7 +434,7 @@ func (self *Server) sendToPeer(peerIdx uint32, data []byte) error {
 	if present {
 		self.p2p.Transmit(p2pid, cons)
 	} else {
-		log.Errorf("sendToPeer transmit failed index:%d", peerIdx)
+		logger.Errorf("sendToPeer transmit failed index:%d", peerIdx)
 	}
 	return nil
 }
@@ -45,6 +45,7 @@ import (
 	"github.com/imZhuFei/zeepin/account"
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	actorTypes "github.com/imZhuFei/zeepin/consensus/actor"
 	"github.com/imZhuFei/zeepin/consensus/vbft/config"
 	"github.com/imZhuFei/zeepin/core/ledger"
@@ -57,12 +58,15 @@ import (
 	gover "github.com/imZhuFei/zeepin/smartcontract/service/native/governance"
 	ninit "github.com/imZhuFei/zeepin/smartcontract/service/native/init"
 	nutils "github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+	txpool "github.com/imZhuFei/zeepin/txnpool/common"
 	"github.com/imZhuFei/zeepin/validator/increment"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-crypto/vrf"
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_VBFT)
+
 type BftActionType uint8
 
 const (
@@ -97,6 +101,18 @@ type BlockParticipantConfig struct {
 	Committers  []uint32
 }
 
+//txPool is the transaction pool the server takes proposal txs from
+type txPool interface {
+	GetTxnPool(byCount bool, height uint32) []*txpool.TXEntry
+	VerifyBlock(txs []*types.Transaction, height uint32) error
+}
+
+//p2pNetwork is the network the server sends consensus msgs through
+type p2pNetwork interface {
+	Broadcast(msg interface{})
+	Transmit(target uint64, msg p2pmsg.Message)
+}
+
 type p2pMsgPayload struct {
 	fromPeer uint32
 	payload  *p2pmsg.ConsensusPayload
@@ -105,8 +121,8 @@ type p2pMsgPayload struct {
 type Server struct {
 	Index         uint32
 	account       *account.Account
-	poolActor     *actorTypes.TxPoolActor
-	p2p           *actorTypes.P2PActor
+	poolActor     txPool
+	p2p           p2pNetwork
 	ledger        *ledger.Ledger
 	incrValidator *increment.IncrementValidator
 	pid           *actor.PID
@@ -146,15 +162,8 @@ type Server struct {
 }
 
 func NewVbftServer(account *account.Account, txpool, p2p *actor.PID) (*Server, error) {
-	server := &Server{
-		msgHistoryDuration: 64,
-		account:            account,
-		poolActor:          &actorTypes.TxPoolActor{Pool: txpool},
-		p2p:                &actorTypes.P2PActor{P2P: p2p},
-		ledger:             ledger.DefLedger,
-		incrValidator:      increment.NewIncrementValidator(10),
-	}
-	server.stateMgr = newStateMgr(server)
+	server := newServer(account, ledger.DefLedger, &actorTypes.TxPoolActor{Pool: txpool},
+		&actorTypes.P2PActor{P2P: p2p})
 
 	props := actor.FromProducer(func() actor.Actor {
 		return server
@@ -173,31 +182,44 @@ func NewVbftServer(account *account.Account, txpool, p2p *actor.PID) (*Server, e
 	return server, nil
 }
 
+func newServer(account *account.Account, db *ledger.Ledger, pool txPool, p2p p2pNetwork) *Server {
+	server := &Server{
+		msgHistoryDuration: 64,
+		account:            account,
+		poolActor:          pool,
+		p2p:                p2p,
+		ledger:             db,
+		incrValidator:      increment.NewIncrementValidator(10),
+	}
+	server.stateMgr = newStateMgr(server)
+	return server
+}
+
 func (self *Server) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Restarting:
-		log.Info("vbft actor restarting")
+		logger.Info("vbft actor restarting")
 	case *actor.Stopping:
-		log.Info("vbft actor stopping")
+		logger.Info("vbft actor stopping")
 	case *actor.Stopped:
-		log.Info("vbft actor stopped")
+		logger.Info("vbft actor stopped")
 	case *actor.Started:
-		log.Info("vbft actor started")
+		logger.Info("vbft actor started")
 	case *actor.Restart:
-		log.Info("vbft actor restart")
+		logger.Info("vbft actor restart")
 	case *actorTypes.StartConsensus:
-		log.Info("vbft actor start consensus")
+		logger.Info("vbft actor start consensus")
 	case *actorTypes.StopConsensus:
 		self.stop()
 	case *message.SaveBlockCompleteMsg:
-		log.Infof("vbft actor receives block complete event. block height=%d, numtx=%d",
+		logger.Infof("vbft actor receives block complete event. block height=%d, numtx=%d",
 			msg.Block.Header.Height, len(msg.Block.Transactions))
 		self.handleBlockPersistCompleted(msg.Block)
 	case *p2pmsg.ConsensusPayload:
 		self.NewConsensusPayload(msg)
 
 	default:
-		log.Info("vbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+		logger.Info("vbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
 	}
 }
 
@@ -215,7 +237,7 @@ func (self *Server) Halt() error {
 }
 
 func (self *Server) handleBlockPersistCompleted(block *types.Block) {
-	log.Infof("persist block: %d, %x", block.Header.Height, block.Hash())
+	logger.Infof("persist block: %d, %x", block.Header.Height, block.Hash())
 
 	self.incrValidator.AddBlock(block)
 
@@ -230,13 +252,13 @@ func (self *Server) handleBlockPersistCompleted(block *types.Block) {
 			self.metaLock.Unlock()
 		}
 	} else {
-		log.Errorf("server %d, persist block %d, vs completed %d",
+		logger.Errorf("server %d, persist block %d, vs completed %d",
 			self.Index, block.Header.Height, self.completedBlockNum)
 	}
 	if self.checkNeedUpdateChainConfig(self.completedBlockNum) || self.checkUpdateChainConfig() {
 		err := self.updateChainConfig()
 		if err != nil {
-			log.Errorf("updateChainConfig failed:%s", err)
+			logger.Errorf("updateChainConfig failed:%s", err)
 		}
 	}
 }
@@ -245,7 +267,7 @@ func (self *Server) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
 	peerID := vconfig.PubkeyID(payload.Owner)
 	peerIdx, present := self.peerPool.GetPeerIndex(peerID)
 	if !present {
-		log.Debugf("invalid consensus node: %s", peerID)
+		logger.Debugf("invalid consensus node: %s", peerID)
 		return
 	}
 	if self.peerPool.isNewPeer(peerIdx) {
@@ -262,7 +284,7 @@ func (self *Server) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
 			payload:  payload,
 		}
 	} else {
-		log.Errorf("consensus msg without receiver: %d node: %s", peerIdx, peerID)
+		logger.Errorf("consensus msg without receiver: %d node: %s", peerIdx, peerID)
 		return
 	}
 }
@@ -295,6 +317,7 @@ func (self *Server) LoadChainConfig(chainStore *ChainStore) error {
 	self.metaLock.Lock()
 	self.config = &cfg
 	self.metaLock.Unlock()
+	metrics.VbftView.Set(float64(cfg.View))
 
 	self.metaLock.RLock()
 	defer self.metaLock.RUnlock()
@@ -315,7 +338,7 @@ func (self *Server) LoadChainConfig(chainStore *ChainStore) error {
 	self.completedBlockNum = self.GetCommittedBlockNo()
 	self.currentBlockNum = self.GetCommittedBlockNo() + 1
 
-	log.Infof("committed: %d, current block no: %d", self.GetCommittedBlockNo(), self.GetCurrentBlockNo())
+	logger.Infof("committed: %d, current block no: %d", self.GetCommittedBlockNo(), self.GetCurrentBlockNo())
 
 	block, _ = self.blockPool.getSealedBlock(self.GetCommittedBlockNo())
 	if block == nil {
@@ -343,11 +366,15 @@ func (self *Server) updateChainConfig() error {
 	if block.Info.NewChainConfig == nil {
 		return fmt.Errorf("GetNewChainConfig nil,%d", self.completedBlockNum)
 	}
-	log.Infof("updateChainConfig blkNum:%d", self.completedBlockNum)
+	logger.Infof("updateChainConfig blkNum:%d", self.completedBlockNum)
 	self.metaLock.Lock()
+	if block.Info.NewChainConfig.View != self.config.View {
+		metrics.VbftViewChanges.Inc()
+	}
 	self.config = block.Info.NewChainConfig
 	self.LastConfigBlockNum = block.getLastConfigBlockNum()
 	self.metaLock.Unlock()
+	metrics.VbftView.Set(float64(block.Info.NewChainConfig.View))
 
 	self.metaLock.RLock()
 	defer self.metaLock.RUnlock()
@@ -363,7 +390,7 @@ func (self *Server) updateChainConfig() error {
 		peermap[p.Index] = p.ID
 		if self.Index == math.MaxUint32 && pubkey == p.ID {
 			self.Index = p.Index
-			log.Infof("updateChainConfig add index :%d", self.Index)
+			logger.Infof("updateChainConfig add index :%d", self.Index)
 		}
 		_, present := self.peerPool.GetPeerIndex(p.ID)
 		if !present {
@@ -379,7 +406,7 @@ func (self *Server) updateChainConfig() error {
 			}
 			publickey, err := vconfig.Pubkey(p.ID)
 			if err != nil {
-				log.Errorf("Pubkey failed: %v", err)
+				logger.Errorf("Pubkey failed: %v", err)
 				return fmt.Errorf("Pubkey failed: %v", err)
 			}
 			peerIdx := p.Index
@@ -388,11 +415,11 @@ func (self *Server) updateChainConfig() error {
 			}
 			go func() {
 				if err := self.run(publickey); err != nil {
-					log.Errorf("server %d, processor on peer %d failed: %s",
+					logger.Errorf("server %d, processor on peer %d failed: %s",
 						self.Index, peerIdx, err)
 				}
 			}()
-			log.Infof("updateChainConfig add peer index:%v,id:%v", p.ID, p.Index)
+			logger.Infof("updateChainConfig add peer index:%v,id:%v", p.ID, p.Index)
 		}
 	}
 	for index, peer := range self.peerPool.peers {
@@ -400,12 +427,12 @@ func (self *Server) updateChainConfig() error {
 		if !present {
 			if index == self.Index {
 				self.Index = math.MaxUint32
-				log.Infof("updateChainConfig remove index :%d", index)
+				logger.Infof("updateChainConfig remove index :%d", index)
 			} else {
 				if C, present := self.msgRecvC[index]; present {
 					pubkey := vconfig.PubkeyID(peer.PubKey)
 					self.peerPool.RemovePeerIndex(pubkey)
-					log.Infof("updateChainConfig remove consensus:index:%d,id:%v", index, pubkey)
+					logger.Infof("updateChainConfig remove consensus:index:%d,id:%v", index, pubkey)
 					C <- nil
 				}
 			}
@@ -419,19 +446,19 @@ func (self *Server) initialize() error {
 
 	// TODO: configurable log
 	selfNodeId := vconfig.PubkeyID(self.account.PublicKey)
-	log.Infof("server: %s starting", selfNodeId)
+	logger.Infof("server: %s starting", selfNodeId)
 
 	store, err := OpenBlockStore(self.ledger)
 	if err != nil {
-		log.Errorf("failed to open block store: %s", err)
+		logger.Errorf("failed to open block store: %s", err)
 		return fmt.Errorf("failed to open block store: %s", err)
 	}
 	self.chainStore = store
-	log.Info("block store opened")
+	logger.Info("block store opened")
 
 	self.blockPool, err = newBlockPool(self, self.msgHistoryDuration, store)
 	if err != nil {
-		log.Errorf("init blockpool: %s", err)
+		logger.Errorf("init blockpool: %s", err)
 		return fmt.Errorf("init blockpool: %s", err)
 	}
 	self.msgPool = newMsgPool(self, self.msgHistoryDuration)
@@ -446,10 +473,10 @@ func (self *Server) initialize() error {
 
 	self.quitC = make(chan struct{})
 	if err := self.LoadChainConfig(store); err != nil {
-		log.Errorf("failed to load config: %s", err)
+		logger.Errorf("failed to load config: %s", err)
 		return fmt.Errorf("failed to load config: %s", err)
 	}
-	log.Infof("chain config loaded from local, current blockNum: %d", self.GetCurrentBlockNo())
+	logger.Infof("chain config loaded from local, current blockNum: %d", self.GetCurrentBlockNo())
 
 	// add all consensus peers to peer_pool
 	for _, p := range self.config.Peers {
@@ -463,7 +490,7 @@ func (self *Server) initialize() error {
 		if err := self.peerPool.addPeer(p); err != nil {
 			return fmt.Errorf("failed to add peer %d: %s", p.Index, err)
 		}
-		log.Infof("added peer: %s", p.ID)
+		logger.Infof("added peer: %s", p.ID)
 	}
 
 	//index equal math.MaxUint32  is noconsensus node
@@ -475,7 +502,9 @@ func (self *Server) initialize() error {
 		self.Index = math.MaxUint32
 	}
 
-	self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	if self.sub != nil {
+		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
 	go self.syncer.run()
 	go self.stateMgr.run()
 	go self.msgSendLoop()
@@ -487,7 +516,7 @@ func (self *Server) initialize() error {
 
 		for {
 			if err := self.processMsgEvent(); err != nil {
-				log.Errorf("server %d: %s", self.Index, err)
+				logger.Errorf("server %d: %s", self.Index, err)
 			}
 			if self.quit {
 				break
@@ -499,7 +528,7 @@ func (self *Server) initialize() error {
 		Type: ConfigLoaded,
 	}
 
-	log.Infof("peer %d started", self.Index)
+	logger.Infof("peer %d started", self.Index)
 
 	// TODO: start peer-conn-handlers
 
@@ -525,7 +554,7 @@ func (self *Server) start() error {
 
 		go func() {
 			if err := self.run(pk); err != nil {
-				log.Errorf("server %d, processor on peer %d failed: %s",
+				logger.Errorf("server %d, processor on peer %d failed: %s",
 					self.Index, peerIdx, err)
 			}
 		}()
@@ -537,7 +566,9 @@ func (self *Server) start() error {
 func (self *Server) stop() error {
 
 	self.incrValidator.Clean()
-	self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	if self.sub != nil {
+		self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
 
 	// stop syncer, statemgr, msgSendLoop, timer, actionLoop, msgProcessingLoop
 	self.quit = true
@@ -573,8 +604,15 @@ func (self *Server) run(peerPubKey keypair.PublicKey) error {
 	}
 
 	defer func() {
+		select {
+		case <-self.quitC:
+			// stop() has cleaned the peer pool and the state mgr is gone,
+			// there is no peer state left to update
+			return
+		default:
+		}
 		// TODO: handle peer disconnection here
-		log.Warnf("server %d: disconnected with peer %d", self.Index, peerIdx)
+		logger.Warnf("server %d: disconnected with peer %d", self.Index, peerIdx)
 		close(self.msgRecvC[peerIdx])
 		delete(self.msgRecvC, peerIdx)
 
@@ -599,11 +637,11 @@ func (self *Server) run(peerPubKey keypair.PublicKey) error {
 			msg, err := DeserializeVbftMsg(msgData)
 
 			if err != nil {
-				log.Errorf("server %d failed to deserialize vbft msg (len %d): %s", self.Index, len(msgData), err)
+				logger.Errorf("server %d failed to deserialize vbft msg (len %d): %s", self.Index, len(msgData), err)
 			} else {
 				pk := self.peerPool.GetPeerPubKey(fromPeer)
 				if pk == nil {
-					log.Errorf("server %d failed to get peer %d pubkey", self.Index, fromPeer)
+					logger.Errorf("server %d failed to get peer %d pubkey", self.Index, fromPeer)
 					continue
 				}
 
@@ -615,13 +653,13 @@ func (self *Server) run(peerPubKey keypair.PublicKey) error {
 				}
 
 				if err := msg.Verify(pk); err != nil {
-					log.Errorf("server %d failed to verify msg, type %d, err: %s",
+					logger.Errorf("server %d failed to verify msg, type %d, err: %s",
 						self.Index, msg.Type(), err)
 					continue
 				}
 
 				if msg.Type() < 4 {
-					log.Infof("server %d received consensus msg, blk %d, type: %d from %d",
+					logger.Infof("server %d received consensus msg, blk %d, type: %d from %d",
 						self.Index, msg.GetBlockNum(), msg.Type(), fromPeer)
 				}
 
@@ -666,9 +704,10 @@ func (self *Server) startNewRound() error {
 	blkNum := self.GetCurrentBlockNo()
 
 	if err := self.updateParticipantConfig(); err != nil {
-		log.Errorf("startNewRound error:%s", err)
+		logger.Errorf("startNewRound error:%s", err)
 		return err
 	}
+	metrics.VbftRounds.Inc()
 	// check proposals in msgpool
 	var proposal *blockProposalMsg
 	if proposals := self.msgPool.GetProposalMsgs(blkNum); len(proposals) > 0 {
@@ -683,7 +722,7 @@ func (self *Server) startNewRound() error {
 			} else {
 				// add other proposals to blockpool
 				if err := self.blockPool.newBlockProposal(msg); err != nil {
-					log.Errorf("starting new round, failed to add proposal from %d: %s",
+					logger.Errorf("starting new round, failed to add proposal from %d: %s",
 						msg.Block.getProposer(), err)
 				}
 			}
@@ -698,7 +737,7 @@ func (self *Server) startNewRound() error {
 				continue
 			}
 			if err := self.blockPool.newBlockEndorsement(msg); err != nil {
-				log.Infof("starting new round, failed to add endorse, blk %d, endorse for %d: %s",
+				logger.Infof("starting new round, failed to add endorse, blk %d, endorse for %d: %s",
 					blkNum, msg.EndorsedProposer, err)
 			}
 		}
@@ -712,7 +751,7 @@ func (self *Server) startNewRound() error {
 				continue
 			}
 			if err := self.blockPool.newBlockCommitment(msg); err != nil {
-				log.Infof("start new round, failed to add commit, blk %d, commit for %d: %s",
+				logger.Infof("start new round, failed to add commit, blk %d, commit for %d: %s",
 					blkNum, msg.BlockProposer, err)
 			}
 		}
@@ -740,7 +779,7 @@ func (self *Server) startNewRound() error {
 func (self *Server) startNewProposal(blkNum uint32) error {
 	// make proposal
 	if self.isProposer(blkNum, self.Index) {
-		log.Infof("server %d, proposer for block %d", self.Index, blkNum)
+		logger.Infof("server %d, proposer for block %d", self.Index, blkNum)
 		// FIXME: possible deadlock on channel
 		self.bftActionC <- &BftAction{
 			Type:     MakeProposal,
@@ -748,7 +787,7 @@ func (self *Server) startNewProposal(blkNum uint32) error {
 			forEmpty: false,
 		}
 	} else if self.is2ndProposer(blkNum, self.Index) {
-		log.Infof("server %d, 2nd proposer for block %d", self.Index, blkNum)
+		logger.Infof("server %d, 2nd proposer for block %d", self.Index, blkNum)
 		self.timer.StartProposalBackoffTimer(blkNum)
 	}
 
@@ -762,7 +801,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 
 	if self.msgPool.HasMsg(msg, msgHash) {
 		// dup msg checking
-		log.Debugf("dup msg with msg type %d from %d", msg.Type(), peerIdx)
+		logger.Debugf("dup msg with msg type %d from %d", msg.Type(), peerIdx)
 		return
 	}
 
@@ -770,7 +809,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 	case BlockProposalMessage:
 		pMsg, ok := msg.(*blockProposalMsg)
 		if !ok {
-			log.Error("invalid msg with proposal msg type")
+			logger.Error("invalid msg with proposal msg type")
 			return
 		}
 
@@ -779,7 +818,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 			// for concurrency, support two active consensus round
 			if err := self.msgPool.AddMsg(msg, msgHash); err != nil {
 				if err != errDropFarFutureMsg {
-					log.Errorf("failed to add proposal msg (%d) to pool: %s", msgBlkNum, err)
+					logger.Errorf("failed to add proposal msg (%d) to pool: %s", msgBlkNum, err)
 				}
 				return
 			}
@@ -796,7 +835,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 
 			if msgBlkNum <= self.GetCommittedBlockNo() {
 				if msgBlkNum+MAX_SYNCING_CHECK_BLK_NUM < self.GetCommittedBlockNo() {
-					log.Infof("server %d get proposal msg for block %d, from %d, current committed %d",
+					logger.Infof("server %d get proposal msg for block %d, from %d, current committed %d",
 						self.Index, msgBlkNum, pMsg.Block.getProposer(), self.GetCommittedBlockNo())
 					self.timer.C <- &TimerEvent{
 						evtType:  EventPeerHeartbeat,
@@ -816,7 +855,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 
 		} else {
 			if err := self.msgPool.AddMsg(msg, msgHash); err != nil {
-				log.Errorf("failed to add proposal msg (%d) to pool", msgBlkNum)
+				logger.Errorf("failed to add proposal msg (%d) to pool", msgBlkNum)
 				return
 			}
 			self.processProposalMsg(pMsg)
@@ -825,7 +864,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 	case BlockEndorseMessage:
 		pMsg, ok := msg.(*blockEndorseMsg)
 		if !ok {
-			log.Error("invalid msg with endorse msg type")
+			logger.Error("invalid msg with endorse msg type")
 			return
 		}
 
@@ -836,7 +875,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 			// for concurrency, support two active consensus round
 			if err := self.msgPool.AddMsg(msg, msgHash); err != nil {
 				if err != errDropFarFutureMsg {
-					log.Errorf("failed to add endorse msg (%d) to pool: %s", msgBlkNum, err)
+					logger.Errorf("failed to add endorse msg (%d) to pool: %s", msgBlkNum, err)
 				}
 				return
 			}
@@ -852,7 +891,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		} else if msgBlkNum < self.GetCurrentBlockNo() {
 			if msgBlkNum <= self.GetCommittedBlockNo() {
 				if msgBlkNum+MAX_SYNCING_CHECK_BLK_NUM < self.GetCommittedBlockNo() {
-					log.Infof("server %d get endorse msg for block %d, from %d, current committed %d",
+					logger.Infof("server %d get endorse msg for block %d, from %d, current committed %d",
 						self.Index, msgBlkNum, pMsg.Endorser, self.GetCommittedBlockNo())
 					self.timer.C <- &TimerEvent{
 						evtType:  EventPeerHeartbeat,
@@ -873,7 +912,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		} else {
 			// add to msg pool
 			if err := self.msgPool.AddMsg(msg, msgHash); err != nil {
-				log.Errorf("failed to add endorse msg (%d) to pool", msgBlkNum)
+				logger.Errorf("failed to add endorse msg (%d) to pool", msgBlkNum)
 				return
 			}
 			self.processConsensusMsg(msg)
@@ -882,7 +921,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 	case BlockCommitMessage:
 		pMsg, ok := msg.(*blockCommitMsg)
 		if !ok {
-			log.Error("invalid msg with commit msg type")
+			logger.Error("invalid msg with commit msg type")
 			return
 		}
 
@@ -892,7 +931,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		if msgBlkNum > self.GetCurrentBlockNo() {
 			if err := self.msgPool.AddMsg(msg, msgHash); err != nil {
 				if err != errDropFarFutureMsg {
-					log.Errorf("failed to add commit msg (%d) to pool: %s", msgBlkNum, err)
+					logger.Errorf("failed to add commit msg (%d) to pool: %s", msgBlkNum, err)
 				}
 				return
 			}
@@ -909,7 +948,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		} else if msgBlkNum < self.GetCurrentBlockNo() {
 			if msgBlkNum <= self.GetCommittedBlockNo() {
 				if msgBlkNum+MAX_SYNCING_CHECK_BLK_NUM < self.GetCommittedBlockNo() {
-					log.Infof("server %d get commit msg for block %d, from %d, current committed %d",
+					logger.Infof("server %d get commit msg for block %d, from %d, current committed %d",
 						self.Index, msgBlkNum, pMsg.Committer, self.GetCommittedBlockNo())
 					self.timer.C <- &TimerEvent{
 						evtType:  EventPeerHeartbeat,
@@ -929,7 +968,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		} else {
 			// add to msg pool
 			if err := self.msgPool.AddMsg(msg, msgHash); err != nil {
-				log.Errorf("failed to add commit msg (%d) to pool", msgBlkNum)
+				logger.Errorf("failed to add commit msg (%d) to pool", msgBlkNum)
 				return
 			}
 			self.processConsensusMsg(msg)
@@ -937,11 +976,11 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 	case PeerHeartbeatMessage:
 		pMsg, ok := msg.(*peerHeartbeatMsg)
 		if !ok {
-			log.Errorf("invalid msg with heartbeat msg type")
+			logger.Errorf("invalid msg with heartbeat msg type")
 			return
 		}
 		if err := self.processHeartbeatMsg(peerIdx, pMsg); err != nil {
-			log.Errorf("server %d, failed to process heartbeat %d: %s", self.Index, peerIdx, err)
+			logger.Errorf("server %d, failed to process heartbeat %d: %s", self.Index, peerIdx, err)
 		}
 		if pMsg.CommittedBlockNumber+MAX_SYNCING_CHECK_BLK_NUM < self.GetCommittedBlockNo() {
 			// delayed peer detected, response heartbeat with our chain Info
@@ -954,7 +993,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 	case ProposalFetchMessage:
 		pMsg, ok := msg.(*proposalFetchMsg)
 		if !ok {
-			log.Errorf("invalid msg with proposal fetch msg type")
+			logger.Errorf("invalid msg with proposal fetch msg type")
 			return
 		}
 		var pmsg *blockProposalMsg
@@ -963,7 +1002,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 			for _, msg := range pMsgs {
 				p := msg.(*blockProposalMsg)
 				if p != nil && p.Block.getProposer() == pMsg.ProposerID {
-					log.Infof("server %d rebroadcast proposal to %d, blk %d",
+					logger.Infof("server %d rebroadcast proposal to %d, blk %d",
 						self.Index, peerIdx, p.Block.getBlockNum())
 					pmsg = p
 				}
@@ -981,7 +1020,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		}
 
 		if pmsg != nil {
-			log.Infof("server %d, handle proposal fetch %d from %d",
+			logger.Infof("server %d, handle proposal fetch %d from %d",
 				self.Index, pMsg.BlockNum, peerIdx)
 			self.msgSendC <- &SendMsgEvent{
 				ToPeer: peerIdx,
@@ -993,16 +1032,16 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		// handle block fetch msg
 		pMsg, ok := msg.(*blockFetchMsg)
 		if !ok {
-			log.Errorf("invalid msg with blockfetch msg type")
+			logger.Errorf("invalid msg with blockfetch msg type")
 			return
 		}
 		blk, blkHash := self.blockPool.getSealedBlock(pMsg.BlockNum)
 		msg, err := self.constructBlockFetchRespMsg(pMsg.BlockNum, blk, blkHash)
 		if err != nil {
-			log.Errorf("server %d, failed to handle blockfetch %d from %d: %s",
+			logger.Errorf("server %d, failed to handle blockfetch %d from %d: %s",
 				self.Index, pMsg.BlockNum, peerIdx, err)
 		} else {
-			log.Infof("server %d, handle blockfetch %d from %d",
+			logger.Infof("server %d, handle blockfetch %d from %d",
 				self.Index, pMsg.BlockNum, peerIdx)
 			self.msgSendC <- &SendMsgEvent{
 				ToPeer: peerIdx,
@@ -1020,7 +1059,7 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		// handle block Info fetch msg
 		pMsg, ok := msg.(*BlockInfoFetchMsg)
 		if !ok {
-			log.Errorf("invalid msg with blockinfo fetch msg type")
+			logger.Errorf("invalid msg with blockinfo fetch msg type")
 			return
 		}
 		maxCnt := 64
@@ -1041,10 +1080,10 @@ func (self *Server) onConsensusMsg(peerIdx uint32, msg ConsensusMsg, msgHash com
 		}
 		msg, err := self.constructBlockInfoFetchRespMsg(blkInfos)
 		if err != nil {
-			log.Errorf("server %d, failed to handle blockinfo fetch %d to %d: %s",
+			logger.Errorf("server %d, failed to handle blockinfo fetch %d to %d: %s",
 				self.Index, pMsg.StartBlockNum, peerIdx, err)
 		} else {
-			log.Infof("server %d, response blockinfo fetch to %d, blk %d, len %d",
+			logger.Infof("server %d, response blockinfo fetch to %d, blk %d, len %d",
 				self.Index, peerIdx, pMsg.StartBlockNum, len(blkInfos))
 			self.msgSendC <- &SendMsgEvent{
 				ToPeer: peerIdx,
@@ -1064,17 +1103,17 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 	msgBlkNum := msg.GetBlockNum()
 	blk, prevBlkHash := self.blockPool.getSealedBlock(msg.GetBlockNum() - 1)
 	if blk == nil {
-		log.Errorf("BlockProposal failed to GetPreBlock:%d", (msg.GetBlockNum() - 1))
+		logger.Errorf("BlockProposal failed to GetPreBlock:%d", (msg.GetBlockNum() - 1))
 		return
 	}
 
 	msgPrevBlkHash := msg.Block.getPrevBlockHash()
 	if prevBlkHash != msgPrevBlkHash {
-		log.Errorf("BlockPrposalMessage check blocknum:%d,prevhash:%s,msg prevhash:%s", msg.GetBlockNum(), prevBlkHash.ToHexString(), msgPrevBlkHash.ToHexString())
+		logger.Errorf("BlockPrposalMessage check blocknum:%d,prevhash:%s,msg prevhash:%s", msg.GetBlockNum(), prevBlkHash.ToHexString(), msgPrevBlkHash.ToHexString())
 		return
 	}
 	if self.LastConfigBlockNum != math.MaxUint32 && blk.Info.LastConfigBlockNum != self.LastConfigBlockNum {
-		log.Errorf("BlockPrposalMessage  check LastConfigBlockNum blocknum:%d,prvLastConfigBlockNum:%d,self LastConfigBlockNum:%d", msg.GetBlockNum(), blk.Info.LastConfigBlockNum, self.LastConfigBlockNum)
+		logger.Errorf("BlockPrposalMessage  check LastConfigBlockNum blocknum:%d,prvLastConfigBlockNum:%d,self LastConfigBlockNum:%d", msg.GetBlockNum(), blk.Info.LastConfigBlockNum, self.LastConfigBlockNum)
 		return
 	}
 
@@ -1082,7 +1121,7 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 	if blk.getNewChainConfig() != nil {
 		cfg = *blk.getNewChainConfig()
 		if cfg.Hash() != self.config.Hash() {
-			log.Errorf("processProposalMsg chainconfig unqeual to blockinfo cfg,view:(%d,%d),N:(%d,%d),C:(%d,%d),BlockMsgDelay:(%d,%d),HashMsgDelay:(%d,%d),PeerHandshakeTimeout:(%d,%d),posTable:(%v,%v),MaxBlockChangeView:(%d,%d)", cfg.View, self.config.View, cfg.N, self.config.N, cfg.C,
+			logger.Errorf("processProposalMsg chainconfig unqeual to blockinfo cfg,view:(%d,%d),N:(%d,%d),C:(%d,%d),BlockMsgDelay:(%d,%d),HashMsgDelay:(%d,%d),PeerHandshakeTimeout:(%d,%d),posTable:(%v,%v),MaxBlockChangeView:(%d,%d)", cfg.View, self.config.View, cfg.N, self.config.N, cfg.C,
 				self.config.C, cfg.BlockMsgDelay, self.config.BlockMsgDelay, cfg.HashMsgDelay, self.config.HashMsgDelay, cfg.PeerHandshakeTimeout, self.config.PeerHandshakeTimeout, cfg.PosTable, self.config.PosTable, cfg.MaxBlockChangeView, self.config.MaxBlockChangeView)
 			return
 		}
@@ -1091,19 +1130,19 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 	prevBlockTimestamp := blk.Block.Header.Timestamp
 	currentBlockTimestamp := msg.Block.Block.Header.Timestamp
 	if currentBlockTimestamp <= prevBlockTimestamp || currentBlockTimestamp > uint32(time.Now().Add(time.Minute*10).Unix()) {
-		log.Errorf("BlockPrposalMessage check  blocknum:%d,prevBlockTimestamp:%d,currentBlockTimestamp:%d", msg.GetBlockNum(), prevBlockTimestamp, currentBlockTimestamp)
+		logger.Errorf("BlockPrposalMessage check  blocknum:%d,prevBlockTimestamp:%d,currentBlockTimestamp:%d", msg.GetBlockNum(), prevBlockTimestamp, currentBlockTimestamp)
 		return
 	}
 
 	// verify VRF
 	proposerPk := self.peerPool.GetPeerPubKey(msg.Block.getProposer())
 	if proposerPk == nil {
-		log.Errorf("server %d failed to get proposer %d pk of block %d",
+		logger.Errorf("server %d failed to get proposer %d pk of block %d",
 			self.Index, msg.Block.getProposer(), msgBlkNum)
 		return
 	}
 	if err := verifyVrf(proposerPk, msgBlkNum, blk.getVrfValue(), msg.Block.getVrfValue(), msg.Block.getVrfProof()); err != nil {
-		log.Errorf("server %d failed to verify vrf of block %d proposal from %d",
+		logger.Errorf("server %d failed to verify vrf of block %d proposal from %d",
 			self.Index, msgBlkNum, msg.Block.getProposer())
 		return
 	}
@@ -1118,21 +1157,21 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 			validHeight = start
 		} else {
 			self.incrValidator.Clean()
-			log.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
+			logger.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
 		}
 		// start new routine to verify txs in proposal block
 		go func() {
 			if err := self.poolActor.VerifyBlock(txs, validHeight); err != nil && err != actor.ErrTimeout {
-				log.Errorf("server %d verify proposal blk from %d failed, blk %d, txs %d, err: %s",
+				logger.Errorf("server %d verify proposal blk from %d failed, blk %d, txs %d, err: %s",
 					self.Index, msg.Block.getProposer(), msgBlkNum, len(txs), err)
 				return
 			} else if err == actor.ErrTimeout {
-				log.Errorf("server %d verify proposal blk from %d timedout, blk %d, txs %d, err: %s",
+				logger.Errorf("server %d verify proposal blk from %d timedout, blk %d, txs %d, err: %s",
 					self.Index, msg.Block.getProposer(), msgBlkNum, len(txs), err)
 			}
 			for _, tx := range txs {
 				if err := self.incrValidator.Verify(tx, validHeight); err != nil {
-					log.Errorf("server %d verify proposal tx from %d failed, blk %d, txs %d, err: %s",
+					logger.Errorf("server %d verify proposal tx from %d failed, blk %d, txs %d, err: %s",
 						self.Index, msg.Block.getProposer(), msgBlkNum, len(txs), err)
 					return
 				}
@@ -1155,7 +1194,7 @@ func (self *Server) processMsgEvent() error {
 	select {
 	case msg := <-self.msgC:
 
-		log.Debugf("server %d process msg, block %d, type %d, current blk %d",
+		logger.Debugf("server %d process msg, block %d, type %d, current blk %d",
 			self.Index, msg.GetBlockNum(), msg.Type(), self.GetCurrentBlockNo())
 
 		switch msg.Type() {
@@ -1174,7 +1213,7 @@ func (self *Server) processMsgEvent() error {
 					if err == errDupProposal {
 						// TODO: faulty proposer detected
 					}
-					log.Errorf("failed to add block proposal (%d): %s", msgBlkNum, err)
+					logger.Errorf("failed to add block proposal (%d): %s", msgBlkNum, err)
 					return nil
 				}
 
@@ -1182,19 +1221,19 @@ func (self *Server) processMsgEvent() error {
 					// check if agreed on prev-blockhash
 					if err := self.verifyPrevBlockHash(msgBlkNum, pMsg); err != nil {
 						// continue
-						log.Errorf("failed verify prevBlockHash from proposer %d, blk %d",
+						logger.Errorf("failed verify prevBlockHash from proposer %d, blk %d",
 							pMsg.Block.getProposer(), msgBlkNum)
 						return nil
 					}
 
 					// stop proposal timer
 					if err := self.timer.CancelProposalTimer(msgBlkNum); err != nil {
-						log.Errorf("failed to cancel proposal timer, blockNum %d, err: %s", msgBlkNum, err)
+						logger.Errorf("failed to cancel proposal timer, blockNum %d, err: %s", msgBlkNum, err)
 					}
 
 					if self.isEndorser(msgBlkNum, self.Index) {
 						if err := self.endorseBlock(pMsg, false); err != nil {
-							log.Errorf("failed to endorse block proposal (%d): %s", msgBlkNum, err)
+							logger.Errorf("failed to endorse block proposal (%d): %s", msgBlkNum, err)
 						}
 					}
 				} else {
@@ -1202,7 +1241,7 @@ func (self *Server) processMsgEvent() error {
 						for _, msg := range self.msgPool.GetProposalMsgs(msgBlkNum) {
 							p := msg.(*blockProposalMsg)
 							if p != nil && p.Block.getProposer() == self.Index {
-								log.Infof("server %d rebroadcast proposal to %d, blk %d",
+								logger.Infof("server %d rebroadcast proposal to %d, blk %d",
 									self.Index, pMsg.Block.getProposer(), msgBlkNum)
 								self.broadcast(msg)
 								break
@@ -1231,10 +1270,10 @@ func (self *Server) processMsgEvent() error {
 			if msgBlkNum == self.GetCurrentBlockNo() {
 				// add endorse to block-pool
 				if err := self.blockPool.newBlockEndorsement(pMsg); err != nil {
-					log.Errorf("failed to add endorsement (%d): %s", msgBlkNum, err)
+					logger.Errorf("failed to add endorsement (%d): %s", msgBlkNum, err)
 					return nil
 				}
-				log.Infof("server %d received endorse from %d, for proposer %d, block %d, empty: %t",
+				logger.Infof("server %d received endorse from %d, for proposer %d, block %d, empty: %t",
 					self.Index, pMsg.Endorser, pMsg.EndorsedProposer, msgBlkNum, pMsg.EndorseForEmpty)
 
 				if self.isEndorser(msgBlkNum, pMsg.Endorser) {
@@ -1248,20 +1287,20 @@ func (self *Server) processMsgEvent() error {
 					if proposer, forEmpty, done := self.blockPool.endorseDone(msgBlkNum, self.config.C); done {
 						// stop endorse timer
 						if err := self.timer.CancelEndorseMsgTimer(msgBlkNum); err != nil {
-							log.Errorf("failed to cancel endorse timer, blockNum %d, err: %s", msgBlkNum, err)
+							logger.Errorf("failed to cancel endorse timer, blockNum %d, err: %s", msgBlkNum, err)
 						}
 						// stop empty endorse timer
 						if err := self.timer.CancelEndorseEmptyBlockTimer(msgBlkNum); err != nil {
-							log.Errorf("failed to cancel empty endorse timer, blockNum %d, err: %s", msgBlkNum, err)
+							logger.Errorf("failed to cancel empty endorse timer, blockNum %d, err: %s", msgBlkNum, err)
 						}
 
 						proposal := self.findBlockProposal(msgBlkNum, proposer, forEmpty)
 						if proposal == nil {
-							log.Infof("server %d endorse %d done, waiting proposal from %d", self.Index, msgBlkNum, proposer)
+							logger.Infof("server %d endorse %d done, waiting proposal from %d", self.Index, msgBlkNum, proposer)
 						} else if self.isCommitter(msgBlkNum, self.Index) {
 							// make endorsement
 							if err := self.makeCommitment(proposal, msgBlkNum, forEmpty); err != nil {
-								log.Errorf("failed to endorse for block %d: %s", msgBlkNum, err)
+								logger.Errorf("failed to endorse for block %d: %s", msgBlkNum, err)
 								return nil
 							}
 						}
@@ -1295,32 +1334,32 @@ func (self *Server) processMsgEvent() error {
 				//              else if WaitCommitsTimer has not started:
 				//                      start WaitCommitsTimer
 				if err := self.blockPool.newBlockCommitment(pMsg); err != nil {
-					log.Errorf("failed to add commit msg (%d): %s", msgBlkNum, err)
+					logger.Errorf("failed to add commit msg (%d): %s", msgBlkNum, err)
 					return nil
 				}
 
-				log.Infof("server %d received commit from %d, for proposer %d, block %d, empty: %t",
+				logger.Infof("server %d received commit from %d, for proposer %d, block %d, empty: %t",
 					self.Index, pMsg.Committer, pMsg.BlockProposer, msgBlkNum, pMsg.CommitForEmpty)
 
 				if proposer, forEmpty, done := self.blockPool.commitDone(msgBlkNum, self.config.C, self.config.N); done {
 					self.blockPool.setCommitDone(msgBlkNum)
-					log.Infof("server %d commit done", msgBlkNum)
+					logger.Infof("server %d commit done", msgBlkNum)
 					proposal := self.findBlockProposal(msgBlkNum, proposer, forEmpty)
 					if proposal == nil {
 						// TODO: commit done, but we not have the proposal, should request proposal from neighbours
 						//       commitTimeout handle this
-						log.Infof("server %d commit %d done, waiting proposal",
+						logger.Infof("server %d commit %d done, waiting proposal",
 							self.Index, msgBlkNum)
 						return nil
 					}
 
 					// stop commit timer
 					if err := self.timer.CancelCommitMsgTimer(msgBlkNum); err != nil {
-						log.Errorf("failed to cancel commit timer, blockNum: %d, err: %s", msgBlkNum, err)
+						logger.Errorf("failed to cancel commit timer, blockNum: %d, err: %s", msgBlkNum, err)
 					}
 
 					if err := self.makeSealed(proposal, forEmpty); err != nil {
-						log.Errorf("failed to seal block %d, err: %s", msgBlkNum, err)
+						logger.Errorf("failed to seal block %d, err: %s", msgBlkNum, err)
 					}
 				} else {
 					// wait commit timeout, nothing to do
@@ -1364,7 +1403,7 @@ func (self *Server) actionLoop() {
 				}
 				if proposal == nil {
 					if err := self.makeProposal(blkNum, action.forEmpty); err != nil {
-						log.Errorf("server %d failed to making proposal (%d): %s",
+						logger.Errorf("server %d failed to making proposal (%d): %s",
 							self.Index, blkNum, err)
 					}
 				}
@@ -1373,7 +1412,7 @@ func (self *Server) actionLoop() {
 				// endorse the proposal
 				blkNum := action.Proposal.GetBlockNum()
 				if err := self.endorseBlock(action.Proposal, action.forEmpty); err != nil {
-					log.Errorf("server %d failed to endorse block proposal (%d): %s",
+					logger.Errorf("server %d failed to endorse block proposal (%d): %s",
 						self.Index, blkNum, err)
 					continue
 				}
@@ -1381,7 +1420,7 @@ func (self *Server) actionLoop() {
 			case CommitBlock:
 				blkNum := action.Proposal.GetBlockNum()
 				if err := self.commitBlock(action.Proposal, action.forEmpty); err != nil {
-					log.Errorf("server %d failed to commit block proposal (%d): %s",
+					logger.Errorf("server %d failed to commit block proposal (%d): %s",
 						self.Index, blkNum, err)
 					continue
 				}
@@ -1390,7 +1429,7 @@ func (self *Server) actionLoop() {
 					continue
 				}
 				if err := self.sealProposal(action.Proposal, action.forEmpty); err != nil {
-					log.Errorf("server %d failed to seal block (%d): %s",
+					logger.Errorf("server %d failed to seal block (%d): %s",
 						self.Index, action.Proposal.GetBlockNum(), err)
 				}
 			case FastForward:
@@ -1401,7 +1440,7 @@ func (self *Server) actionLoop() {
 					C := int(self.config.C)
 
 					if err := self.updateParticipantConfig(); err != nil {
-						log.Errorf("server %d update config failed in forwarding: %s", self.Index, err)
+						logger.Errorf("server %d update config failed in forwarding: %s", self.Index, err)
 					}
 
 					// get pending msgs from msgpool
@@ -1410,7 +1449,7 @@ func (self *Server) actionLoop() {
 						p := msg.(*blockProposalMsg)
 						if p != nil {
 							if err := self.blockPool.newBlockProposal(p); err != nil {
-								log.Errorf("server %d failed add proposal in fastforwarding: %s",
+								logger.Errorf("server %d failed add proposal in fastforwarding: %s",
 									self.Index, err)
 							}
 						}
@@ -1424,16 +1463,16 @@ func (self *Server) actionLoop() {
 							if err := self.blockPool.newBlockCommitment(c); err == nil {
 								commitMsgs = append(commitMsgs, c)
 							} else {
-								log.Errorf("server %d failed to add commit in fastforwarding: %s",
+								logger.Errorf("server %d failed to add commit in fastforwarding: %s",
 									self.Index, err)
 							}
 						}
 					}
 
-					log.Infof("server %d fastforwarding from %d, (%d, %d)",
+					logger.Infof("server %d fastforwarding from %d, (%d, %d)",
 						self.Index, self.GetCurrentBlockNo(), len(cMsgs), len(pMsgs))
 					if len(pMsgs) == 0 && len(cMsgs) == 0 {
-						log.Infof("server %d fastforward done, no msg", self.Index)
+						logger.Infof("server %d fastforward done, no msg", self.Index)
 						self.startNewRound()
 						break
 					}
@@ -1442,9 +1481,9 @@ func (self *Server) actionLoop() {
 					proposer, forEmpty := getCommitConsensus(commitMsgs, C)
 					if proposer == math.MaxUint32 {
 						if err := self.catchConsensus(blkNum); err != nil {
-							log.Infof("server %d fastforward done, catch consensus: %s", self.Index, err)
+							logger.Infof("server %d fastforward done, catch consensus: %s", self.Index, err)
 						}
-						log.Infof("server %d fastforward done at blk %d, no consensus", self.Index, blkNum)
+						logger.Infof("server %d fastforward done at blk %d, no consensus", self.Index, blkNum)
 						break
 					}
 
@@ -1461,18 +1500,18 @@ func (self *Server) actionLoop() {
 						}
 					}
 					if proposal == nil {
-						log.Infof("server %d fastforward stopped at blk %d, no proposal", self.Index, blkNum)
+						logger.Infof("server %d fastforward stopped at blk %d, no proposal", self.Index, blkNum)
 						self.fetchProposal(blkNum, proposer)
 						self.timer.StartCommitTimer(blkNum)
 						break
 					}
 
-					log.Infof("server %d fastforwarding block %d, proposer %d",
+					logger.Infof("server %d fastforwarding block %d, proposer %d",
 						self.Index, blkNum, proposal.Block.getProposer())
 
 					// fastforward the block
 					if err := self.sealBlock(proposal.Block, forEmpty, true); err != nil {
-						log.Errorf("server %d fastforward stopped at blk %d, seal failed: %s",
+						logger.Errorf("server %d fastforward stopped at blk %d, seal failed: %s",
 							self.Index, blkNum, err)
 						break
 					}
@@ -1494,7 +1533,7 @@ func (self *Server) actionLoop() {
 
 				for _, p := range proposals {
 					if p.Block.getProposer() == self.Index {
-						log.Infof("server %d rebroadcast proposal, blk %d",
+						logger.Infof("server %d rebroadcast proposal, blk %d",
 							self.Index, p.Block.getBlockNum())
 						self.broadcast(p)
 					}
@@ -1506,7 +1545,7 @@ func (self *Server) actionLoop() {
 					for _, msg := range eMsgs {
 						e := msg.(*blockEndorseMsg)
 						if e != nil && e.Endorser == self.Index && e.EndorseForEmpty == endorseFailed {
-							log.Infof("server %d rebroadcast endorse, blk %d for %d, %t",
+							logger.Infof("server %d rebroadcast endorse, blk %d for %d, %t",
 								self.Index, e.GetBlockNum(), e.EndorsedProposer, e.EndorseForEmpty)
 							self.broadcast(e)
 							rebroadcasted = true
@@ -1516,11 +1555,11 @@ func (self *Server) actionLoop() {
 						proposal := self.getHighestRankProposal(blkNum, proposals)
 						if proposal != nil {
 							if err := self.endorseBlock(proposal, false); err != nil {
-								log.Errorf("server %d rebroadcasting failed to endorse (%d): %s",
+								logger.Errorf("server %d rebroadcasting failed to endorse (%d): %s",
 									self.Index, blkNum, err)
 							}
 						} else {
-							log.Errorf("server %d rebroadcasting failed to endorse(%d), no proposal found(%d)",
+							logger.Errorf("server %d rebroadcasting failed to endorse(%d), no proposal found(%d)",
 								self.Index, blkNum, len(proposals))
 						}
 					}
@@ -1536,7 +1575,7 @@ func (self *Server) actionLoop() {
 					for _, msg := range cMsgs {
 						c := msg.(*blockCommitMsg)
 						if c != nil && c.Committer == self.Index {
-							log.Infof("server %d rebroadcast commit, blk %d for %d, %t",
+							logger.Infof("server %d rebroadcast commit, blk %d for %d, %t",
 								self.Index, c.GetBlockNum(), c.BlockProposer, c.CommitForEmpty)
 							self.broadcast(msg)
 							committed = true
@@ -1551,9 +1590,9 @@ func (self *Server) actionLoop() {
 								self.fetchProposal(blkNum, proposer)
 								// restart endorsing timer
 								self.timer.StartEndorsingTimer(blkNum)
-								log.Errorf("server %d endorse %d done, but no proposal", self.Index, blkNum)
+								logger.Errorf("server %d endorse %d done, but no proposal", self.Index, blkNum)
 							} else if err := self.makeCommitment(proposal, blkNum, forEmpty); err != nil {
-								log.Errorf("server %d failed to commit block %d on rebroadcasting: %s",
+								logger.Errorf("server %d failed to commit block %d on rebroadcasting: %s",
 									self.Index, blkNum, err)
 							}
 						} else if self.blockPool.endorseFailed(blkNum, self.config.C) {
@@ -1568,7 +1607,7 @@ func (self *Server) actionLoop() {
 			}
 
 		case <-self.quitC:
-			log.Infof("server %d actionLoop quit", self.Index)
+			logger.Infof("server %d actionLoop quit", self.Index)
 			return
 		}
 	}
@@ -1582,11 +1621,11 @@ func (self *Server) timerLoop() {
 		select {
 		case evt := <-self.timer.C:
 			if err := self.processTimerEvent(evt); err != nil {
-				log.Errorf("failed to process timer evt: %d, err: %s", evt.evtType, err)
+				logger.Errorf("failed to process timer evt: %d, err: %s", evt.evtType, err)
 			}
 
 		case <-self.quitC:
-			log.Infof("server %d timerLoop quit", self.Index)
+			logger.Infof("server %d timerLoop quit", self.Index)
 			return
 		}
 	}
@@ -1685,7 +1724,7 @@ func (self *Server) processTimerEvent(evt *TimerEvent) error {
 		}
 		proposals := self.blockPool.getBlockProposals(evt.blockNum)
 		if len(proposals) == 0 {
-			log.Errorf("endorsing timeout, without any proposal. restarting syncing")
+			logger.Errorf("endorsing timeout, without any proposal. restarting syncing")
 			self.restartSyncing()
 			return nil
 		}
@@ -1723,7 +1762,7 @@ func (self *Server) processTimerEvent(evt *TimerEvent) error {
 			}
 			return nil
 		} else {
-			log.Errorf("server %d: empty endorse timeout, no quorum", self.Index)
+			logger.Errorf("server %d: empty endorse timeout, no quorum", self.Index)
 			if !isActive(self.getState()) {
 				proposals := self.blockPool.getBlockProposals(evt.blockNum)
 				proposal := self.getHighestRankProposal(evt.blockNum, proposals)
@@ -1766,7 +1805,7 @@ func (self *Server) processTimerEvent(evt *TimerEvent) error {
 				}
 				return nil
 			} else {
-				log.Errorf("server %d commit blk %d timeout without consensus", self.Index, evt.blockNum)
+				logger.Errorf("server %d commit blk %d timeout without consensus", self.Index, evt.blockNum)
 				self.restartSyncing()
 			}
 		}
@@ -1818,7 +1857,7 @@ func (self *Server) processHeartbeatMsg(peerIdx uint32, msg *peerHeartbeatMsg) e
 	if err := self.peerPool.peerHeartbeat(peerIdx, msg); err != nil {
 		return fmt.Errorf("failed to update peer %d: %s", peerIdx, err)
 	}
-	log.Debugf("server %d received heartbeat from peer %d, chainview %d, blkNum %d",
+	logger.Debugf("server %d received heartbeat from peer %d, chainview %d, blkNum %d",
 		self.Index, peerIdx, msg.ChainConfigView, msg.CommittedBlockNumber)
 	self.stateMgr.StateEventC <- &StateEvent{
 		Type: UpdatePeerState,
@@ -1848,7 +1887,7 @@ func (self *Server) endorseBlock(proposal *blockProposalMsg, forEmpty bool) erro
 	if !forEmpty {
 		if self.blockPool.endorseFailed(blkNum, self.config.C) {
 			forEmpty = true
-			log.Errorf("server %d, endorsing %d, changed from true to false", self.Index, blkNum)
+			logger.Errorf("server %d, endorsing %d, changed from true to false", self.Index, blkNum)
 		}
 	}
 
@@ -1868,7 +1907,7 @@ func (self *Server) endorseBlock(proposal *blockProposalMsg, forEmpty bool) erro
 	if forEmpty || self.isEndorser(blkNum, self.Index) {
 		h, _ := HashMsg(endorseMsg)
 		self.msgPool.AddMsg(endorseMsg, h)
-		log.Infof("endorser %d, endorsed block %d, from server %d",
+		logger.Infof("endorser %d, endorsed block %d, from server %d",
 			self.Index, blkNum, proposal.Block.getProposer())
 		// broadcast my endorsement
 		return self.broadcast(endorseMsg)
@@ -1934,7 +1973,7 @@ func (self *Server) commitBlock(proposal *blockProposalMsg, forEmpty bool) error
 	if forEmpty || self.isCommitter(blkNum, self.Index) {
 		h, _ := HashMsg(commitMsg)
 		self.msgPool.AddMsg(commitMsg, h)
-		log.Infof("committer %d, set block %d committed, from server %d",
+		logger.Infof("committer %d, set block %d committed, from server %d",
 			self.Index, blkNum, proposal.Block.getProposer())
 		// broadcast my commitment
 		return self.broadcast(commitMsg)
@@ -1994,7 +2033,7 @@ func (self *Server) sealBlock(block *Block, empty bool, sigdata bool) error {
 	sealedBlkNum := block.getBlockNum()
 	if sealedBlkNum < self.GetCurrentBlockNo() {
 		// we already in future round
-		log.Errorf("late seal of %d, current blkNum: %d", sealedBlkNum, self.GetCurrentBlockNo())
+		logger.Errorf("late seal of %d, current blkNum: %d", sealedBlkNum, self.GetCurrentBlockNo())
 		return nil
 	} else if sealedBlkNum > self.GetCurrentBlockNo() {
 		// we have lost sync, restarting syncing
@@ -2015,7 +2054,7 @@ func (self *Server) sealBlock(block *Block, empty bool, sigdata bool) error {
 
 	_, h := self.blockPool.getSealedBlock(sealedBlkNum)
 	prevBlkHash := block.getPrevBlockHash()
-	log.Infof("server %d, sealed block %d, proposer %d, prevhash: %s, hash: %s", self.Index,
+	logger.Infof("server %d, sealed block %d, proposer %d, prevhash: %s, hash: %s", self.Index,
 		sealedBlkNum, block.getProposer(), prevBlkHash.ToHexString(), h.ToHexString())
 
 	// broadcast to other modules
@@ -2043,23 +2082,23 @@ func (self *Server) msgSendLoop() {
 			}
 			payload, err := SerializeVbftMsg(evt.Msg)
 			if err != nil {
-				log.Errorf("server %d failed to serialized msg (type: %d): %s", self.Index, evt.Msg.Type(), err)
+				logger.Errorf("server %d failed to serialized msg (type: %d): %s", self.Index, evt.Msg.Type(), err)
 				continue
 			}
 			if evt.ToPeer == math.MaxUint32 {
 				// broadcast
 				if err := self.broadcastToAll(payload); err != nil {
-					log.Errorf("server %d xmit msg (type %d): %s",
+					logger.Errorf("server %d xmit msg (type %d): %s",
 						self.Index, evt.Msg.Type(), err)
 				}
 			} else {
 				if err := self.sendToPeer(evt.ToPeer, payload); err != nil {
-					log.Errorf("server %d xmit to peer %d failed: %s", self.Index, evt.ToPeer, err)
+					logger.Errorf("server %d xmit to peer %d failed: %s", self.Index, evt.ToPeer, err)
 				}
 			}
 
 		case <-self.quitC:
-			log.Infof("server %d msgSendLoop quit", self.Index)
+			logger.Infof("server %d msgSendLoop quit", self.Index)
 			return
 		}
 	}
@@ -2077,7 +2116,7 @@ func (self *Server) creategovernaceTransaction(blkNum uint32) (*types.Transactio
 func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 	prevBlk, _ := self.blockPool.getSealedBlock(blockNum - 1)
 	if prevBlk == nil {
-		log.Errorf("failed to get prevBlock (%d)", blockNum-1)
+		logger.Errorf("failed to get prevBlock (%d)", blockNum-1)
 		return false
 	}
 	lastConfigBlkNum := prevBlk.getLastConfigBlockNum()
@@ -2089,12 +2128,12 @@ func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 
 //checkUpdateChainConfig query leveldb check is force update
 func (self *Server) checkUpdateChainConfig() bool {
-	force, err := isUpdate(self.config.View)
+	force, err := isUpdate(self.ledger, self.config.View)
 	if err != nil {
-		log.Errorf("checkUpdateChainConfig err:%s", err)
+		logger.Errorf("checkUpdateChainConfig err:%s", err)
 		return false
 	}
-	log.Debugf("checkUpdateChainConfig force: %v", force)
+	logger.Debugf("checkUpdateChainConfig force: %v", force)
 	return force
 }
 
@@ -2114,7 +2153,7 @@ func (self *Server) nonSystxs(sysTxs []*types.Transaction, blkNum uint32) bool {
 	if self.checkNeedUpdateChainConfig(blkNum) && len(sysTxs) == 1 {
 		invoke := sysTxs[0].Payload.(*payload.InvokeCode)
 		if invoke == nil {
-			log.Errorf("nonSystxs invoke is nil,blocknum:%d", blkNum)
+			logger.Errorf("nonSystxs invoke is nil,blocknum:%d", blkNum)
 			return true
 		}
 		if bytes.Compare(invoke.Code, ninit.COMMIT_DPOS_BYTES) == 0 {
@@ -2138,7 +2177,7 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 	cfg := &vconfig.ChainConfig{}
 	cfg = nil
 	if self.checkNeedUpdateChainConfig(blkNum) || self.checkUpdateChainConfig() {
-		chainconfig, err := getChainConfig(blkNum)
+		chainconfig, err := getChainConfig(self.ledger, blkNum)
 		if err != nil {
 			return fmt.Errorf("getChainConfig failed:%s", err)
 		}
@@ -2170,7 +2209,7 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 		return fmt.Errorf("failed to construct proposal: %s", err)
 	}
 
-	log.Infof("server %d make proposal for block %d", self.Index, blkNum)
+	logger.Infof("server %d make proposal for block %d", self.Index, blkNum)
 
 	// add proposal to self
 	h, _ := HashMsg(proposal)
@@ -2195,7 +2234,7 @@ func (self *Server) makeSealed(proposal *blockProposalMsg, forEmpty bool) error
 		return fmt.Errorf("verify prev block hash failed: %s", err)
 	}
 
-	log.Infof("server %d ready to seal block %d, for proposer %d, empty: %t",
+	logger.Infof("server %d ready to seal block %d, for proposer %d, empty: %t",
 		self.Index, blkNum, proposal.Block.getProposer(), forEmpty)
 
 	// seal the block
@@ -2263,16 +2302,16 @@ func (self *Server) handleProposalTimeout(evt *TimerEvent) error {
 	}
 	proposals := self.blockPool.getBlockProposals(evt.blockNum)
 
-	log.Infof("server %d proposal timeout, known proposals %d, timeout: %d", self.Index, len(proposals), evt.evtType)
+	logger.Infof("server %d proposal timeout, known proposals %d, timeout: %d", self.Index, len(proposals), evt.evtType)
 
 	// if no proposal available, random backoff
 	if len(proposals) == 0 {
-		log.Infof("no proposal available for block %d, timeout: %d", evt.blockNum, evt.evtType)
+		logger.Infof("no proposal available for block %d, timeout: %d", evt.blockNum, evt.evtType)
 
 		switch evt.evtType {
 		case EventProposeBlockTimeout:
 			self.timer.StartBackoffTimer(evt.blockNum)
-			log.Infof("server %d started backoff timer for blk %d", self.Index, evt.blockNum)
+			logger.Infof("server %d started backoff timer for blk %d", self.Index, evt.blockNum)
 			return nil
 		case EventRandomBackoff:
 			if err := self.makeProposal(evt.blockNum, true); err != nil {
@@ -2281,7 +2320,7 @@ func (self *Server) handleProposalTimeout(evt *TimerEvent) error {
 			if err := self.timer.Start2ndProposalTimer(evt.blockNum); err != nil {
 				return fmt.Errorf("failed to start 2nd proposal timer: %s", err)
 			}
-			log.Infof("server %d proposed empty block for blk %d", self.Index, evt.blockNum)
+			logger.Infof("server %d proposed empty block for blk %d", self.Index, evt.blockNum)
 			return nil
 		case EventPropose2ndBlockTimeout:
 			// 2nd proposal without any proposal, force resync
@@ -2310,7 +2349,7 @@ func (self *Server) handleProposalTimeout(evt *TimerEvent) error {
 			forEmpty: false,
 		}
 	} else {
-		log.Errorf("server: %d, blkNum: %d, failed get better proposal, first proposal: %d, %d",
+		logger.Errorf("server: %d, blkNum: %d, failed get better proposal, first proposal: %d, %d",
 			self.Index, evt.blockNum, proposals[0].Block.getProposer(), proposals[0].Block.getBlockNum())
 	}
 	return nil
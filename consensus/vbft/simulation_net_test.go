@@ -0,0 +1,304 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package vbft
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	p2pmsg "github.com/imZhuFei/zeepin/p2pserver/message/types"
+)
+
+const SIM_LINK_QUEUE_SIZE = 4096
+
+//simPacket is a serialized consensus msg travelling on a link
+type simPacket struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+//simLink is the ordered one way connection between two simulated nodes, like a tcp
+//connection of the p2p server it keeps the order of the msgs it carries
+type simLink struct {
+	from  uint64
+	to    uint64
+	msgC  chan *simPacket
+	delay time.Duration //extra delay of this link only
+}
+
+//simNetwork is an in-memory replacement of the p2p server connecting the simulated
+//nodes, the msgs on it can be delayed, dropped and cut by partitions
+type simNetwork struct {
+	lock      sync.RWMutex
+	servers   map[uint64]*Server     //p2p id => consensus server
+	links     map[[2]uint64]*simLink //(from, to) => link
+	partition map[uint64]int         //p2p id => partition group, nil when all nodes are connected
+	delay     time.Duration          //base delay of every msg
+	jitter    time.Duration          //random extra delay up to jitter
+	dropRate  float64                //probability a msg is lost
+	random    *rand.Rand
+	quitC     chan struct{}
+	wg        sync.WaitGroup
+
+	sent      uint64
+	delivered uint64
+	dropped   uint64
+}
+
+func newSimNetwork(seed int64) *simNetwork {
+	return &simNetwork{
+		servers: make(map[uint64]*Server),
+		links:   make(map[[2]uint64]*simLink),
+		random:  rand.New(rand.NewSource(seed)),
+		quitC:   make(chan struct{}),
+	}
+}
+
+//simEndpoint is the p2p network of one simulated node
+type simEndpoint struct {
+	net *simNetwork
+	id  uint64
+}
+
+func (self *simEndpoint) Broadcast(msg interface{}) {
+	payload, ok := msg.(*p2pmsg.ConsensusPayload)
+	if !ok {
+		logger.Errorf("sim node %d: broadcast unknown msg %T", self.id, msg)
+		return
+	}
+	self.net.broadcast(self.id, payload)
+}
+
+func (self *simEndpoint) Transmit(target uint64, msg p2pmsg.Message) {
+	cons, ok := msg.(*p2pmsg.Consensus)
+	if !ok {
+		logger.Errorf("sim node %d: transmit unknown msg %T", self.id, msg)
+		return
+	}
+	self.net.send(self.id, target, &cons.Cons)
+}
+
+func (self *simNetwork) endpoint(id uint64) *simEndpoint {
+	return &simEndpoint{
+		net: self,
+		id:  id,
+	}
+}
+
+//attach connects the server to the network as node id
+func (self *simNetwork) attach(id uint64, server *Server) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.servers[id] = server
+}
+
+func (self *simNetwork) setDelay(delay, jitter time.Duration) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.delay = delay
+	self.jitter = jitter
+}
+
+//setLinkDelay adds delay to the msgs from one node to another
+func (self *simNetwork) setLinkDelay(from, to uint64, delay time.Duration) {
+	link := self.getLink(from, to)
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	link.delay = delay
+}
+
+func (self *simNetwork) setDropRate(rate float64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.dropRate = rate
+}
+
+//split cuts the network into the groups, nodes only reach the nodes of their own group,
+//nodes not listed in any group are isolated
+func (self *simNetwork) split(groups ...[]uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.partition = make(map[uint64]int)
+	for i, group := range groups {
+		for _, id := range group {
+			self.partition[id] = i + 1
+		}
+	}
+}
+
+//isolate cuts the node off from all others
+func (self *simNetwork) isolate(id uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.partition = make(map[uint64]int)
+	for peer := range self.servers {
+		if peer != id {
+			self.partition[peer] = 1
+		}
+	}
+}
+
+//heal reconnects all nodes
+func (self *simNetwork) heal() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.partition = nil
+}
+
+func (self *simNetwork) connected(from, to uint64) bool {
+	if self.partition == nil {
+		return true
+	}
+	group := self.partition[from]
+	return group != 0 && group == self.partition[to]
+}
+
+func (self *simNetwork) stop() {
+	close(self.quitC)
+	self.wg.Wait()
+}
+
+func (self *simNetwork) broadcast(from uint64, payload *p2pmsg.ConsensusPayload) {
+	self.lock.RLock()
+	targets := make([]uint64, 0, len(self.servers))
+	for id := range self.servers {
+		if id != from {
+			targets = append(targets, id)
+		}
+	}
+	self.lock.RUnlock()
+
+	for _, to := range targets {
+		self.send(from, to, payload)
+	}
+}
+
+//send queues the payload on the link, the same as the p2p server it is serialized
+//on the way so every receiver gets its own copy
+func (self *simNetwork) send(from, to uint64, payload *p2pmsg.ConsensusPayload) {
+	atomic.AddUint64(&self.sent, 1)
+	cons := &p2pmsg.Consensus{Cons: *payload}
+	data, err := cons.Serialization()
+	if err != nil {
+		logger.Errorf("sim node %d: serialize consensus msg: %s", from, err)
+		return
+	}
+	link := self.getLink(from, to)
+
+	self.lock.Lock()
+	if !self.connected(from, to) || self.random.Float64() < self.dropRate {
+		self.lock.Unlock()
+		atomic.AddUint64(&self.dropped, 1)
+		return
+	}
+	delay := self.delay + link.delay
+	if self.jitter > 0 {
+		delay += time.Duration(self.random.Int63n(int64(self.jitter)))
+	}
+	self.lock.Unlock()
+
+	select {
+	case link.msgC <- &simPacket{data: data, deliverAt: time.Now().Add(delay)}:
+	default:
+		atomic.AddUint64(&self.dropped, 1)
+		logger.Warnf("sim link %d -> %d overflow", from, to)
+	}
+}
+
+func (self *simNetwork) getLink(from, to uint64) *simLink {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	key := [2]uint64{from, to}
+	if link, present := self.links[key]; present {
+		return link
+	}
+	link := &simLink{
+		from: from,
+		to:   to,
+		msgC: make(chan *simPacket, SIM_LINK_QUEUE_SIZE),
+	}
+	self.links[key] = link
+	self.wg.Add(1)
+	go self.runLink(link)
+	return link
+}
+
+func (self *simNetwork) runLink(link *simLink) {
+	defer self.wg.Done()
+	for {
+		select {
+		case pkt := <-link.msgC:
+			if d := time.Until(pkt.deliverAt); d > 0 {
+				select {
+				case <-time.After(d):
+				case <-self.quitC:
+					return
+				}
+			}
+			self.deliver(link, pkt)
+		case <-self.quitC:
+			return
+		}
+	}
+}
+
+//deliver hands the msg to the receiving server the way the p2p msg handler does,
+//msgs still in flight when a partition starts are lost
+func (self *simNetwork) deliver(link *simLink, pkt *simPacket) {
+	self.lock.RLock()
+	server := self.servers[link.to]
+	connected := self.connected(link.from, link.to)
+	self.lock.RUnlock()
+	if server == nil || !connected {
+		atomic.AddUint64(&self.dropped, 1)
+		return
+	}
+
+	cons := new(p2pmsg.Consensus)
+	if err := cons.Deserialization(pkt.data); err != nil {
+		logger.Errorf("sim link %d -> %d: %s", link.from, link.to, err)
+		return
+	}
+	if err := cons.Cons.Verify(); err != nil {
+		logger.Errorf("sim link %d -> %d: %s", link.from, link.to, err)
+		return
+	}
+	cons.Cons.PeerId = link.from
+	atomic.AddUint64(&self.delivered, 1)
+	server.NewConsensusPayload(&cons.Cons)
+}
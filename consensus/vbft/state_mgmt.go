@@ -37,7 +37,6 @@ package vbft
 import (
 	"time"
 
-	"github.com/imZhuFei/zeepin/common/log"
 )
 
 const (
@@ -152,11 +151,11 @@ func (self *StateMgr) run() {
 				if self.currentState >= LocalConfigured {
 					v := self.getSyncedChainConfigView()
 					if v == self.server.config.View && self.currentState < Syncing {
-						log.Infof("server %d, start syncing", self.server.Index)
+						logger.Infof("server %d, start syncing", self.server.Index)
 						self.currentState = Syncing
 					} else if v > self.server.config.View {
 						// update ChainConfig
-						log.Errorf("todo: chain config changed, need update chain config from peers")
+						logger.Errorf("todo: chain config changed, need update chain config from peers")
 						// TODO: fetch config from neighbours, update chain config
 						self.currentState = LocalConfigured
 					}
@@ -164,28 +163,28 @@ func (self *StateMgr) run() {
 			case UpdatePeerState:
 				if evt.peerState.connected {
 					if err := self.onPeerUpdate(evt.peerState); err != nil {
-						log.Errorf("statemgr process peer (%d) err: %s", evt.peerState.peerIdx, err)
+						logger.Errorf("statemgr process peer (%d) err: %s", evt.peerState.peerIdx, err)
 					}
 				} else {
 					if err := self.onPeerDisconnected(evt.peerState.peerIdx); err != nil {
-						log.Errorf("statmgr process peer (%d) disconn err: %s", evt.peerState.peerIdx, err)
+						logger.Errorf("statmgr process peer (%d) disconn err: %s", evt.peerState.peerIdx, err)
 					}
 				}
 
 			case SyncDone:
-				log.Infof("server %d sync done, curr blkNum: %d", self.server.Index, self.server.GetCurrentBlockNo())
+				logger.Infof("server %d sync done, curr blkNum: %d", self.server.Index, self.server.GetCurrentBlockNo())
 				if err := self.setSyncedReady(); err != nil {
-					log.Warnf("server %d set syncready: %s", self.server.Index, err)
+					logger.Warnf("server %d set syncready: %s", self.server.Index, err)
 				}
 
 			case LiveTick:
 				if err := self.onLiveTick(evt); err != nil {
-					log.Errorf("server %d, live ticker: %s", self.server.Index, err)
+					logger.Errorf("server %d, live ticker: %s", self.server.Index, err)
 				}
 			}
 
 		case <-self.server.quitC:
-			log.Infof("server %d, state mgr quit", self.server.Index)
+			logger.Infof("server %d, state mgr quit", self.server.Index)
 			return
 		}
 	}
@@ -198,7 +197,7 @@ func (self *StateMgr) onPeerUpdate(peerState *PeerState) error {
 		newPeer = true
 	}
 
-	log.Infof("server %d peer update, current blk %d, state %d, from peer %d, committed %d",
+	logger.Infof("server %d peer update, current blk %d, state %d, from peer %d, committed %d",
 		self.server.Index, self.server.GetCurrentBlockNo(), self.currentState, peerState.peerIdx, peerState.committedBlockNum)
 
 	// update peer state
@@ -206,10 +205,10 @@ func (self *StateMgr) onPeerUpdate(peerState *PeerState) error {
 
 	if !newPeer {
 		if isActive(self.currentState) && peerState.committedBlockNum > self.server.GetCurrentBlockNo()+MAX_SYNCING_CHECK_BLK_NUM {
-			log.Warnf("server %d seems lost sync: %d(%d) vs %d", self.server.Index,
+			logger.Warnf("server %d seems lost sync: %d(%d) vs %d", self.server.Index,
 				peerState.committedBlockNum, peerState.peerIdx, self.server.GetCurrentBlockNo())
 			if err := self.checkStartSyncing(self.server.GetCommittedBlockNo()+MAX_SYNCING_CHECK_BLK_NUM, false); err != nil {
-				log.Errorf("server %d start syncing check failed", self.server.Index)
+				logger.Errorf("server %d start syncing check failed", self.server.Index)
 			}
 			return nil
 		}
@@ -218,7 +217,7 @@ func (self *StateMgr) onPeerUpdate(peerState *PeerState) error {
 	switch self.currentState {
 	case LocalConfigured:
 		v := self.getSyncedChainConfigView()
-		log.Infof("server %d statemgr update, current state: %d, from peer: %d, peercnt: %d, v1: %d, v2: %d",
+		logger.Infof("server %d statemgr update, current state: %d, from peer: %d, peercnt: %d, v1: %d, v2: %d",
 			self.server.Index, self.currentState, peerIdx, len(self.peers), v, self.server.config.View)
 
 		if v == self.server.config.View {
@@ -231,11 +230,11 @@ func (self *StateMgr) onPeerUpdate(peerState *PeerState) error {
 			committedBlkNum, ok := self.getConsensusedCommittedBlockNum()
 			if ok && committedBlkNum > self.server.GetCommittedBlockNo() {
 				fastforward := self.canFastForward(committedBlkNum)
-				log.Infof("server %d, syncing %d, target %d, fastforward %t",
+				logger.Infof("server %d, syncing %d, target %d, fastforward %t",
 					self.server.Index, self.server.GetCommittedBlockNo(), committedBlkNum, fastforward)
 				if fastforward {
 					if err := self.server.makeFastForward(); err != nil {
-						log.Errorf("server %d state %d fastforward: %s",
+						logger.Errorf("server %d state %d fastforward: %s",
 							self.server.Index, self.currentState, err)
 					}
 				} else {
@@ -244,31 +243,31 @@ func (self *StateMgr) onPeerUpdate(peerState *PeerState) error {
 			}
 		}
 		if self.isSyncedReady() {
-			log.Infof("server %d synced from syncing", self.server.Index)
+			logger.Infof("server %d synced from syncing", self.server.Index)
 			if err := self.setSyncedReady(); err != nil {
-				log.Warnf("server %d, state %d set syncready: %s", self.server.Index, self.currentState, err)
+				logger.Warnf("server %d, state %d set syncready: %s", self.server.Index, self.currentState, err)
 			}
 		}
 	case WaitNetworkReady:
 		if self.isSyncedReady() {
-			log.Infof("server %d synced from sync-ready", self.server.Index)
+			logger.Infof("server %d synced from sync-ready", self.server.Index)
 			self.setSyncedReady()
 		}
 	case SyncReady:
 	case Synced:
 		committedBlkNum, ok := self.getConsensusedCommittedBlockNum()
 		if ok && committedBlkNum > self.server.GetCommittedBlockNo()+1 {
-			log.Infof("server %d synced try fastforward from %d",
+			logger.Infof("server %d synced try fastforward from %d",
 				self.server.Index, self.server.GetCommittedBlockNo())
 			if err := self.server.makeFastForward(); err != nil {
-				log.Errorf("server %d state %d fast forward from %d: %s",
+				logger.Errorf("server %d state %d fast forward from %d: %s",
 					self.server.Index, self.currentState, self.server.GetCommittedBlockNo(), err)
 			}
 		}
 	case SyncingCheck:
 		if self.isSyncedReady() {
 			if err := self.setSyncedReady(); err != nil {
-				log.Warnf("server %d, state %d set syncready: %s", self.server.Index, self.currentState, err)
+				logger.Warnf("server %d, state %d set syncready: %s", self.server.Index, self.currentState, err)
 			}
 		} else {
 			self.checkStartSyncing(self.server.GetCommittedBlockNo()+MAX_SYNCING_CHECK_BLK_NUM, false)
@@ -310,17 +309,17 @@ func (self *StateMgr) onLiveTick(evt *StateEvent) error {
 		return nil
 	}
 
-	log.Warnf("server %d detected consensus halt %d",
+	logger.Warnf("server %d detected consensus halt %d",
 		self.server.Index, self.server.GetCurrentBlockNo())
 
 	committedBlkNum, ok := self.getConsensusedCommittedBlockNum()
 	if ok && committedBlkNum > self.server.GetCommittedBlockNo() {
 		fastforward := self.canFastForward(committedBlkNum)
-		log.Infof("server %d, syncing %d, target %d, fast-forward %t",
+		logger.Infof("server %d, syncing %d, target %d, fast-forward %t",
 			self.server.Index, self.server.GetCommittedBlockNo(), committedBlkNum, fastforward)
 		if fastforward {
 			if err := self.server.makeFastForward(); err != nil {
-				log.Errorf("server %d on live ticker fast forward: %s", self.server.Index, err)
+				logger.Errorf("server %d on live ticker fast forward: %s", self.server.Index, err)
 			}
 		} else {
 			self.checkStartSyncing(self.server.GetCommittedBlockNo(), false)
@@ -380,7 +379,7 @@ func (self *StateMgr) setSyncedReady() error {
 	prevState := self.currentState
 	self.currentState = SyncReady
 	if prevState <= SyncReady {
-		log.Infof("server %d start sync ready", self.server.Index)
+		logger.Infof("server %d start sync ready", self.server.Index)
 		blkNum := self.server.GetCurrentBlockNo()
 		time.AfterFunc(self.syncReadyTimeout, func() {
 			self.StateEventC <- &StateEvent{
@@ -425,7 +424,7 @@ func (self *StateMgr) checkStartSyncing(startBlkNum uint32, forceSync bool) erro
 
 		if maxCommitted > self.lastBlockSyncReqHeight {
 			// syncer is much slower than peer-update, too much SyncReq can make channel full
-			log.Infof("server %d, start syncing %d - %d, with %v", self.server.Index, startBlkNum, maxCommitted, peers)
+			logger.Infof("server %d, start syncing %d - %d, with %v", self.server.Index, startBlkNum, maxCommitted, peers)
 			self.lastBlockSyncReqHeight = maxCommitted
 			self.server.syncer.blockSyncReqC <- &BlockSyncReq{
 				targetPeers:    peers[maxCommitted],
@@ -434,7 +433,7 @@ func (self *StateMgr) checkStartSyncing(startBlkNum uint32, forceSync bool) erro
 			}
 		}
 	} else if self.currentState == Synced {
-		log.Infof("server %d, start syncing check %v, %d", self.server.Index, peers, self.server.GetCurrentBlockNo())
+		logger.Infof("server %d, start syncing check %v, %d", self.server.Index, peers, self.server.GetCurrentBlockNo())
 		self.currentState = SyncingCheck
 	}
 
@@ -479,13 +478,13 @@ func (self *StateMgr) canFastForward(targetBlkNum uint32) bool {
 	// one block less than targetBlkNum is also acceptable for fastforward
 	for blkNum := self.server.GetCurrentBlockNo(); blkNum < targetBlkNum; blkNum++ {
 		if len(self.server.msgPool.GetProposalMsgs(blkNum)) == 0 {
-			log.Infof("server %d check fastforward false, no proposal for block %d",
+			logger.Infof("server %d check fastforward false, no proposal for block %d",
 				self.server.Index, blkNum)
 			return false
 		}
 		cMsgs := self.server.msgPool.GetCommitMsgs(blkNum)
 		if len(cMsgs) <= C {
-			log.Infof("server %d check fastforward false, only %d commit msg for block %d",
+			logger.Infof("server %d check fastforward false, only %d commit msg for block %d",
 				self.server.Index, len(cMsgs), blkNum)
 			return false
 		}
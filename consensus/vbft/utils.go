@@ -141,12 +141,12 @@ func verifyVrf(pk keypair.PublicKey, blkNum uint32, prevVrf, newVrf, proof []byt
 	}
 	return nil
 }
-func GetVbftConfigInfo() (*config.VBFTConfig, error) {
+func GetVbftConfigInfo(db *ledger.Ledger) (*config.VBFTConfig, error) {
 	storageKey := &states.StorageKey{
 		ContractAddress: nutils.GovernanceContractAddress,
 		Key:             append([]byte(gov.VBFT_CONFIG)),
 	}
-	data, err := ledger.DefLedger.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
+	data, err := db.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -168,8 +168,8 @@ func GetVbftConfigInfo() (*config.VBFTConfig, error) {
 	return chainconfig, nil
 }
 
-func GetPeersConfigNew() ([]*config.VBFTPeerStakeInfo, error) {
-	goveranceview, err := GetGovernanceView()
+func GetPeersConfigNew(db *ledger.Ledger) ([]*config.VBFTPeerStakeInfo, error) {
+	goveranceview, err := GetGovernanceView(db)
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +181,7 @@ func GetPeersConfigNew() ([]*config.VBFTPeerStakeInfo, error) {
 		ContractAddress: nutils.GovernanceContractAddress,
 		Key:             append([]byte(gov.PEER_POOL), viewBytes...),
 	}
-	data, err := ledger.DefLedger.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
+	data, err := db.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -207,8 +207,8 @@ func GetPeersConfigNew() ([]*config.VBFTPeerStakeInfo, error) {
 	return peerstakes, nil
 }
 
-func GetPeersConfig(height uint32) ([]*config.VBFTPeerStakeInfo, error) {
-	goveranceview, err := GetGovernanceView()
+func GetPeersConfig(db *ledger.Ledger, height uint32) ([]*config.VBFTPeerStakeInfo, error) {
+	goveranceview, err := GetGovernanceView(db)
 	if err != nil {
 		return nil, err
 	}
@@ -220,7 +220,7 @@ func GetPeersConfig(height uint32) ([]*config.VBFTPeerStakeInfo, error) {
 		ContractAddress: nutils.GovernanceContractAddress,
 		Key:             append([]byte(gov.PEER_POOL), viewBytes...),
 	}
-	data, err := ledger.DefLedger.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
+	data, err := db.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -252,8 +252,8 @@ func GetPeersConfig(height uint32) ([]*config.VBFTPeerStakeInfo, error) {
 	return peerstakes, nil
 }
 
-func isUpdate(view uint32) (bool, error) {
-	goveranceview, err := GetGovernanceView()
+func isUpdate(db *ledger.Ledger, view uint32) (bool, error) {
+	goveranceview, err := GetGovernanceView(db)
 	if err != nil {
 		return false, err
 	}
@@ -263,12 +263,12 @@ func isUpdate(view uint32) (bool, error) {
 	return false, nil
 }
 
-func GetGovernanceView() (*gov.GovernanceView, error) {
+func GetGovernanceView(db *ledger.Ledger) (*gov.GovernanceView, error) {
 	storageKey := &states.StorageKey{
 		ContractAddress: nutils.GovernanceContractAddress,
 		Key:             append([]byte(gov.GOVERNANCE_VIEW)),
 	}
-	data, err := ledger.DefLedger.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
+	data, err := db.GetStorageItem(storageKey.ContractAddress, storageKey.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -280,17 +280,17 @@ func GetGovernanceView() (*gov.GovernanceView, error) {
 	return governanceView, nil
 }
 
-func getChainConfig(blkNum uint32) (*vconfig.ChainConfig, error) {
-	config, err := GetVbftConfigInfo()
+func getChainConfig(db *ledger.Ledger, blkNum uint32) (*vconfig.ChainConfig, error) {
+	config, err := GetVbftConfigInfo(db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chainconfig from leveldb: %s", err)
 	}
 
-	peersinfo, err := GetPeersConfig(blkNum)
+	peersinfo, err := GetPeersConfig(db, blkNum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get peersinfo from leveldb: %s", err)
 	}
-	goverview, err := GetGovernanceView()
+	goverview, err := GetGovernanceView(db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get governanceview failed:%s", err)
 	}
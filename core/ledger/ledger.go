@@ -44,8 +44,10 @@ import (
 	"github.com/imZhuFei/zeepin/core/store"
 	"github.com/imZhuFei/zeepin/core/store/ledgerstore"
 	"github.com/imZhuFei/zeepin/core/types"
+	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	cstate "github.com/imZhuFei/zeepin/smartcontract/states"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 	"github.com/ontio/ontology-crypto/keypair"
 )
 
@@ -65,6 +67,17 @@ func NewLedger(dataDir string) (*Ledger, error) {
 	}, nil
 }
 
+//NewMemLedger return a ledger which keeps all data in memory
+func NewMemLedger() (*Ledger, error) {
+	ldgStore, err := ledgerstore.NewMemLedgerStore()
+	if err != nil {
+		return nil, fmt.Errorf("NewMemLedgerStore error %s", err)
+	}
+	return &Ledger{
+		ldgStore: ldgStore,
+	}, nil
+}
+
 func (self *Ledger) GetStore() store.LedgerStore {
 	return self.ldgStore
 }
@@ -181,6 +194,10 @@ func (self *Ledger) PreExecuteContract(tx *types.Transaction) (*cstate.PreExecRe
 	return self.ldgStore.PreExecuteContract(tx)
 }
 
+func (self *Ledger) TraceTransaction(txHash common.Uint256, wasmTracer exec.Tracer, embedTracer vm.Tracer) (*cstate.PreExecResult, error) {
+	return self.ldgStore.TraceTransaction(txHash, wasmTracer, embedTracer)
+}
+
 func (self *Ledger) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
 	return self.ldgStore.GetEventNotifyByTx(tx)
 }
@@ -40,6 +40,37 @@ import (
 	"github.com/imZhuFei/zeepin/common/serialization"
 )
 
+// VmType is the virtual machine a deployed contract runs on
+type VmType byte
+
+const (
+	LegacyVm VmType = 0 // deployed before the vm type was recorded
+	EmbedVm  VmType = 1
+	WasmVm   VmType = 2
+)
+
+func (v VmType) String() string {
+	switch v {
+	case LegacyVm:
+		return "legacy"
+	case EmbedVm:
+		return "embed"
+	case WasmVm:
+		return "wasm"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(v))
+}
+
+// the NeedStorage byte of a versioned DeployCode carries the format version in
+// its high bits, version 1 is followed by the vm type and version 2 also ends
+// with the event abi
+const (
+	DEPLOY_FLAG_NEED_STORAGE = 0x01
+	DEPLOY_VERSION_SHIFT     = 4
+	DEPLOY_VERSION_VMTYPE    = 1
+	DEPLOY_VERSION_EVENT_ABI = 2
+)
+
 // DeployCode is an implementation of transaction payload for deploy smartcontract
 type DeployCode struct {
 	Code        []byte
@@ -49,6 +80,49 @@ type DeployCode struct {
 	Author      string
 	Email       string
 	Description string
+	VmType      VmType
+	EventAbi    []byte // json abi of the events the contract notifies, optional
+}
+
+func (dc *DeployCode) version() byte {
+	if len(dc.EventAbi) != 0 {
+		return DEPLOY_VERSION_EVENT_ABI
+	}
+	if dc.VmType != LegacyVm {
+		return DEPLOY_VERSION_VMTYPE
+	}
+	return 0
+}
+
+func (dc *DeployCode) flags() byte {
+	var flags byte
+	if dc.NeedStorage {
+		flags |= DEPLOY_FLAG_NEED_STORAGE
+	}
+	return flags | dc.version()<<DEPLOY_VERSION_SHIFT
+}
+
+func (dc *DeployCode) setFlags(flags byte) (byte, error) {
+	version := flags >> DEPLOY_VERSION_SHIFT
+	if flags&^(DEPLOY_FLAG_NEED_STORAGE|0xf0) != 0 {
+		return 0, fmt.Errorf("invalid flags %x", flags)
+	}
+	switch version {
+	case 0:
+	case DEPLOY_VERSION_VMTYPE:
+	case DEPLOY_VERSION_EVENT_ABI:
+	default:
+		return 0, fmt.Errorf("unsupported version %d", version)
+	}
+	dc.NeedStorage = flags&DEPLOY_FLAG_NEED_STORAGE != 0
+	return version, nil
+}
+
+func checkVmType(vmType VmType) error {
+	if vmType != EmbedVm && vmType != WasmVm {
+		return fmt.Errorf("invalid vm type %d", vmType)
+	}
+	return nil
 }
 
 func (dc *DeployCode) Serialize(w io.Writer) error {
@@ -59,11 +133,22 @@ func (dc *DeployCode) Serialize(w io.Writer) error {
 		return fmt.Errorf("DeployCode Code Serialize failed: %s", err)
 	}
 
-	err = serialization.WriteBool(w, dc.NeedStorage)
+	err = serialization.WriteByte(w, dc.flags())
 	if err != nil {
 		return fmt.Errorf("DeployCode NeedStorage Serialize failed: %s", err)
 	}
 
+	version := dc.version()
+	if version == DEPLOY_VERSION_EVENT_ABI && dc.VmType == LegacyVm {
+		return fmt.Errorf("DeployCode EventAbi Serialize failed: event abi requires a vm type")
+	}
+	if version != 0 {
+		err = serialization.WriteByte(w, byte(dc.VmType))
+		if err != nil {
+			return fmt.Errorf("DeployCode VmType Serialize failed: %s", err)
+		}
+	}
+
 	err = serialization.WriteString(w, dc.Name)
 	if err != nil {
 		return fmt.Errorf("DeployCode Name Serialize failed: %s", err)
@@ -89,6 +174,13 @@ func (dc *DeployCode) Serialize(w io.Writer) error {
 		return fmt.Errorf("DeployCode Description Serialize failed: %s", err)
 	}
 
+	if version == DEPLOY_VERSION_EVENT_ABI {
+		err = serialization.WriteVarBytes(w, dc.EventAbi)
+		if err != nil {
+			return fmt.Errorf("DeployCode EventAbi Serialize failed: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -99,11 +191,27 @@ func (dc *DeployCode) Deserialize(r io.Reader) error {
 	}
 	dc.Code = code
 
-	dc.NeedStorage, err = serialization.ReadBool(r)
+	flags, err := serialization.ReadByte(r)
+	if err != nil {
+		return fmt.Errorf("DeployCode NeedStorage Deserialize failed: %s", err)
+	}
+	version, err := dc.setFlags(flags)
 	if err != nil {
 		return fmt.Errorf("DeployCode NeedStorage Deserialize failed: %s", err)
 	}
 
+	dc.VmType = LegacyVm
+	if version != 0 {
+		vmType, err := serialization.ReadByte(r)
+		if err != nil {
+			return fmt.Errorf("DeployCode VmType Deserialize failed: %s", err)
+		}
+		dc.VmType = VmType(vmType)
+		if err = checkVmType(dc.VmType); err != nil {
+			return fmt.Errorf("DeployCode VmType Deserialize failed: %s", err)
+		}
+	}
+
 	dc.Name, err = serialization.ReadString(r)
 	if err != nil {
 		return fmt.Errorf("DeployCode Name Deserialize failed: %s", err)
@@ -129,6 +237,17 @@ func (dc *DeployCode) Deserialize(r io.Reader) error {
 		return fmt.Errorf("DeployCode Description Deserialize failed: %s", err)
 	}
 
+	dc.EventAbi = nil
+	if version == DEPLOY_VERSION_EVENT_ABI {
+		dc.EventAbi, err = serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("DeployCode EventAbi Deserialize failed: %s", err)
+		}
+		if len(dc.EventAbi) == 0 {
+			return fmt.Errorf("DeployCode EventAbi Deserialize failed: empty event abi")
+		}
+	}
+
 	return nil
 }
 
@@ -139,12 +258,22 @@ func (dc *DeployCode) ToArray() []byte {
 }
 func (dc *DeployCode) Serialization(sink *common.ZeroCopySink) error {
 	sink.WriteVarBytes(dc.Code)
-	sink.WriteBool(dc.NeedStorage)
+	version := dc.version()
+	if version == DEPLOY_VERSION_EVENT_ABI && dc.VmType == LegacyVm {
+		return fmt.Errorf("DeployCode EventAbi Serialization failed: event abi requires a vm type")
+	}
+	sink.WriteByte(dc.flags())
+	if version != 0 {
+		sink.WriteByte(byte(dc.VmType))
+	}
 	sink.WriteString(dc.Name)
 	sink.WriteString(dc.Version)
 	sink.WriteString(dc.Author)
 	sink.WriteString(dc.Email)
 	sink.WriteString(dc.Description)
+	if version == DEPLOY_VERSION_EVENT_ABI {
+		sink.WriteVarBytes(dc.EventAbi)
+	}
 	return nil
 }
 
@@ -155,10 +284,20 @@ func (dc *DeployCode) Deserialization(source *common.ZeroCopySource) error {
 	if irregular {
 		return common.ErrIrregularData
 	}
-	dc.NeedStorage, irregular, eof = source.NextBool()
-	if irregular {
+	flags, eof := source.NextByte()
+	version, err := dc.setFlags(flags)
+	if err != nil {
 		return common.ErrIrregularData
 	}
+	dc.VmType = LegacyVm
+	if version != 0 {
+		var vmType byte
+		vmType, eof = source.NextByte()
+		dc.VmType = VmType(vmType)
+		if !eof && checkVmType(dc.VmType) != nil {
+			return common.ErrIrregularData
+		}
+	}
 	dc.Name, _, irregular, eof = source.NextString()
 	if irregular {
 		return common.ErrIrregularData
@@ -179,6 +318,13 @@ func (dc *DeployCode) Deserialization(source *common.ZeroCopySource) error {
 	if irregular {
 		return common.ErrIrregularData
 	}
+	dc.EventAbi = nil
+	if version == DEPLOY_VERSION_EVENT_ABI && !eof {
+		dc.EventAbi, _, irregular, eof = source.NextVarBytes()
+		if irregular || (!eof && len(dc.EventAbi) == 0) {
+			return common.ErrIrregularData
+		}
+	}
 	if eof {
 		return io.ErrUnexpectedEOF
 	}
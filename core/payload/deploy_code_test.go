@@ -37,6 +37,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/imZhuFei/zeepin/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,3 +57,64 @@ func TestDeployCode_Serialize(t *testing.T) {
 	err := deploy2.Deserialize(buf)
 	assert.NotNil(t, err)
 }
+
+func TestDeployCode_VmType(t *testing.T) {
+	legacy := DeployCode{Code: []byte{1, 2, 3}, NeedStorage: true}
+	typed := DeployCode{Code: []byte{1, 2, 3}, NeedStorage: true, VmType: WasmVm}
+
+	legacyBytes := legacy.ToArray()
+	typedBytes := typed.ToArray()
+	assert.Equal(t, len(legacyBytes)+1, len(typedBytes))
+	assert.Equal(t, byte(1), legacyBytes[4])
+	assert.Equal(t, byte(0x11), typedBytes[4])
+
+	var deploy DeployCode
+	assert.Nil(t, deploy.Deserialize(bytes.NewBuffer(typedBytes)))
+	assert.Equal(t, typed, deploy)
+
+	sink := common.NewZeroCopySink(nil)
+	typed.Serialization(sink)
+	assert.Equal(t, typedBytes, sink.Bytes())
+	var deploy2 DeployCode
+	assert.Nil(t, deploy2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, typed, deploy2)
+
+	var deploy3 DeployCode
+	assert.Nil(t, deploy3.Deserialization(common.NewZeroCopySource(legacyBytes)))
+	assert.Equal(t, legacy, deploy3)
+
+	invalid := append([]byte{}, typedBytes...)
+	invalid[5] = 9
+	assert.NotNil(t, deploy.Deserialize(bytes.NewBuffer(invalid)))
+	invalid[4] = 0x31
+	assert.NotNil(t, deploy.Deserialize(bytes.NewBuffer(invalid)))
+}
+
+func TestDeployCode_EventAbi(t *testing.T) {
+	typed := DeployCode{Code: []byte{1, 2, 3}, NeedStorage: true, VmType: EmbedVm}
+	withAbi := typed
+	withAbi.EventAbi = []byte(`{"events":[{"name":"transfer","parameters":[]}]}`)
+
+	typedBytes := typed.ToArray()
+	abiBytes := withAbi.ToArray()
+	assert.Equal(t, byte(0x21), abiBytes[4])
+	assert.Equal(t, len(typedBytes)+1+len(withAbi.EventAbi), len(abiBytes))
+
+	var deploy DeployCode
+	assert.Nil(t, deploy.Deserialize(bytes.NewBuffer(abiBytes)))
+	assert.Equal(t, withAbi, deploy)
+
+	sink := common.NewZeroCopySink(nil)
+	assert.Nil(t, withAbi.Serialization(sink))
+	assert.Equal(t, abiBytes, sink.Bytes())
+	var deploy2 DeployCode
+	assert.Nil(t, deploy2.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, withAbi, deploy2)
+
+	legacy := DeployCode{Code: []byte{1, 2, 3}, EventAbi: withAbi.EventAbi}
+	assert.NotNil(t, legacy.Serialize(new(bytes.Buffer)))
+
+	truncated := abiBytes[:len(typedBytes)]
+	assert.NotNil(t, deploy.Deserialize(bytes.NewBuffer(truncated)))
+	assert.NotNil(t, deploy2.Deserialization(common.NewZeroCopySource(truncated)))
+}
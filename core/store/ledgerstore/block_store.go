@@ -56,6 +56,14 @@ type BlockStore struct {
 
 //NewBlockStore return the block store instance
 func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
+	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	return newBlockStore(dbDir, enableCache, store)
+}
+
+func newBlockStore(dbDir string, enableCache bool, store *leveldbstore.LevelDBStore) (*BlockStore, error) {
 	var cache *BlockCache
 	var err error
 	if enableCache {
@@ -64,11 +72,6 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 			return nil, fmt.Errorf("NewBlockCache error %s", err)
 		}
 	}
-
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
-	if err != nil {
-		return nil, err
-	}
 	blockStore := &BlockStore{
 		dbDir:       dbDir,
 		enableCache: enableCache,
@@ -401,7 +404,7 @@ func (this *BlockStore) loadTransaction(txHash common.Uint256) (*types.Transacti
 		return nil, 0, fmt.Errorf("ReadUint32 error %s", err)
 	}
 	tx = new(types.Transaction)
-	err = tx.Deserialize(reader)
+	err = tx.DeserializeAt(reader, height)
 	if err != nil {
 		return nil, 0, fmt.Errorf("transaction deserialize error %s", err)
 	}
@@ -41,7 +41,6 @@ import (
 	"fmt"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/common/serialization"
 	scom "github.com/imZhuFei/zeepin/core/store/common"
 	"github.com/imZhuFei/zeepin/core/store/leveldbstore"
@@ -60,10 +59,14 @@ func NewEventStore(dbDir string) (*EventStore, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newEventStore(dbDir, store), nil
+}
+
+func newEventStore(dbDir string, store *leveldbstore.LevelDBStore) *EventStore {
 	return &EventStore{
 		dbDir: dbDir,
 		store: store,
-	}, nil
+	}
 }
 
 //NewBatch start event commit batch
@@ -143,7 +146,7 @@ func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNo
 		}
 		evtNotify, err := this.GetEventNotifyByTx(txHash)
 		if err != nil {
-			log.Errorf("getEventNotifyByTx Height:%d by txhash:%s error:%s", height, txHash.ToHexString(), err)
+			logger.Errorf("getEventNotifyByTx Height:%d by txhash:%s error:%s", height, txHash.ToHexString(), err)
 			continue
 		}
 		evtNotifies = append(evtNotifies, evtNotify)
@@ -44,36 +44,43 @@ import (
 	"sync"
 
 	"strconv"
+	"time"
 
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	"github.com/imZhuFei/zeepin/common/serialization"
 	vconfig "github.com/imZhuFei/zeepin/consensus/vbft/config"
 	"github.com/imZhuFei/zeepin/core/payload"
 	"github.com/imZhuFei/zeepin/core/signature"
 	"github.com/imZhuFei/zeepin/core/states"
 	scom "github.com/imZhuFei/zeepin/core/store/common"
+	"github.com/imZhuFei/zeepin/core/store/leveldbstore"
 	"github.com/imZhuFei/zeepin/core/store/statestore"
 	"github.com/imZhuFei/zeepin/core/types"
+	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/errors"
 	"github.com/imZhuFei/zeepin/events"
 	"github.com/imZhuFei/zeepin/events/message"
 	"github.com/imZhuFei/zeepin/smartcontract"
 	scommon "github.com/imZhuFei/zeepin/smartcontract/common"
-	"github.com/imZhuFei/zeepin/smartcontract/context"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/global_params"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
 	sstate "github.com/imZhuFei/zeepin/smartcontract/states"
 	"github.com/imZhuFei/zeepin/smartcontract/storage"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 	"github.com/ontio/ontology-crypto/keypair"
 )
 
+var logger = log.Module(log.MODULE_LEDGER)
+
 const (
 	SYSTEM_VERSION          = byte(1)      //Version of ledger store
 	HEADER_INDEX_BATCH_SIZE = uint32(2000) //Bath size of saving header index
+	TRACE_HISTORY_BLOCKS    = uint32(64)   //Number of recent blocks whose transactions can be traced
 )
 
 var (
@@ -97,7 +104,9 @@ type LedgerStoreImp struct {
 	savingBlock        bool                             //is saving block now
 	vbftPeerInfoheader map[string]uint32                //pubInfo save pubkey,peerindex
 	vbftPeerInfoblock  map[string]uint32                //pubInfo save pubkey,peerindex
+	stateJournal       map[uint32]map[string][]byte     //Block height => state values overwritten by the block, kept for tracing
 	lock               sync.RWMutex
+	traceLock          sync.RWMutex //held by traces so that no block is committed under them
 }
 
 //NewLedgerStore return LedgerStoreImp instance
@@ -131,6 +140,44 @@ func NewLedgerStore(dataDir string) (*LedgerStoreImp, error) {
 	return ledgerStore, nil
 }
 
+//NewMemLedgerStore return LedgerStoreImp instance which keeps all data in memory, used by tests and simulations
+func NewMemLedgerStore() (*LedgerStoreImp, error) {
+	ledgerStore := &LedgerStoreImp{
+		headerIndex:        make(map[uint32]common.Uint256),
+		headerCache:        make(map[common.Uint256]*types.Header, 0),
+		vbftPeerInfoheader: make(map[string]uint32),
+		vbftPeerInfoblock:  make(map[string]uint32),
+	}
+
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		return nil, fmt.Errorf("NewMemLevelDBStore error %s", err)
+	}
+	blockStore, err := newBlockStore("", true, store)
+	if err != nil {
+		return nil, fmt.Errorf("NewBlockStore error %s", err)
+	}
+	ledgerStore.blockStore = blockStore
+
+	store, err = leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		return nil, fmt.Errorf("NewMemLevelDBStore error %s", err)
+	}
+	stateStore, err := newStateStore("", "", store)
+	if err != nil {
+		return nil, fmt.Errorf("NewStateStore error %s", err)
+	}
+	ledgerStore.stateStore = stateStore
+
+	store, err = leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		return nil, fmt.Errorf("NewMemLevelDBStore error %s", err)
+	}
+	ledgerStore.eventStore = newEventStore("", store)
+
+	return ledgerStore, nil
+}
+
 //InitLedgerStoreWithGenesisBlock init the ledger store with genesis block. It's the first operation after NewLedgerStore.
 func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.Block, defaultBookkeeper []keypair.PublicKey) error {
 	hasInit, err := this.hasAlreadyInitGenesisBlock()
@@ -168,7 +215,7 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 			return fmt.Errorf("init error %s", err)
 		}
 		genHash := genesisBlock.Hash()
-		log.Infof("GenesisBlock init success. GenesisBlock hash:%s\n", genHash.ToHexString())
+		logger.Infof("GenesisBlock init success. GenesisBlock hash:%s\n", genHash.ToHexString())
 	} else {
 		genesisHash := genesisBlock.Hash()
 		exist, err := this.blockStore.ContainBlock(genesisHash)
@@ -256,7 +303,7 @@ func (this *LedgerStoreImp) initCurrentBlock() error {
 	if err != nil {
 		return fmt.Errorf("LoadCurrentBlock error %s", err)
 	}
-	log.Infof("InitCurrentBlock currentBlockHash %s currentBlockHeight %d", currentBlockHash.ToHexString(), currentBlockHeight)
+	logger.Infof("InitCurrentBlock currentBlockHash %s currentBlockHeight %d", currentBlockHash.ToHexString(), currentBlockHeight)
 	this.currBlockHash = currentBlockHash
 	this.currBlockHeight = currentBlockHeight
 	return nil
@@ -462,14 +509,14 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[
 			pubkey := vconfig.PubkeyID(bookkeeper)
 			_, present := vbftPeerInfo[pubkey]
 			if !present {
-				log.Errorf("invalid pubkey :%v,height:%d", pubkey, header.Height)
+				logger.Errorf("invalid pubkey :%v,height:%d", pubkey, header.Height)
 				return vbftPeerInfo, fmt.Errorf("invalid pubkey :%v", pubkey)
 			}
 		}
 		hash := header.Hash()
 		err = signature.VerifyMultiSignature(hash[:], header.Bookkeepers, m, header.SigData)
 		if err != nil {
-			log.Errorf("VerifyMultiSignature:%s,Bookkeepers:%d,pubkey:%d,heigh:%d", err, len(header.Bookkeepers), len(vbftPeerInfo), header.Height)
+			logger.Errorf("VerifyMultiSignature:%s,Bookkeepers:%d,pubkey:%d,heigh:%d", err, len(header.Bookkeepers), len(vbftPeerInfo), header.Height)
 			return vbftPeerInfo, err
 		}
 		blkInfo, err := vconfig.VbftBlock(header)
@@ -546,6 +593,12 @@ func (this *LedgerStoreImp) AddBlock(block *types.Block) error {
 	if blockHeight != nextBlockHeight {
 		return fmt.Errorf("block height %d not equal next block height %d", blockHeight, nextBlockHeight)
 	}
+	for _, tx := range block.Transactions {
+		if err := tx.CheckValidityWindow(blockHeight); err != nil {
+			txHash := tx.Hash()
+			return fmt.Errorf("transaction %s error %s", txHash.ToHexString(), err)
+		}
+	}
 	var err error
 	this.vbftPeerInfoblock, err = this.verifyHeader(block.Header, this.vbftPeerInfoblock)
 	if err != nil {
@@ -615,6 +668,12 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block) error {
 	if err != nil {
 		return fmt.Errorf("SaveCurrentBlock error %s", err)
 	}
+	if config.DefConfig.Rpc.EnableDebugTrace {
+		err = this.journalState(blockHeight, stateBatch)
+		if err != nil {
+			return fmt.Errorf("journalState error %s", err)
+		}
+	}
 	err = stateBatch.CommitTo()
 	if err != nil {
 		return fmt.Errorf("stateBatch.CommitTo error %s", err)
@@ -622,6 +681,46 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block) error {
 	return nil
 }
 
+//journalState keeps the state values the block overwrites, so that the state before
+//the last TRACE_HISTORY_BLOCKS blocks can be rebuilt
+func (this *LedgerStoreImp) journalState(blockHeight uint32, stateBatch *statestore.StateBatch) error {
+	prev, err := stateBatch.PrevValues()
+	if err != nil {
+		return err
+	}
+	this.traceLock.Lock()
+	defer this.traceLock.Unlock()
+	if this.stateJournal == nil {
+		this.stateJournal = make(map[uint32]map[string][]byte)
+	}
+	this.stateJournal[blockHeight] = prev
+	if blockHeight >= TRACE_HISTORY_BLOCKS {
+		delete(this.stateJournal, blockHeight-TRACE_HISTORY_BLOCKS)
+	}
+	return nil
+}
+
+//getPrevState return the state values overwritten since the block at height was executed,
+//the caller has to hold traceLock
+func (this *LedgerStoreImp) getPrevState(height uint32) (map[string][]byte, error) {
+	prev := make(map[string][]byte)
+	for h := this.GetCurrentBlockHeight(); h >= height; h-- {
+		journal, ok := this.stateJournal[h]
+		if !ok {
+			return nil, fmt.Errorf("state before block %d is not kept, only the last %d blocks saved with debug trace enabled can be traced",
+				height, TRACE_HISTORY_BLOCKS)
+		}
+		//older blocks overwrite the values of newer ones
+		for k, v := range journal {
+			prev[k] = v
+		}
+		if h == 0 {
+			break
+		}
+	}
+	return prev, nil
+}
+
 func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) error {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
@@ -673,6 +772,7 @@ func (this *LedgerStoreImp) saveBlock(block *types.Block) error {
 		return nil
 	}
 
+	start := time.Now()
 	this.blockStore.NewBatch()
 	this.stateStore.NewBatch()
 	this.eventStore.NewBatch()
@@ -692,15 +792,25 @@ func (this *LedgerStoreImp) saveBlock(block *types.Block) error {
 	if err != nil {
 		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
 	}
+	//the state and the current height move together for the traces
+	this.traceLock.Lock()
 	err = this.stateStore.CommitTo()
 	if err != nil {
+		this.traceLock.Unlock()
 		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
 	}
 	err = this.eventStore.CommitTo()
 	if err != nil {
+		this.traceLock.Unlock()
 		return fmt.Errorf("eventStore.CommitTo height:%d error %s", blockHeight, err)
 	}
 	this.setCurrentBlock(blockHeight, blockHash)
+	this.traceLock.Unlock()
+
+	metrics.BlockExecTime.Observe(time.Since(start).Seconds())
+	metrics.BlockTxs.Observe(float64(len(block.Transactions)))
+	metrics.BlockHeight.Set(float64(blockHeight))
+	metrics.BlockTime.Set(float64(block.Header.Timestamp))
 
 	if events.DefActorPublisher != nil {
 		events.DefActorPublisher.Publish(
@@ -722,7 +832,7 @@ func (this *LedgerStoreImp) handleTransaction(stateBatch *statestore.StateBatch,
 			return fmt.Errorf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), stateBatch.Error())
 		}
 		if err != nil {
-			log.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
+			logger.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 		SaveNotify(this.eventStore, txHash, notify)
 	case types.Invoke:
@@ -731,7 +841,7 @@ func (this *LedgerStoreImp) handleTransaction(stateBatch *statestore.StateBatch,
 			return fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), stateBatch.Error())
 		}
 		if err != nil {
-			log.Debugf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), err)
+			logger.Debugf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 		SaveNotify(this.eventStore, txHash, notify)
 	}
@@ -889,11 +999,9 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 		}
 
 		//start the smart contract executive function
-		var engine context.Engine
-		if tx.Attributes == 0 {
-			engine, _ = sc.NewExecuteEngine(invoke.Code)
-		} else {
-			engine, _ = sc.NewWasmExecuteEngine(invoke.Code)
+		engine, isWasm, err := newInvokeEngine(&sc, invoke.Code)
+		if err != nil {
+			return &sstate.PreExecResult{State: event.CONTRACT_STATE_FAIL, Gas: embed.MIN_TRANSACTION_GAS, Result: nil}, err
 		}
 		result, err := engine.Invoke()
 		if err != nil {
@@ -904,7 +1012,7 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 		if gasCost < mixGas {
 			gasCost = mixGas
 		}
-		if tx.Attributes == 0 {
+		if !isWasm {
 			result = scommon.ConvertEmbededTypeHexString(result)
 		} else {
 			if v, ok := result.([]byte); ok {
@@ -914,12 +1022,70 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 		return &sstate.PreExecResult{State: event.CONTRACT_STATE_SUCCESS, Gas: gasCost, Result: result}, nil
 	} else if tx.TxType == types.Deploy {
 		deploy := tx.Payload.(*payload.DeployCode)
-		return &sstate.PreExecResult{State: event.CONTRACT_STATE_SUCCESS, Gas: preGas[embed.CONTRACT_CREATE_NAME] + calcGasByCodeLen(len(deploy.Code), preGas[embed.UINT_DEPLOY_CODE_LEN_NAME]), Result: nil}, nil
+		//the deployment is executed in the next block
+		codeLen := deployCodeLen(deploy, header.Height+1)
+		return &sstate.PreExecResult{State: event.CONTRACT_STATE_SUCCESS, Gas: preGas[embed.CONTRACT_CREATE_NAME] + calcGasByCodeLen(codeLen, preGas[embed.UINT_DEPLOY_CODE_LEN_NAME]), Result: nil}, nil
 	} else {
 		return &sstate.PreExecResult{State: event.CONTRACT_STATE_FAIL, Gas: embed.MIN_TRANSACTION_GAS, Result: nil}, errors.NewErr("transaction type error")
 	}
 }
 
+//TraceTransaction re-executes an invoke transaction of the chain with the tracers attached to its engines.
+//The state before its block is rebuilt from the state journal and the transactions of the block before
+//it are replayed on top, so only the last TRACE_HISTORY_BLOCKS blocks saved with debug trace enabled
+//can be traced. Chain queries of the contracts still see the current chain, and nothing is committed
+func (this *LedgerStoreImp) TraceTransaction(txHash common.Uint256, wasmTracer exec.Tracer, embedTracer vm.Tracer) (*sstate.PreExecResult, error) {
+	tx, height, err := this.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", txHash.ToHexString())
+	}
+	if tx.TxType != types.Invoke {
+		return nil, errors.NewErr("only invoke transactions can be traced")
+	}
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	this.traceLock.RLock()
+	defer this.traceLock.RUnlock()
+	prev, err := this.getPrevState(height)
+	if err != nil {
+		return nil, err
+	}
+	stateBatch := statestore.NewStateStoreBatch(statestore.NewMemDatabase(), statestore.NewHistoryStore(this.stateStore.store, prev))
+	for _, t := range block.Transactions {
+		notify := &event.ExecuteNotify{TxHash: t.Hash(), State: event.CONTRACT_STATE_FAIL}
+		if t.Hash() == txHash {
+			trace := &invokeTrace{wasmTracer: wasmTracer, embedTracer: embedTracer}
+			err := this.stateStore.handleInvokeTransaction(this, stateBatch, t, block, notify, trace)
+			if stateBatch.Error() != nil {
+				return nil, stateBatch.Error()
+			}
+			result := trace.result
+			if result != nil && !trace.isWasm {
+				result = scommon.ConvertEmbededTypeHexString(result)
+			} else if v, ok := result.([]byte); ok {
+				result = common.ToHexString(v)
+			}
+			return &sstate.PreExecResult{State: notify.State, Gas: trace.gas, Result: result}, err
+		}
+		switch t.TxType {
+		case types.Deploy:
+			this.stateStore.HandleDeployTransaction(this, stateBatch, t, block, notify)
+		case types.Invoke:
+			this.stateStore.HandleInvokeTransaction(this, stateBatch, t, block, notify)
+		}
+		if stateBatch.Error() != nil {
+			return nil, stateBatch.Error()
+		}
+	}
+	return nil, fmt.Errorf("transaction %s not found in block %d", txHash.ToHexString(), height)
+}
+
 func (this *LedgerStoreImp) getPreGas(config *smartcontract.Config, cache *storage.CloneCache) (map[string]uint64, error) {
 	bf := new(bytes.Buffer)
 	names := []string{embed.CONTRACT_CREATE_NAME, embed.UINT_INVOKE_CODE_LEN_NAME, embed.UINT_DEPLOY_CODE_LEN_NAME}
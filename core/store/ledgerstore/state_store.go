@@ -63,11 +63,15 @@ type StateStore struct {
 
 //NewStateStore return state store instance
 func NewStateStore(dbDir, merklePath string) (*StateStore, error) {
-	var err error
 	store, err := leveldbstore.NewLevelDBStore(dbDir)
 	if err != nil {
 		return nil, err
 	}
+	return newStateStore(dbDir, merklePath, store)
+}
+
+//newStateStore return state store instance on store, an empty merklePath keeps the merkle tree in memory
+func newStateStore(dbDir, merklePath string, store scom.PersistStore) (*StateStore, error) {
 	stateStore := &StateStore{
 		dbDir:      dbDir,
 		store:      store,
@@ -97,9 +101,13 @@ func (self *StateStore) init(currBlockHeight uint32) error {
 	if treeSize > 0 && treeSize != currBlockHeight+1 {
 		return fmt.Errorf("merkle tree size is inconsistent with blockheight: %d", currBlockHeight+1)
 	}
-	self.merkleHashStore, err = merkle.NewFileHashStore(self.merklePath, treeSize)
-	if err != nil {
-		return fmt.Errorf("merkle store is inconsistent with ChainStore. persistence will be disabled")
+	if self.merklePath == "" {
+		self.merkleHashStore = merkle.NewMemHashStore()
+	} else {
+		self.merkleHashStore, err = merkle.NewFileHashStore(self.merklePath, treeSize)
+		if err != nil {
+			return fmt.Errorf("merkle store is inconsistent with ChainStore. persistence will be disabled")
+		}
 	}
 	self.merkleTree = merkle.NewTree(treeSize, hashes, self.merkleHashStore)
 	return nil
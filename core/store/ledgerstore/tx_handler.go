@@ -42,16 +42,17 @@ import (
 
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/config"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/core/payload"
 	"github.com/imZhuFei/zeepin/core/store"
 	scommon "github.com/imZhuFei/zeepin/core/store/common"
 	"github.com/imZhuFei/zeepin/core/store/statestore"
 	"github.com/imZhuFei/zeepin/core/types"
+	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	ntypes "github.com/imZhuFei/zeepin/embed/simulator/types"
 	"github.com/imZhuFei/zeepin/errors"
 	"github.com/imZhuFei/zeepin/smartcontract"
+	sccommon "github.com/imZhuFei/zeepin/smartcontract/common"
 	"github.com/imZhuFei/zeepin/smartcontract/context"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
@@ -59,7 +60,9 @@ import (
 	ninit "github.com/imZhuFei/zeepin/smartcontract/service/native/init"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/zpt"
+	sstates "github.com/imZhuFei/zeepin/smartcontract/states"
 	"github.com/imZhuFei/zeepin/smartcontract/storage"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 )
 
 //HandleDeployTransaction deal with smart contract deploy transaction
@@ -93,7 +96,7 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, stateBa
 			return nil
 		}
 
-		gasLimit := createGasPrice.(uint64) + calcGasByCodeLen(len(deploy.Code), uintCodePrice.(uint64))
+		gasLimit := createGasPrice.(uint64) + calcGasByCodeLen(deployCodeLen(deploy, block.Header.Height), uintCodePrice.(uint64))
 		balance, err := isBalanceSufficient(tx.Payer, cache, config, store, gasLimit*tx.GasPrice)
 		if err != nil {
 			if err := costInvalidGas(tx.Payer, balance, config, stateBatch, store, notify); err != nil {
@@ -116,21 +119,41 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, stateBa
 		cache.Commit()
 	}
 
-	log.Infof("deploy contract address:%s", address.ToHexString())
+	notify.Notify = append(notify.Notify, notifies...)
+	notify.GasConsumed = gasConsumed
+	contract, err := sccommon.PrepareDeployCode(deploy, block.Header.Height)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("deploy contract address:%s, vm type:%s", address.ToHexString(), contract.VmType)
 	// store contract message
-	err = stateBatch.TryGetOrAdd(scommon.ST_CONTRACT, address[:], deploy)
+	err = stateBatch.TryGetOrAdd(scommon.ST_CONTRACT, address[:], contract)
 	if err != nil {
 		return err
 	}
-	notify.Notify = append(notify.Notify, notifies...)
-	notify.GasConsumed = gasConsumed
 	notify.State = event.CONTRACT_STATE_SUCCESS
 	return nil
 }
 
+//invokeTrace attaches tracers to the engine of an invoke transaction and receives
+//what the engine returned and the gas it used
+type invokeTrace struct {
+	wasmTracer  exec.Tracer
+	embedTracer vm.Tracer
+	result      interface{}
+	isWasm      bool
+	gas         uint64
+}
+
 //HandleInvokeTransaction deal with smart contract invoke transaction
 func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, stateBatch *statestore.StateBatch,
 	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify) error {
+	return self.handleInvokeTransaction(store, stateBatch, tx, block, notify, nil)
+}
+
+func (self *StateStore) handleInvokeTransaction(store store.LedgerStore, stateBatch *statestore.StateBatch,
+	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify, trace *invokeTrace) error {
 	invoke := tx.Payload.(*payload.InvokeCode)
 	code := invoke.Code
 	sysTransFlag := bytes.Compare(code, ninit.COMMIT_DPOS_BYTES) == 0 || block.Header.Height == 0
@@ -143,6 +166,10 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, stateBa
 		Height: block.Header.Height,
 		Tx:     tx,
 	}
+	if trace != nil {
+		config.WasmTracer = trace.wasmTracer
+		config.EmbedTracer = trace.embedTracer
+	}
 
 	var (
 		costGasLimit      uint64
@@ -208,19 +235,23 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, stateBa
 	}
 
 	//start the smart contract executive function
-	var engine context.Engine
-	if tx.Attributes == 0 {
-		engine, _ = sc.NewExecuteEngine(invoke.Code)
-	} else {
-		engine, _ = sc.NewWasmExecuteEngine(invoke.Code)
+	var (
+		engine context.Engine
+		isWasm bool
+		result interface{}
+	)
+	engine, isWasm, err = newInvokeEngine(&sc, invoke.Code)
+	if err == nil {
+		result, err = engine.Invoke()
 	}
 
-	_, err = engine.Invoke()
-
 	costGasLimit = availableGasLimit - sc.Gas
 	if costGasLimit < embed.MIN_TRANSACTION_GAS {
 		costGasLimit = embed.MIN_TRANSACTION_GAS
 	}
+	if trace != nil {
+		trace.result, trace.isWasm, trace.gas = result, isWasm, costGasLimit
+	}
 
 	costGas = costGasLimit * tx.GasPrice
 	if err != nil {
@@ -259,6 +290,42 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, stateBa
 	return nil
 }
 
+// newInvokeEngine selects the engine running an invoke transaction. From the vm
+// type check height, a payload naming a wasm contract always runs on the wasm
+// engine and an embed contract can not be invoked as wasm, otherwise the engine
+// is chosen by the transaction attributes. It also reports whether the engine is wasm.
+func newInvokeEngine(sc *smartcontract.SmartContract, code []byte) (context.Engine, bool, error) {
+	tx := sc.Config.Tx
+	vmType := payload.LegacyVm
+	if sccommon.VmTypeCheckEnabled(sc.Config.Height) {
+		target := new(sstates.Contract)
+		source := common.NewZeroCopySource(code)
+		if err := target.Deserialization(source); err == nil && source.Len() == 0 {
+			item, err := sc.CloneCache.Get(scommon.ST_CONTRACT, target.Address[:])
+			if err != nil {
+				return nil, false, err
+			}
+			if deploy, ok := item.(*payload.DeployCode); ok {
+				vmType = deploy.VmType
+			}
+		}
+	}
+
+	switch {
+	case vmType == payload.WasmVm:
+		engine, err := sc.NewWasmExecuteEngine(code)
+		return engine, true, err
+	case vmType == payload.EmbedVm && tx.Attributes != 0:
+		return nil, false, fmt.Errorf("embed contract can not be invoked by wasm vm")
+	case tx.Attributes == 0:
+		engine, err := sc.NewExecuteEngine(code)
+		return engine, false, err
+	default:
+		engine, err := sc.NewWasmExecuteEngine(code)
+		return engine, true, err
+	}
+}
+
 func SaveNotify(eventStore scommon.EventStore, txHash common.Uint256, notify *event.ExecuteNotify) error {
 	if !config.DefConfig.Common.EnableEventLog {
 		return nil
@@ -341,7 +408,7 @@ func refreshGlobalParam(config *smartcontract.Config, cache *storage.CloneCache,
 		if n != -1 && ps.Value != "" {
 			pu, err := strconv.ParseUint(ps.Value, 10, 64)
 			if err != nil {
-				log.Errorf("[refreshGlobalParam] failed to parse uint %v\n", ps.Value)
+				logger.Errorf("[refreshGlobalParam] failed to parse uint %v\n", ps.Value)
 			} else {
 				embed.GAS_TABLE.Store(key, pu)
 
@@ -385,6 +452,15 @@ func costInvalidGas(address common.Address, gas uint64, config *smartcontract.Co
 	return nil
 }
 
+//deployCodeLen return the length of a deployment charged by code length, the
+//event abi is stored along with the code from the height it is enabled
+func deployCodeLen(deploy *payload.DeployCode, height uint32) int {
+	if sccommon.EventAbiEnabled(height) {
+		return len(deploy.Code) + len(deploy.EventAbi)
+	}
+	return len(deploy.Code)
+}
+
 func calcGasByCodeLen(codeLen int, codeGas uint64) uint64 {
 	return uint64(codeLen/embed.PER_UNIT_CODE_LEN) * codeGas
 }
@@ -39,6 +39,9 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/core/payload"
 )
 
 func TestSyncMapRange(t *testing.T) {
@@ -91,3 +94,23 @@ func TestSyncMapRW(t *testing.T) {
 func addsync(m *sync.Map, va int) {
 	m.Store("key", va)
 }
+
+func TestDeployCodeLen(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	deploy := &payload.DeployCode{Code: make([]byte, 1000), EventAbi: make([]byte, 300)}
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	if n := deployCodeLen(deploy, 0); n != 1300 {
+		t.Fatalf("event abi is not charged, code length %d", n)
+	}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	height := config.GetEventAbiHeight(config.NETWORK_ID_MAIN_NET)
+	if height == 0 {
+		t.Skip("event abis are enabled from genesis on mainnet")
+	}
+	if n := deployCodeLen(deploy, height-1); n != 1000 {
+		t.Fatalf("charged %d before the event abi height", n)
+	}
+}
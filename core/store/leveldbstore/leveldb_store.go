@@ -40,6 +40,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -78,6 +79,18 @@ func NewLevelDBStore(file string) (*LevelDBStore, error) {
 	}, nil
 }
 
+//NewMemLevelDBStore return LevelDBStore instance which keeps all data in memory
+func NewMemLevelDBStore() (*LevelDBStore, error) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBStore{
+		db:    db,
+		batch: nil,
+	}, nil
+}
+
 //Put a key-value pair to leveldb
 func (self *LevelDBStore) Put(key []byte, value []byte) error {
 	return self.db.Put(key, value, nil)
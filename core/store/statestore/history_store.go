@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package statestore
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/imZhuFei/zeepin/core/store/common"
+)
+
+var errReadOnly = errors.New("history store is read only")
+
+//HistoryStore is a read only view of a PersistStore as it was before some batches were
+//committed to it, prev holds the values the batches overwrote and nil for the keys they added
+type HistoryStore struct {
+	store common.PersistStore
+	prev  map[string][]byte
+}
+
+//NewHistoryStore return the view of store before the changes recorded in prev
+func NewHistoryStore(store common.PersistStore, prev map[string][]byte) *HistoryStore {
+	return &HistoryStore{
+		store: store,
+		prev:  prev,
+	}
+}
+
+func (self *HistoryStore) Put(key []byte, value []byte) error {
+	return errReadOnly
+}
+
+func (self *HistoryStore) Get(key []byte) ([]byte, error) {
+	if value, ok := self.prev[string(key)]; ok {
+		if value == nil {
+			return nil, common.ErrNotFound
+		}
+		return value, nil
+	}
+	return self.store.Get(key)
+}
+
+func (self *HistoryStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == common.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (self *HistoryStore) Delete(key []byte) error {
+	return errReadOnly
+}
+
+func (self *HistoryStore) NewBatch() {}
+
+func (self *HistoryStore) BatchPut(key []byte, value []byte) {}
+
+func (self *HistoryStore) BatchDelete(key []byte) {}
+
+func (self *HistoryStore) BatchCommit() error {
+	return errReadOnly
+}
+
+//Close does nothing, the underlying store is owned by the caller
+func (self *HistoryStore) Close() error {
+	return nil
+}
+
+//NewIterator return the iterator of the keys with prefix, it loads them all in memory
+func (self *HistoryStore) NewIterator(prefix []byte) common.StoreIterator {
+	iter := &historyIterator{pos: -1}
+	it := self.store.NewIterator(prefix)
+	for it.Next() {
+		if _, ok := self.prev[string(it.Key())]; ok {
+			continue
+		}
+		iter.keys = append(iter.keys, append([]byte{}, it.Key()...))
+		iter.values = append(iter.values, append([]byte{}, it.Value()...))
+	}
+	it.Release()
+	for k, v := range self.prev {
+		if v != nil && strings.HasPrefix(k, string(prefix)) {
+			iter.keys = append(iter.keys, []byte(k))
+			iter.values = append(iter.values, v)
+		}
+	}
+	sort.Sort(iter)
+	return iter
+}
+
+//historyIterator iterates over the sorted key-value pairs of a HistoryStore
+type historyIterator struct {
+	keys   [][]byte
+	values [][]byte
+	pos    int
+}
+
+func (self *historyIterator) Len() int {
+	return len(self.keys)
+}
+
+func (self *historyIterator) Less(i, j int) bool {
+	return bytes.Compare(self.keys[i], self.keys[j]) < 0
+}
+
+func (self *historyIterator) Swap(i, j int) {
+	self.keys[i], self.keys[j] = self.keys[j], self.keys[i]
+	self.values[i], self.values[j] = self.values[j], self.values[i]
+}
+
+func (self *historyIterator) valid() bool {
+	return self.pos >= 0 && self.pos < len(self.keys)
+}
+
+func (self *historyIterator) Next() bool {
+	if self.pos < len(self.keys) {
+		self.pos++
+	}
+	return self.valid()
+}
+
+func (self *historyIterator) Prev() bool {
+	if self.pos >= 0 {
+		self.pos--
+	}
+	return self.valid()
+}
+
+func (self *historyIterator) First() bool {
+	self.pos = 0
+	return self.valid()
+}
+
+func (self *historyIterator) Last() bool {
+	self.pos = len(self.keys) - 1
+	return self.valid()
+}
+
+func (self *historyIterator) Seek(key []byte) bool {
+	self.pos = sort.Search(len(self.keys), func(i int) bool {
+		return bytes.Compare(self.keys[i], key) >= 0
+	})
+	return self.valid()
+}
+
+func (self *historyIterator) Key() []byte {
+	if !self.valid() {
+		return nil
+	}
+	return self.keys[self.pos]
+}
+
+func (self *historyIterator) Value() []byte {
+	if !self.valid() {
+		return nil
+	}
+	return self.values[self.pos]
+}
+
+func (self *historyIterator) Release() {}
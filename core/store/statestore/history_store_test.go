@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package statestore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/core/states"
+	com "github.com/imZhuFei/zeepin/core/store/common"
+	"github.com/imZhuFei/zeepin/core/store/leveldbstore"
+)
+
+func TestHistoryStore(t *testing.T) {
+	db, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put([]byte{byte(com.ST_STORAGE), 1}, []byte{1})
+	db.Put([]byte{byte(com.ST_STORAGE), 2}, []byte{2})
+
+	//a block changes key 1, deletes key 2 and adds key 3
+	batch := NewStateStoreBatch(NewMemDatabase(), db)
+	batch.TryAdd(com.ST_STORAGE, []byte{1}, &states.StorageItem{Value: []byte{10}})
+	batch.TryDelete(com.ST_STORAGE, []byte{2})
+	batch.TryAdd(com.ST_STORAGE, []byte{3}, &states.StorageItem{Value: []byte{30}})
+	prev, err := batch.PrevValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prev) != 3 || prev[string([]byte{byte(com.ST_STORAGE), 3})] != nil {
+		t.Fatalf("unexpected prev values %v", prev)
+	}
+	db.NewBatch()
+	if err = batch.CommitTo(); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	history := NewHistoryStore(db, prev)
+	if value, err := history.Get([]byte{byte(com.ST_STORAGE), 1}); err != nil || !bytes.Equal(value, []byte{1}) {
+		t.Fatalf("key 1 is %x, %v", value, err)
+	}
+	if ok, _ := history.Has([]byte{byte(com.ST_STORAGE), 3}); ok {
+		t.Fatal("key 3 did not exist before the block")
+	}
+	iter := history.NewIterator([]byte{byte(com.ST_STORAGE)})
+	defer iter.Release()
+	expect := [][]byte{{1}, {2}}
+	for i := 0; iter.Next(); i++ {
+		if i >= len(expect) || !bytes.Equal(iter.Value(), expect[i]) {
+			t.Fatalf("unexpected value %x at %d", iter.Value(), i)
+		}
+	}
+	if err := history.Put([]byte{1}, []byte{1}); err == nil {
+		t.Fatal("history store should be read only")
+	}
+}
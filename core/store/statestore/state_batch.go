@@ -164,6 +164,20 @@ func (self *StateBatch) CommitTo() error {
 	return nil
 }
 
+//PrevValues return the stored values of the keys changed in the batch, nil for the keys
+//not stored yet. It has to be called before the batch is committed
+func (self *StateBatch) PrevValues() (map[string][]byte, error) {
+	prev := make(map[string][]byte)
+	for k := range self.memoryStore.GetChangeSet() {
+		value, err := self.store.Get([]byte(k))
+		if err != nil && err != common.ErrNotFound {
+			return nil, err
+		}
+		prev[k] = value
+	}
+	return prev, nil
+}
+
 func (self *StateBatch) setStateObject(prefix byte, key []byte, value states.StateValue, state common.ItemState) {
 	self.memoryStore.Put(prefix, key, value, state)
 }
@@ -39,8 +39,10 @@ import (
 	"github.com/imZhuFei/zeepin/core/payload"
 	"github.com/imZhuFei/zeepin/core/states"
 	"github.com/imZhuFei/zeepin/core/types"
+	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	cstates "github.com/imZhuFei/zeepin/smartcontract/states"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 	"github.com/ontio/ontology-crypto/keypair"
 )
 
@@ -68,6 +70,7 @@ type LedgerStore interface {
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
+	TraceTransaction(txHash common.Uint256, wasmTracer exec.Tracer, embedTracer vm.Tracer) (*cstates.PreExecResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
 }
@@ -86,7 +86,7 @@ func (b *Block) Deserialize(r io.Reader) error {
 	var hashes = make([]common.Uint256, 0, length)
 	for i := uint32(0); i < length; i++ {
 		transaction := new(Transaction)
-		err := transaction.Deserialize(r)
+		err := transaction.DeserializeAt(r, b.Header.Height)
 		if err != nil {
 			return err
 		}
@@ -55,7 +55,10 @@ type MutableTransaction struct {
 	Payload  Payload
 	//Attributes []*TxAttribute
 	Attributes byte //this must be 0 now, Attribute Array length use VarUint encoding, so byte is enough for extension
-	Sigs       []Sig
+	// the blocks [NotBefore, ExpireHeight] a version 1 transaction may be included in
+	NotBefore    uint32
+	ExpireHeight uint32
+	Sigs         []Sig
 }
 
 // output has no reference to self
@@ -136,6 +139,13 @@ func (tx *MutableTransaction) serializeUnsigned(sink *common.ZeroCopySink) error
 		return errors.New("wrong transaction payload type")
 	}
 	sink.WriteVarUint(uint64(tx.Attributes))
+	if tx.Version == TX_VERSION_VALIDITY_WINDOW {
+		if tx.ExpireHeight == 0 {
+			return errors.New("transaction expire height not set")
+		}
+		sink.WriteUint32(tx.NotBefore)
+		sink.WriteUint32(tx.ExpireHeight)
+	}
 	return nil
 }
 func (tx *MutableTransaction) DeserializeUnsigned(r io.Reader) error {
@@ -185,5 +195,16 @@ func (tx *MutableTransaction) DeserializeUnsigned(r io.Reader) error {
 		return fmt.Errorf("transaction attribute must be 0, got %d", length)
 	}
 	tx.Attributes = byte(length)
+	if tx.Version == TX_VERSION_VALIDITY_WINDOW {
+		if tx.NotBefore, err = serialization.ReadUint32(r); err != nil {
+			return err
+		}
+		if tx.ExpireHeight, err = serialization.ReadUint32(r); err != nil {
+			return err
+		}
+		if tx.ExpireHeight == 0 {
+			return errors.New("transaction expire height not set")
+		}
+	}
 	return nil
 }
@@ -42,6 +42,7 @@ import (
 	"io"
 
 	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/constants"
 	"github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/core/payload"
@@ -51,6 +52,14 @@ import (
 
 const MAX_TX_SIZE = 1024 * 1024 * 2 // The max size of a transaction to prevent DOS attacks
 
+// transaction versions, from the validity window height on the unsigned part of
+// a version 1 transaction ends with its validity window, the version byte was not
+// checked before so earlier version 1 transactions in the chain have no window
+const (
+	TX_VERSION_DEFAULT         = byte(0)
+	TX_VERSION_VALIDITY_WINDOW = byte(1)
+)
+
 type Transaction struct {
 	Version  byte
 	TxType   TransactionType
@@ -61,7 +70,10 @@ type Transaction struct {
 	Payload  Payload
 	//Attributes []*TxAttribute
 	Attributes byte //this must be 0 now, Attribute Array length use VarUint encoding, so byte is enough for extension
-	Sigs       []*Sig
+	// the blocks [NotBefore, ExpireHeight] a version 1 transaction may be included in
+	NotBefore    uint32
+	ExpireHeight uint32
+	Sigs         []*Sig
 
 	Raw []byte // raw transaction data
 
@@ -145,6 +157,9 @@ func (tx *Transaction) IntoMutable() (*MutableTransaction, error) {
 		GasLimit: tx.GasLimit,
 		Payer:    tx.Payer,
 		Payload:  tx.Payload,
+
+		NotBefore:    tx.NotBefore,
+		ExpireHeight: tx.ExpireHeight,
 	}
 
 	for _, sig := range tx.Sigs {
@@ -203,6 +218,17 @@ func (tx *Transaction) deserializationUnsigned(source *common.ZeroCopySource) er
 		return fmt.Errorf("transaction attribute must be 0, got %d", length)
 	}
 	tx.Attributes = byte(attr)
+
+	if tx.Version == TX_VERSION_VALIDITY_WINDOW {
+		tx.NotBefore, eof = source.NextUint32()
+		tx.ExpireHeight, eof = source.NextUint32()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		if tx.ExpireHeight == 0 {
+			return errors.New("transaction expire height not set")
+		}
+	}
 	return nil
 }
 
@@ -450,14 +476,32 @@ func (tx *Transaction) SerializeUnsigned(w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("[SerializeUnsigned], Transaction item txAttribute length serialization failed. %v", err)
 	}
+	if tx.HasValidityWindow() {
+		if err := serialization.WriteUint32(w, tx.NotBefore); err != nil {
+			return fmt.Errorf("[SerializeUnsigned], Transaction notBefore failed. %v", err)
+		}
+		if err := serialization.WriteUint32(w, tx.ExpireHeight); err != nil {
+			return fmt.Errorf("[SerializeUnsigned], Transaction expireHeight failed. %v", err)
+		}
+	}
 
 	return nil
 }
 
-// deserialize the Transaction
+// deserialize the Transaction, a transaction outside of a block is for the next
+// one so a version 1 transaction is read with its validity window
 func (tx *Transaction) Deserialize(r io.Reader) error {
+	return tx.deserialize(r, true)
+}
+
+// DeserializeAt deserializes a Transaction of the block at height
+func (tx *Transaction) DeserializeAt(r io.Reader, height uint32) error {
+	return tx.deserialize(r, height >= config.GetTxValidityWindowHeight(config.DefConfig.P2PNode.NetworkId))
+}
+
+func (tx *Transaction) deserialize(r io.Reader, window bool) error {
 	// tx deserialize
-	err := tx.DeserializeUnsigned(r)
+	err := tx.deserializeUnsigned(r, window)
 	if err != nil {
 		return fmt.Errorf("[Deserialize], Transaction deserializeUnsigned error. %v", err)
 	}
@@ -485,6 +529,10 @@ func (tx *Transaction) Deserialize(r io.Reader) error {
 }
 
 func (tx *Transaction) DeserializeUnsigned(r io.Reader) error {
+	return tx.deserializeUnsigned(r, true)
+}
+
+func (tx *Transaction) deserializeUnsigned(r io.Reader, window bool) error {
 	var versiontype [2]byte
 	_, err := io.ReadFull(r, versiontype[:])
 	if err != nil {
@@ -535,6 +583,18 @@ func (tx *Transaction) DeserializeUnsigned(r io.Reader) error {
 	}*/
 	tx.Attributes = byte(attr)
 
+	if window && tx.Version == TX_VERSION_VALIDITY_WINDOW {
+		if tx.NotBefore, err = serialization.ReadUint32(r); err != nil {
+			return err
+		}
+		if tx.ExpireHeight, err = serialization.ReadUint32(r); err != nil {
+			return err
+		}
+		if tx.ExpireHeight == 0 {
+			return errors.New("transaction expire height not set")
+		}
+	}
+
 	return nil
 }
 
@@ -562,6 +622,37 @@ func (tx *Transaction) Hash() common.Uint256 {
 	return *tx.hash
 }
 
+// HasValidityWindow reports whether the transaction carries a validity window,
+// a window always ends at a nonzero height
+func (tx *Transaction) HasValidityWindow() bool {
+	return tx.Version == TX_VERSION_VALIDITY_WINDOW && tx.ExpireHeight != 0
+}
+
+// CheckValidityWindow returns an error if the transaction can not be included
+// in the block at height, transactions without window are always accepted
+func (tx *Transaction) CheckValidityWindow(height uint32) error {
+	if !tx.HasValidityWindow() {
+		return nil
+	}
+	forkHeight := config.GetTxValidityWindowHeight(config.DefConfig.P2PNode.NetworkId)
+	if height < forkHeight {
+		return fmt.Errorf("transaction validity window is not supported before height %d", forkHeight)
+	}
+	if height < tx.NotBefore {
+		return fmt.Errorf("transaction is not valid before height %d, current %d", tx.NotBefore, height)
+	}
+	if tx.Expired(height) {
+		return fmt.Errorf("transaction expired at height %d, current %d", tx.ExpireHeight, height)
+	}
+	return nil
+}
+
+// Expired reports whether the transaction can no longer be included in the
+// block at height or any later one
+func (tx *Transaction) Expired(height uint32) bool {
+	return tx.HasValidityWindow() && height > tx.ExpireHeight
+}
+
 func (tx *Transaction) Type() common.InventoryType {
 	return common.TRANSACTION
 }
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/core/payload"
+	"github.com/stretchr/testify/assert"
+)
+
+func newValidityWindowTx(t *testing.T) *Transaction {
+	mutable := &MutableTransaction{
+		Version:      TX_VERSION_VALIDITY_WINDOW,
+		TxType:       Invoke,
+		Nonce:        1,
+		GasPrice:     500,
+		GasLimit:     20000,
+		Payload:      &payload.InvokeCode{Code: []byte{1, 2, 3}},
+		NotBefore:    10,
+		ExpireHeight: 20,
+	}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func TestTransaction_ValidityWindowSerialize(t *testing.T) {
+	tx := newValidityWindowTx(t)
+	assert.Equal(t, uint32(10), tx.NotBefore)
+	assert.Equal(t, uint32(20), tx.ExpireHeight)
+
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, tx.Serialize(buf))
+	assert.Equal(t, tx.Raw, buf.Bytes())
+
+	var tx2 Transaction
+	assert.Nil(t, tx2.Deserialize(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, tx.NotBefore, tx2.NotBefore)
+	assert.Equal(t, tx.ExpireHeight, tx2.ExpireHeight)
+
+	mutable, err := tx.IntoMutable()
+	assert.Nil(t, err)
+	assert.Equal(t, tx.ExpireHeight, mutable.ExpireHeight)
+	assert.Equal(t, tx.Hash(), mutable.Hash())
+
+	//the window is part of the signed data
+	mutable.ExpireHeight = 21
+	assert.NotEqual(t, tx.Hash(), mutable.Hash())
+
+	_, err = TransactionFromRawBytes(tx.Raw[:len(tx.Raw)-3])
+	assert.NotNil(t, err)
+}
+
+func TestTransaction_CheckValidityWindow(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+
+	tx := newValidityWindowTx(t)
+	assert.NotNil(t, tx.CheckValidityWindow(9))
+	assert.Nil(t, tx.CheckValidityWindow(10))
+	assert.Nil(t, tx.CheckValidityWindow(20))
+	assert.NotNil(t, tx.CheckValidityWindow(21))
+	assert.False(t, tx.Expired(20))
+	assert.True(t, tx.Expired(21))
+
+	legacy := &Transaction{Version: TX_VERSION_DEFAULT}
+	assert.Nil(t, legacy.CheckValidityWindow(100))
+	assert.False(t, legacy.Expired(100))
+}
+
+func TestTransaction_LegacyVersion1(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	forkHeight := config.GetTxValidityWindowHeight(config.NETWORK_ID_MAIN_NET)
+	if forkHeight == 0 {
+		t.Skip("validity windows are active from genesis on mainnet")
+	}
+
+	//the version byte was free before the validity window height
+	legacy := &Transaction{
+		Version:  TX_VERSION_VALIDITY_WINDOW,
+		TxType:   Invoke,
+		Nonce:    1,
+		GasPrice: 500,
+		GasLimit: 20000,
+		Payload:  &payload.InvokeCode{Code: []byte{1, 2, 3}},
+		Sigs:     []*Sig{},
+	}
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, legacy.Serialize(buf))
+	raw := buf.Bytes()
+
+	var tx Transaction
+	assert.Nil(t, tx.DeserializeAt(bytes.NewReader(raw), forkHeight-1))
+	assert.False(t, tx.HasValidityWindow())
+	assert.Nil(t, tx.CheckValidityWindow(forkHeight-1))
+	assert.Equal(t, legacy.Hash(), tx.Hash())
+	assert.Equal(t, raw, tx.ToArray())
+
+	//outside of a block it is read as a transaction with window
+	var loose Transaction
+	assert.NotNil(t, loose.Deserialize(bytes.NewReader(raw)))
+}
@@ -60,6 +60,11 @@ func VerifyTransaction(tx *types.Transaction) ontErrors.ErrCode {
 		return ontErrors.ErrTransactionPayload
 	}
 
+	if err := checkTransactionValidityWindow(tx); err != nil {
+		log.Info("[VerifyTransaction],", err)
+		return ontErrors.ErrTxValidityWindow
+	}
+
 	return ontErrors.ErrNoError
 }
 
@@ -131,3 +136,12 @@ func checkTransactionPayload(tx *types.Transaction) error {
 	}
 	return nil
 }
+
+//the version byte is not checked, it was free before the validity window and
+//historical transactions carry other values
+func checkTransactionValidityWindow(tx *types.Transaction) error {
+	if tx.HasValidityWindow() && tx.NotBefore > tx.ExpireHeight {
+		return fmt.Errorf("transaction not before height %d is above expire height %d", tx.NotBefore, tx.ExpireHeight)
+	}
+	return nil
+}
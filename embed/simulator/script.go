@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/common"
+)
+
+// ValidateScript scans code instruction by instruction, and fails on an unknown
+// opcode or on an operand running past the end of the script
+func ValidateScript(code []byte) error {
+	if len(code) == 0 {
+		return fmt.Errorf("[ValidateScript] empty script")
+	}
+	source := common.NewZeroCopySource(code)
+	for source.Len() > 0 {
+		pos := source.Pos()
+		b, _ := source.NextByte()
+		op := OpCode(b)
+		var eof bool
+		switch {
+		case op >= PUSHBYTES1 && op <= PUSHBYTES75:
+			eof = source.Skip(uint64(op))
+		case op == PUSHDATA1:
+			var n uint8
+			n, eof = source.NextUint8()
+			if !eof {
+				eof = source.Skip(uint64(n))
+			}
+		case op == PUSHDATA2:
+			var n uint16
+			n, eof = source.NextUint16()
+			if !eof {
+				eof = source.Skip(uint64(n))
+			}
+		case op == PUSHDATA4:
+			var n uint32
+			n, eof = source.NextUint32()
+			if !eof {
+				eof = source.Skip(uint64(n))
+			}
+		case op == JMP || op == JMPIF || op == JMPIFNOT || op == CALL:
+			eof = source.Skip(2)
+		case op == APPCALL || op == TAILCALL:
+			eof = source.Skip(common.ADDR_LEN)
+		case op == SYSCALL:
+			var irregular bool
+			_, _, irregular, eof = source.NextVarBytes()
+			if irregular {
+				return fmt.Errorf("[ValidateScript] irregular syscall name at %d", pos)
+			}
+		default:
+			if OpExecList[op].Name == "" {
+				return fmt.Errorf("[ValidateScript] unknown opcode %x at %d", b, pos)
+			}
+		}
+		if eof {
+			return fmt.Errorf("[ValidateScript] operand of opcode %x at %d is truncated", b, pos)
+		}
+	}
+	return nil
+}
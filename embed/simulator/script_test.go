@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"testing"
+)
+
+func TestValidateScript(t *testing.T) {
+	valid := [][]byte{
+		{byte(PUSH1), byte(PUSH2), byte(ADD), byte(RET)},
+		{0x03, 0x01, 0x02, 0x03, byte(PUSHDATA1), 0x02, 0xaa, 0xbb, byte(DROP)},
+		{byte(PUSHDATA2), 0x01, 0x00, 0xff, byte(JMP), 0x03, 0x00, byte(RET)},
+		append(append([]byte{byte(APPCALL)}, make([]byte, 20)...), byte(RET)),
+		{byte(SYSCALL), 0x03, 'a', 'b', 'c'},
+	}
+	for i, code := range valid {
+		if err := ValidateScript(code); err != nil {
+			t.Fatalf("script %d: %v", i, err)
+		}
+	}
+
+	invalid := [][]byte{
+		{},
+		{0x05, 0x01, 0x02},
+		{byte(PUSHDATA4), 0xff, 0xff, 0xff, 0xff},
+		{byte(JMP), 0x01},
+		{byte(APPCALL), 0x01, 0x02},
+		{byte(SYSCALL), 0x05, 'a'},
+		{byte(PUSH1), 0x50},
+	}
+	for i, code := range invalid {
+		if err := ValidateScript(code); err == nil {
+			t.Fatalf("script %d should be invalid", i)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/imZhuFei/zeepin/embed/simulator/types"
+)
+
+const (
+	DEFAULT_TRACE_STEPS   = 100000
+	MAX_TRACE_ITEM_DEPTH  = 16
+	MAX_TRACE_STACK_ITEMS = 8
+)
+
+// Tracer observes the embed vm, BeforeOp is called once an opcode is read and
+// AfterOp once it executed or failed, stack is the live evaluation stack which
+// tracers must neither keep nor modify
+type Tracer interface {
+	BeforeOp(op OpCode, stack *RandomAccessStack, depth int, gasLeft uint64)
+	AfterOp(op OpCode, stack *RandomAccessStack, depth int, gasLeft uint64, err error)
+}
+
+// StructLog is the record of one opcode, Stack holds the topmost items of the
+// evaluation stack before the opcode ran, from bottom to top, and StackSize
+// the number of items on it
+type StructLog struct {
+	Op        string        `json:"op"`
+	Depth     int           `json:"depth"`
+	Gas       uint64        `json:"gas"`
+	GasCost   uint64        `json:"gasCost"`
+	StackSize int           `json:"stackSize"`
+	Stack     []interface{} `json:"stack"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// JSONLogger collects the StructLog of every opcode, once MaxSteps logs are
+// recorded the rest of the execution is dropped and Truncated is set
+type JSONLogger struct {
+	MaxSteps  int         `json:"-"`
+	Truncated bool        `json:"truncated"`
+	Logs      []StructLog `json:"logs"`
+	pending   []int
+}
+
+func NewJSONLogger(maxSteps int) *JSONLogger {
+	if maxSteps <= 0 {
+		maxSteps = DEFAULT_TRACE_STEPS
+	}
+	return &JSONLogger{MaxSteps: maxSteps, Logs: make([]StructLog, 0)}
+}
+
+func (l *JSONLogger) BeforeOp(op OpCode, stack *RandomAccessStack, depth int, gasLeft uint64) {
+	//calls into other contracts nest their opcodes between BeforeOp and AfterOp
+	if len(l.Logs) >= l.MaxSteps {
+		l.Truncated = true
+		l.pending = append(l.pending, -1)
+		return
+	}
+	size := 0
+	if stack != nil {
+		size = stack.Count()
+	}
+	top := size
+	if top > MAX_TRACE_STACK_ITEMS {
+		top = MAX_TRACE_STACK_ITEMS
+	}
+	items := make([]interface{}, 0, top)
+	for i := top - 1; i >= 0; i-- {
+		items = append(items, StackItemJSON(stack.Peek(i)))
+	}
+	l.Logs = append(l.Logs, StructLog{Op: OpName(op), Depth: depth, Gas: gasLeft, StackSize: size, Stack: items})
+	l.pending = append(l.pending, len(l.Logs)-1)
+}
+
+func (l *JSONLogger) AfterOp(op OpCode, stack *RandomAccessStack, depth int, gasLeft uint64, err error) {
+	if len(l.pending) == 0 {
+		return
+	}
+	index := l.pending[len(l.pending)-1]
+	l.pending = l.pending[:len(l.pending)-1]
+	if index < 0 {
+		return
+	}
+	log := &l.Logs[index]
+	if log.Gas > gasLeft {
+		log.GasCost = log.Gas - gasLeft
+	}
+	if err != nil {
+		log.Error = err.Error()
+	}
+}
+
+// JSON returns the collected logs encoded as json
+func (l *JSONLogger) JSON() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+// OpName returns the mnemonic of op
+func OpName(op OpCode) string {
+	if op >= PUSHBYTES1 && op <= PUSHBYTES75 {
+		return fmt.Sprintf("PUSHBYTES%d", op)
+	}
+	if name := OpExecList[op].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(op))
+}
+
+// StackItemJSON converts a stack item to a json friendly value, byte arrays are
+// hex encoded and integers are decimal strings
+func StackItemJSON(item types.StackItems) interface{} {
+	return stackItemJSON(item, 0)
+}
+
+func stackItemJSON(item types.StackItems, level int) interface{} {
+	if item == nil {
+		return nil
+	}
+	//arrays may contain themselves
+	if level >= MAX_TRACE_ITEM_DEPTH {
+		return "..."
+	}
+	switch v := item.(type) {
+	case *types.ByteArray:
+		arr, _ := v.GetByteArray()
+		return hex.EncodeToString(arr)
+	case *types.Integer:
+		i, _ := v.GetBigInteger()
+		return i.String()
+	case *types.Boolean:
+		b, _ := v.GetBoolean()
+		return b
+	case *types.Array, *types.Struct:
+		var elems []types.StackItems
+		if a, ok := v.(*types.Array); ok {
+			elems, _ = a.GetArray()
+		} else {
+			elems, _ = v.GetStruct()
+		}
+		arr := make([]interface{}, 0, len(elems))
+		for _, elem := range elems {
+			arr = append(arr, stackItemJSON(elem, level+1))
+		}
+		return arr
+	case *types.Map:
+		m, _ := v.GetMap()
+		entries := make([][2]interface{}, 0, len(m))
+		for key, value := range m {
+			entries = append(entries, [2]interface{}{stackItemJSON(key, level+1), stackItemJSON(value, level+1)})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return fmt.Sprint(entries[i][0]) < fmt.Sprint(entries[j][0])
+		})
+		return entries
+	case *types.Interop:
+		return "interop"
+	}
+	return fmt.Sprintf("%T", item)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/embed/simulator/types"
+)
+
+func TestStackItemJSON(t *testing.T) {
+	arr := types.NewArray([]types.StackItems{
+		types.NewByteArray([]byte{0xab}),
+		types.NewInteger(big.NewInt(-7)),
+		types.NewBoolean(true),
+	})
+	expect := []interface{}{"ab", "-7", true}
+	if v := StackItemJSON(arr); !reflect.DeepEqual(v, expect) {
+		t.Fatalf("got %v, expect %v", v, expect)
+	}
+
+	m := types.NewMap()
+	m.Add(types.NewByteArray([]byte{2}), types.NewInteger(big.NewInt(2)))
+	m.Add(types.NewByteArray([]byte{1}), types.NewInteger(big.NewInt(1)))
+	expectMap := [][2]interface{}{{"01", "1"}, {"02", "2"}}
+	if v := StackItemJSON(m); !reflect.DeepEqual(v, expectMap) {
+		t.Fatalf("got %v, expect %v", v, expectMap)
+	}
+}
+
+func TestJSONLoggerTruncate(t *testing.T) {
+	logger := NewJSONLogger(2)
+	stack := NewRandAccessStack()
+	stack.Push(types.NewInteger(big.NewInt(1)))
+	logger.BeforeOp(APPCALL, stack, 1, 100)
+	logger.BeforeOp(PUSH1, nil, 2, 90)
+	logger.AfterOp(PUSH1, stack, 2, 89, nil)
+	logger.BeforeOp(PUSH2, nil, 2, 89)
+	logger.AfterOp(PUSH2, stack, 2, 88, nil)
+	logger.AfterOp(APPCALL, stack, 1, 80, nil)
+
+	if len(logger.Logs) != 2 || !logger.Truncated {
+		t.Fatalf("logger kept %d logs, truncated %v", len(logger.Logs), logger.Truncated)
+	}
+	if logger.Logs[0].GasCost != 20 || logger.Logs[1].GasCost != 1 {
+		t.Fatalf("unexpected gas costs %d %d", logger.Logs[0].GasCost, logger.Logs[1].GasCost)
+	}
+	if OpName(PUSHBYTES1+3) != "PUSHBYTES4" {
+		t.Fatalf("unexpected name %s", OpName(PUSHBYTES1+3))
+	}
+}
+
+func TestJSONLoggerStackTop(t *testing.T) {
+	logger := NewJSONLogger(0)
+	stack := NewRandAccessStack()
+	for i := 0; i < MAX_TRACE_STACK_ITEMS+4; i++ {
+		stack.Push(types.NewInteger(big.NewInt(int64(i))))
+	}
+	logger.BeforeOp(PUSH1, stack, 1, 100)
+
+	log := logger.Logs[0]
+	if log.StackSize != MAX_TRACE_STACK_ITEMS+4 || len(log.Stack) != MAX_TRACE_STACK_ITEMS {
+		t.Fatalf("stack size %d, %d items recorded", log.StackSize, len(log.Stack))
+	}
+	if log.Stack[0] != "4" || log.Stack[MAX_TRACE_STACK_ITEMS-1] != fmt.Sprint(MAX_TRACE_STACK_ITEMS+3) {
+		t.Fatalf("unexpected stack top %v", log.Stack)
+	}
+}
@@ -68,6 +68,7 @@ const (
 	ErrNetVerifyFail        ErrCode = 45019
 	ErrGasPrice             ErrCode = 45020
 	ErrVerifySignature      ErrCode = 45021
+	ErrTxValidityWindow     ErrCode = 45022
 )
 
 func (err ErrCode) Error() string {
@@ -116,6 +117,8 @@ func (err ErrCode) Error() string {
 		return "invalid gas price"
 	case ErrVerifySignature:
 		return "transaction verify signature fail"
+	case ErrTxValidityWindow:
+		return "transaction outside its validity window"
 
 	}
 
@@ -39,8 +39,10 @@ import (
 	"github.com/imZhuFei/zeepin/core/ledger"
 	"github.com/imZhuFei/zeepin/core/payload"
 	"github.com/imZhuFei/zeepin/core/types"
+	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	cstate "github.com/imZhuFei/zeepin/smartcontract/states"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 )
 
 const (
@@ -104,6 +106,11 @@ func PreExecuteContract(tx *types.Transaction) (*cstate.PreExecResult, error) {
 	return ledger.DefLedger.PreExecuteContract(tx)
 }
 
+//TraceTransaction re-executes a confirmed transaction with the tracers
+func TraceTransaction(txHash common.Uint256, wasmTracer exec.Tracer, embedTracer vm.Tracer) (*cstate.PreExecResult, error) {
+	return ledger.DefLedger.TraceTransaction(txHash, wasmTracer, embedTracer)
+}
+
 //GetEventNotifyByTxHash from ledger
 func GetEventNotifyByTxHash(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByTx(txHash)
@@ -220,3 +220,21 @@ func GetNodeType() (uint64, error) {
 	}
 	return r.NodeType, nil
 }
+
+//GetSyncStatus from netSever actor
+func GetSyncStatus() (*common.SyncStatus, error) {
+	if netServerPid == nil {
+		return nil, nil
+	}
+	future := netServerPid.RequestFuture(&ac.GetSyncStatusReq{}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	r, ok := result.(*ac.GetSyncStatusRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return r.Status, nil
+}
@@ -55,8 +55,10 @@ import (
 	"github.com/imZhuFei/zeepin/embed/simulator"
 	ontErrors "github.com/imZhuFei/zeepin/errors"
 	bactor "github.com/imZhuFei/zeepin/http/base/actor"
+	p2pcommon "github.com/imZhuFei/zeepin/p2pserver/common"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	embed "github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/gid"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
 	"github.com/imZhuFei/zeepin/smartcontract/service/wasmvm"
 	cstates "github.com/imZhuFei/zeepin/smartcontract/states"
@@ -93,9 +95,20 @@ type ExecuteNotify struct {
 	Notify      []NotifyEventInfo
 }
 
+type TraceResult struct {
+	TxHash     string
+	State      byte
+	Gas        uint64
+	Result     interface{}
+	Error      string
+	WasmTrace  *exec.JSONTracer
+	EmbedTrace *simulator.JSONLogger
+}
+
 type NotifyEventInfo struct {
 	ContractAddress string
 	States          interface{}
+	Decoded         *event.DecodedEvent `json:",omitempty"`
 }
 
 type TxAttributeInfo struct {
@@ -130,6 +143,9 @@ type Transactions struct {
 	Sigs       []Sig
 	Hash       string
 	Height     uint32
+
+	NotBefore    uint32 `json:",omitempty"`
+	ExpireHeight uint32 `json:",omitempty"`
 }
 
 type BlockHead struct {
@@ -161,11 +177,12 @@ type NodeInfo struct {
 	NodePort    uint16 // The nodes's port
 	ID          uint64 // The nodes's id
 	NodeTime    int64
-	NodeVersion uint32   // The network protocol the node used
-	NodeType    uint64   // The services the node supplied
-	Relay       bool     // The relay capability of the node (merge into capbility flag)
-	Height      uint32   // The node latest block height
-	TxnCnt      []uint32 // The transactions in pool
+	NodeVersion uint32                // The network protocol the node used
+	NodeType    uint64                // The services the node supplied
+	Relay       bool                  // The relay capability of the node (merge into capbility flag)
+	Height      uint32                // The node latest block height
+	TxnCnt      []uint32              // The transactions in pool
+	Sync        *p2pcommon.SyncStatus `json:",omitempty"` // The block sync progress and per peer stats
 	//RxTxnCnt uint64 // The transaction received by this node
 }
 
@@ -194,17 +211,43 @@ func GetExecuteNotify(obj *event.ExecuteNotify) (map[string]bool, ExecuteNotify)
 	evts := []NotifyEventInfo{}
 	var contractAddrs = make(map[string]bool)
 	for _, v := range obj.Notify {
-		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
+		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States, DecodeNotify(v)})
 		contractAddrs[v.ContractAddress.ToHexString()] = true
 	}
 	txhash := obj.TxHash.ToHexString()
 	return contractAddrs, ExecuteNotify{txhash, obj.State, obj.GasConsumed, evts}
 }
 
+//TraceTransaction re-executes a confirmed transaction, a failed execution is
+//reported in the result along with the trace up to the failure
+func TraceTransaction(txHash common.Uint256, maxSteps int) (*TraceResult, error) {
+	wasmTracer := exec.NewJSONTracer(maxSteps)
+	embedTracer := simulator.NewJSONLogger(maxSteps)
+	result, err := bactor.TraceTransaction(txHash, wasmTracer, embedTracer)
+	if result == nil {
+		return nil, err
+	}
+	rsp := &TraceResult{
+		TxHash:     txHash.ToHexString(),
+		State:      result.State,
+		Gas:        result.Gas,
+		Result:     result.Result,
+		WasmTrace:  wasmTracer,
+		EmbedTrace: embedTracer,
+	}
+	if err != nil {
+		rsp.Error = err.Error()
+	}
+	return rsp, nil
+}
+
 func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
 	trans := new(Transactions)
+	trans.Version = ptx.Version
 	trans.TxType = ptx.TxType
 	trans.Nonce = ptx.Nonce
+	trans.NotBefore = ptx.NotBefore
+	trans.ExpireHeight = ptx.ExpireHeight
 	trans.GasLimit = ptx.GasLimit
 	trans.GasPrice = ptx.GasPrice
 	trans.Payer = ptx.Payer.ToBase58()
@@ -369,6 +412,34 @@ func GetContractAllowance(cVersion byte, contractAddr, fromAddr, toAddr common.A
 	return allowance.Uint64(), nil
 }
 
+//GetDIDDocument resolve did:zpt: identifier to DID document by pre-executing gid getDDO
+func GetDIDDocument(did string) (*gid.DIDDocument, error) {
+	id, err := gid.DIDToGID(did)
+	if err != nil {
+		return nil, err
+	}
+	mutable, err := NewNativeInvokeTransaction(0, 0, utils.GIDContractAddress, 0, "getDDO", []interface{}{[]byte(id)})
+	if err != nil {
+		return nil, fmt.Errorf("NewNativeInvokeTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bactor.PreExecuteContract(tx)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareInvokeContract error:%s", err)
+	}
+	if result.State == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	data, err := hex.DecodeString(result.Result.(string))
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return gid.NewDIDDocument(did, data)
+}
+
 func GetGasPrice() (map[string]interface{}, error) {
 	start := bactor.GetCurrentBlockHeight()
 	var gasPrice uint64 = 0
@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/core/payload"
+	bactor "github.com/imZhuFei/zeepin/http/base/actor"
+	sccommon "github.com/imZhuFei/zeepin/smartcontract/common"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+)
+
+type contractEventAbi struct {
+	raw    []byte
+	format event.EventFormat
+	abi    *event.EventAbi
+}
+
+//eventAbiRegistry keeps the event abis of native contracts, loaded from the abi
+//directory, and the parsed event abis of deployed contracts
+var eventAbiRegistry = struct {
+	sync.RWMutex
+	natives  map[common.Address]*event.EventAbi
+	deployed map[common.Address]*contractEventAbi
+}{
+	natives:  make(map[common.Address]*event.EventAbi),
+	deployed: make(map[common.Address]*contractEventAbi),
+}
+
+//InitNativeEventAbis loads the event abis of native contracts from the json files
+//of path, in the format of cmd/abi/native_abi_script
+func InitNativeEventAbis(path string) error {
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		contract, abi, err := parseNativeEventAbi(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		RegisterNativeEventAbi(contract, abi)
+		log.Infof("native contract %s event abi loaded from %s", contract.ToHexString(), file)
+	}
+	return nil
+}
+
+func parseNativeEventAbi(data []byte) (common.Address, *event.EventAbi, error) {
+	var header struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return common.ADDRESS_EMPTY, nil, err
+	}
+	contract, err := common.AddressFromHexString(header.Hash)
+	if err != nil {
+		return common.ADDRESS_EMPTY, nil, fmt.Errorf("invalid hash %s: %v", header.Hash, err)
+	}
+	abi, err := event.ParseEventAbi(data)
+	if err != nil {
+		return common.ADDRESS_EMPTY, nil, err
+	}
+	return contract, abi, nil
+}
+
+//RegisterNativeEventAbi sets the event abi used to decode the notifications of a native contract
+func RegisterNativeEventAbi(contract common.Address, abi *event.EventAbi) {
+	eventAbiRegistry.Lock()
+	eventAbiRegistry.natives[contract] = abi
+	eventAbiRegistry.Unlock()
+}
+
+//getEventAbi returns the event abi of a contract and how its notifications are
+//encoded, or nil if the contract has none
+func getEventAbi(contract common.Address) (*event.EventAbi, event.EventFormat) {
+	eventAbiRegistry.RLock()
+	abi, ok := eventAbiRegistry.natives[contract]
+	eventAbiRegistry.RUnlock()
+	if ok {
+		return abi, event.EVENT_FORMAT_NATIVE
+	}
+	if _, ok := native.Contracts[contract]; ok {
+		return nil, event.EVENT_FORMAT_NATIVE
+	}
+
+	deploy, err := bactor.GetContractStateFromStore(contract)
+	if err != nil || deploy == nil || len(deploy.EventAbi) == 0 {
+		return nil, event.EVENT_FORMAT_NATIVE
+	}
+	eventAbiRegistry.RLock()
+	cached, ok := eventAbiRegistry.deployed[contract]
+	eventAbiRegistry.RUnlock()
+	//a destroyed contract may be deployed again with another abi
+	if ok && bytes.Equal(cached.raw, deploy.EventAbi) {
+		return cached.abi, cached.format
+	}
+
+	abi, err = event.ParseEventAbi(deploy.EventAbi)
+	if err != nil {
+		log.Warnf("contract %s event abi: %v", contract.ToHexString(), err)
+		return nil, event.EVENT_FORMAT_NATIVE
+	}
+	vmType := deploy.VmType
+	if vmType == payload.LegacyVm {
+		vmType = sccommon.InferVmType(deploy.Code)
+	}
+	cached = &contractEventAbi{raw: deploy.EventAbi, format: event.EVENT_FORMAT_EMBED, abi: abi}
+	if vmType == payload.WasmVm {
+		cached.format = event.EVENT_FORMAT_WASM
+	}
+	eventAbiRegistry.Lock()
+	eventAbiRegistry.deployed[contract] = cached
+	eventAbiRegistry.Unlock()
+	return cached.abi, cached.format
+}
+
+//DecodeNotify decodes a notification against the event abi of its contract,
+//nil is returned when there is no abi or the notification doesn't match it
+func DecodeNotify(notify *event.NotifyEventInfo) *event.DecodedEvent {
+	abi, format := getEventAbi(notify.ContractAddress)
+	if abi == nil {
+		return nil
+	}
+	decoded, err := abi.Decode(format, notify.States)
+	if err != nil {
+		log.Debugf("decode notify of contract %s: %v", notify.ContractAddress.ToHexString(), err)
+		return nil
+	}
+	return decoded
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitNativeEventAbis(t *testing.T) {
+	log.InitLog(log.WarnLog, log.Stdout)
+	err := InitNativeEventAbis("../../../cmd/abi/native_abi_script")
+	assert.Nil(t, err)
+	abi, format := getEventAbi(utils.NftContractAddress)
+	assert.NotNil(t, abi)
+	assert.NotNil(t, abi.GetEvent("transfer"))
+
+	from := utils.ZptContractAddress.ToBase58()
+	to := utils.GalaContractAddress.ToBase58()
+	decoded, err := abi.Decode(format, []interface{}{"transfer", from, to, "0a", "01"})
+	assert.Nil(t, err)
+	assert.Equal(t, "tokenId", decoded.Fields[3].Name)
+	assert.Equal(t, "01", decoded.Fields[3].Value)
+}
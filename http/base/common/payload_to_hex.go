@@ -59,6 +59,8 @@ type DeployCodeInfo struct {
 	Author      string
 	Email       string
 	Description string
+	VmType      string
+	EventAbi    string `json:",omitempty"`
 }
 
 type RecordInfo struct {
@@ -123,6 +125,8 @@ func TransPayloadToHex(p types.Payload) PayloadInfo {
 		obj.Author = object.Author
 		obj.Email = object.Email
 		obj.Description = object.Description
+		obj.VmType = object.VmType.String()
+		obj.EventAbi = string(object.EventAbi)
 		return obj
 	}
 	return nil
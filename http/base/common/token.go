@@ -0,0 +1,369 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/core/payload"
+	"github.com/imZhuFei/zeepin/core/types"
+	bactor "github.com/imZhuFei/zeepin/http/base/actor"
+	"github.com/imZhuFei/zeepin/smartcontract/service/wasmvm"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/util"
+)
+
+// The fungible token standard for embed and wasm contracts.
+//
+// An embed token is invoked as Main(operation, args) and implements
+//   name() string, symbol() string, decimals() int, totalSupply() int
+//   balanceOf(address) int, allowance(owner, spender address) int
+//   transfer(from, to address, amount int) bool
+//   approve(owner, spender address, amount int) bool
+//   transferFrom(sender, from, to address, amount int) bool
+// where addresses are the 20 bytes of the account address. A transfer is
+// notified as Runtime.Notify("transfer", from, to, amount).
+//
+// A wasm token implements the same methods through invoke(method, args) with
+// json params, addresses are base58 strings and amounts int64, every method
+// returns ZPT_JsonMashalResult of its value with succeed set to 1. A transfer
+// is notified as ZPT_Runtime_Notify of the json array
+// ["transfer","<from>","<to>","<amount>"].
+const (
+	TOKEN_NAME          = "name"
+	TOKEN_SYMBOL        = "symbol"
+	TOKEN_DECIMALS      = "decimals"
+	TOKEN_TOTAL_SUPPLY  = "totalSupply"
+	TOKEN_BALANCE_OF    = "balanceOf"
+	TOKEN_ALLOWANCE     = "allowance"
+	TOKEN_TRANSFER      = "transfer"
+	TOKEN_APPROVE       = "approve"
+	TOKEN_TRANSFER_FROM = "transferFrom"
+
+	TOKEN_TRANSFER_EVENT = "transfer"
+	TOKEN_WASM_VERSION   = byte(1)
+	TOKEN_MAX_DECIMALS   = 18
+)
+
+type TokenInfo struct {
+	Contract    string
+	VmType      payload.VmType
+	Name        string
+	Symbol      string
+	Decimals    uint64
+	TotalSupply string
+}
+
+type TokenBalanceRsp struct {
+	Contract string
+	Address  string
+	Symbol   string
+	Decimals uint64
+	Balance  string
+}
+
+type TokenTransfer struct {
+	From   common.Address
+	To     common.Address
+	Amount *big.Int
+}
+
+//tokenRegistry keeps the contracts which passed the conformance check
+var tokenRegistry = struct {
+	sync.RWMutex
+	tokens map[common.Address]*TokenInfo
+}{tokens: make(map[common.Address]*TokenInfo)}
+
+//ParseContractAddress accepts a contract address in hex or base58
+func ParseContractAddress(str string) (common.Address, error) {
+	if len(str) == common.ADDR_LEN*2 {
+		return common.AddressFromHexString(str)
+	}
+	return common.AddressFromBase58(str)
+}
+
+//GetTokenInfo returns the token info of a contract conforming to the token standard,
+//contracts are checked once and then served from the registry while they exist
+func GetTokenInfo(contract common.Address) (*TokenInfo, error) {
+	deploy, err := bactor.GetContractStateFromStore(contract)
+	if err != nil || deploy == nil {
+		tokenRegistry.Lock()
+		delete(tokenRegistry.tokens, contract)
+		tokenRegistry.Unlock()
+		return nil, fmt.Errorf("contract %s not found", contract.ToHexString())
+	}
+	tokenRegistry.RLock()
+	info, ok := tokenRegistry.tokens[contract]
+	tokenRegistry.RUnlock()
+	if ok {
+		return info, nil
+	}
+
+	info, err = CheckTokenConformance(contract, deploy.VmType)
+	if err != nil {
+		return nil, err
+	}
+	tokenRegistry.Lock()
+	tokenRegistry.tokens[contract] = info
+	tokenRegistry.Unlock()
+	return info, nil
+}
+
+//CheckTokenConformance pre-executes the query methods of the token standard on a deployed contract,
+//a contract deployed before the vm type was recorded is tried as embed and then as wasm
+func CheckTokenConformance(contract common.Address, vmType payload.VmType) (*TokenInfo, error) {
+	if vmType == payload.LegacyVm {
+		info, err := CheckTokenConformance(contract, payload.EmbedVm)
+		if err == nil {
+			return info, nil
+		}
+		return CheckTokenConformance(contract, payload.WasmVm)
+	}
+
+	info := &TokenInfo{Contract: contract.ToHexString(), VmType: vmType}
+	var err error
+	if info.Name, err = preExecTokenString(contract, vmType, TOKEN_NAME); err != nil {
+		return nil, err
+	}
+	if info.Symbol, err = preExecTokenString(contract, vmType, TOKEN_SYMBOL); err != nil {
+		return nil, err
+	}
+	decimals, err := preExecTokenInteger(contract, vmType, TOKEN_DECIMALS)
+	if err != nil {
+		return nil, err
+	}
+	if !decimals.IsUint64() || decimals.Uint64() > TOKEN_MAX_DECIMALS {
+		return nil, fmt.Errorf("token decimals %s out of range", decimals)
+	}
+	info.Decimals = decimals.Uint64()
+	supply, err := preExecTokenInteger(contract, vmType, TOKEN_TOTAL_SUPPLY)
+	if err != nil {
+		return nil, err
+	}
+	info.TotalSupply = supply.String()
+	if _, err := preExecTokenInteger(contract, vmType, TOKEN_BALANCE_OF, tokenAddressParam(vmType, contract)); err != nil {
+		return nil, err
+	}
+	if _, err := preExecTokenInteger(contract, vmType, TOKEN_ALLOWANCE,
+		tokenAddressParam(vmType, contract), tokenAddressParam(vmType, contract)); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+//GetTokenBalance returns the balance of an account in a standard token contract
+func GetTokenBalance(contract, address common.Address) (*TokenBalanceRsp, error) {
+	info, err := GetTokenInfo(contract)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := preExecTokenInteger(contract, info.VmType, TOKEN_BALANCE_OF, tokenAddressParam(info.VmType, address))
+	if err != nil {
+		return nil, err
+	}
+	return &TokenBalanceRsp{
+		Contract: info.Contract,
+		Address:  address.ToBase58(),
+		Symbol:   info.Symbol,
+		Decimals: info.Decimals,
+		Balance:  balance.String(),
+	}, nil
+}
+
+//NewTokenTransferTransaction return the invoke transaction of the transfer method of a standard token
+func NewTokenTransferTransaction(gasPrice, gasLimit uint64, vmType payload.VmType, contract, from, to common.Address, amount uint64) (*types.MutableTransaction, error) {
+	if vmType == payload.WasmVm {
+		return NewWASMVMInvokeTransaction(gasPrice, gasLimit, contract, TOKEN_TRANSFER, wasmvm.Json, TOKEN_WASM_VERSION,
+			[]interface{}{from.ToBase58(), to.ToBase58(), int64(amount)})
+	}
+	return NewEmbeddedInvokeTransaction(gasPrice, gasLimit, contract,
+		[]interface{}{TOKEN_TRANSFER, []interface{}{from[:], to[:], amount}})
+}
+
+//ParseTokenTransfer decodes the states of a transfer notify of an embed or wasm token,
+//ok is false when the states are not a transfer of the token standard
+func ParseTokenTransfer(states interface{}) (*TokenTransfer, bool) {
+	var items []interface{}
+	switch v := states.(type) {
+	case []interface{}:
+		items = v
+	case []string:
+		for _, str := range v {
+			items = append(items, str)
+		}
+	default:
+		return nil, false
+	}
+	fields := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		fields = append(fields, str)
+	}
+	switch len(fields) {
+	case 1:
+		return parseWasmTokenTransfer(fields[0])
+	case 4:
+		return parseEmbedTokenTransfer(fields)
+	}
+	return nil, false
+}
+
+func parseEmbedTokenTransfer(fields []string) (*TokenTransfer, bool) {
+	data := make([][]byte, 0, len(fields))
+	for _, field := range fields {
+		d, err := hex.DecodeString(field)
+		if err != nil {
+			return nil, false
+		}
+		data = append(data, d)
+	}
+	if string(data[0]) != TOKEN_TRANSFER_EVENT {
+		return nil, false
+	}
+	from, err := common.AddressParseFromBytes(data[1])
+	if err != nil {
+		return nil, false
+	}
+	to, err := common.AddressParseFromBytes(data[2])
+	if err != nil {
+		return nil, false
+	}
+	return &TokenTransfer{From: from, To: to, Amount: common.BigIntFromEmbeddedBytes(data[3])}, true
+}
+
+func parseWasmTokenTransfer(state string) (*TokenTransfer, bool) {
+	var fields []string
+	if err := json.Unmarshal([]byte(state), &fields); err != nil || len(fields) != 4 {
+		return nil, false
+	}
+	if fields[0] != TOKEN_TRANSFER_EVENT {
+		return nil, false
+	}
+	from, err := common.AddressFromBase58(fields[1])
+	if err != nil {
+		return nil, false
+	}
+	to, err := common.AddressFromBase58(fields[2])
+	if err != nil {
+		return nil, false
+	}
+	amount, ok := new(big.Int).SetString(fields[3], 10)
+	if !ok {
+		return nil, false
+	}
+	return &TokenTransfer{From: from, To: to, Amount: amount}, true
+}
+
+func tokenAddressParam(vmType payload.VmType, address common.Address) interface{} {
+	if vmType == payload.WasmVm {
+		return address.ToBase58()
+	}
+	return address[:]
+}
+
+//preExecToken pre-executes a method of the token standard and returns the raw bytes of its result
+func preExecToken(contract common.Address, vmType payload.VmType, method string, params ...interface{}) ([]byte, error) {
+	var mutable *types.MutableTransaction
+	var err error
+	if vmType == payload.WasmVm {
+		mutable, err = NewWASMVMInvokeTransaction(0, 0, contract, method, wasmvm.Json, TOKEN_WASM_VERSION, params)
+	} else {
+		mutable, err = NewEmbeddedInvokeTransaction(0, 0, contract, []interface{}{method, params})
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bactor.PreExecuteContract(tx)
+	if err != nil {
+		return nil, fmt.Errorf("token %s error:%s", method, err)
+	}
+	if result.State == 0 {
+		return nil, fmt.Errorf("token %s failed", method)
+	}
+	str, ok := result.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("token %s returns no value", method)
+	}
+	data, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, fmt.Errorf("token %s result error:%s", method, err)
+	}
+	if vmType != payload.WasmVm {
+		return data, nil
+	}
+	ret := &exec.Result{}
+	if err := json.Unmarshal([]byte(util.TrimBuffToString(data)), ret); err != nil {
+		return nil, fmt.Errorf("token %s result error:%s", method, err)
+	}
+	if ret.Psucceed != 1 {
+		return nil, fmt.Errorf("token %s failed", method)
+	}
+	return []byte(ret.Pval), nil
+}
+
+func preExecTokenString(contract common.Address, vmType payload.VmType, method string, params ...interface{}) (string, error) {
+	data, err := preExecToken(contract, vmType, method, params...)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func preExecTokenInteger(contract common.Address, vmType payload.VmType, method string, params ...interface{}) (*big.Int, error) {
+	data, err := preExecToken(contract, vmType, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	if vmType != payload.WasmVm {
+		return common.BigIntFromEmbeddedBytes(data), nil
+	}
+	value, ok := new(big.Int).SetString(string(data), 10)
+	if !ok {
+		return nil, fmt.Errorf("token %s returns %q instead of an integer", method, data)
+	}
+	return value, nil
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+)
+
+func TestParseTokenTransfer(t *testing.T) {
+	from := common.Address{1}
+	to := common.Address{2}
+
+	embedStates := []interface{}{
+		common.ToHexString([]byte(TOKEN_TRANSFER_EVENT)),
+		common.ToHexString(from[:]),
+		common.ToHexString(to[:]),
+		common.ToHexString(common.BigIntToEmbededBytes(big.NewInt(1000))),
+	}
+	transfer, ok := ParseTokenTransfer(embedStates)
+	if !ok || transfer.From != from || transfer.To != to || transfer.Amount.Int64() != 1000 {
+		t.Fatalf("embed transfer parsed as %+v %v", transfer, ok)
+	}
+
+	state, _ := json.Marshal([]string{TOKEN_TRANSFER_EVENT, from.ToBase58(), to.ToBase58(), "2500"})
+	transfer, ok = ParseTokenTransfer([]string{string(state)})
+	if !ok || transfer.From != from || transfer.To != to || transfer.Amount.Int64() != 2500 {
+		t.Fatalf("wasm transfer parsed as %+v %v", transfer, ok)
+	}
+	//notify states read back from json
+	transfer, ok = ParseTokenTransfer([]interface{}{string(state)})
+	if !ok || transfer.Amount.Int64() != 2500 {
+		t.Fatalf("wasm transfer parsed as %+v %v", transfer, ok)
+	}
+
+	embedStates[0] = common.ToHexString([]byte("approve"))
+	if _, ok := ParseTokenTransfer(embedStates); ok {
+		t.Fatal("approve notify parsed as transfer")
+	}
+	if _, ok := ParseTokenTransfer([]string{"transfer"}); ok {
+		t.Fatal("malformed notify parsed as transfer")
+	}
+}
+
+func TestParseContractAddress(t *testing.T) {
+	addr := common.Address{0xab, 0xcd}
+	parsed, err := ParseContractAddress(addr.ToHexString())
+	if err != nil || parsed != addr {
+		t.Fatalf("hex address parsed as %s, %v", parsed.ToHexString(), err)
+	}
+	parsed, err = ParseContractAddress(addr.ToBase58())
+	if err != nil || parsed != addr {
+		t.Fatalf("base58 address parsed as %s, %v", parsed.ToHexString(), err)
+	}
+}
@@ -570,6 +570,22 @@ func GetUnboundGala(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//get DID document
+func GetDIDDocument(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	did, ok := cmd["Id"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	doc, err := bcomn.GetDIDDocument(did)
+	if err != nil {
+		log.Errorf("GetDIDDocument %s error:%s", did, err)
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	resp["Result"] = doc
+	return resp
+}
+
 //get memory pool transaction count
 func GetMemPoolTxCount(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -49,6 +49,7 @@ import (
 	bcomn "github.com/imZhuFei/zeepin/http/base/common"
 	berr "github.com/imZhuFei/zeepin/http/base/error"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 )
 
 //get generate block time
@@ -392,6 +393,40 @@ func GetContractState(params []interface{}) map[string]interface{} {
 	return responseSuccess(common.ToHexString(w.Bytes()))
 }
 
+//re-execute a confirmed transaction and return its execution trace,
+//the optional second param limits the number of recorded steps
+func TraceTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	maxSteps := exec.DEFAULT_TRACE_STEPS
+	if len(params) >= 2 {
+		steps, ok := params[1].(float64)
+		if !ok || steps <= 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		if int(steps) < maxSteps {
+			maxSteps = int(steps)
+		}
+	}
+	if _, _, err := bactor.GetTxnWithHeightByTxHash(hash); err != nil {
+		return responsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
+	}
+	rsp, err := bcomn.TraceTransaction(hash, maxSteps)
+	if err != nil {
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(rsp)
+}
+
 //get smartconstract event
 func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 	if !config.DefConfig.Common.EnableEventLog {
@@ -485,6 +520,54 @@ func GetBalance(params []interface{}) map[string]interface{} {
 	return responseSuccess(rsp)
 }
 
+//get token info of a contract conforming to the token standard
+func GetTokenInfo(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.ParseContractAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetTokenInfo(contract)
+	if err != nil {
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(rsp)
+}
+
+//get balance of address in a contract conforming to the token standard
+func GetTokenBalance(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.ParseContractAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	addrBase58, ok := params[1].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := common.AddressFromBase58(addrBase58)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetTokenBalance(contract, address)
+	if err != nil {
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(rsp)
+}
+
 //get allowance
 func GetAllowance(params []interface{}) map[string]interface{} {
 	if len(params) < 3 {
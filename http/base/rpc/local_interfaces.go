@@ -38,6 +38,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/log"
 	bactor "github.com/imZhuFei/zeepin/http/base/actor"
 	"github.com/imZhuFei/zeepin/http/base/common"
@@ -48,6 +49,13 @@ const (
 	RANDBYTELEN = 4
 )
 
+var reloadConfig func() (*config.ReloadResult, error)
+
+//SetReloadConfigFunc sets the function reloading the node config for ReloadConfig
+func SetReloadConfigFunc(fn func() (*config.ReloadResult, error)) {
+	reloadConfig = fn
+}
+
 func getCurrentDirectory() string {
 	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
@@ -95,6 +103,10 @@ func GetNodeState(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INTERNAL_ERROR, false)
 	}
+	syncStatus, err := bactor.GetSyncStatus()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, false)
+	}
 	n := common.NodeInfo{
 		NodeState:   uint(state),
 		NodeTime:    t,
@@ -105,6 +117,7 @@ func GetNodeState(params []interface{}) map[string]interface{} {
 		Relay:       relay,
 		Height:      height,
 		TxnCnt:      txnCnt,
+		Sync:        syncStatus,
 	}
 	return responseSuccess(n)
 }
@@ -133,8 +146,26 @@ func SetDebugInfo(params []interface{}) map[string]interface{} {
 		if err := log.Log.SetDebugLevel(int(level)); err != nil {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
+	case string:
+		//level spec like "info,vbft=debug,p2p=warn"
+		if err := log.Log.SetModuleLevels(params[0].(string)); err != nil {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
 	default:
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	return responsePack(berr.SUCCESS, true)
 }
+
+//ReloadConfig reloads the node config file, the result lists the applied settings
+//and the changed ones requiring a restart
+func ReloadConfig(params []interface{}) map[string]interface{} {
+	if reloadConfig == nil {
+		return responsePack(berr.INTERNAL_ERROR, "config reload not supported")
+	}
+	result, err := reloadConfig()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(result)
+}
@@ -43,8 +43,10 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	berr "github.com/imZhuFei/zeepin/http/base/error"
 )
 
@@ -133,7 +135,9 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 	//get the corresponding function
 	function, ok := mainMux.m[method]
 	if ok {
+		start := time.Now()
 		response := function(request["params"].([]interface{}))
+		metrics.RpcLatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
 		data, err := json.Marshal(map[string]interface{}{
 			"jsonrpc": "2.0",
 			"error":   response["error"],
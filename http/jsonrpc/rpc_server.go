@@ -38,6 +38,7 @@ package jsonrpc
 import (
 	"net/http"
 	"strconv"
+	"sync/atomic"
 
 	"fmt"
 
@@ -46,9 +47,24 @@ import (
 	"github.com/imZhuFei/zeepin/http/base/rpc"
 )
 
+//started guards the server against a second start when a config reload enables it
+var started int32
+
+//handle serves the requests while the json rpc is enabled, a config reload can disable it
+func handle(w http.ResponseWriter, r *http.Request) {
+	if !cfg.DefConfig.GetEnableHttpJsonRpc() {
+		http.Error(w, "json rpc disabled", http.StatusServiceUnavailable)
+		return
+	}
+	rpc.Handle(w, r)
+}
+
 func StartRPCServer() error {
+	if !atomic.CompareAndSwapInt32(&started, 0, 1) {
+		return nil
+	}
 	log.Debug()
-	http.HandleFunc("/", rpc.Handle)
+	http.HandleFunc("/", handle)
 
 	rpc.HandleFunc("getgenerateblocktime", rpc.GetGenerateBlockTime)
 	rpc.HandleFunc("getbestblockhash", rpc.GetBestBlockHash)
@@ -69,6 +85,9 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getmempooltxstate", rpc.GetMemPoolTxState)
 	rpc.HandleFunc("getsmartcodeevent", rpc.GetSmartCodeEvent)
 	rpc.HandleFunc("getblockheightbytxhash", rpc.GetBlockHeightByTxHash)
+	if cfg.DefConfig.Rpc.EnableDebugTrace {
+		rpc.HandleFunc("debug_traceTransaction", rpc.TraceTransaction)
+	}
 
 	rpc.HandleFunc("getbalance", rpc.GetBalance)
 	rpc.HandleFunc("getallowance", rpc.GetAllowance)
@@ -76,6 +95,8 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getblocktxsbyheight", rpc.GetBlockTxsByHeight)
 	rpc.HandleFunc("getgasprice", rpc.GetGasPrice)
 	rpc.HandleFunc("getunboundgala", rpc.GetUnboundGala)
+	rpc.HandleFunc("gettokeninfo", rpc.GetTokenInfo)
+	rpc.HandleFunc("gettokenbalance", rpc.GetTokenBalance)
 
 	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpJsonPort)), nil)
 	if err != nil {
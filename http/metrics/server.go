@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+// Package metrics privides a function to start the prometheus metrics server
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const METRICS_DIR = "/metrics"
+
+func StartServer() error {
+	//own mux so the metrics are not served by the other servers on the default one
+	mux := http.NewServeMux()
+	mux.Handle(METRICS_DIR, promhttp.Handler())
+
+	port := int(config.DefConfig.Metrics.MetricsPort)
+	err := http.ListenAndServe(":"+strconv.Itoa(port), mux)
+	if err != nil {
+		log.Errorf("Metrics server ListenAndServe error:%s", err)
+		return err
+	}
+	return nil
+}
@@ -90,7 +90,7 @@ func (this *Router) add(method string, path string, handler http.HandlerFunc) {
 		if matches != nil {
 			for _, v := range matches {
 				route.Params = append(route.Params, v[1])
-				path = strings.Replace(path, v[0], `(\w+)`, 1)
+				path = strings.Replace(path, v[0], `([^/]+)`, 1)
 			}
 		}
 	}
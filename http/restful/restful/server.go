@@ -90,6 +90,7 @@ const (
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
+	GET_DID_DOCUMENT      = "/api/v1/did/:id"
 
 	POST_RAW_TX = "/api/v1/transaction"
 )
@@ -166,6 +167,7 @@ func (this *restServer) registryMethod() {
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
 		GET_VERSION:           {name: "getversion", handler: rest.GetNodeVersion},
 		GET_NETWORKID:         {name: "getnetworkid", handler: rest.GetNetworkId},
+		GET_DID_DOCUMENT:      {name: "getdiddocument", handler: rest.GetDIDDocument},
 	}
 
 	postMethodMap := map[string]Action{
@@ -206,6 +208,8 @@ func (this *restServer) getPath(url string) string {
 		return GET_UNBOUNDGALA
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
+	} else if strings.Contains(url, strings.TrimRight(GET_DID_DOCUMENT, ":id")) {
+		return GET_DID_DOCUMENT
 	}
 	return url
 }
@@ -249,6 +253,8 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
+	case GET_DID_DOCUMENT:
+		req["Id"] = getParam(r, "id")
 	default:
 	}
 	return req
@@ -57,14 +57,18 @@ import (
 	"github.com/imZhuFei/zeepin/events"
 	bactor "github.com/imZhuFei/zeepin/http/base/actor"
 	hserver "github.com/imZhuFei/zeepin/http/base/actor"
+	bcomn "github.com/imZhuFei/zeepin/http/base/common"
+	"github.com/imZhuFei/zeepin/http/base/rpc"
 	"github.com/imZhuFei/zeepin/http/jsonrpc"
 	"github.com/imZhuFei/zeepin/http/localrpc"
+	"github.com/imZhuFei/zeepin/http/metrics"
 	"github.com/imZhuFei/zeepin/http/nodeinfo"
 	"github.com/imZhuFei/zeepin/http/restful"
 	"github.com/imZhuFei/zeepin/http/websocket"
 	"github.com/imZhuFei/zeepin/p2pserver"
 	netreqactor "github.com/imZhuFei/zeepin/p2pserver/actor/req"
 	p2pactor "github.com/imZhuFei/zeepin/p2pserver/actor/server"
+	"github.com/imZhuFei/zeepin/p2pserver/link"
 	"github.com/imZhuFei/zeepin/txnpool"
 	tc "github.com/imZhuFei/zeepin/txnpool/common"
 	"github.com/imZhuFei/zeepin/txnpool/proc"
@@ -88,11 +92,17 @@ func setupAPP() *cli.App {
 		cmd.AssetCommand,
 		cmd.ContractCommand,
 		cmd.ExportCommand,
+		cmd.TestnetCommand,
 	}
 	app.Flags = []cli.Flag{
 		//common setting
 		utils.ConfigFlag,
+		utils.NodeConfigFlag,
 		utils.LogLevelFlag,
+		utils.LogModulesFlag,
+		utils.LogFormatFlag,
+		utils.LogMaxSizeFlag,
+		utils.LogMaxAgeFlag,
 		utils.DisableEventLogFlag,
 		utils.DataDirFlag,
 		utils.ImportEnableFlag,
@@ -118,6 +128,9 @@ func setupAPP() *cli.App {
 		utils.NodePortFlag,
 		utils.ConsensusPortFlag,
 		utils.DualPortSupportFlag,
+		utils.DisableP2PCompressionFlag,
+		utils.EnableP2PEncryptionFlag,
+		utils.DisableCompactBlockFlag,
 		utils.MaxConnInBoundFlag,
 		utils.MaxConnOutBoundFlag,
 		utils.MaxConnInBoundForSingleIPFlag,
@@ -130,12 +143,18 @@ func setupAPP() *cli.App {
 		utils.RPCPortFlag,
 		utils.RPCLocalEnableFlag,
 		utils.RPCLocalProtFlag,
+		utils.RPCDebugTraceFlag,
 		//rest setting
 		utils.RestfulEnableFlag,
 		utils.RestfulPortFlag,
 		//ws setting
 		utils.WsEnabledFlag,
 		utils.WsPortFlag,
+		//metrics setting
+		utils.MetricsEnableFlag,
+		utils.MetricsPortFlag,
+		//event abi setting
+		utils.NativeAbiPathFlag,
 	}
 	app.Before = func(context *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
@@ -180,7 +199,7 @@ func startZeepinChain(ctx *cli.Context) {
 		log.Errorf("initTxPool error:%s", err)
 		return
 	}
-	p2pSvr, p2pPid, err := initP2PNode(ctx, txpool)
+	p2pSvr, p2pPid, err := initP2PNode(ctx, txpool, acc)
 	if err != nil {
 		log.Errorf("initP2PNode error:%s", err)
 		return
@@ -190,11 +209,16 @@ func startZeepinChain(ctx *cli.Context) {
 		log.Errorf("initConsensus error:%s", err)
 		return
 	}
+	initEventAbi(ctx)
 	err = initRpc(ctx)
 	if err != nil {
 		log.Errorf("initRpc error:%s", err)
 		return
 	}
+	reload := func() (*config.ReloadResult, error) {
+		return reloadConfig(ctx, txpool)
+	}
+	rpc.SetReloadConfigFunc(reload)
 	err = initLocalRpc(ctx)
 	if err != nil {
 		log.Errorf("initLocalRpc error:%s", err)
@@ -203,9 +227,10 @@ func startZeepinChain(ctx *cli.Context) {
 	initRestful(ctx)
 	initWs(ctx)
 	initNodeInfo(ctx, p2pSvr)
+	initMetrics(ctx)
 
 	go logCurrBlockHeight()
-	waitToExit()
+	waitToExit(ctx, reload)
 }
 
 func initLog(ctx *cli.Context) {
@@ -215,16 +240,63 @@ func initLog(ctx *cli.Context) {
 	log.InitLog(logLevel, log.PATH, log.Stdout)
 }
 
+//setLogConfig applies the log settings of the config to the running logger
+func setLogConfig(cfg *config.CommonConfig) error {
+	err := log.Log.SetDebugLevel(int(cfg.LogLevel))
+	if err != nil {
+		return err
+	}
+	err = log.Log.SetModuleLevels(cfg.LogModules)
+	if err != nil {
+		return err
+	}
+	err = log.Log.SetFormat(cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	log.Log.SetRotation(int64(cfg.MaxLogSize), time.Duration(cfg.MaxLogAge)*time.Hour)
+	return nil
+}
+
 func initConfig(ctx *cli.Context) (*config.ZeepinChainConfig, error) {
 	//init ZeepinChain config from cli
 	cfg, err := cmd.SetZeepinChainConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
+	err = setLogConfig(cfg.Common)
+	if err != nil {
+		return nil, err
+	}
 	log.Infof("Config init success")
 	return cfg, nil
 }
 
+//reloadConfig reloads the node config file and applies the settings which can change live
+func reloadConfig(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*config.ReloadResult, error) {
+	result, err := cmd.ReloadZeepinChainConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commonCfg := config.DefConfig.GetCommonConfig()
+	err = setLogConfig(&commonCfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range result.Applied {
+		if name == "Common.GasPrice" {
+			txpoolSvr.RefreshGasPrice()
+		}
+	}
+	//starts the json rpc server if it was disabled at startup
+	err = initRpc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Config reloaded, applied:%v, requires restart:%v", result.Applied, result.RequiresRestart)
+	return result, nil
+}
+
 func initAccount(ctx *cli.Context) (*account.Account, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
@@ -308,10 +380,11 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	return txPoolServer, nil
 }
 
-func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*p2pserver.P2PServer, *actor.PID, error) {
+func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acc *account.Account) (*p2pserver.P2PServer, *actor.PID, error) {
 	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		return nil, nil, nil
 	}
+	link.SetNodeAccount(acc)
 	p2p := p2pserver.NewServer()
 
 	p2pActor := p2pactor.NewP2PActor(p2p)
@@ -352,8 +425,16 @@ func initConsensus(ctx *cli.Context, p2pPid *actor.PID, txpoolSvr *proc.TXPoolSe
 	return consensusService, nil
 }
 
+func initEventAbi(ctx *cli.Context) {
+	path := ctx.GlobalString(utils.GetFlagName(utils.NativeAbiPathFlag))
+	err := bcomn.InitNativeEventAbis(path)
+	if err != nil {
+		log.Warnf("initEventAbi error:%s", err)
+	}
+}
+
 func initRpc(ctx *cli.Context) error {
-	if !config.DefConfig.Rpc.EnableHttpJsonRpc {
+	if !config.DefConfig.GetEnableHttpJsonRpc() {
 		return nil
 	}
 	var err error
@@ -428,6 +509,15 @@ func initNodeInfo(ctx *cli.Context, p2pSvr *p2pserver.P2PServer) {
 	log.Infof("Nodeinfo init success")
 }
 
+func initMetrics(ctx *cli.Context) {
+	if !config.DefConfig.Metrics.EnableMetrics {
+		return
+	}
+	go metrics.StartServer()
+
+	log.Infof("Metrics init success")
+}
+
 func importBlocks(ctx *cli.Context) error {
 	if !ctx.GlobalBool(utils.GetFlagName(utils.ImportEnableFlag)) {
 		return nil
@@ -448,19 +538,25 @@ func logCurrBlockHeight() {
 			log.Infof("CurrentBlockHeight = %d", ledger.DefLedger.GetCurrentBlockHeight())
 			isNeedNewFile := log.CheckIfNeedNewFile()
 			if isNeedNewFile {
-				log.ClosePrintLog()
-				log.InitLog(int(config.DefConfig.Common.LogLevel), log.PATH, log.Stdout)
+				log.Rotate()
 			}
 		}
 	}
 }
 
-func waitToExit() {
+func waitToExit(ctx *cli.Context, reload func() (*config.ReloadResult, error)) {
 	exit := make(chan bool, 0)
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	go func() {
 		for sig := range sc {
+			//with a node config file SIGHUP reloads it instead of exiting
+			if sig == syscall.SIGHUP && ctx.GlobalIsSet(utils.GetFlagName(utils.NodeConfigFlag)) {
+				if _, err := reload(); err != nil {
+					log.Errorf("reloadConfig error:%s", err)
+				}
+				continue
+			}
 			log.Infof("zeepin received exit signal:%v.", sig.String())
 			close(exit)
 			break
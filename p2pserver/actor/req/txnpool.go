@@ -46,6 +46,8 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 const txnPoolReqTimeout = p2pcommon.ACTOR_TIMEOUT * time.Second
 
 var txnPoolPid *actor.PID
@@ -57,7 +59,7 @@ func SetTxnPoolPid(txnPid *actor.PID) {
 //add txn to txnpool
 func AddTransaction(transaction *types.Transaction) {
 	if txnPoolPid == nil {
-		log.Error("net_server AddTransaction(): txnpool pid is nil")
+		logger.Error("net_server AddTransaction(): txnpool pid is nil")
 		return
 	}
 	txReq := &tc.TxReq{
@@ -71,14 +73,29 @@ func AddTransaction(transaction *types.Transaction) {
 //get txn according to hash
 func GetTransaction(hash common.Uint256) (*types.Transaction, error) {
 	if txnPoolPid == nil {
-		log.Error("net_server tx pool pid is nil")
+		logger.Error("net_server tx pool pid is nil")
 		return nil, errors.NewErr("net_server tx pool pid is nil")
 	}
 	future := txnPoolPid.RequestFuture(&tc.GetTxnReq{Hash: hash}, txnPoolReqTimeout)
 	result, err := future.Result()
 	if err != nil {
-		log.Errorf("net_server GetTransaction error: %v\n", err)
+		logger.Errorf("net_server GetTransaction error: %v\n", err)
+		return nil, err
+	}
+	return result.(*tc.GetTxnRsp).Txn, nil
+}
+
+//get txns according to the short ids of a compact block, nil for the ones not found in txnpool
+func GetTransactionsByShortId(key []byte, ids []uint64) ([]*types.Transaction, error) {
+	if txnPoolPid == nil {
+		logger.Error("net_server tx pool pid is nil")
+		return nil, errors.NewErr("net_server tx pool pid is nil")
+	}
+	future := txnPoolPid.RequestFuture(&tc.GetTxnsByShortIdReq{Key: key, ShortIds: ids}, txnPoolReqTimeout)
+	result, err := future.Result()
+	if err != nil {
+		logger.Errorf("net_server GetTransactionsByShortId error: %v\n", err)
 		return nil, err
 	}
-	return result.(tc.GetTxnRsp).Txn, nil
+	return result.(*tc.GetTxnsByShortIdRsp).Txns, nil
 }
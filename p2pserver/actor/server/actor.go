@@ -43,6 +43,8 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 type P2PActor struct {
 	props  *actor.Props
 	server *p2pserver.P2PServer
@@ -67,15 +69,15 @@ func (this *P2PActor) Start() (*actor.PID, error) {
 func (this *P2PActor) Receive(ctx actor.Context) {
 	switch msg := ctx.Message().(type) {
 	case *actor.Restarting:
-		log.Info("p2p actor restarting")
+		logger.Info("p2p actor restarting")
 	case *actor.Stopping:
-		log.Info("p2p actor stopping")
+		logger.Info("p2p actor stopping")
 	case *actor.Stopped:
-		log.Info("p2p actor stopped")
+		logger.Info("p2p actor stopped")
 	case *actor.Started:
-		log.Info("p2p actor started")
+		logger.Info("p2p actor started")
 	case *actor.Restart:
-		log.Info("p2p actor restart")
+		logger.Info("p2p actor restart")
 	case *StopServerReq:
 		this.handleStopServerReq(ctx, msg)
 	case *GetPortReq:
@@ -100,6 +102,8 @@ func (this *P2PActor) Receive(ctx actor.Context) {
 		this.handleGetRelayStateReq(ctx, msg)
 	case *GetNodeTypeReq:
 		this.handleGetNodeTypeReq(ctx, msg)
+	case *GetSyncStatusReq:
+		this.handleGetSyncStatusReq(ctx, msg)
 	case *TransmitConsensusMsgReq:
 		this.handleTransmitConsensusMsgReq(ctx, msg)
 	case *common.AppendPeerID:
@@ -113,7 +117,7 @@ func (this *P2PActor) Receive(ctx actor.Context) {
 	default:
 		err := this.server.Xmit(ctx.Message())
 		if nil != err {
-			log.Error("error xmit message ", err.Error(), reflect.TypeOf(ctx.Message()))
+			logger.Error("error xmit message ", err.Error(), reflect.TypeOf(ctx.Message()))
 		}
 	}
 }
@@ -251,11 +255,22 @@ func (this *P2PActor) handleGetNodeTypeReq(ctx actor.Context, req *GetNodeTypeRe
 	}
 }
 
+//block sync progress handler
+func (this *P2PActor) handleGetSyncStatusReq(ctx actor.Context, req *GetSyncStatusReq) {
+	ret := this.server.GetSyncStatus()
+	if ctx.Sender() != nil {
+		resp := &GetSyncStatusRsp{
+			Status: ret,
+		}
+		ctx.Sender().Request(resp, ctx.Self())
+	}
+}
+
 func (this *P2PActor) handleTransmitConsensusMsgReq(ctx actor.Context, req *TransmitConsensusMsgReq) {
 	peer := this.server.GetNetWork().GetPeer(req.Target)
 	if peer != nil {
 		this.server.Send(peer, req.Msg, true)
 	} else {
-		log.Errorf("handleTransmit consensus msg failed:%d", req.Target)
+		logger.Errorf("handleTransmit consensus msg failed:%d", req.Target)
 	}
 }
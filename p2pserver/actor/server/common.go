@@ -144,6 +144,15 @@ type GetNeighborAddrsRsp struct {
 	Addrs []types.PeerAddr
 }
 
+//get block sync progress request
+type GetSyncStatusReq struct {
+}
+
+//response of block sync progress
+type GetSyncStatusRsp struct {
+	Status *types.SyncStatus
+}
+
 type TransmitConsensusMsgReq struct {
 	Target uint64
 	Msg    ptypes.Message
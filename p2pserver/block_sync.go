@@ -41,18 +41,18 @@ import (
 	"time"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/core/ledger"
 	"github.com/imZhuFei/zeepin/core/types"
 	p2pComm "github.com/imZhuFei/zeepin/p2pserver/common"
 	"github.com/imZhuFei/zeepin/p2pserver/message/msg_pack"
+	msgtypes "github.com/imZhuFei/zeepin/p2pserver/message/types"
+	"github.com/imZhuFei/zeepin/p2pserver/message/utils"
 	"github.com/imZhuFei/zeepin/p2pserver/peer"
 )
 
 const (
 	SYNC_MAX_HEADER_FORWARD_SIZE = 5000       //keep CurrentHeaderHeight - CurrentBlockHeight <= SYNC_MAX_HEADER_FORWARD_SIZE
 	SYNC_MAX_FLIGHT_HEADER_SIZE  = 1          //Number of headers on flight
-	SYNC_MAX_FLIGHT_BLOCK_SIZE   = 50         //Number of blocks on flight
 	SYNC_MAX_BLOCK_CACHE_SIZE    = 500        //Cache size of block wait to commit to ledger
 	SYNC_HEADER_REQUEST_TIMEOUT  = 2          //s, Request header timeout time. If header haven't receive after SYNC_HEADER_REQUEST_TIMEOUT second, retry
 	SYNC_BLOCK_REQUEST_TIMEOUT   = 2          //s, Request block timeout time. If block haven't received after SYNC_BLOCK_REQUEST_TIMEOUT second, retry
@@ -63,6 +63,12 @@ const (
 	SYNC_NODE_SPEED_INIT         = 100 * 1024 //Init a big speed (100MB/s) for every node in first round
 	SYNC_MAX_ERROR_RESP_TIMES    = 5          //Max error headers/blocks response times, if reaches, delete it
 	SYNC_MAX_HEIGHT_OFFSET       = 5          //Offset of the max height and current height
+	SYNC_COMPACT_BLOCK_DEPTH     = 5          //Blocks within the depth from the header height are requested as compact blocks
+	SYNC_NODE_RECORD_RECV_CNT    = 16         //Record block receive time count for the node throughput
+	SYNC_NODE_WINDOW_INIT        = 4          //Init in-flight block window of a node before its throughput is measured
+	SYNC_NODE_WINDOW_MIN         = 1          //Min in-flight block window of a node
+	SYNC_NODE_WINDOW_MAX         = 64         //Max in-flight block window of a node
+	SYNC_PROGRESS_RECORD_CNT     = 64         //Record saved block count for the sync rate
 )
 
 //NodeWeight record some params of node, using for sort
@@ -72,6 +78,10 @@ type NodeWeight struct {
 	timeoutCnt   int       //Node response timeout count
 	errorRespCnt int       //Node response error data count
 	reqTime      []int64   //Record request time, using for calc the avg req time interval, unit millisecond
+	latency      []int64   //Record block request-response time, using for calc the window, unit millisecond
+	recvTime     []int64   //Record block receive time, using for calc the throughput, unit millisecond
+	windowCap    int       //Upper bound of the window, halved on timeout and raised on response
+	lock         sync.RWMutex
 }
 
 //NewNodeWeight new a nodeweight
@@ -91,43 +101,152 @@ func NewNodeWeight(id uint64) *NodeWeight {
 		timeoutCnt:   0,
 		errorRespCnt: 0,
 		reqTime:      r,
+		windowCap:    SYNC_NODE_WINDOW_MAX,
 	}
 }
 
-//AddTimeoutCnt incre timeout count
+//AddTimeoutCnt incre timeout count, and halve the window cap
 func (this *NodeWeight) AddTimeoutCnt() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	this.timeoutCnt++
+	this.windowCap /= 2
+	if this.windowCap < SYNC_NODE_WINDOW_MIN {
+		this.windowCap = SYNC_NODE_WINDOW_MIN
+	}
 }
 
 //AddErrorRespCnt incre receive error header/block count
 func (this *NodeWeight) AddErrorRespCnt() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	this.errorRespCnt++
 }
 
+//GetTimeoutCnt get the timeout count
+func (this *NodeWeight) GetTimeoutCnt() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.timeoutCnt
+}
+
 //GetErrorRespCnt get the error response count
 func (this *NodeWeight) GetErrorRespCnt() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
 	return this.errorRespCnt
 }
 
 //AppendNewReqTime append new request time
 func (this *NodeWeight) AppendNewReqtime() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	copy(this.reqTime[0:SYNC_NODE_RECORD_TIME_CNT-1], this.reqTime[1:])
 	this.reqTime[SYNC_NODE_RECORD_TIME_CNT-1] = time.Now().UnixNano() / int64(time.Millisecond)
 }
 
 //addNewSpeed apend the new speed to tail, remove the oldest one
 func (this *NodeWeight) AppendNewSpeed(s float32) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
 	copy(this.speed[0:SYNC_NODE_RECORD_SPEED_CNT-1], this.speed[1:])
 	this.speed[SYNC_NODE_RECORD_SPEED_CNT-1] = s
 }
 
-//Weight calculate node's weight for sort. Highest weight node will be accessed first for next request.
-func (this *NodeWeight) Weight() float32 {
+//AppendBlockResp record the latency of a block response and its receive time, and raise the window cap
+func (this *NodeWeight) AppendBlockResp(latency int64) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.latency = append(this.latency, latency)
+	if len(this.latency) > SYNC_NODE_RECORD_TIME_CNT {
+		this.latency = this.latency[1:]
+	}
+	this.recvTime = append(this.recvTime, time.Now().UnixNano()/int64(time.Millisecond))
+	if len(this.recvTime) > SYNC_NODE_RECORD_RECV_CNT {
+		this.recvTime = this.recvTime[1:]
+	}
+	if this.windowCap < SYNC_NODE_WINDOW_MAX {
+		this.windowCap++
+	}
+}
+
+//Throughput return the blocks per second received from the node, 0 before it is measured
+func (this *NodeWeight) Throughput() float32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.throughput()
+}
+
+func (this *NodeWeight) throughput() float32 {
+	cnt := len(this.recvTime)
+	if cnt < 2 {
+		return 0
+	}
+	elapsed := this.recvTime[cnt-1] - this.recvTime[0]
+	if elapsed <= 0 {
+		return 0
+	}
+	return float32(cnt-1) * 1000.0 / float32(elapsed)
+}
+
+//Latency return the avg block response latency of the node in millisecond, 0 before it is measured
+func (this *NodeWeight) Latency() int64 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.avgLatency()
+}
+
+func (this *NodeWeight) avgLatency() int64 {
+	if len(this.latency) == 0 {
+		return 0
+	}
+	sum := int64(0)
+	for _, l := range this.latency {
+		sum += l
+	}
+	return sum / int64(len(this.latency))
+}
+
+//Speed return the avg response speed of the node in kB/s
+func (this *NodeWeight) Speed() float32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.avgSpeed()
+}
+
+func (this *NodeWeight) avgSpeed() float32 {
 	avgSpeed := float32(0.0)
 	for _, s := range this.speed {
 		avgSpeed += s
 	}
-	avgSpeed = avgSpeed / float32(len(this.speed))
+	return avgSpeed / float32(len(this.speed))
+}
+
+//Window return the number of blocks can be in flight with the node. It is sized from the measured
+//throughput as the blocks received during twice the avg latency, bounded by the window cap
+func (this *NodeWeight) Window() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	window := SYNC_NODE_WINDOW_INIT
+	throughput := this.throughput()
+	latency := this.avgLatency()
+	if throughput > 0 && latency > 0 {
+		window = int(math.Ceil(float64(throughput) * float64(latency) * 2 / 1000.0))
+	}
+	if window > this.windowCap {
+		window = this.windowCap
+	}
+	if window < SYNC_NODE_WINDOW_MIN {
+		window = SYNC_NODE_WINDOW_MIN
+	}
+	return window
+}
+
+//Weight calculate node's weight for sort. Highest weight node will be accessed first for next request.
+func (this *NodeWeight) Weight() float32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	avgSpeed := this.avgSpeed()
 
 	avgInterval := float32(0.0)
 	now := time.Now().UnixNano() / int64(time.Millisecond)
@@ -152,7 +271,7 @@ func (nws NodeWeights) Swap(i, j int) {
 func (nws NodeWeights) Less(i, j int) bool {
 	ni := nws[i]
 	nj := nws[j]
-	return ni.Weight() < nj.Weight() && ni.errorRespCnt >= nj.errorRespCnt && ni.timeoutCnt >= nj.timeoutCnt
+	return ni.Weight() < nj.Weight() && ni.GetErrorRespCnt() >= nj.GetErrorRespCnt() && ni.GetTimeoutCnt() >= nj.GetTimeoutCnt()
 }
 
 //SyncFlightInfo record the info of fight object(header or block)
@@ -235,6 +354,40 @@ type BlockInfo struct {
 	block  *types.Block
 }
 
+//syncProgress records the heights saved to ledger, using for calc the sync rate
+type syncProgress struct {
+	heights []uint32
+	times   []time.Time
+	lock    sync.Mutex
+}
+
+//record append a saved height, remove the oldest one
+func (this *syncProgress) record(height uint32) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.heights = append(this.heights, height)
+	this.times = append(this.times, time.Now())
+	if len(this.heights) > SYNC_PROGRESS_RECORD_CNT {
+		this.heights = this.heights[1:]
+		this.times = this.times[1:]
+	}
+}
+
+//rate return the blocks saved per second since the oldest record, so it decays when sync stalls
+func (this *syncProgress) rate() float64 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	cnt := len(this.heights)
+	if cnt < 2 {
+		return 0
+	}
+	elapsed := time.Since(this.times[0]).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(this.heights[cnt-1]-this.heights[0]) / elapsed
+}
+
 //BlockSyncMgr is the manager class to deal with block sync
 type BlockSyncMgr struct {
 	flightBlocks   map[common.Uint256][]*SyncFlightInfo //Map BlockHash => []SyncFlightInfo, using for manager all of those block flights
@@ -243,7 +396,8 @@ type BlockSyncMgr struct {
 	server         *P2PServer                           //Pointer to the local node
 	syncBlockLock  bool                                 //Help to avoid send block sync request duplicate
 	syncHeaderLock bool                                 //Help to avoid send header sync request duplicate
-	saveBlockLock  bool                                 //Help to avoid saving block concurrently
+	saveCh         chan struct{}                        //Wake up the save loop, which commits cached blocks to ledger apart from downloading
+	progress       *syncProgress                        //Saved heights, using for sync rate
 	exitCh         chan interface{}                     //ExitCh to receive exit signal
 	ledger         *ledger.Ledger                       //ledger
 	lock           sync.RWMutex                         //lock
@@ -258,6 +412,8 @@ func NewBlockSyncMgr(server *P2PServer) *BlockSyncMgr {
 		blocksCache:   make(map[uint32]*BlockInfo, 0),
 		server:        server,
 		ledger:        server.ledger,
+		saveCh:        make(chan struct{}, 1),
+		progress:      &syncProgress{},
 		exitCh:        make(chan interface{}, 1),
 		nodeWeights:   make(map[uint64]*NodeWeight, 0),
 	}
@@ -265,6 +421,7 @@ func NewBlockSyncMgr(server *P2PServer) *BlockSyncMgr {
 
 //Start to sync
 func (this *BlockSyncMgr) Start() {
+	go this.saveLoop()
 	go this.sync()
 	ticker := time.NewTicker(time.Second)
 	for {
@@ -274,7 +431,29 @@ func (this *BlockSyncMgr) Start() {
 		case <-ticker.C:
 			go this.checkTimeout()
 			go this.sync()
-			go this.saveBlock()
+			this.notifySave()
+		}
+	}
+}
+
+//notifySave wake up the save loop, without blocking the caller
+func (this *BlockSyncMgr) notifySave() {
+	select {
+	case this.saveCh <- struct{}{}:
+	default:
+	}
+}
+
+//saveLoop commits the cached blocks to ledger, so block execution is pipelined with the download
+func (this *BlockSyncMgr) saveLoop() {
+	for {
+		select {
+		case <-this.exitCh:
+			return
+		case <-this.saveCh:
+			if this.saveBlock() {
+				this.syncBlock()
+			}
 		}
 	}
 }
@@ -311,7 +490,7 @@ func (this *BlockSyncMgr) checkTimeout() {
 		}
 		flightInfo.ResetStartTime()
 		flightInfo.MarkFailedNode()
-		log.Infof("checkTimeout sync headers:%d timeout after:%d s Times:%d", height, SYNC_HEADER_REQUEST_TIMEOUT, flightInfo.GetTotalFailedTimes())
+		logger.Infof("checkTimeout sync headers:%d timeout after:%d s Times:%d", height, SYNC_HEADER_REQUEST_TIMEOUT, flightInfo.GetTotalFailedTimes())
 		reqNode := this.getNodeWithMinFailedTimes(flightInfo, curBlockHeight)
 		if reqNode == nil {
 			break
@@ -322,7 +501,7 @@ func (this *BlockSyncMgr) checkTimeout() {
 		msg := msgpack.NewHeadersReq(headerHash)
 		err := this.server.Send(reqNode, msg, false)
 		if err != nil {
-			log.Error("checkTimeout failed build a new headersReq")
+			logger.Error("checkTimeout failed build a new headersReq")
 		} else {
 			this.appendReqTime(reqNode.GetID())
 		}
@@ -336,7 +515,7 @@ func (this *BlockSyncMgr) checkTimeout() {
 			}
 			flightInfo.ResetStartTime()
 			flightInfo.MarkFailedNode()
-			log.Debugf("checkTimeout sync height:%d block:0x%x timeout after:%d s times:%d", flightInfo.Height, blockHash, SYNC_BLOCK_REQUEST_TIMEOUT, flightInfo.GetTotalFailedTimes())
+			logger.Debugf("checkTimeout sync height:%d block:0x%x timeout after:%d s times:%d", flightInfo.Height, blockHash, SYNC_BLOCK_REQUEST_TIMEOUT, flightInfo.GetTotalFailedTimes())
 			reqNode := this.getNodeWithMinFailedTimes(flightInfo, curBlockHeight)
 			if reqNode == nil {
 				break
@@ -346,13 +525,13 @@ func (this *BlockSyncMgr) checkTimeout() {
 			msg := msgpack.NewBlkDataReq(blockHash)
 			err := this.server.Send(reqNode, msg, false)
 			if err != nil {
-				log.Error("checkTimeout NewBlkDataReq error:", err)
+				logger.Error("checkTimeout NewBlkDataReq error:", err)
 			} else {
 				this.appendReqTime(reqNode.GetID())
 			}
 
 			if err != nil {
-				log.Errorf("checkTimeout reqNode ID:%d Send error:%s", reqNode.GetID(), err)
+				logger.Errorf("checkTimeout reqNode ID:%d Send error:%s", reqNode.GetID(), err)
 				continue
 			}
 		}
@@ -395,12 +574,12 @@ func (this *BlockSyncMgr) syncHeader() {
 	msg := msgpack.NewHeadersReq(headerHash)
 	err := this.server.Send(reqNode, msg, false)
 	if err != nil {
-		log.Error("syncHeader failed build a new headersReq")
+		logger.Error("syncHeader failed build a new headersReq")
 	} else {
 		this.appendReqTime(reqNode.GetID())
 	}
 
-	log.Infof("syncHeader request Height:%d", NextHeaderId)
+	logger.Infof("syncHeader request Height:%d", NextHeaderId)
 }
 
 func (this *BlockSyncMgr) syncBlock() {
@@ -409,7 +588,8 @@ func (this *BlockSyncMgr) syncBlock() {
 	}
 	defer this.releaseSyncBlockLock()
 
-	availCount := SYNC_MAX_FLIGHT_BLOCK_SIZE - this.getFlightBlockCount()
+	flights := this.getNodeFlightBlockCounts()
+	availCount := this.getAvailWindow(flights)
 	if availCount <= 0 {
 		return
 	}
@@ -455,15 +635,16 @@ func (this *BlockSyncMgr) syncBlock() {
 			reqTimes = SYNC_NEXT_BLOCK_TIMES
 		}
 		for t := 0; t < reqTimes; t++ {
-			reqNode := this.getNextNode(nextBlockHeight)
+			reqNode := this.getNextNodeInWindow(nextBlockHeight, flights)
 			if reqNode == nil {
 				return
 			}
+			flights[reqNode.GetID()]++
 			this.addFlightBlock(reqNode.GetID(), nextBlockHeight, nextBlockHash)
-			msg := msgpack.NewBlkDataReq(nextBlockHash)
+			msg := this.newBlockDataReq(reqNode, nextBlockHeight, nextBlockHash, curHeaderHeight)
 			err := this.server.Send(reqNode, msg, false)
 			if err != nil {
-				log.Errorf("syncBlock Height:%d ReqBlkData error:%s", nextBlockHeight, err)
+				logger.Errorf("syncBlock Height:%d ReqBlkData error:%s", nextBlockHeight, err)
 				return
 			} else {
 				this.appendReqTime(reqNode.GetID())
@@ -474,12 +655,22 @@ func (this *BlockSyncMgr) syncBlock() {
 	}
 }
 
+//newBlockDataReq requests the blocks near the header height as compact blocks, their txs
+//are likely in the txnpool already, while older blocks are requested in full
+func (this *BlockSyncMgr) newBlockDataReq(reqNode *peer.Peer, height uint32, blockHash common.Uint256,
+	curHeaderHeight uint32) msgtypes.Message {
+	if height+SYNC_COMPACT_BLOCK_DEPTH > curHeaderHeight {
+		return utils.NewBlockDataReq(reqNode, blockHash)
+	}
+	return msgpack.NewBlkDataReq(blockHash)
+}
+
 //OnHeaderReceive receive header from net
 func (this *BlockSyncMgr) OnHeaderReceive(fromID uint64, headers []*types.Header) {
 	if len(headers) == 0 {
 		return
 	}
-	log.Infof("OnHeaderReceive Height:%d - %d", headers[0].Height, headers[len(headers)-1].Height)
+	logger.Infof("OnHeaderReceive Height:%d - %d", headers[0].Height, headers[len(headers)-1].Height)
 	height := headers[0].Height
 	curHeaderHeight := this.ledger.GetCurrentHeaderHeight()
 
@@ -498,7 +689,7 @@ func (this *BlockSyncMgr) OnHeaderReceive(fromID uint64, headers []*types.Header
 		if n != nil && n.GetErrorRespCnt() >= SYNC_MAX_ERROR_RESP_TIMES {
 			this.delNode(fromID)
 		}
-		log.Errorf("OnHeaderReceive AddHeaders error:%s", err)
+		logger.Errorf("OnHeaderReceive AddHeaders error:%s", err)
 		return
 	}
 	this.syncHeader()
@@ -508,12 +699,16 @@ func (this *BlockSyncMgr) OnHeaderReceive(fromID uint64, headers []*types.Header
 func (this *BlockSyncMgr) OnBlockReceive(fromID uint64, blockSize uint32, block *types.Block) {
 	height := block.Header.Height
 	blockHash := block.Hash()
-	log.Trace("[p2p]OnBlockReceive Height:%d", height)
+	logger.Trace("[p2p]OnBlockReceive Height:%d", height)
 	flightInfo := this.getFlightBlock(blockHash, fromID)
 	if flightInfo != nil {
 		t := (time.Now().UnixNano() - flightInfo.GetStartTime().UnixNano()) / int64(time.Millisecond)
+		if t <= 0 {
+			t = 1
+		}
 		s := float32(blockSize) / float32(t) * 1000.0 / 1024.0
 		this.addNewSpeed(fromID, s)
+		this.appendBlockResp(fromID, t)
 	}
 
 	this.delFlightBlock(blockHash)
@@ -526,15 +721,26 @@ func (this *BlockSyncMgr) OnBlockReceive(fromID uint64, blockSize uint32, block
 	if height <= curBlockHeight {
 		return
 	}
+	//the synced headers are verified, a block below the header height must match its header
+	if height <= curHeaderHeight && this.ledger.GetBlockHash(height) != blockHash {
+		logger.Warnf("OnBlockReceive Height:%d block from %d mismatch the header", height, fromID)
+		this.addErrorRespCnt(fromID)
+		n := this.getNodeWeight(fromID)
+		if n != nil && n.GetErrorRespCnt() >= SYNC_MAX_ERROR_RESP_TIMES {
+			this.delNode(fromID)
+		}
+		this.syncBlock()
+		return
+	}
 
 	this.addBlockCache(fromID, block)
-	go this.saveBlock()
+	this.notifySave()
 	this.syncBlock()
 }
 
 //OnAddNode to node list when a new node added
 func (this *BlockSyncMgr) OnAddNode(nodeId uint64) {
-	log.Infof("OnAddNode:%d", nodeId)
+	logger.Infof("OnAddNode:%d", nodeId)
 	this.lock.Lock()
 	defer this.lock.Unlock()
 	w := NewNodeWeight(nodeId)
@@ -544,7 +750,7 @@ func (this *BlockSyncMgr) OnAddNode(nodeId uint64) {
 //OnDelNode remove from node list. When the node disconnect
 func (this *BlockSyncMgr) OnDelNode(nodeId uint64) {
 	this.delNode(nodeId)
-	log.Infof("OnDelNode:%d", nodeId)
+	logger.Infof("OnDelNode:%d", nodeId)
 }
 
 //delNode remove from node list
@@ -552,9 +758,9 @@ func (this *BlockSyncMgr) delNode(nodeId uint64) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 	delete(this.nodeWeights, nodeId)
-	log.Infof("delNode:%d", nodeId)
+	logger.Infof("delNode:%d", nodeId)
 	if len(this.nodeWeights) == 0 {
-		log.Warnf("no sync nodes")
+		logger.Warnf("no sync nodes")
 	}
 }
 
@@ -617,27 +823,8 @@ func (this *BlockSyncMgr) delBlockCache(blockHeight uint32) {
 	delete(this.blocksCache, blockHeight)
 }
 
-func (this *BlockSyncMgr) tryGetSaveBlockLock() bool {
-	this.lock.Lock()
-	defer this.lock.Unlock()
-	if this.saveBlockLock {
-		return true
-	}
-	this.saveBlockLock = true
-	return false
-}
-
-func (this *BlockSyncMgr) releaseSaveBlockLock() {
-	this.lock.Lock()
-	defer this.lock.Unlock()
-	this.saveBlockLock = false
-}
-
-func (this *BlockSyncMgr) saveBlock() {
-	if this.tryGetSaveBlockLock() {
-		return
-	}
-	defer this.releaseSaveBlockLock()
+//saveBlock commits the consecutive cached blocks to ledger, return whether any block was saved
+func (this *BlockSyncMgr) saveBlock() bool {
 	curBlockHeight := this.ledger.GetCurrentBlockHeight()
 	nextBlockHeight := curBlockHeight + 1
 	this.lock.Lock()
@@ -647,10 +834,11 @@ func (this *BlockSyncMgr) saveBlock() {
 		}
 	}
 	this.lock.Unlock()
+	saved := false
 	for {
 		fromID, nextBlock := this.getBlockCache(nextBlockHeight)
 		if nextBlock == nil {
-			return
+			return saved
 		}
 		err := this.ledger.AddBlock(nextBlock)
 		this.delBlockCache(nextBlockHeight)
@@ -660,22 +848,24 @@ func (this *BlockSyncMgr) saveBlock() {
 			if n != nil && n.GetErrorRespCnt() >= SYNC_MAX_ERROR_RESP_TIMES {
 				this.delNode(fromID)
 			}
-			log.Warnf("saveBlock Height:%d AddBlock error:%s", nextBlockHeight, err)
+			logger.Warnf("saveBlock Height:%d AddBlock error:%s", nextBlockHeight, err)
 			reqNode := this.getNextNode(nextBlockHeight)
 			if reqNode == nil {
-				return
+				return saved
 			}
 			this.addFlightBlock(reqNode.GetID(), nextBlockHeight, nextBlock.Hash())
 			msg := msgpack.NewBlkDataReq(nextBlock.Hash())
 			err := this.server.Send(reqNode, msg, false)
 			if err != nil {
-				log.Error("syncBlock error:", err)
-				return
+				logger.Error("syncBlock error:", err)
+				return saved
 			} else {
 				this.appendReqTime(reqNode.GetID())
 			}
-			return
+			return saved
 		}
+		saved = true
+		this.progress.record(nextBlockHeight)
 		nextBlockHeight++
 		this.pingOutsyncNodes(nextBlockHeight - 1)
 	}
@@ -784,6 +974,30 @@ func (this *BlockSyncMgr) getFlightBlockCount() int {
 	return cnt
 }
 
+//getNodeFlightBlockCounts return the count of blocks on flight of each node
+func (this *BlockSyncMgr) getNodeFlightBlockCounts() map[uint64]int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	counts := make(map[uint64]int, len(this.nodeWeights))
+	for _, infos := range this.flightBlocks {
+		for _, info := range infos {
+			counts[info.GetNodeId()]++
+		}
+	}
+	return counts
+}
+
+//getAvailWindow return the count of blocks can be requested within the windows of all nodes
+func (this *BlockSyncMgr) getAvailWindow(flights map[uint64]int) int {
+	avail := 0
+	for _, w := range this.getAllNodeWeights() {
+		if n := w.Window() - flights[w.id]; n > 0 {
+			avail += n
+		}
+	}
+	return avail
+}
+
 func (this *BlockSyncMgr) isBlockOnFlight(blockHash common.Uint256) bool {
 	flightInfos := this.getFlightBlocks(blockHash)
 	if len(flightInfos) != 0 {
@@ -793,6 +1007,12 @@ func (this *BlockSyncMgr) isBlockOnFlight(blockHash common.Uint256) bool {
 }
 
 func (this *BlockSyncMgr) getNextNode(nextBlockHeight uint32) *peer.Peer {
+	return this.getNextNodeInWindow(nextBlockHeight, nil)
+}
+
+//getNextNodeInWindow return the node with the highest weight whose blocks on flight are under its window,
+//nil flights ignore the windows
+func (this *BlockSyncMgr) getNextNodeInWindow(nextBlockHeight uint32, flights map[uint64]int) *peer.Peer {
 	weights := this.getAllNodeWeights()
 	sort.Sort(sort.Reverse(weights))
 	nodelist := make([]uint64, 0)
@@ -812,6 +1032,12 @@ func (this *BlockSyncMgr) getNextNode(nextBlockHeight uint32) *peer.Peer {
 			return nil
 		}
 		triedNode[nextNodeId] = true
+		if flights != nil {
+			w := this.getNodeWeight(nextNodeId)
+			if w == nil || flights[nextNodeId] >= w.Window() {
+				continue
+			}
+		}
 		n := this.server.getNode(nextNodeId)
 		if n == nil {
 			continue
@@ -851,6 +1077,47 @@ func (this *BlockSyncMgr) getNodeWithMinFailedTimes(flightInfo *SyncFlightInfo,
 	}
 }
 
+//GetSyncStatus return the sync progress and the stats of the sync nodes
+func (this *BlockSyncMgr) GetSyncStatus() *p2pComm.SyncStatus {
+	status := &p2pComm.SyncStatus{
+		CurrentHeight: this.ledger.GetCurrentBlockHeight(),
+		HeaderHeight:  this.ledger.GetCurrentHeaderHeight(),
+		Rate:          this.progress.rate(),
+		ETA:           -1,
+		InFlight:      this.getFlightBlockCount(),
+		CachedBlocks:  this.getBlockCacheSize(),
+	}
+	status.TargetHeight = status.HeaderHeight
+	flights := this.getNodeFlightBlockCounts()
+	weights := this.getAllNodeWeights()
+	sort.Sort(sort.Reverse(weights))
+	for _, w := range weights {
+		peerStatus := p2pComm.SyncPeerStatus{
+			ID:           w.id,
+			Window:       w.Window(),
+			InFlight:     flights[w.id],
+			Speed:        w.Speed(),
+			Throughput:   w.Throughput(),
+			Latency:      w.Latency(),
+			TimeoutCnt:   w.GetTimeoutCnt(),
+			ErrorRespCnt: w.GetErrorRespCnt(),
+		}
+		if n := this.server.getNode(w.id); n != nil {
+			peerStatus.Height = n.GetHeight()
+			if uint32(peerStatus.Height) > status.TargetHeight {
+				status.TargetHeight = uint32(peerStatus.Height)
+			}
+		}
+		status.Peers = append(status.Peers, peerStatus)
+	}
+	if status.CurrentHeight >= status.TargetHeight {
+		status.ETA = 0
+	} else if status.Rate > 0 {
+		status.ETA = int64(math.Ceil(float64(status.TargetHeight-status.CurrentHeight) / status.Rate))
+	}
+	return status
+}
+
 //Stop to sync
 func (this *BlockSyncMgr) Close() {
 	close(this.exitCh)
@@ -905,6 +1172,14 @@ func (this *BlockSyncMgr) addNewSpeed(nodeId uint64, speed float32) {
 		n.AppendNewSpeed(speed)
 	}
 }
+
+//appendBlockResp append a node's block response latency
+func (this *BlockSyncMgr) appendBlockResp(nodeId uint64, latency int64) {
+	n := this.getNodeWeight(nodeId)
+	if n != nil {
+		n.AppendBlockResp(latency)
+	}
+}
 func (this *BlockSyncMgr) pingOutsyncNodes(curHeight uint32) {
 	peers := make([]*peer.Peer, 0)
 	maxHeight := curHeight
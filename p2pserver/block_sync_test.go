@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2pserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeWeightWindow(t *testing.T) {
+	w := NewNodeWeight(1)
+	assert.Equal(t, SYNC_NODE_WINDOW_INIT, w.Window())
+
+	//10 blocks per second with 400ms latency keeps 8 blocks on flight
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	for i := 0; i < SYNC_NODE_RECORD_RECV_CNT; i++ {
+		w.AppendBlockResp(400)
+		w.recvTime[len(w.recvTime)-1] = now + int64(i)*100
+	}
+	assert.Equal(t, int64(400), w.Latency())
+	assert.InDelta(t, 10.0, w.Throughput(), 0.01)
+	assert.Equal(t, 8, w.Window())
+
+	//timeouts halve the window cap
+	w.AddTimeoutCnt()
+	w.AddTimeoutCnt()
+	w.AddTimeoutCnt()
+	assert.Equal(t, SYNC_NODE_WINDOW_MAX/8, w.Window())
+	for i := 0; i < 10; i++ {
+		w.AddTimeoutCnt()
+	}
+	assert.Equal(t, SYNC_NODE_WINDOW_MIN, w.Window())
+	assert.Equal(t, 13, w.GetTimeoutCnt())
+}
+
+func TestSyncProgressRate(t *testing.T) {
+	p := &syncProgress{}
+	assert.Equal(t, float64(0), p.rate())
+	for h := uint32(1); h <= uint32(SYNC_PROGRESS_RECORD_CNT+10); h++ {
+		p.record(h)
+	}
+	assert.Equal(t, SYNC_PROGRESS_RECORD_CNT, len(p.heights))
+	p.times[0] = time.Now().Add(-time.Duration(SYNC_PROGRESS_RECORD_CNT-1) * time.Second)
+	assert.InDelta(t, 1.0, p.rate(), 0.01)
+}
@@ -47,14 +47,26 @@ const (
 	SERVICE_NODE = 2 //peer only sync with consensus peer
 )
 
+//peer service bits of the framing upgrade, or-ed with the peer capability
+const (
+	SERVICE_FRAMING_COMPRESS = 1 << 8 //peer accepts compressed block, headers, inv and blocktxn payloads
+	SERVICE_FRAMING_ENCRYPT  = 1 << 9 //peer offers the authenticated encryption handshake
+)
+
+//peer service bits of the block relay, or-ed with the peer capability
+const (
+	SERVICE_COMPACT_BLOCK = 1 << 10 //peer serves and accepts compact blocks
+)
+
 //link and concurrent const
 const (
-	PER_SEND_LEN        = 1024 * 256 //byte len per conn write
-	MAX_BUF_LEN         = 1024 * 256 //the maximum buffer to receive message
-	WRITE_DEADLINE      = 5          //deadline of conn write
-	REQ_INTERVAL        = 3          //single request max interval in second
-	MAX_REQ_RECORD_SIZE = 1000       //the maximum request record size
-	MAX_RESP_CACHE_SIZE = 50         //the maximum response cache
+	PER_SEND_LEN               = 1024 * 256 //byte len per conn write
+	MAX_BUF_LEN                = 1024 * 256 //the maximum buffer to receive message
+	WRITE_DEADLINE             = 5          //deadline of conn write
+	REQ_INTERVAL               = 3          //single request max interval in second
+	MAX_REQ_RECORD_SIZE        = 1000       //the maximum request record size
+	MAX_RESP_CACHE_SIZE        = 50         //the maximum response cache
+	MAX_CMPCT_BLOCK_CACHE_SIZE = 50         //the maximum compact blocks waiting for txs
 )
 
 //msg cmd const
@@ -128,22 +140,25 @@ type PeerAddr struct {
 
 //const channel msg id and type
 const (
-	VERSION_TYPE     = "version"    //peer`s information
-	VERACK_TYPE      = "verack"     //ack msg after version recv
-	GetADDR_TYPE     = "getaddr"    //req nbr address from peer
-	ADDR_TYPE        = "addr"       //nbr address
-	PING_TYPE        = "ping"       //ping  sync height
-	PONG_TYPE        = "pong"       //pong  recv nbr height
-	GET_HEADERS_TYPE = "getheaders" //req blk hdr
-	HEADERS_TYPE     = "headers"    //blk hdr
-	INV_TYPE         = "inv"        //inv payload
-	GET_DATA_TYPE    = "getdata"    //req data from peer
-	BLOCK_TYPE       = "block"      //blk payload
-	TX_TYPE          = "tx"         //transaction
-	CONSENSUS_TYPE   = "consensus"  //consensus payload
-	GET_BLOCKS_TYPE  = "getblocks"  //req blks from peer
-	NOT_FOUND_TYPE   = "notfound"   //peer can`t find blk according to the hash
-	DISCONNECT_TYPE  = "disconnect" //peer disconnect info raise by link
+	VERSION_TYPE     = "version"     //peer`s information
+	VERACK_TYPE      = "verack"      //ack msg after version recv
+	GetADDR_TYPE     = "getaddr"     //req nbr address from peer
+	ADDR_TYPE        = "addr"        //nbr address
+	PING_TYPE        = "ping"        //ping  sync height
+	PONG_TYPE        = "pong"        //pong  recv nbr height
+	GET_HEADERS_TYPE = "getheaders"  //req blk hdr
+	HEADERS_TYPE     = "headers"     //blk hdr
+	INV_TYPE         = "inv"         //inv payload
+	GET_DATA_TYPE    = "getdata"     //req data from peer
+	BLOCK_TYPE       = "block"       //blk payload
+	TX_TYPE          = "tx"          //transaction
+	CONSENSUS_TYPE   = "consensus"   //consensus payload
+	GET_BLOCKS_TYPE  = "getblocks"   //req blks from peer
+	NOT_FOUND_TYPE   = "notfound"    //peer can`t find blk according to the hash
+	DISCONNECT_TYPE  = "disconnect"  //peer disconnect info raise by link
+	CMPCT_BLOCK_TYPE = "cmpctblock"  //blk header and tx hashes
+	GET_BLK_TXN_TYPE = "getblocktxn" //req missing txs of a compact blk
+	BLK_TXN_TYPE     = "blocktxn"    //missing txs of a compact blk
 )
 
 type AppendPeerID struct {
@@ -165,6 +180,31 @@ type AppendBlock struct {
 	Block     *types.Block // Block to be added to the ledger
 }
 
+//SyncPeerStatus represent the block sync stats of a peer
+type SyncPeerStatus struct {
+	ID           uint64  //peer id
+	Height       uint64  //peer block height
+	Window       int     //blocks can be on flight with the peer
+	InFlight     int     //blocks on flight with the peer
+	Speed        float32 //avg response speed in kB/s
+	Throughput   float32 //blocks received per second
+	Latency      int64   //avg block response latency in millisecond
+	TimeoutCnt   int     //request timeout count
+	ErrorRespCnt int     //error response count
+}
+
+//SyncStatus represent the block sync progress of the node
+type SyncStatus struct {
+	CurrentHeight uint32           //block height of ledger
+	HeaderHeight  uint32           //header height of ledger
+	TargetHeight  uint32           //highest height known from ledger headers and peers
+	Rate          float64          //blocks saved per second
+	ETA           int64            //seconds to reach the target height, -1 if unknown
+	InFlight      int              //blocks on flight
+	CachedBlocks  int              //blocks received and waiting for commit to ledger
+	Peers         []SyncPeerStatus //per peer stats
+}
+
 //ParseIPAddr return ip address
 func ParseIPAddr(s string) (string, error) {
 	i := strings.Index(s, ":")
@@ -0,0 +1,278 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/core/signature"
+	"github.com/imZhuFei/zeepin/p2pserver/common"
+	"github.com/imZhuFei/zeepin/p2pserver/message/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	HELLO_SIGN_PREFIX = "zeepin p2p hello"
+	FRAME_KEY_INFO    = "zeepin p2p framing"
+)
+
+//nodeAccount signs the handshake hello of the links, nil disables encryption
+var nodeAccount *account.Account
+
+//SetNodeAccount sets the node key pair used to authenticate encrypted links
+func SetNodeAccount(acc *account.Account) {
+	nodeAccount = acc
+}
+
+//NodeIdFromPubKey return the node id of the node key, a peer authenticating
+//an encrypted link must use it so a node id can not be taken without its key
+func NodeIdFromPubKey(pubKey keypair.PublicKey) uint64 {
+	hash := sha256.Sum256(keypair.SerializePublicKey(pubKey))
+	return binary.LittleEndian.Uint64(hash[:8])
+}
+
+//NodeId return the node id of the node account, 0 if it is not set
+func NodeId() uint64 {
+	if nodeAccount == nil {
+		return 0
+	}
+	return NodeIdFromPubKey(nodeAccount.PublicKey)
+}
+
+//FramingServices return the framing service bits this node advertises in its version
+func FramingServices() uint64 {
+	var services uint64
+	if !config.DefConfig.P2PNode.DisableCompression {
+		services |= common.SERVICE_FRAMING_COMPRESS
+	}
+	if config.DefConfig.P2PNode.EnableEncryption && nodeAccount != nil {
+		services |= common.SERVICE_FRAMING_ENCRYPT
+	}
+	return services
+}
+
+//framing tracks the framing upgrade of a link. The codec is negotiated once
+//both version messages are known, the send side switches after the verack
+//is sent and the receive side after the peer verack is read, so every message
+//following the handshake uses the upgraded framing
+type framing struct {
+	lock          sync.Mutex
+	localSent     bool
+	localServices uint64
+	ephemeralKey  []byte
+	localHello    *types.HandshakeHello
+	remote        *types.Version
+	pending       *types.FrameCodec
+	send          *types.FrameCodec
+	recv          *types.FrameCodec
+	remotePubKey  keypair.PublicKey
+}
+
+func (this *framing) sendCodec() *types.FrameCodec {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.send
+}
+
+func (this *framing) recvCodec() *types.FrameCodec {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.recv
+}
+
+//onTxVersion attaches the handshake hello to the local version
+func (this *framing) onTxVersion(version *types.Version) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if version.P.Services&common.SERVICE_FRAMING_ENCRYPT != 0 {
+		hello, err := this.newHello(version.P.Nonce)
+		if err != nil {
+			logger.Warnf("handshake hello error, encryption disabled: %s", err)
+			version.P.Services &^= common.SERVICE_FRAMING_ENCRYPT
+		} else {
+			version.Hello = hello
+		}
+	}
+	this.localSent = true
+	this.localServices = version.P.Services
+	return this.negotiate()
+}
+
+//onRxVersion records the peer version
+func (this *framing) onRxVersion(version *types.Version) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.remote = version
+	return this.negotiate()
+}
+
+func (this *framing) onTxVerAck() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.pending != nil {
+		this.send = this.pending
+	}
+}
+
+func (this *framing) onRxVerAck() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.pending != nil {
+		this.recv = this.pending
+	}
+}
+
+func (this *framing) newHello(nodeId uint64) (*types.HandshakeHello, error) {
+	if nodeAccount == nil {
+		return nil, errors.New("node account not set")
+	}
+	if this.localHello != nil {
+		return this.localHello, nil
+	}
+	priv := make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rand.Reader, priv); err != nil {
+		return nil, err
+	}
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	hello := &types.HandshakeHello{
+		PubKey: keypair.SerializePublicKey(nodeAccount.PublicKey),
+	}
+	copy(hello.EphemeralKey[:], pub)
+	hello.Signature, err = signature.Sign(nodeAccount, helloSignData(hello, nodeId))
+	if err != nil {
+		return nil, err
+	}
+	this.ephemeralKey = priv
+	this.localHello = hello
+	return hello, nil
+}
+
+//negotiate builds the pending codec from the features both peers advertised
+func (this *framing) negotiate() error {
+	if !this.localSent || this.remote == nil || this.pending != nil {
+		return nil
+	}
+	features := this.localServices & this.remote.P.Services
+	compress := features&common.SERVICE_FRAMING_COMPRESS != 0
+	var sendKey, recvKey []byte
+	if features&common.SERVICE_FRAMING_ENCRYPT != 0 {
+		hello := this.remote.Hello
+		if hello == nil || this.localHello == nil {
+			return errors.New("encryption advertised without handshake hello")
+		}
+		pubKey, err := verifyHello(hello, this.remote.P.Nonce)
+		if err != nil {
+			return err
+		}
+		sendKey, recvKey, err = deriveFrameKeys(this.ephemeralKey, this.localHello.EphemeralKey[:], hello.EphemeralKey[:])
+		if err != nil {
+			return err
+		}
+		this.remotePubKey = pubKey
+	}
+	if !compress && sendKey == nil {
+		return nil
+	}
+	codec, err := types.NewFrameCodec(compress, sendKey, recvKey)
+	if err != nil {
+		return err
+	}
+	this.pending = codec
+	logger.Debugf("peer %d framing upgrade compress:%v encrypt:%v", this.remote.P.Nonce, compress, sendKey != nil)
+	return nil
+}
+
+func helloSignData(hello *types.HandshakeHello, nodeId uint64) []byte {
+	buf := bytes.NewBuffer([]byte(HELLO_SIGN_PREFIX))
+	buf.Write(hello.EphemeralKey[:])
+	binary.Write(buf, binary.LittleEndian, nodeId)
+	hash := sha256.Sum256(buf.Bytes())
+	return hash[:]
+}
+
+func verifyHello(hello *types.HandshakeHello, nodeId uint64) (keypair.PublicKey, error) {
+	pubKey, err := keypair.DeserializePublicKey(hello.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("handshake public key error: %s", err)
+	}
+	err = signature.Verify(pubKey, helloSignData(hello, nodeId), hello.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("handshake hello of peer %d: %s", nodeId, err)
+	}
+	if NodeIdFromPubKey(pubKey) != nodeId {
+		return nil, fmt.Errorf("handshake key of peer %d belongs to node %d", nodeId, NodeIdFromPubKey(pubKey))
+	}
+	return pubKey, nil
+}
+
+//deriveFrameKeys derives the send and receive keys of a link from the x25519
+//shared secret, the peer with the lower ephemeral key sends with the first key
+func deriveFrameKeys(priv, localPub, remotePub []byte) ([]byte, []byte, error) {
+	order := bytes.Compare(localPub, remotePub)
+	if order == 0 {
+		return nil, nil, errors.New("peer reflected the handshake hello")
+	}
+	shared, err := curve25519.X25519(priv, remotePub)
+	if err != nil {
+		return nil, nil, err
+	}
+	salt := append(append([]byte{}, localPub...), remotePub...)
+	if order > 0 {
+		salt = append(append([]byte{}, remotePub...), localPub...)
+	}
+	keys := make([]byte, 64)
+	_, err = io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(FRAME_KEY_INFO)), keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order < 0 {
+		return keys[:32], keys[32:], nil
+	}
+	return keys[32:], keys[:32], nil
+}
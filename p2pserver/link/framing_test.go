@@ -0,0 +1,165 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/p2pserver/common"
+	mt "github.com/imZhuFei/zeepin/p2pserver/message/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	log.InitLog(log.WarnLog, log.Stdout)
+}
+
+func newTestVersion(id uint64, services uint64) *mt.Version {
+	return &mt.Version{P: mt.VersionPayload{Nonce: id, Services: services}}
+}
+
+//transfer returns the version as the peer reads it
+func transfer(t *testing.T, version *mt.Version) *mt.Version {
+	data, err := version.Serialization()
+	assert.Nil(t, err)
+	received := &mt.Version{}
+	assert.Nil(t, received.Deserialization(data))
+	return received
+}
+
+//newTestNode returns a node account and the version of the node id it authenticates
+func newTestNode(services uint64) (*account.Account, *mt.Version) {
+	acc := account.NewAccount("SHA256withECDSA")
+	return acc, newTestVersion(NodeIdFromPubKey(acc.PublicKey), services)
+}
+
+//handshake runs the version exchange of a and b, the hellos are signed with
+//the node accounts given in the same order
+func handshake(t *testing.T, a, b *framing, va, vb *mt.Version, accs ...*account.Account) {
+	if len(accs) == 2 {
+		SetNodeAccount(accs[0])
+	}
+	assert.Nil(t, a.onTxVersion(va))
+	assert.Nil(t, b.onRxVersion(transfer(t, va)))
+	if len(accs) == 2 {
+		SetNodeAccount(accs[1])
+	}
+	assert.Nil(t, b.onTxVersion(vb))
+	assert.Nil(t, a.onRxVersion(transfer(t, vb)))
+	a.onTxVerAck()
+	b.onRxVerAck()
+	b.onTxVerAck()
+	a.onRxVerAck()
+}
+
+func TestFramingEncrypted(t *testing.T) {
+	defer SetNodeAccount(nil)
+
+	services := uint64(common.SERVICE_FRAMING_COMPRESS | common.SERVICE_FRAMING_ENCRYPT)
+	accA, va := newTestNode(services)
+	accB, vb := newTestNode(services)
+	a, b := &framing{}, &framing{}
+	handshake(t, a, b, va, vb, accA, accB)
+	assert.NotNil(t, a.send)
+	assert.True(t, a.send.Encrypted())
+	assert.True(t, a.send.Compressed())
+	assert.Equal(t, accB.PublicKey, a.remotePubKey)
+	assert.Equal(t, accA.PublicKey, b.remotePubKey)
+
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, a.send.WriteMessage(buf, &mt.Ping{Height: 7}))
+	msg, _, err := b.recv.ReadMessage(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, &mt.Ping{Height: 7}, msg)
+
+	assert.Nil(t, b.send.WriteMessage(buf, &mt.Pong{Height: 8}))
+	msg, _, err = a.recv.ReadMessage(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, &mt.Pong{Height: 8}, msg)
+}
+
+func TestFramingLegacyPeer(t *testing.T) {
+	a, b := &framing{}, &framing{}
+	handshake(t, a, b, newTestVersion(1, common.SERVICE_FRAMING_COMPRESS), newTestVersion(2, 0))
+	assert.Nil(t, a.send)
+	assert.Nil(t, a.recv)
+	assert.Nil(t, b.send)
+
+	//compression only without node account
+	a, b = &framing{}, &framing{}
+	services := uint64(common.SERVICE_FRAMING_COMPRESS | common.SERVICE_FRAMING_ENCRYPT)
+	va := newTestVersion(1, services)
+	handshake(t, a, b, va, newTestVersion(2, services))
+	assert.Nil(t, va.Hello)
+	assert.NotNil(t, a.send)
+	assert.False(t, a.send.Encrypted())
+}
+
+func TestFramingBadHello(t *testing.T) {
+	defer SetNodeAccount(nil)
+
+	services := uint64(common.SERVICE_FRAMING_ENCRYPT)
+	accA, va := newTestNode(services)
+	accB, vb := newTestNode(services)
+	a, b := &framing{}, &framing{}
+	SetNodeAccount(accA)
+	assert.Nil(t, a.onTxVersion(va))
+	SetNodeAccount(accB)
+	assert.Nil(t, b.onTxVersion(vb))
+
+	//the hello is bound to the node id
+	forged := transfer(t, va)
+	forged.P.Nonce = vb.P.Nonce
+	assert.NotNil(t, b.onRxVersion(forged))
+
+	//a validly signed hello of a key which is not the one of the node id
+	c := &framing{}
+	SetNodeAccount(accA)
+	vc := newTestVersion(vb.P.Nonce, services)
+	assert.Nil(t, c.onTxVersion(vc))
+	d := &framing{}
+	SetNodeAccount(accB)
+	assert.Nil(t, d.onTxVersion(newTestVersion(vb.P.Nonce, services)))
+	assert.NotNil(t, d.onRxVersion(transfer(t, vc)))
+
+	//a reflected hello is rejected
+	e := &framing{}
+	ve := newTestVersion(vb.P.Nonce, services)
+	assert.Nil(t, e.onTxVersion(ve))
+	assert.NotNil(t, e.onRxVersion(transfer(t, ve)))
+}
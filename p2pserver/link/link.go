@@ -39,14 +39,19 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	"github.com/imZhuFei/zeepin/p2pserver/common"
 	"github.com/imZhuFei/zeepin/p2pserver/message/types"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 //Link used to establish
 type Link struct {
 	id        uint64
@@ -56,11 +61,14 @@ type Link struct {
 	time      time.Time // The latest time the node activity
 	recvChan  chan *types.MsgPayload
 	reqRecord map[string]int64 //Map RequestId to Timestamp, using for rejecting duplicate request in specific time
+	framing   *framing         //framing upgrade negotiated in the handshake
+	txLock    sync.Mutex       //keeps the frame order of concurrent senders
 }
 
 func NewLink() *Link {
 	link := &Link{
 		reqRecord: make(map[string]int64, 0),
+		framing:   &framing{},
 	}
 
 	return link
@@ -114,6 +122,7 @@ func (this *Link) GetConn() net.Conn {
 //set connection
 func (this *Link) SetConn(conn net.Conn) {
 	this.conn = conn
+	this.framing = &framing{}
 }
 
 //record latest message time
@@ -131,18 +140,39 @@ func (this *Link) Rx() {
 	if conn == nil {
 		return
 	}
-	reader := bufio.NewReaderSize(conn, common.MAX_BUF_LEN)
+	reader := &countReader{reader: bufio.NewReaderSize(conn, common.MAX_BUF_LEN)}
+	framing := this.framing
 	for {
-		msg, payloadSize, err := types.ReadMessage(reader)
+		var msg types.Message
+		var payloadSize uint32
+		var err error
+		reader.count = 0
+		if codec := framing.recvCodec(); codec != nil {
+			msg, payloadSize, err = codec.ReadMessage(reader)
+		} else {
+			msg, payloadSize, err = types.ReadMessage(reader)
+		}
 		if err != nil {
-			log.Error("read connection error ", err)
+			logger.Error("read connection error ", err)
+			break
+		}
+		metrics.P2PMessages.WithLabelValues(msg.CmdType(), metrics.DIRECTION_IN).Inc()
+		metrics.P2PBytes.WithLabelValues(msg.CmdType(), metrics.DIRECTION_IN).Add(float64(reader.count))
+		switch m := msg.(type) {
+		case *types.Version:
+			err = framing.onRxVersion(m)
+		case *types.VerACK:
+			framing.onRxVerAck()
+		}
+		if err != nil {
+			logger.Error("framing negotiation error ", err)
 			break
 		}
 
 		t := time.Now()
 		this.UpdateRXTime(t)
 		if !this.needSendMsg(msg) {
-			log.Debugf("skip handle msgType:%s from:%d", msg.CmdType(), this.id)
+			logger.Debugf("skip handle msgType:%s from:%d", msg.CmdType(), this.id)
 			continue
 		}
 		this.addReqRecord(msg)
@@ -184,16 +214,31 @@ func (this *Link) Tx(msg types.Message) error {
 	if conn == nil {
 		return errors.New("tx link invalid")
 	}
+	this.txLock.Lock()
+	defer this.txLock.Unlock()
+	framing := this.framing
+	if version, ok := msg.(*types.Version); ok {
+		if err := framing.onTxVersion(version); err != nil {
+			logger.Error("framing negotiation error ", err)
+			this.disconnectNotify()
+			return err
+		}
+	}
 	buf := bytes.NewBuffer(nil)
-	err := types.WriteMessage(buf, msg)
+	var err error
+	if codec := framing.sendCodec(); codec != nil {
+		err = codec.WriteMessage(buf, msg)
+	} else {
+		err = types.WriteMessage(buf, msg)
+	}
 	if err != nil {
-		log.Error("error serialize messge ", err.Error())
+		logger.Error("error serialize messge ", err.Error())
 		return err
 	}
 
 	payload := buf.Bytes()
 	nByteCnt := len(payload)
-	log.Debugf("TX buf length: %d\n", nByteCnt)
+	logger.Debugf("TX buf length: %d\n", nByteCnt)
 
 	nCount := nByteCnt / common.PER_SEND_LEN
 	if nCount == 0 {
@@ -202,14 +247,31 @@ func (this *Link) Tx(msg types.Message) error {
 	conn.SetWriteDeadline(time.Now().Add(time.Duration(nCount*common.WRITE_DEADLINE) * time.Second))
 	_, err = conn.Write(payload)
 	if err != nil {
-		log.Error("error sending messge to peer node ", err.Error())
+		logger.Error("error sending messge to peer node ", err.Error())
 		this.disconnectNotify()
 		return err
 	}
+	metrics.P2PMessages.WithLabelValues(msg.CmdType(), metrics.DIRECTION_OUT).Inc()
+	metrics.P2PBytes.WithLabelValues(msg.CmdType(), metrics.DIRECTION_OUT).Add(float64(nByteCnt))
+	if msg.CmdType() == common.VERACK_TYPE {
+		framing.onTxVerAck()
+	}
 
 	return nil
 }
 
+//countReader counts the bytes read of a message on the wire
+type countReader struct {
+	reader io.Reader
+	count  int
+}
+
+func (this *countReader) Read(p []byte) (int, error) {
+	n, err := this.reader.Read(p)
+	this.count += n
+	return n, err
+}
+
 //needSendMsg check whether the msg is needed to push to channel
 func (this *Link) needSendMsg(msg types.Message) bool {
 	if msg.CmdType() != common.GET_DATA_TYPE {
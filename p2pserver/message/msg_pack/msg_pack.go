@@ -35,6 +35,7 @@
 package msgpack
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/imZhuFei/zeepin/common"
@@ -44,8 +45,11 @@ import (
 	msgCommon "github.com/imZhuFei/zeepin/p2pserver/common"
 	mt "github.com/imZhuFei/zeepin/p2pserver/message/types"
 	p2pnet "github.com/imZhuFei/zeepin/p2pserver/net/protocol"
+	tc "github.com/imZhuFei/zeepin/txnpool/common"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 //Peer address package
 func NewAddrs(nodeAddrs []msgCommon.PeerAddr) mt.Message {
 	var addr mt.Addr
@@ -62,7 +66,7 @@ func NewAddrReq() mt.Message {
 
 ///block package
 func NewBlock(bk *ct.Block) mt.Message {
-	log.Debug()
+	logger.Debug()
 	var blk mt.Block
 	blk.Blk = *bk
 
@@ -88,7 +92,7 @@ func NewHeadersReq(curHdrHash common.Uint256) mt.Message {
 
 ////Consensus info package
 func NewConsensus(cp *mt.ConsensusPayload) mt.Message {
-	log.Debug()
+	logger.Debug()
 	var cons mt.Consensus
 	cons.Cons = *cp
 
@@ -114,7 +118,7 @@ func NewInv(invPayload *mt.InvPayload) mt.Message {
 
 //NotFound package
 func NewNotFound(hash common.Uint256) mt.Message {
-	log.Debug()
+	logger.Debug()
 	var notFound mt.NotFound
 	notFound.Hash = hash
 
@@ -123,7 +127,7 @@ func NewNotFound(hash common.Uint256) mt.Message {
 
 //ping msg package
 func NewPingMsg(height uint64) *mt.Ping {
-	log.Debug()
+	logger.Debug()
 	var ping mt.Ping
 	ping.Height = uint64(height)
 
@@ -132,7 +136,7 @@ func NewPingMsg(height uint64) *mt.Ping {
 
 //pong msg package
 func NewPongMsg(height uint64) *mt.Pong {
-	log.Debug()
+	logger.Debug()
 	var pong mt.Pong
 	pong.Height = uint64(height)
 
@@ -141,7 +145,7 @@ func NewPongMsg(height uint64) *mt.Pong {
 
 //Transaction package
 func NewTxn(txn *ct.Transaction) mt.Message {
-	log.Debug()
+	logger.Debug()
 	var trn mt.Trn
 	trn.Txn = txn
 
@@ -210,3 +214,44 @@ func NewConsensusDataReq(hash common.Uint256) mt.Message {
 
 	return &dataReq
 }
+
+//compact block request package
+func NewCmpctBlkDataReq(hash common.Uint256) mt.Message {
+	var dataReq mt.DataReq
+	dataReq.DataType = common.COMPACT_BLOCK
+	dataReq.Hash = hash
+
+	return &dataReq
+}
+
+//compact block package
+func NewCmpctBlock(bk *ct.Block) mt.Message {
+	var cmpct mt.CmpctBlock
+	cmpct.Header = bk.Header
+	cmpct.Nonce = rand.Uint64()
+	key := tc.ShortTxIdKey(bk.Hash(), cmpct.Nonce)
+	cmpct.ShortIds = make([]uint64, 0, len(bk.Transactions))
+	for _, txn := range bk.Transactions {
+		cmpct.ShortIds = append(cmpct.ShortIds, tc.ShortTxId(key, txn.Hash()))
+	}
+
+	return &cmpct
+}
+
+//compact block missing txs request package
+func NewBlockTxnReq(blockHash common.Uint256, indexes []uint32) mt.Message {
+	var req mt.BlockTxnReq
+	req.BlockHash = blockHash
+	req.Indexes = indexes
+
+	return &req
+}
+
+//compact block missing txs package
+func NewBlockTxn(blockHash common.Uint256, txns []*ct.Transaction) mt.Message {
+	var blkTxn mt.BlockTxn
+	blkTxn.BlockHash = blockHash
+	blkTxn.Txs = txns
+
+	return &blkTxn
+}
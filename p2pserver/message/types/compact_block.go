@@ -0,0 +1,231 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	comm "github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	ct "github.com/imZhuFei/zeepin/core/types"
+	"github.com/imZhuFei/zeepin/errors"
+	"github.com/imZhuFei/zeepin/p2pserver/common"
+	tc "github.com/imZhuFei/zeepin/txnpool/common"
+)
+
+//CmpctBlock carries a block header and the short ids of its transactions,
+//the receiver rebuilds the block from the transactions of its own pool. The
+//ids are keyed by the block hash and Nonce, see tc.ShortTxId
+type CmpctBlock struct {
+	Header   *ct.Header
+	Nonce    uint64
+	ShortIds []uint64
+}
+
+//Serialize message payload
+func (this CmpctBlock) Serialization() ([]byte, error) {
+	p := bytes.NewBuffer([]byte{})
+	err := this.Header.Serialize(p)
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("serialize error. Header:%v", this.Header))
+	}
+	err = serialization.WriteUint64(p, this.Nonce)
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("write error. Nonce:%d", this.Nonce))
+	}
+	err = serialization.WriteUint32(p, uint32(len(this.ShortIds)))
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("write error. ShortIds len:%d", len(this.ShortIds)))
+	}
+	id := make([]byte, 8)
+	for _, shortId := range this.ShortIds {
+		binary.LittleEndian.PutUint64(id, shortId)
+		p.Write(id[:tc.SHORT_TX_ID_LEN])
+	}
+
+	return p.Bytes(), nil
+}
+
+func (this *CmpctBlock) CmdType() string {
+	return common.CMPCT_BLOCK_TYPE
+}
+
+//Deserialize message payload
+func (this *CmpctBlock) Deserialization(p []byte) error {
+	buf := bytes.NewBuffer(p)
+	header := new(ct.Header)
+	err := header.Deserialize(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read Header error. buf:%v", buf))
+	}
+	nonce, err := serialization.ReadUint64(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read Nonce error. buf:%v", buf))
+	}
+	count, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read ShortIds len error. buf:%v", buf))
+	}
+	if uint64(count)*tc.SHORT_TX_ID_LEN > uint64(buf.Len()) {
+		return errors.NewDetailErr(errors.NewErr("short id count exceeds payload"), errors.ErrNetUnPackFail, fmt.Sprintf("ShortIds len:%d", count))
+	}
+	ids := make([]uint64, count)
+	id := make([]byte, 8)
+	for i := range ids {
+		buf.Read(id[:tc.SHORT_TX_ID_LEN])
+		ids[i] = binary.LittleEndian.Uint64(id)
+	}
+	this.Header = header
+	this.Nonce = nonce
+	this.ShortIds = ids
+
+	return nil
+}
+
+//BlockTxnReq requests the transactions at the given indexes of a compact block
+type BlockTxnReq struct {
+	BlockHash comm.Uint256
+	Indexes   []uint32
+}
+
+//Serialize message payload
+func (this BlockTxnReq) Serialization() ([]byte, error) {
+	p := bytes.NewBuffer([]byte{})
+	err := this.BlockHash.Serialize(p)
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("serialize error. BlockHash:%v", this.BlockHash))
+	}
+	err = serialization.WriteUint32(p, uint32(len(this.Indexes)))
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("write error. Indexes len:%d", len(this.Indexes)))
+	}
+	for _, index := range this.Indexes {
+		err = serialization.WriteUint32(p, index)
+		if err != nil {
+			return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("write error. Index:%d", index))
+		}
+	}
+
+	return p.Bytes(), nil
+}
+
+func (this *BlockTxnReq) CmdType() string {
+	return common.GET_BLK_TXN_TYPE
+}
+
+//Deserialize message payload
+func (this *BlockTxnReq) Deserialization(p []byte) error {
+	buf := bytes.NewBuffer(p)
+	err := this.BlockHash.Deserialize(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read BlockHash error. buf:%v", buf))
+	}
+	count, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read Indexes len error. buf:%v", buf))
+	}
+	if uint64(count)*4 > uint64(buf.Len()) {
+		return errors.NewDetailErr(errors.NewErr("index count exceeds payload"), errors.ErrNetUnPackFail, fmt.Sprintf("Indexes len:%d", count))
+	}
+	this.Indexes = make([]uint32, count)
+	for i := range this.Indexes {
+		this.Indexes[i], err = serialization.ReadUint32(buf)
+		if err != nil {
+			return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read Index error. buf:%v", buf))
+		}
+	}
+
+	return nil
+}
+
+//BlockTxn answers a BlockTxnReq with the requested transactions in order
+type BlockTxn struct {
+	BlockHash comm.Uint256
+	Txs       []*ct.Transaction
+}
+
+//Serialize message payload
+func (this BlockTxn) Serialization() ([]byte, error) {
+	p := bytes.NewBuffer([]byte{})
+	err := this.BlockHash.Serialize(p)
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("serialize error. BlockHash:%v", this.BlockHash))
+	}
+	err = serialization.WriteUint32(p, uint32(len(this.Txs)))
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("write error. Txs len:%d", len(this.Txs)))
+	}
+	for _, tx := range this.Txs {
+		err = tx.Serialize(p)
+		if err != nil {
+			return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("serialize error. Txn:%v", tx))
+		}
+	}
+
+	return p.Bytes(), nil
+}
+
+func (this *BlockTxn) CmdType() string {
+	return common.BLK_TXN_TYPE
+}
+
+//Deserialize message payload
+func (this *BlockTxn) Deserialization(p []byte) error {
+	buf := bytes.NewBuffer(p)
+	err := this.BlockHash.Deserialize(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read BlockHash error. buf:%v", buf))
+	}
+	count, err := serialization.ReadUint32(buf)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read Txs len error. buf:%v", buf))
+	}
+	if uint64(count) > uint64(buf.Len()) {
+		return errors.NewDetailErr(errors.NewErr("tx count exceeds payload"), errors.ErrNetUnPackFail, fmt.Sprintf("Txs len:%d", count))
+	}
+	this.Txs = make([]*ct.Transaction, 0, count)
+	for i := uint32(0); i < count; i++ {
+		tx := new(ct.Transaction)
+		err = tx.Deserialize(buf)
+		if err != nil {
+			return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read txn error. buf:%v", buf))
+		}
+		this.Txs = append(this.Txs, tx)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/core/payload"
+	ct "github.com/imZhuFei/zeepin/core/types"
+	tc "github.com/imZhuFei/zeepin/txnpool/common"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTxs(t *testing.T, count int) []*ct.Transaction {
+	var txs []*ct.Transaction
+	for i := 0; i < count; i++ {
+		mutable := &ct.MutableTransaction{
+			TxType:   ct.Invoke,
+			Nonce:    uint32(i),
+			GasPrice: 500,
+			GasLimit: 20000,
+			Payload:  &payload.InvokeCode{Code: []byte{byte(i)}},
+		}
+		tx, err := mutable.IntoImmutable()
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+func roundTrip(t *testing.T, msg Message) Message {
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteMessage(buf, msg))
+	demsg, _, err := ReadMessage(buf)
+	assert.Nil(t, err)
+	return demsg
+}
+
+func TestCmpctBlockSerializationDeserialization(t *testing.T) {
+	cmpct := &CmpctBlock{
+		Header: &ct.Header{
+			Height:      10,
+			Bookkeepers: make([]keypair.PublicKey, 0),
+			SigData:     make([][]byte, 0),
+		},
+		Nonce: 42,
+	}
+	key := tc.ShortTxIdKey(cmpct.Header.Hash(), cmpct.Nonce)
+	for _, tx := range newTestTxs(t, 3) {
+		cmpct.ShortIds = append(cmpct.ShortIds, tc.ShortTxId(key, tx.Hash()))
+	}
+
+	demsg := roundTrip(t, cmpct).(*CmpctBlock)
+	assert.Equal(t, cmpct.ShortIds, demsg.ShortIds)
+	assert.Equal(t, cmpct.Nonce, demsg.Nonce)
+	assert.Equal(t, cmpct.Header.Hash(), demsg.Header.Hash())
+
+	//the short ids take 6 bytes and their count is bounded by the payload
+	p, err := cmpct.Serialization()
+	assert.Nil(t, err)
+	header := bytes.NewBuffer(nil)
+	assert.Nil(t, cmpct.Header.Serialize(header))
+	assert.Equal(t, header.Len()+8+4+3*tc.SHORT_TX_ID_LEN, len(p))
+	assert.NotNil(t, new(CmpctBlock).Deserialization(p[:len(p)-1]))
+}
+
+func TestBlockTxnSerializationDeserialization(t *testing.T) {
+	hash := common.Uint256{1, 2, 3}
+	req := &BlockTxnReq{BlockHash: hash, Indexes: []uint32{0, 2, 5}}
+	assert.Equal(t, req, roundTrip(t, req))
+
+	txs := newTestTxs(t, 2)
+	blkTxn := &BlockTxn{BlockHash: hash, Txs: txs}
+	demsg := roundTrip(t, blkTxn).(*BlockTxn)
+	assert.Equal(t, hash, demsg.BlockHash)
+	assert.Equal(t, len(txs), len(demsg.Txs))
+	for i, tx := range txs {
+		assert.Equal(t, tx.Hash(), demsg.Txs[i].Hash())
+	}
+}
@@ -43,6 +43,8 @@ import (
 	"github.com/imZhuFei/zeepin/p2pserver/common"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 type Consensus struct {
 	Cons ConsensusPayload
 }
@@ -63,7 +65,7 @@ func (this *Consensus) CmdType() string {
 
 //Deserialize message payload
 func (this *Consensus) Deserialization(p []byte) error {
-	log.Debug()
+	logger.Debug()
 	buf := bytes.NewBuffer(p)
 	err := this.Cons.Deserialize(buf)
 	if err != nil {
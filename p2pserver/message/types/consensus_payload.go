@@ -40,7 +40,6 @@ import (
 	"io"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/core/signature"
 	"github.com/imZhuFei/zeepin/errors"
@@ -84,7 +83,7 @@ func (this *ConsensusPayload) ToArray() []byte {
 	b := new(bytes.Buffer)
 	err := this.Serialize(b)
 	if err != nil {
-		log.Errorf("consensus payload serialize error in ToArray(). payload:%v", this)
+		logger.Errorf("consensus payload serialize error in ToArray(). payload:%v", this)
 		return nil
 	}
 	return b.Bytes()
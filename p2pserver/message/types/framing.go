@@ -0,0 +1,212 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/imZhuFei/zeepin/p2pserver/common"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+//frame flags, the first payload byte of upgraded framing
+const (
+	FRAME_FLAG_COMPRESSED = 0x01
+)
+
+const (
+	FRAME_COMPRESS_MIN_LEN = 512 //payloads below are not worth compressing
+	FRAME_MAX_SEALED_LEN   = common.MAX_MSG_LEN + 1 + chacha20poly1305.Overhead
+)
+
+//FrameCodec writes and reads messages with the upgraded framing negotiated by
+//two peers: a flags byte leads the checked payload, block, headers, inv and
+//blocktxn payloads may be snappy compressed, and with session keys the whole frame is
+//sealed by chacha20-poly1305 with a per direction counter nonce
+type FrameCodec struct {
+	compress bool
+
+	sendLock  sync.Mutex
+	sendAead  cipher.AEAD
+	sendNonce uint64
+
+	recvAead  cipher.AEAD
+	recvNonce uint64
+}
+
+//NewFrameCodec returns the codec of a link, frames are only sealed if both keys are given
+func NewFrameCodec(compress bool, sendKey, recvKey []byte) (*FrameCodec, error) {
+	codec := &FrameCodec{compress: compress}
+	if sendKey == nil && recvKey == nil {
+		return codec, nil
+	}
+	var err error
+	codec.sendAead, err = chacha20poly1305.New(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	codec.recvAead, err = chacha20poly1305.New(recvKey)
+	if err != nil {
+		return nil, err
+	}
+	return codec, nil
+}
+
+//Compressed return whether payloads may be compressed
+func (this *FrameCodec) Compressed() bool {
+	return this.compress
+}
+
+//Encrypted return whether frames are sealed
+func (this *FrameCodec) Encrypted() bool {
+	return this.sendAead != nil
+}
+
+//WriteMessage writes msg to writer, callers sharing the writer must not
+//interleave writes of a sealed codec
+func (this *FrameCodec) WriteMessage(writer io.Writer, msg Message) error {
+	buf, err := msg.Serialization()
+	if err != nil {
+		return err
+	}
+	flags := byte(0)
+	if this.compress && compressible(msg.CmdType()) && len(buf) >= FRAME_COMPRESS_MIN_LEN {
+		buf = snappy.Encode(nil, buf)
+		flags |= FRAME_FLAG_COMPRESSED
+	}
+	body := make([]byte, 0, len(buf)+1)
+	body = append(body, flags)
+	body = append(body, buf...)
+
+	frame := bytes.NewBuffer(nil)
+	hdr := newMessageHeader(msg.CmdType(), uint32(len(body)), CheckSum(body))
+	err = writeMessageHeader(frame, hdr)
+	if err != nil {
+		return err
+	}
+	frame.Write(body)
+	if this.sendAead == nil {
+		_, err = writer.Write(frame.Bytes())
+		return err
+	}
+
+	this.sendLock.Lock()
+	defer this.sendLock.Unlock()
+	sealed := this.sendAead.Seal(nil, frameNonce(this.sendNonce), frame.Bytes(), nil)
+	this.sendNonce++
+	out := make([]byte, 4, 4+len(sealed))
+	binary.LittleEndian.PutUint32(out, uint32(len(sealed)))
+	_, err = writer.Write(append(out, sealed...))
+	return err
+}
+
+//ReadMessage reads the next message from reader, it must be called by a single reader
+func (this *FrameCodec) ReadMessage(reader io.Reader) (Message, uint32, error) {
+	if this.recvAead != nil {
+		var length uint32
+		err := binary.Read(reader, binary.LittleEndian, &length)
+		if err != nil {
+			return nil, 0, err
+		}
+		if length > FRAME_MAX_SEALED_LEN {
+			return nil, 0, fmt.Errorf("sealed frame length:%d exceed max size: %d", length, FRAME_MAX_SEALED_LEN)
+		}
+		sealed := make([]byte, length)
+		_, err = io.ReadFull(reader, sealed)
+		if err != nil {
+			return nil, 0, err
+		}
+		plain, err := this.recvAead.Open(sealed[:0], frameNonce(this.recvNonce), sealed, nil)
+		if err != nil {
+			return nil, 0, errors.New("frame authentication failed")
+		}
+		this.recvNonce++
+		reader = bytes.NewReader(plain)
+	}
+
+	cmdType, body, err := readMessageFrame(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(body) == 0 {
+		return nil, 0, errors.New("frame flags missing")
+	}
+	flags, buf := body[0], body[1:]
+	if flags&^FRAME_FLAG_COMPRESSED != 0 {
+		return nil, 0, fmt.Errorf("unknown frame flags %x", flags)
+	}
+	if flags&FRAME_FLAG_COMPRESSED != 0 {
+		if !this.compress || !compressible(cmdType) {
+			return nil, 0, fmt.Errorf("unexpected compressed %s message", cmdType)
+		}
+		size, err := snappy.DecodedLen(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		if size > common.MAX_PAYLOAD_LEN {
+			return nil, 0, fmt.Errorf("msg payload length:%d exceed max payload size: %d", size, common.MAX_PAYLOAD_LEN)
+		}
+		buf, err = snappy.Decode(nil, buf)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	msg, err := decodeMessage(cmdType, buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	return msg, uint32(len(buf)), nil
+}
+
+func compressible(cmdType string) bool {
+	switch cmdType {
+	case common.BLOCK_TYPE, common.HEADERS_TYPE, common.INV_TYPE, common.BLK_TXN_TYPE:
+		return true
+	}
+	return false
+}
+
+func frameNonce(counter uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce[chacha20poly1305.NonceSize-8:], counter)
+	return nonce
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	p2pCommon "github.com/imZhuFei/zeepin/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestInv() *Inv {
+	inv := &Inv{}
+	inv.P.InvType = common.BLOCK
+	for i := 0; i < p2pCommon.MAX_INV_BLK_CNT; i++ {
+		inv.P.Blk = append(inv.P.Blk, common.Uint256{byte(i % 4)})
+	}
+	return inv
+}
+
+func TestFrameCodecCompress(t *testing.T) {
+	codec, err := NewFrameCodec(true, nil, nil)
+	assert.Nil(t, err)
+	inv := newTestInv()
+
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, codec.WriteMessage(buf, inv))
+	legacy := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteMessage(legacy, inv))
+	assert.True(t, buf.Len() < legacy.Len())
+
+	msg, _, err := codec.ReadMessage(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, inv, msg)
+
+	//small messages are sent as is
+	ping := &Ping{Height: 10}
+	assert.Nil(t, codec.WriteMessage(buf, ping))
+	msg, _, err = codec.ReadMessage(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, ping, msg)
+}
+
+func TestFrameCodecEncrypt(t *testing.T) {
+	keyA := bytes.Repeat([]byte{1}, 32)
+	keyB := bytes.Repeat([]byte{2}, 32)
+	sender, err := NewFrameCodec(true, keyA, keyB)
+	assert.Nil(t, err)
+	receiver, err := NewFrameCodec(true, keyB, keyA)
+	assert.Nil(t, err)
+	assert.True(t, sender.Encrypted())
+
+	buf := bytes.NewBuffer(nil)
+	for i := uint64(0); i < 3; i++ {
+		assert.Nil(t, sender.WriteMessage(buf, &Ping{Height: i}))
+	}
+	for i := uint64(0); i < 3; i++ {
+		msg, _, err := receiver.ReadMessage(buf)
+		assert.Nil(t, err)
+		assert.Equal(t, &Ping{Height: i}, msg)
+	}
+
+	//tampered frames fail authentication
+	assert.Nil(t, sender.WriteMessage(buf, newTestInv()))
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+	_, _, err = receiver.ReadMessage(buf)
+	assert.NotNil(t, err)
+
+	//legacy readers can not parse sealed frames
+	buf.Reset()
+	assert.Nil(t, sender.WriteMessage(buf, &Ping{Height: 1}))
+	_, _, err = ReadMessage(buf)
+	assert.NotNil(t, err)
+}
+
+func TestVersionHello(t *testing.T) {
+	version := &Version{}
+	version.P.Nonce = 100
+	version.P.Services = p2pCommon.SERVICE_FRAMING_ENCRYPT
+	version.Hello = &HandshakeHello{
+		PubKey:    []byte{1, 2, 3},
+		Signature: []byte{4, 5},
+	}
+	version.Hello.EphemeralKey[0] = 9
+	data, err := version.Serialization()
+	assert.Nil(t, err)
+
+	version2 := &Version{}
+	assert.Nil(t, version2.Deserialization(data))
+	assert.Equal(t, version, version2)
+
+	//legacy version payload has no hello
+	version.Hello = nil
+	data, err = version.Serialization()
+	assert.Nil(t, err)
+	assert.Nil(t, version2.Deserialization(data))
+	assert.Nil(t, version2.Hello)
+}
@@ -105,44 +105,60 @@ func WriteMessage(writer io.Writer, msg Message) error {
 }
 
 func ReadMessage(reader io.Reader) (Message, uint32, error) {
-	hdr, err := readMessageHeader(reader)
+	cmdType, buf, err := readMessageFrame(reader)
 	if err != nil {
 		return nil, 0, err
 	}
+	msg, err := decodeMessage(cmdType, buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	return msg, 0, nil
+}
+
+//readMessageFrame reads a message header and its checked payload
+func readMessageFrame(reader io.Reader) (string, []byte, error) {
+	hdr, err := readMessageHeader(reader)
+	if err != nil {
+		return "", nil, err
+	}
 
 	magic := config.DefConfig.P2PNode.NetworkMagic
 	if hdr.Magic != magic {
-		return nil, 0, fmt.Errorf("unmatched magic number %d, expected %d", hdr.Magic, magic)
+		return "", nil, fmt.Errorf("unmatched magic number %d, expected %d", hdr.Magic, magic)
 	}
 
 	if hdr.Length > common.MAX_PAYLOAD_LEN {
-		return nil, 0, fmt.Errorf("msg payload length:%d exceed max payload size: %d",
+		return "", nil, fmt.Errorf("msg payload length:%d exceed max payload size: %d",
 			hdr.Length, common.MAX_PAYLOAD_LEN)
 	}
 
 	buf := make([]byte, hdr.Length)
 	_, err = io.ReadFull(reader, buf)
 	if err != nil {
-		return nil, 0, err
+		return "", nil, err
 	}
 
 	checksum := CheckSum(buf)
 	if checksum != hdr.Checksum {
-		return nil, 0, fmt.Errorf("message checksum mismatch: %x != %x ", hdr.Checksum, checksum)
+		return "", nil, fmt.Errorf("message checksum mismatch: %x != %x ", hdr.Checksum, checksum)
 	}
 
 	cmdType := string(bytes.TrimRight(hdr.CMD[:], string(0)))
+	return cmdType, buf, nil
+}
+
+func decodeMessage(cmdType string, buf []byte) (Message, error) {
 	msg, err := MakeEmptyMessage(cmdType)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	err = msg.Deserialization(buf)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-
-	return msg, 0, nil
+	return msg, nil
 }
 
 func MakeEmptyMessage(cmdType string) (Message, error) {
@@ -179,6 +195,12 @@ func MakeEmptyMessage(cmdType string) (Message, error) {
 		return &Disconnected{}, nil
 	case common.GET_BLOCKS_TYPE:
 		return &BlocksReq{}, nil
+	case common.CMPCT_BLOCK_TYPE:
+		return &CmpctBlock{}, nil
+	case common.GET_BLK_TXN_TYPE:
+		return &BlockTxnReq{}, nil
+	case common.BLK_TXN_TYPE:
+		return &BlockTxn{}, nil
 	default:
 		return nil, errors.New("unsupported cmd type:" + cmdType)
 	}
@@ -38,11 +38,16 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 
+	"github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/errors"
 	"github.com/imZhuFei/zeepin/p2pserver/common"
 )
 
+//HANDSHAKE_HELLO_MAGIC marks the handshake hello appended to the version payload
+const HANDSHAKE_HELLO_MAGIC = uint32(0x5a504853)
+
 type VersionPayload struct {
 	Version      uint32
 	Services     uint64
@@ -57,8 +62,17 @@ type VersionPayload struct {
 	IsConsensus  bool
 }
 
+//HandshakeHello carries the key material of the authenticated encryption
+//handshake. Peers without the framing upgrade ignore it as trailing bytes
+type HandshakeHello struct {
+	EphemeralKey [32]byte //x25519 public key of this link
+	PubKey       []byte   //serialized node public key
+	Signature    []byte   //node signature of the ephemeral key
+}
+
 type Version struct {
-	P VersionPayload
+	P     VersionPayload
+	Hello *HandshakeHello
 }
 
 //Serialize message payload
@@ -68,6 +82,12 @@ func (this Version) Serialization() ([]byte, error) {
 	if err != nil {
 		return nil, errors.NewDetailErr(err, errors.ErrNetPackFail, fmt.Sprintf("write error. payload:%v", this.P))
 	}
+	if this.Hello != nil {
+		serialization.WriteUint32(p, HANDSHAKE_HELLO_MAGIC)
+		p.Write(this.Hello.EphemeralKey[:])
+		serialization.WriteVarBytes(p, this.Hello.PubKey)
+		serialization.WriteVarBytes(p, this.Hello.Signature)
+	}
 
 	return p.Bytes(), nil
 }
@@ -84,5 +104,24 @@ func (this *Version) Deserialization(p []byte) error {
 	if err != nil {
 		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, fmt.Sprintf("read payload error. buf:%v", buf))
 	}
+	this.Hello = nil
+	if buf.Len() < 4 {
+		return nil
+	}
+	magic, _ := serialization.ReadUint32(buf)
+	if magic != HANDSHAKE_HELLO_MAGIC {
+		return nil
+	}
+	hello := &HandshakeHello{}
+	if _, err = io.ReadFull(buf, hello.EphemeralKey[:]); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, "read handshake ephemeral key error")
+	}
+	if hello.PubKey, err = serialization.ReadVarBytes(buf); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, "read handshake public key error")
+	}
+	if hello.Signature, err = serialization.ReadVarBytes(buf); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNetUnPackFail, "read handshake signature error")
+	}
+	this.Hello = hello
 	return nil
 }
@@ -0,0 +1,189 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/core/types"
+	msgCommon "github.com/imZhuFei/zeepin/p2pserver/common"
+	"github.com/imZhuFei/zeepin/p2pserver/message/msg_pack"
+	msgTypes "github.com/imZhuFei/zeepin/p2pserver/message/types"
+	"github.com/imZhuFei/zeepin/p2pserver/peer"
+	tc "github.com/imZhuFei/zeepin/txnpool/common"
+)
+
+//cmpctBlockCache keeps the compact blocks waiting for their missing txs
+var cmpctBlockCache *lru.Cache
+
+func init() {
+	cmpctBlockCache, _ = lru.New(msgCommon.MAX_CMPCT_BLOCK_CACHE_SIZE)
+}
+
+// NewBlockDataReq returns a compact block request when the peer serves
+// compact blocks and a full block request otherwise
+func NewBlockDataReq(p *peer.Peer, hash common.Uint256) msgTypes.Message {
+	if CompactBlockEnabled(p) {
+		return msgpack.NewCmpctBlkDataReq(hash)
+	}
+	return msgpack.NewBlkDataReq(hash)
+}
+
+// CompactBlockEnabled returns whether blocks are relayed as compact blocks
+// with the peer
+func CompactBlockEnabled(p *peer.Peer) bool {
+	return !config.DefConfig.P2PNode.DisableCompactBlock &&
+		p.GetServices()&msgCommon.SERVICE_COMPACT_BLOCK != 0
+}
+
+//cmpctBlockState rebuilds a block from a compact block, the txs found in
+//the local txnpool and the missing ones fetched from the relaying peer
+type cmpctBlockState struct {
+	header   *types.Header
+	key      []byte
+	ids      []uint64
+	repeated map[uint64]bool //ids shared by several txs of the block
+	txs      []*types.Transaction
+	missing  []uint32
+	size     uint32
+}
+
+//newCmpctBlockState records the short ids shared by several txs of the block
+func newCmpctBlockState(cmpct *msgTypes.CmpctBlock, size uint32) (*cmpctBlockState, error) {
+	if cmpct.Header == nil {
+		return nil, errors.New("compact block without header")
+	}
+	seen := make(map[uint64]bool, len(cmpct.ShortIds))
+	repeated := make(map[uint64]bool)
+	for _, id := range cmpct.ShortIds {
+		if seen[id] {
+			repeated[id] = true
+		}
+		seen[id] = true
+	}
+	return &cmpctBlockState{
+		header:   cmpct.Header,
+		key:      tc.ShortTxIdKey(cmpct.Header.Hash(), cmpct.Nonce),
+		ids:      cmpct.ShortIds,
+		repeated: repeated,
+		txs:      make([]*types.Transaction, len(cmpct.ShortIds)),
+		size:     size,
+	}, nil
+}
+
+//addPoolTxs fills the block with the txs looked up in the txnpool, in the
+//order of the short ids, and records the indexes still missing. The txs of
+//a short id repeated in the block are always requested in full
+func (this *cmpctBlockState) addPoolTxs(txs []*types.Transaction) {
+	this.missing = this.missing[:0]
+	for i, id := range this.ids {
+		if i < len(txs) && txs[i] != nil && !this.repeated[id] {
+			this.txs[i] = txs[i]
+		}
+		if this.txs[i] == nil {
+			this.missing = append(this.missing, uint32(i))
+		}
+	}
+}
+
+//addBlockTxns fills the missing txs with the ones answered by the peer
+func (this *cmpctBlockState) addBlockTxns(txs []*types.Transaction, size uint32) error {
+	if len(txs) != len(this.missing) {
+		return fmt.Errorf("blocktxn count %d mismatch missing count %d",
+			len(txs), len(this.missing))
+	}
+	for i, index := range this.missing {
+		if tc.ShortTxId(this.key, txs[i].Hash()) != this.ids[index] {
+			return fmt.Errorf("blocktxn tx %d short id mismatch", index)
+		}
+		this.txs[index] = txs[i]
+	}
+	this.missing = this.missing[:0]
+	this.size += size
+	return nil
+}
+
+//verify checks the rebuilt txs against the transactions root of the header,
+//a pool tx sharing the short id of a block tx makes it fail
+func (this *cmpctBlockState) verify() error {
+	hashes := make([]common.Uint256, 0, len(this.txs))
+	for _, tx := range this.txs {
+		hashes = append(hashes, tx.Hash())
+	}
+	if common.ComputeMerkleRoot(hashes) != this.header.TransactionsRoot {
+		hash := this.header.Hash()
+		return fmt.Errorf("compact block %s rebuilt txs mismatch transactions root",
+			hash.ToHexString())
+	}
+	return nil
+}
+
+//complete returns whether all txs of the block are known
+func (this *cmpctBlockState) complete() bool {
+	return len(this.missing) == 0
+}
+
+//block returns the rebuilt block
+func (this *cmpctBlockState) block() *types.Block {
+	return &types.Block{
+		Header:       this.header,
+		Transactions: this.txs,
+	}
+}
+
+//cmpctBlockKey return the cache key of a compact block relayed by a peer
+func cmpctBlockKey(peerID uint64, blockHash common.Uint256) string {
+	return fmt.Sprintf("%d%s", peerID, blockHash.ToHexString())
+}
+
+//saveCmpctBlockState keeps the compact block until the peer answers the missing txs
+func saveCmpctBlockState(peerID uint64, state *cmpctBlockState) {
+	cmpctBlockCache.Add(cmpctBlockKey(peerID, state.header.Hash()), state)
+}
+
+//takeCmpctBlockState removes and returns the compact block waiting for the peer
+func takeCmpctBlockState(peerID uint64, blockHash common.Uint256) *cmpctBlockState {
+	key := cmpctBlockKey(peerID, blockHash)
+	value, ok := cmpctBlockCache.Get(key)
+	if !ok {
+		return nil
+	}
+	cmpctBlockCache.Remove(key)
+	return value.(*cmpctBlockState)
+}
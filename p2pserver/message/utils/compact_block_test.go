@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/core/payload"
+	"github.com/imZhuFei/zeepin/core/types"
+	msgTypes "github.com/imZhuFei/zeepin/p2pserver/message/types"
+	tc "github.com/imZhuFei/zeepin/txnpool/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCmpctBlock(t *testing.T, count int) (*msgTypes.CmpctBlock, []*types.Transaction) {
+	var txs []*types.Transaction
+	var hashes []common.Uint256
+	for i := 0; i < count; i++ {
+		mutable := &types.MutableTransaction{
+			TxType:   types.Invoke,
+			Nonce:    uint32(i),
+			GasPrice: 500,
+			GasLimit: 20000,
+			Payload:  &payload.InvokeCode{Code: []byte{byte(i)}},
+		}
+		tx, err := mutable.IntoImmutable()
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+		hashes = append(hashes, tx.Hash())
+	}
+	cmpct := &msgTypes.CmpctBlock{
+		Header: &types.Header{Height: 10, TransactionsRoot: common.ComputeMerkleRoot(hashes)},
+		Nonce:  7,
+	}
+	key := tc.ShortTxIdKey(cmpct.Header.Hash(), cmpct.Nonce)
+	for _, hash := range hashes {
+		cmpct.ShortIds = append(cmpct.ShortIds, tc.ShortTxId(key, hash))
+	}
+	return cmpct, txs
+}
+
+func TestCmpctBlockStateRebuild(t *testing.T) {
+	cmpct, txs := newTestCmpctBlock(t, 4)
+	state, err := newCmpctBlockState(cmpct, 100)
+	assert.Nil(t, err)
+
+	//tx 1 and 3 are not in the txnpool
+	state.addPoolTxs([]*types.Transaction{txs[0], nil, txs[2], nil})
+	assert.False(t, state.complete())
+	assert.Equal(t, []uint32{1, 3}, state.missing)
+
+	assert.NotNil(t, state.addBlockTxns([]*types.Transaction{txs[3], txs[1]}, 50))
+	assert.NotNil(t, state.addBlockTxns([]*types.Transaction{txs[1]}, 50))
+	assert.Nil(t, state.addBlockTxns([]*types.Transaction{txs[1], txs[3]}, 50))
+	assert.True(t, state.complete())
+	assert.Equal(t, uint32(150), state.size)
+	assert.Nil(t, state.verify())
+
+	block := state.block()
+	assert.Equal(t, txs, block.Transactions)
+}
+
+func TestCmpctBlockStateCollision(t *testing.T) {
+	//a pool tx matching the short id of another tx fails the transactions root
+	cmpct, txs := newTestCmpctBlock(t, 2)
+	state, err := newCmpctBlockState(cmpct, 100)
+	assert.Nil(t, err)
+	state.addPoolTxs([]*types.Transaction{txs[1], txs[0]})
+	assert.True(t, state.complete())
+	assert.NotNil(t, state.verify())
+
+	//the txs of a repeated short id are requested in full
+	cmpct, txs = newTestCmpctBlock(t, 3)
+	cmpct.ShortIds[2] = cmpct.ShortIds[0]
+	state, err = newCmpctBlockState(cmpct, 100)
+	assert.Nil(t, err)
+	state.addPoolTxs(txs)
+	assert.Equal(t, []uint32{0, 2}, state.missing)
+	assert.NotNil(t, state.addBlockTxns([]*types.Transaction{txs[0], txs[2]}, 50))
+
+	_, err = newCmpctBlockState(&msgTypes.CmpctBlock{}, 100)
+	assert.NotNil(t, err)
+}
+
+func TestCmpctBlockCache(t *testing.T) {
+	cmpct, _ := newTestCmpctBlock(t, 1)
+	state, err := newCmpctBlockState(cmpct, 100)
+	assert.Nil(t, err)
+	saveCmpctBlockState(1, state)
+
+	blockHash := cmpct.Header.Hash()
+	assert.Nil(t, takeCmpctBlockState(2, blockHash))
+	assert.Equal(t, state, takeCmpctBlockState(1, blockHash))
+	assert.Nil(t, takeCmpctBlockState(1, blockHash))
+}
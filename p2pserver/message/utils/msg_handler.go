@@ -56,23 +56,26 @@ import (
 	evtActor "github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 //respCache cache for some response data
 var respCache *lru.ARCCache
 
 // AddrReqHandle handles the neighbor address request from peer
 func AddrReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive addr request message", data.Addr, data.Id)
+	logger.Debug("receive addr request message", data.Addr, data.Id)
 	remotePeer := p2p.GetPeer(data.Id)
 	if remotePeer == nil {
-		log.Error("remotePeer invalid in AddrReqHandle")
+		logger.Error("remotePeer invalid in AddrReqHandle")
 		return
 	}
 
 	var addrStr []msgCommon.PeerAddr
 	addrStr = p2p.GetNeighborAddrs()
 	//check mask peers
-	mskPeers := config.DefConfig.P2PNode.ReservedCfg.MaskPeers
-	if config.DefConfig.P2PNode.ReservedPeersOnly && len(mskPeers) > 0 {
+	reservedOnly, reservedCfg := config.DefConfig.GetReservedCfg()
+	mskPeers := reservedCfg.MaskPeers
+	if reservedOnly && len(mskPeers) > 0 {
 		for i := 0; i < len(addrStr); i++ {
 			var ip net.IP
 			ip = addrStr[i].IpAddr[:]
@@ -90,14 +93,14 @@ func AddrReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 	msg := msgpack.NewAddrs(addrStr)
 	err := p2p.Send(remotePeer, msg, false)
 	if err != nil {
-		log.Error(err)
+		logger.Error(err)
 		return
 	}
 }
 
 // HeaderReqHandle handles the header sync req from peer
 func HeadersReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive headers request message", data.Addr, data.Id)
+	logger.Debug("receive headers request message", data.Addr, data.Id)
 
 	headersReq := data.Payload.(*msgTypes.HeadersReq)
 
@@ -106,30 +109,30 @@ func HeadersReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID,
 
 	headers, err := GetHeadersFromHash(startHash, stopHash)
 	if err != nil {
-		log.Errorf("get headers in HeadersReqHandle error: %s,startHash:%s,stopHash:%s", err.Error(), startHash.ToHexString(), stopHash.ToHexString())
+		logger.Errorf("get headers in HeadersReqHandle error: %s,startHash:%s,stopHash:%s", err.Error(), startHash.ToHexString(), stopHash.ToHexString())
 		return
 	}
 	remotePeer := p2p.GetPeer(data.Id)
 	if remotePeer == nil {
-		log.Errorf("remotePeer invalid in HeadersReqHandle, peer id: %d", data.Id)
+		logger.Errorf("remotePeer invalid in HeadersReqHandle, peer id: %d", data.Id)
 		return
 	}
 	msg := msgpack.NewHeaders(headers)
 	err = p2p.Send(remotePeer, msg, false)
 	if err != nil {
-		log.Error(err)
+		logger.Error(err)
 		return
 	}
 }
 
 //PingHandle handle ping msg from peer
 func PingHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive ping message", data.Addr, data.Id)
+	logger.Debug("receive ping message", data.Addr, data.Id)
 
 	ping := data.Payload.(*msgTypes.Ping)
 	remotePeer := p2p.GetPeer(data.Id)
 	if remotePeer == nil {
-		log.Error("remotePeer invalid in PingHandle")
+		logger.Error("remotePeer invalid in PingHandle")
 		return
 	}
 	remotePeer.SetHeight(ping.Height)
@@ -140,19 +143,19 @@ func PingHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 
 	err := p2p.Send(remotePeer, msg, false)
 	if err != nil {
-		log.Error(err)
+		logger.Error(err)
 	}
 }
 
 ///PongHandle handle pong msg from peer
 func PongHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive pong message", data.Addr, data.Id)
+	logger.Debug("receive pong message", data.Addr, data.Id)
 
 	pong := data.Payload.(*msgTypes.Pong)
 
 	remotePeer := p2p.GetPeer(data.Id)
 	if remotePeer == nil {
-		log.Error("remotePeer invalid in PongHandle")
+		logger.Error("remotePeer invalid in PongHandle")
 		return
 	}
 	remotePeer.SetHeight(pong.Height)
@@ -160,7 +163,7 @@ func PongHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 
 // BlkHeaderHandle handles the sync headers from peer
 func BlkHeaderHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive block header message", data.Addr, data.Id)
+	logger.Debug("receive block header message", data.Addr, data.Id)
 	if pid != nil {
 		var blkHeader = data.Payload.(*msgTypes.BlkHeader)
 		input := &msgCommon.AppendHeaders{
@@ -173,7 +176,7 @@ func BlkHeaderHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID,
 
 // BlockHandle handles the block message from peer
 func BlockHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive block message from ", data.Addr, data.Id)
+	logger.Debug("receive block message from ", data.Addr, data.Id)
 
 	if pid != nil {
 		var block = data.Payload.(*msgTypes.Block)
@@ -186,14 +189,147 @@ func BlockHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 	}
 }
 
+// CmpctBlockHandle handles the compact block from peer, the block is rebuilt
+// from the txnpool and the missing txs are requested from the peer
+func CmpctBlockHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	logger.Debug("receive compact block message from ", data.Addr, data.Id)
+
+	if pid == nil {
+		return
+	}
+	var cmpct = data.Payload.(*msgTypes.CmpctBlock)
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		logger.Error("remotePeer invalid in CmpctBlockHandle")
+		return
+	}
+	if cmpct.Header.Height <= ledger.DefLedger.GetCurrentBlockHeight() {
+		return
+	}
+	blockHash := cmpct.Header.Hash()
+	state, err := newCmpctBlockState(cmpct, data.PayloadSize)
+	if err != nil {
+		logger.Warnf("invalid compact block from %d: %s, request full block", data.Id, err)
+		err = p2p.Send(remotePeer, msgpack.NewBlkDataReq(blockHash), false)
+		if err != nil {
+			logger.Error(err)
+		}
+		return
+	}
+	var poolTxs []*types.Transaction
+	if len(cmpct.ShortIds) > 0 {
+		poolTxs, err = actor.GetTransactionsByShortId(state.key, cmpct.ShortIds)
+		if err != nil {
+			logger.Warnf("compact block %s txnpool lookup error: %s", blockHash.ToHexString(), err)
+		}
+	}
+	state.addPoolTxs(poolTxs)
+	if state.complete() {
+		appendCmpctBlock(data, p2p, pid, state)
+		return
+	}
+	logger.Debugf("compact block %s height %d misses %d of %d txs", blockHash.ToHexString(),
+		cmpct.Header.Height, len(state.missing), len(cmpct.ShortIds))
+	saveCmpctBlockState(data.Id, state)
+	err = p2p.Send(remotePeer, msgpack.NewBlockTxnReq(blockHash, state.missing), false)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// BlockTxnReqHandle handles the missing txs request of a compact block from peer
+func BlockTxnReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	logger.Debug("receive blocktxn req message from ", data.Addr, data.Id)
+
+	var req = data.Payload.(*msgTypes.BlockTxnReq)
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		logger.Error("remotePeer invalid in BlockTxnReqHandle")
+		return
+	}
+	block := getRespBlock(req.BlockHash)
+	if block == nil {
+		logger.Debug("can't get block by hash: ", req.BlockHash,
+			" ,send not found message")
+		err := p2p.Send(remotePeer, msgpack.NewNotFound(req.BlockHash), false)
+		if err != nil {
+			logger.Error(err)
+		}
+		return
+	}
+	txns := make([]*types.Transaction, 0, len(req.Indexes))
+	for _, index := range req.Indexes {
+		if index >= uint32(len(block.Transactions)) {
+			logger.Warnf("blocktxn req from %d index %d out of range", data.Id, index)
+			return
+		}
+		txns = append(txns, block.Transactions[index])
+	}
+	err := p2p.Send(remotePeer, msgpack.NewBlockTxn(req.BlockHash, txns), false)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+// BlockTxnHandle handles the missing txs of a compact block from peer
+func BlockTxnHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	logger.Debug("receive blocktxn message from ", data.Addr, data.Id)
+
+	if pid == nil {
+		return
+	}
+	var blkTxn = data.Payload.(*msgTypes.BlockTxn)
+	state := takeCmpctBlockState(data.Id, blkTxn.BlockHash)
+	if state == nil {
+		logger.Debug("no compact block waiting for blocktxn ", blkTxn.BlockHash)
+		return
+	}
+	err := state.addBlockTxns(blkTxn.Txs, data.PayloadSize)
+	if err != nil {
+		logger.Warnf("invalid blocktxn from %d: %s, request full block", data.Id, err)
+		remotePeer := p2p.GetPeer(data.Id)
+		if remotePeer == nil {
+			return
+		}
+		err = p2p.Send(remotePeer, msgpack.NewBlkDataReq(blkTxn.BlockHash), false)
+		if err != nil {
+			logger.Error(err)
+		}
+		return
+	}
+	appendCmpctBlock(data, p2p, pid, state)
+}
+
+// appendCmpctBlock hands the rebuilt block to the sync, a short id collision
+// with a pool tx is resolved by requesting the full block from the peer
+func appendCmpctBlock(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, state *cmpctBlockState) {
+	if err := state.verify(); err != nil {
+		logger.Warnf("compact block from %d: %s, request full block", data.Id, err)
+		remotePeer := p2p.GetPeer(data.Id)
+		if remotePeer == nil {
+			return
+		}
+		err = p2p.Send(remotePeer, msgpack.NewBlkDataReq(state.header.Hash()), false)
+		if err != nil {
+			logger.Error(err)
+		}
+		return
+	}
+	pid.Tell(&msgCommon.AppendBlock{
+		FromID:    data.Id,
+		BlockSize: state.size,
+		Block:     state.block(),
+	})
+}
+
 // ConsensusHandle handles the consensus message from peer
 func ConsensusHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debugf("receive consensus message:%v,%d", data.Addr, data.Id)
+	logger.Debugf("receive consensus message:%v,%d", data.Addr, data.Id)
 
 	if actor.ConsensusPid != nil {
 		var consensus = data.Payload.(*msgTypes.Consensus)
 		if err := consensus.Cons.Verify(); err != nil {
-			log.Error(err)
+			logger.Error(err)
 			return
 		}
 		consensus.Cons.PeerId = data.Id
@@ -204,44 +340,45 @@ func ConsensusHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID,
 // NotFoundHandle handles the not found message from peer
 func NotFoundHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
 	var notFound = data.Payload.(*msgTypes.NotFound)
-	log.Debug("receive notFound message, hash is ", notFound.Hash)
+	logger.Debug("receive notFound message, hash is ", notFound.Hash)
 }
 
 // TransactionHandle handles the transaction message from peer
 func TransactionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive transaction message", data.Addr, data.Id)
+	logger.Debug("receive transaction message", data.Addr, data.Id)
 
 	var trn = data.Payload.(*msgTypes.Trn)
 	actor.AddTransaction(trn.Txn)
-	log.Debug("receive Transaction message hash", trn.Txn.Hash())
+	logger.Debug("receive Transaction message hash", trn.Txn.Hash())
 
 }
 
 // VersionHandle handles version handshake protocol from peer
 func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive version message", data.Addr, data.Id)
+	logger.Debug("receive version message", data.Addr, data.Id)
 
 	version := data.Payload.(*msgTypes.Version)
 
 	remotePeer := p2p.GetPeerFromAddr(data.Addr)
 	if remotePeer == nil {
-		log.Warn("peer is not exist", data.Addr)
+		logger.Warn("peer is not exist", data.Addr)
 		//peer not exist,just remove list and return
 		p2p.RemoveFromConnectingList(data.Addr)
 		return
 	}
 	addrIp, err := msgCommon.ParseIPAddr(data.Addr)
 	if err != nil {
-		log.Warn(err)
+		logger.Warn(err)
 		return
 	}
 	nodeAddr := addrIp + ":" +
 		strconv.Itoa(int(version.P.SyncPort))
-	if config.DefConfig.P2PNode.ReservedPeersOnly && len(config.DefConfig.P2PNode.ReservedCfg.ReservedPeers) > 0 {
+	reservedOnly, reservedCfg := config.DefConfig.GetReservedCfg()
+	if reservedOnly && len(reservedCfg.ReservedPeers) > 0 {
 		found := false
-		for _, addr := range config.DefConfig.P2PNode.ReservedCfg.ReservedPeers {
+		for _, addr := range reservedCfg.ReservedPeers {
 			if strings.HasPrefix(data.Addr, addr) {
-				log.Info("peer in reserved list")
+				logger.Info("peer in reserved list")
 				found = true
 				break
 			}
@@ -249,7 +386,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 		if !found {
 			remotePeer.CloseSync()
 			remotePeer.CloseCons()
-			log.Info("peer not in reserved list,close")
+			logger.Info("peer not in reserved list,close")
 			return
 		}
 
@@ -257,7 +394,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 	if version.P.IsConsensus == true {
 		if config.DefConfig.P2PNode.DualPortSupport == false {
-			log.Warn("consensus port not surpport")
+			logger.Warn("consensus port not surpport")
 			remotePeer.CloseCons()
 			return
 		}
@@ -265,7 +402,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 		p := p2p.GetPeer(version.P.Nonce)
 
 		if p == nil {
-			log.Warn("sync link is not exist", version.P.Nonce)
+			logger.Warn("sync link is not exist", version.P.Nonce)
 			remotePeer.CloseCons()
 			remotePeer.CloseSync()
 			return
@@ -278,7 +415,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 		}
 		if version.P.Nonce == p2p.GetID() {
-			log.Warn("the node handshake with itself")
+			logger.Warn("the node handshake with itself")
 			p2p.SetOwnAddress(nodeAddr)
 			p2p.RemoveFromInConnRecord(remotePeer.GetAddr())
 			p2p.RemoveFromOutConnRecord(remotePeer.GetAddr())
@@ -288,7 +425,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 		s := remotePeer.GetConsState()
 		if s != msgCommon.INIT && s != msgCommon.HAND {
-			log.Warn("unknown status to received version", s)
+			logger.Warn("unknown status to received version", s)
 			remotePeer.CloseCons()
 			return
 		}
@@ -310,14 +447,14 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 		}
 		err := p2p.Send(remotePeer, msg, true)
 		if err != nil {
-			log.Error(err)
+			logger.Error(err)
 			return
 		}
 	} else {
 		if version.P.Nonce == p2p.GetID() {
 			p2p.RemoveFromInConnRecord(remotePeer.GetAddr())
 			p2p.RemoveFromOutConnRecord(remotePeer.GetAddr())
-			log.Warn("the node handshake with itself")
+			logger.Warn("the node handshake with itself")
 			p2p.SetOwnAddress(nodeAddr)
 			remotePeer.CloseSync()
 			return
@@ -325,7 +462,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 		s := remotePeer.GetSyncState()
 		if s != msgCommon.INIT && s != msgCommon.HAND {
-			log.Warn("unknown status to received version", s)
+			logger.Warn("unknown status to received version", s)
 			remotePeer.CloseSync()
 			return
 		}
@@ -335,20 +472,20 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 		if p != nil {
 			ipOld, err := msgCommon.ParseIPAddr(p.GetAddr())
 			if err != nil {
-				log.Warn("exist peer %d ip format is wrong %s", version.P.Nonce, p.GetAddr())
+				logger.Warn("exist peer %d ip format is wrong %s", version.P.Nonce, p.GetAddr())
 				return
 			}
 			ipNew, err := msgCommon.ParseIPAddr(data.Addr)
 			if err != nil {
 				remotePeer.CloseSync()
-				log.Warn("connecting peer %d ip format is wrong %s, close", version.P.Nonce, data.Addr)
+				logger.Warn("connecting peer %d ip format is wrong %s, close", version.P.Nonce, data.Addr)
 				return
 			}
 			if ipNew == ipOld {
 				//same id and same ip
 				n, ret := p2p.DelNbrNode(version.P.Nonce)
 				if ret == true {
-					log.Infof("peer reconnect %d", version.P.Nonce)
+					logger.Infof("peer reconnect %d", version.P.Nonce)
 					// Close the connection and release the node source
 					n.CloseSync()
 					n.CloseCons()
@@ -360,7 +497,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 					}
 				}
 			} else {
-				log.Infof("same peer id from different addr: %s, %s close latest one", ipOld, ipNew)
+				logger.Infof("same peer id from different addr: %s, %s close latest one", ipOld, ipNew)
 				remotePeer.CloseSync()
 				return
 
@@ -398,7 +535,7 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 		}
 		err := p2p.Send(remotePeer, msg, false)
 		if err != nil {
-			log.Error(err)
+			logger.Error(err)
 			return
 		}
 	}
@@ -406,24 +543,24 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 // VerAckHandle handles the version ack from peer
 func VerAckHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive verAck message from ", data.Addr, data.Id)
+	logger.Debug("receive verAck message from ", data.Addr, data.Id)
 
 	verAck := data.Payload.(*msgTypes.VerACK)
 	remotePeer := p2p.GetPeer(data.Id)
 
 	if remotePeer == nil {
-		log.Warn("nbr node is not exist", data.Id, data.Addr)
+		logger.Warn("nbr node is not exist", data.Id, data.Addr)
 		return
 	}
 
 	if verAck.IsConsensus == true {
 		if config.DefConfig.P2PNode.DualPortSupport == false {
-			log.Warn("consensus port not surpport")
+			logger.Warn("consensus port not surpport")
 			return
 		}
 		s := remotePeer.GetConsState()
 		if s != msgCommon.HAND_SHAKE && s != msgCommon.HAND_SHAKED {
-			log.Warn("unknown status to received verAck", s)
+			logger.Warn("unknown status to received verAck", s)
 			return
 		}
 
@@ -438,7 +575,7 @@ func VerAckHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, arg
 	} else {
 		s := remotePeer.GetSyncState()
 		if s != msgCommon.HAND_SHAKE && s != msgCommon.HAND_SHAKED {
-			log.Warn("unknown status to received verAck", s)
+			logger.Warn("unknown status to received verAck", s)
 			return
 		}
 
@@ -456,7 +593,7 @@ func VerAckHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, arg
 			if config.DefConfig.P2PNode.DualPortSupport && remotePeer.GetConsPort() > 0 {
 				addrIp, err := msgCommon.ParseIPAddr(addr)
 				if err != nil {
-					log.Warn(err)
+					logger.Warn(err)
 					return
 				}
 				nodeConsensusAddr := addrIp + ":" +
@@ -473,7 +610,7 @@ func VerAckHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, arg
 
 // AddrHandle handles the neighbor address response message from peer
 func AddrHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("handle addr message", data.Addr, data.Id)
+	logger.Debug("handle addr message", data.Addr, data.Id)
 
 	var msg = data.Payload.(*msgTypes.Addr)
 	for _, v := range msg.NodeAddrs {
@@ -499,76 +636,68 @@ func AddrHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args
 		if p2p.IsAddrFromConnecting(address) {
 			continue
 		}
-		log.Debug("connect ip address:", address)
+		logger.Debug("connect ip address:", address)
 		go p2p.Connect(address, false)
 	}
 }
 
 // DataReqHandle handles the data req(block/Transaction) from peer
 func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive data req message", data.Addr, data.Id)
+	logger.Debug("receive data req message", data.Addr, data.Id)
 
 	var dataReq = data.Payload.(*msgTypes.DataReq)
 
 	remotePeer := p2p.GetPeer(data.Id)
 	if remotePeer == nil {
-		log.Error("remotePeer invalid in DataReqHandle")
+		logger.Error("remotePeer invalid in DataReqHandle")
 		return
 	}
 	reqType := common.InventoryType(dataReq.DataType)
 	hash := dataReq.Hash
 	switch reqType {
-	case common.BLOCK:
-		reqID := fmt.Sprintf("%x%s", reqType, hash.ToHexString())
-		data := getRespCacheValue(reqID)
-		var block *types.Block
-		var err error
-		if data != nil {
-			switch data.(type) {
-			case *types.Block:
-				block = data.(*types.Block)
-			}
-		}
+	case common.BLOCK, common.COMPACT_BLOCK:
+		block := getRespBlock(hash)
 		if block == nil {
-			block, err = ledger.DefLedger.GetBlockByHash(hash)
-			if err != nil || block == nil || block.Header == nil {
-				log.Debug("can't get block by hash: ", hash,
-					" ,send not found message")
-				msg := msgpack.NewNotFound(hash)
-				err := p2p.Send(remotePeer, msg, false)
-				if err != nil {
-					log.Error(err)
-					return
-				}
+			logger.Debug("can't get block by hash: ", hash,
+				" ,send not found message")
+			msg := msgpack.NewNotFound(hash)
+			err := p2p.Send(remotePeer, msg, false)
+			if err != nil {
+				logger.Error(err)
 				return
 			}
-			saveRespCache(reqID, block)
+			return
 		}
-		log.Debug("block height is ", block.Header.Height,
+		logger.Debug("block height is ", block.Header.Height,
 			" ,hash is ", hash)
-		msg := msgpack.NewBlock(block)
-		err = p2p.Send(remotePeer, msg, false)
+		var msg msgTypes.Message
+		if reqType == common.COMPACT_BLOCK {
+			msg = msgpack.NewCmpctBlock(block)
+		} else {
+			msg = msgpack.NewBlock(block)
+		}
+		err := p2p.Send(remotePeer, msg, false)
 		if err != nil {
-			log.Error(err)
+			logger.Error(err)
 			return
 		}
 
 	case common.TRANSACTION:
 		txn, err := ledger.DefLedger.GetTransaction(hash)
 		if err != nil {
-			log.Debug("Can't get transaction by hash: ",
+			logger.Debug("Can't get transaction by hash: ",
 				hash, " ,send not found message")
 			msg := msgpack.NewNotFound(hash)
 			err = p2p.Send(remotePeer, msg, false)
 			if err != nil {
-				log.Error(err)
+				logger.Error(err)
 				return
 			}
 		}
 		msg := msgpack.NewTxn(txn)
 		err = p2p.Send(remotePeer, msg, false)
 		if err != nil {
-			log.Error(err)
+			logger.Error(err)
 			return
 		}
 	}
@@ -577,27 +706,27 @@ func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 // InvHandle handles the inventory message(block,
 // transaction and consensus) from peer.
 func InvHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
-	log.Debug("receive inv message", data.Addr, data.Id)
+	logger.Debug("receive inv message", data.Addr, data.Id)
 	var inv = data.Payload.(*msgTypes.Inv)
 
 	remotePeer := p2p.GetPeer(data.Id)
 	if remotePeer == nil {
-		log.Error("remotePeer invalid in InvHandle")
+		logger.Error("remotePeer invalid in InvHandle")
 		return
 	}
 	if len(inv.P.Blk) == 0 {
-		log.Error("empty inv payload in InvHandle")
+		logger.Error("empty inv payload in InvHandle")
 		return
 	}
 	var id common.Uint256
 	str := inv.P.Blk[0].ToHexString()
-	log.Debugf("the inv type: 0x%x block len: %d, %s\n",
+	logger.Debugf("the inv type: 0x%x block len: %d, %s\n",
 		inv.P.InvType, len(inv.P.Blk), str)
 
 	invType := common.InventoryType(inv.P.InvType)
 	switch invType {
 	case common.TRANSACTION:
-		log.Debug("receive transaction message", id)
+		logger.Debug("receive transaction message", id)
 		// TODO check the ID queue
 		id = inv.P.Blk[0]
 		trn, err := ledger.DefLedger.GetTransaction(id)
@@ -605,43 +734,43 @@ func InvHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args .
 			msg := msgpack.NewTxnDataReq(id)
 			err = p2p.Send(remotePeer, msg, false)
 			if err != nil {
-				log.Error(err)
+				logger.Error(err)
 				return
 			}
 		}
 	case common.BLOCK:
-		log.Debug("receive block message")
+		logger.Debug("receive block message")
 		for _, id = range inv.P.Blk {
-			log.Debug("receive inv-block message, hash is ", id)
+			logger.Debug("receive inv-block message, hash is ", id)
 			// TODO check the ID queue
 			isContainBlock, err := ledger.DefLedger.IsContainBlock(id)
 			if err != nil {
-				log.Error(err)
+				logger.Error(err)
 				return
 			}
 			if !isContainBlock && msgTypes.LastInvHash != id {
 				msgTypes.LastInvHash = id
 				// send the block request
-				log.Infof("inv request block hash: %x", id)
-				msg := msgpack.NewBlkDataReq(id)
+				logger.Infof("inv request block hash: %x", id)
+				msg := NewBlockDataReq(remotePeer, id)
 				err = p2p.Send(remotePeer, msg, false)
 				if err != nil {
-					log.Error(err)
+					logger.Error(err)
 					return
 				}
 			}
 		}
 	case common.CONSENSUS:
-		log.Debug("receive consensus message")
+		logger.Debug("receive consensus message")
 		id = inv.P.Blk[0]
 		msg := msgpack.NewConsensusDataReq(id)
 		err := p2p.Send(remotePeer, msg, true)
 		if err != nil {
-			log.Error(err)
+			logger.Error(err)
 			return
 		}
 	default:
-		log.Warn("receive unknown inventory message")
+		logger.Warn("receive unknown inventory message")
 	}
 
 }
@@ -731,7 +860,7 @@ func GetHeadersFromHash(startHash common.Uint256, stopHash common.Uint256) ([]*t
 		hash := ledger.DefLedger.GetBlockHash(stopHeight + i)
 		hd, err := ledger.DefLedger.GetHeaderByHash(hash)
 		if err != nil {
-			log.Errorf("net_server GetBlockWithHeight failed with err=%s, hash=%x,height=%d\n", err.Error(), hash, stopHeight+i)
+			logger.Errorf("net_server GetBlockWithHeight failed with err=%s, hash=%x,height=%d\n", err.Error(), hash, stopHeight+i)
 			return nil, err
 		}
 		headers = append(headers, hd)
@@ -740,6 +869,20 @@ func GetHeadersFromHash(startHash common.Uint256, stopHash common.Uint256) ([]*t
 	return headers, nil
 }
 
+//getRespBlock get the requested block from the response cache or the ledger
+func getRespBlock(hash common.Uint256) *types.Block {
+	reqID := fmt.Sprintf("%x%s", common.BLOCK, hash.ToHexString())
+	if block, ok := getRespCacheValue(reqID).(*types.Block); ok {
+		return block
+	}
+	block, err := ledger.DefLedger.GetBlockByHash(hash)
+	if err != nil || block == nil || block.Header == nil {
+		return nil
+	}
+	saveRespCache(reqID, block)
+	return block
+}
+
 //getRespCacheValue get response data from cache
 func getRespCacheValue(key string) interface{} {
 	if respCache == nil {
@@ -35,7 +35,6 @@
 package utils
 
 import (
-	"github.com/imZhuFei/zeepin/common/log"
 	msgCommon "github.com/imZhuFei/zeepin/p2pserver/common"
 	"github.com/imZhuFei/zeepin/p2pserver/message/types"
 	"github.com/imZhuFei/zeepin/p2pserver/net/protocol"
@@ -89,6 +88,9 @@ func (this *MessageRouter) init(p2p p2p.P2P) {
 	this.RegisterMsgHandler(msgCommon.NOT_FOUND_TYPE, NotFoundHandle)
 	this.RegisterMsgHandler(msgCommon.TX_TYPE, TransactionHandle)
 	this.RegisterMsgHandler(msgCommon.DISCONNECT_TYPE, DisconnectHandle)
+	this.RegisterMsgHandler(msgCommon.CMPCT_BLOCK_TYPE, CmpctBlockHandle)
+	this.RegisterMsgHandler(msgCommon.GET_BLK_TXN_TYPE, BlockTxnReqHandle)
+	this.RegisterMsgHandler(msgCommon.BLK_TXN_TYPE, BlockTxnHandle)
 }
 
 // RegisterMsgHandler registers msg handler with the msg type
@@ -112,7 +114,7 @@ func (this *MessageRouter) SetPID(pid *actor.PID) {
 func (this *MessageRouter) Start() {
 	go this.hookChan(this.RecvSyncChan, this.stopSyncCh)
 	go this.hookChan(this.RecvConsChan, this.stopConsCh)
-	log.Info("MessageRouter start to parse p2p message...")
+	logger.Info("MessageRouter start to parse p2p message...")
 }
 
 // hookChan loops to handle the message from the network
@@ -128,7 +130,7 @@ func (this *MessageRouter) hookChan(channel chan *types.MsgPayload,
 				if ok {
 					go handler(data, this.p2p, this.pid)
 				} else {
-					log.Info("unknown message handler for the msg: ",
+					logger.Info("unknown message handler for the msg: ",
 						msgType)
 				}
 			}
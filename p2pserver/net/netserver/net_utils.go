@@ -44,7 +44,6 @@ import (
 	"time"
 
 	"github.com/imZhuFei/zeepin/common/config"
-	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/p2pserver/common"
 )
 
@@ -57,13 +56,13 @@ func createListener(port uint16) (net.Listener, error) {
 	if isTls {
 		listener, err = initTlsListen(port)
 		if err != nil {
-			log.Error("initTlslisten failed")
+			logger.Error("initTlslisten failed")
 			return nil, errors.New("initTlslisten failed")
 		}
 	} else {
 		listener, err = initNonTlsListen(port)
 		if err != nil {
-			log.Error("initNonTlsListen failed")
+			logger.Error("initNonTlsListen failed")
 			return nil, errors.New("initNonTlsListen failed")
 		}
 	}
@@ -72,7 +71,7 @@ func createListener(port uint16) (net.Listener, error) {
 
 //nonTLSDial return net.Conn with nonTls
 func nonTLSDial(addr string) (net.Conn, error) {
-	log.Debug()
+	logger.Debug()
 	conn, err := net.DialTimeout("tcp", addr, time.Second*common.DIAL_TIMEOUT)
 	if err != nil {
 		return nil, err
@@ -90,7 +89,7 @@ func TLSDial(nodeAddr string) (net.Conn, error) {
 
 	cacert, err := ioutil.ReadFile(CAPath)
 	if err != nil {
-		log.Error("load CA file fail", err)
+		logger.Error("load CA file fail", err)
 		return nil, err
 	}
 	cert, err := tls.LoadX509KeyPair(CertPath, KeyPath)
@@ -119,10 +118,10 @@ func TLSDial(nodeAddr string) (net.Conn, error) {
 
 //initNonTlsListen return net.Listener with nonTls mode
 func initNonTlsListen(port uint16) (net.Listener, error) {
-	log.Debug()
+	logger.Debug()
 	listener, err := net.Listen("tcp", ":"+strconv.Itoa(int(port)))
 	if err != nil {
-		log.Error("Error listening\n", err.Error())
+		logger.Error("Error listening\n", err.Error())
 		return nil, err
 	}
 	return listener, nil
@@ -137,13 +136,13 @@ func initTlsListen(port uint16) (net.Listener, error) {
 	// load cert
 	cert, err := tls.LoadX509KeyPair(CertPath, KeyPath)
 	if err != nil {
-		log.Error("load keys fail", err)
+		logger.Error("load keys fail", err)
 		return nil, err
 	}
 	// load root ca
 	caData, err := ioutil.ReadFile(CAPath)
 	if err != nil {
-		log.Error("read ca fail", err)
+		logger.Error("read ca fail", err)
 		return nil, err
 	}
 	pool := x509.NewCertPool()
@@ -159,10 +158,10 @@ func initTlsListen(port uint16) (net.Listener, error) {
 		ClientCAs:    pool,
 	}
 
-	log.Info("TLS listen port is ", strconv.Itoa(int(port)))
+	logger.Info("TLS listen port is ", strconv.Itoa(int(port)))
 	listener, err := tls.Listen("tcp", ":"+strconv.Itoa(int(port)), tlsConfig)
 	if err != nil {
-		log.Error(err)
+		logger.Error(err)
 		return nil, err
 	}
 	return listener, nil
@@ -47,12 +47,15 @@ import (
 	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/core/ledger"
 	"github.com/imZhuFei/zeepin/p2pserver/common"
+	"github.com/imZhuFei/zeepin/p2pserver/link"
 	"github.com/imZhuFei/zeepin/p2pserver/message/msg_pack"
 	"github.com/imZhuFei/zeepin/p2pserver/message/types"
 	"github.com/imZhuFei/zeepin/p2pserver/net/protocol"
 	"github.com/imZhuFei/zeepin/p2pserver/peer"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 //NewNetServer return the net object in p2p
 func NewNetServer() p2p.P2P {
 	n := &NetServer{
@@ -112,14 +115,18 @@ type PeerAddrMap struct {
 func (this *NetServer) init() error {
 	this.base.SetVersion(common.PROTOCOL_VERSION)
 
+	services := link.FramingServices()
+	if !config.DefConfig.P2PNode.DisableCompactBlock {
+		services |= common.SERVICE_COMPACT_BLOCK
+	}
 	if config.DefConfig.Consensus.EnableConsensus {
-		this.base.SetServices(uint64(common.VERIFY_NODE))
+		this.base.SetServices(uint64(common.VERIFY_NODE) | services)
 	} else {
-		this.base.SetServices(uint64(common.SERVICE_NODE))
+		this.base.SetServices(uint64(common.SERVICE_NODE) | services)
 	}
 
 	if config.DefConfig.P2PNode.NodePort == 0 {
-		log.Error("link port invalid")
+		logger.Error("link port invalid")
 		return errors.New("invalid link port")
 	}
 
@@ -127,7 +134,7 @@ func (this *NetServer) init() error {
 
 	if config.DefConfig.P2PNode.DualPortSupport {
 		if config.DefConfig.P2PNode.NodeConsensusPort == 0 {
-			log.Error("consensus port invalid")
+			logger.Error("consensus port invalid")
 			return errors.New("invalid consensus port")
 		}
 
@@ -140,10 +147,14 @@ func (this *NetServer) init() error {
 
 	rand.Seed(time.Now().UnixNano())
 	id := rand.Uint64()
+	if services&common.SERVICE_FRAMING_ENCRYPT != 0 {
+		//peers verify the id against the node key of the encrypted links
+		id = link.NodeId()
+	}
 
 	this.base.SetID(id)
 
-	log.Infof("init peer ID to %d", this.base.GetID())
+	logger.Infof("init peer ID to %d", this.base.GetID())
 	this.Np = &peer.NbrPeers{}
 	this.Np.Init()
 
@@ -268,7 +279,7 @@ func (this *NetServer) Send(p *peer.Peer, msg types.Message, isConsensus bool) e
 		}
 		return p.Send(msg, isConsensus)
 	}
-	log.Error("send to a invalid peer")
+	logger.Error("send to a invalid peer")
 	return errors.New("send to a invalid peer")
 }
 
@@ -283,7 +294,7 @@ func (this *NetServer) IsPeerEstablished(p *peer.Peer) bool {
 //Connect used to connect net address under sync or cons mode
 func (this *NetServer) Connect(addr string, isConsensus bool) error {
 	if this.IsAddrInOutConnRecord(addr) {
-		log.Error("Addr is in OutConnectionRecord")
+		logger.Error("Addr is in OutConnectionRecord")
 		return nil
 	}
 	if this.IsOwnAddress(addr) {
@@ -295,9 +306,10 @@ func (this *NetServer) Connect(addr string, isConsensus bool) error {
 
 	this.connectLock.Lock()
 	connCount := uint(this.GetOutConnRecordLen())
-	if connCount >= config.DefConfig.P2PNode.MaxConnOutBound {
-		log.Warnf("Connect: out connections(%d) reach the max limit(%d)", connCount,
-			config.DefConfig.P2PNode.MaxConnOutBound)
+	maxConnOutBound := config.DefConfig.GetMaxConnOutBound()
+	if connCount >= maxConnOutBound {
+		logger.Warnf("Connect: out connections(%d) reach the max limit(%d)", connCount,
+			maxConnOutBound)
 		this.connectLock.Unlock()
 		return errors.New("connect: out connections reach the max limit")
 	}
@@ -311,7 +323,7 @@ func (this *NetServer) Connect(addr string, isConsensus bool) error {
 		p := this.GetPeerFromAddr(addr)
 		if p != nil {
 			if p.SyncLink.Valid() {
-				log.Info("node exist in connecting list", addr)
+				logger.Info("node exist in connecting list", addr)
 				this.connectLock.Unlock()
 				return errors.New("node exist in connecting list")
 			}
@@ -328,20 +340,20 @@ func (this *NetServer) Connect(addr string, isConsensus bool) error {
 		conn, err = TLSDial(addr)
 		if err != nil {
 			this.RemoveFromConnectingList(addr)
-			log.Debug("connect failed: ", err)
+			logger.Debug("connect failed: ", err)
 			return err
 		}
 	} else {
 		conn, err = nonTLSDial(addr)
 		if err != nil {
 			this.RemoveFromConnectingList(addr)
-			log.Debug("connect failed: ", err)
+			logger.Debug("connect failed: ", err)
 			return err
 		}
 	}
 
 	addr = conn.RemoteAddr().String()
-	log.Info(fmt.Sprintf("peer %s connect with %s with %s",
+	logger.Info(fmt.Sprintf("peer %s connect with %s with %s",
 		conn.LocalAddr().String(), conn.RemoteAddr().String(),
 		conn.RemoteAddr().Network()))
 
@@ -370,7 +382,7 @@ func (this *NetServer) Connect(addr string, isConsensus bool) error {
 		if !isConsensus {
 			this.RemoveFromOutConnRecord(addr)
 		}
-		log.Error(err)
+		logger.Error(err)
 		return err
 	}
 	return nil
@@ -399,24 +411,24 @@ func (this *NetServer) startListening() error {
 	consPort := this.base.GetConsPort()
 
 	if syncPort == 0 {
-		log.Error("sync port invalid")
+		logger.Error("sync port invalid")
 		return errors.New("sync port invalid")
 	}
 
 	err = this.startSyncListening(syncPort)
 	if err != nil {
-		log.Error("start sync listening fail")
+		logger.Error("start sync listening fail")
 		return err
 	}
 
 	//consensus
 	if config.DefConfig.P2PNode.DualPortSupport == false {
-		log.Info("dual port mode not supported,keep single link")
+		logger.Info("dual port mode not supported,keep single link")
 		return nil
 	}
 	if consPort == 0 || consPort == syncPort {
 		//still work
-		log.Error("consensus port invalid,keep single link")
+		logger.Error("consensus port invalid,keep single link")
 	} else {
 		err = this.startConsListening(consPort)
 		if err != nil {
@@ -431,12 +443,12 @@ func (this *NetServer) startSyncListening(port uint16) error {
 	var err error
 	this.synclistener, err = createListener(port)
 	if err != nil {
-		log.Error("failed to create sync listener")
+		logger.Error("failed to create sync listener")
 		return errors.New("failed to create sync listener")
 	}
 
 	go this.startSyncAccept(this.synclistener)
-	log.Infof("start listen on sync port %d", port)
+	logger.Infof("start listen on sync port %d", port)
 	return nil
 }
 
@@ -445,12 +457,12 @@ func (this *NetServer) startConsListening(port uint16) error {
 	var err error
 	this.conslistener, err = createListener(port)
 	if err != nil {
-		log.Error("failed to create cons listener")
+		logger.Error("failed to create cons listener")
 		return errors.New("failed to create cons listener")
 	}
 
 	go this.startConsAccept(this.conslistener)
-	log.Infof("Start listen on consensus port %d", port)
+	logger.Infof("Start listen on consensus port %d", port)
 	return nil
 }
 
@@ -459,15 +471,15 @@ func (this *NetServer) startSyncAccept(listener net.Listener) {
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Error("error accepting ", err.Error())
+			logger.Error("error accepting ", err.Error())
 			return
 		}
 		if !this.AddrValid(conn.RemoteAddr().String()) {
-			log.Warnf("remote %s not in reserved list, close it ", conn.RemoteAddr())
+			logger.Warnf("remote %s not in reserved list, close it ", conn.RemoteAddr())
 			conn.Close()
 			continue
 		}
-		log.Info("remote sync node connect with ",
+		logger.Info("remote sync node connect with ",
 			conn.RemoteAddr(), conn.LocalAddr())
 
 		if this.IsAddrInInConnRecord(conn.RemoteAddr().String()) {
@@ -476,23 +488,25 @@ func (this *NetServer) startSyncAccept(listener net.Listener) {
 		}
 
 		syncAddrCount := uint(this.GetInConnRecordLen())
-		if syncAddrCount >= config.DefConfig.P2PNode.MaxConnInBound {
-			log.Warnf("SyncAccept: total connections(%d) reach the max limit(%d), conn closed",
-				syncAddrCount, config.DefConfig.P2PNode.MaxConnInBound)
+		maxConnInBound := config.DefConfig.GetMaxConnInBound()
+		if syncAddrCount >= maxConnInBound {
+			logger.Warnf("SyncAccept: total connections(%d) reach the max limit(%d), conn closed",
+				syncAddrCount, maxConnInBound)
 			conn.Close()
 			continue
 		}
 
 		remoteIp, err := common.ParseIPAddr(conn.RemoteAddr().String())
 		if err != nil {
-			log.Error("parse ip error ", err.Error())
+			logger.Error("parse ip error ", err.Error())
 			conn.Close()
 			continue
 		}
 		connNum := this.GetIpCountInInConnRecord(remoteIp)
-		if connNum >= config.DefConfig.P2PNode.MaxConnInBoundForSingleIP {
-			log.Warnf("SyncAccept: connections(%d) with ip(%s) has reach the max limit(%d), "+
-				"conn closed", connNum, remoteIp, config.DefConfig.P2PNode.MaxConnInBoundForSingleIP)
+		maxConnForSingleIP := config.DefConfig.GetMaxConnInBoundForSingleIP()
+		if connNum >= maxConnForSingleIP {
+			logger.Warnf("SyncAccept: connections(%d) with ip(%s) has reach the max limit(%d), "+
+				"conn closed", connNum, remoteIp, maxConnForSingleIP)
 			conn.Close()
 			continue
 		}
@@ -515,20 +529,20 @@ func (this *NetServer) startConsAccept(listener net.Listener) {
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Error("error accepting ", err.Error())
+			logger.Error("error accepting ", err.Error())
 			return
 		}
 		if !this.AddrValid(conn.RemoteAddr().String()) {
-			log.Warnf("remote %s not in reserved list, close it ", conn.RemoteAddr())
+			logger.Warnf("remote %s not in reserved list, close it ", conn.RemoteAddr())
 			conn.Close()
 			continue
 		}
-		log.Info("remote cons node connect with ",
+		logger.Info("remote cons node connect with ",
 			conn.RemoteAddr(), conn.LocalAddr())
 
 		remoteIp, err := common.ParseIPAddr(conn.RemoteAddr().String())
 		if err != nil {
-			log.Error("parse ip error ", err.Error())
+			logger.Error("parse ip error ", err.Error())
 			conn.Close()
 			continue
 		}
@@ -789,10 +803,11 @@ func (this *NetServer) GetOutConnRecordLen() int {
 
 //AddrValid whether the addr could be connect or accept
 func (this *NetServer) AddrValid(addr string) bool {
-	if config.DefConfig.P2PNode.ReservedPeersOnly && len(config.DefConfig.P2PNode.ReservedCfg.ReservedPeers) > 0 {
-		for _, ip := range config.DefConfig.P2PNode.ReservedCfg.ReservedPeers {
+	reservedOnly, reservedCfg := config.DefConfig.GetReservedCfg()
+	if reservedOnly && len(reservedCfg.ReservedPeers) > 0 {
+		for _, ip := range reservedCfg.ReservedPeers {
 			if strings.HasPrefix(addr, ip) {
-				log.Info("found reserved peer :", addr)
+				logger.Info("found reserved peer :", addr)
 				return true
 			}
 		}
@@ -812,7 +827,7 @@ func (this *NetServer) IsOwnAddress(addr string) bool {
 //Set own network address
 func (this *NetServer) SetOwnAddress(addr string) {
 	if addr != this.OwnAddress {
-		log.Infof("set own address %s", addr)
+		logger.Infof("set own address %s", addr)
 		this.OwnAddress = addr
 	}
 
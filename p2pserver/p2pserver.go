@@ -50,6 +50,7 @@ import (
 	comm "github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	"github.com/imZhuFei/zeepin/core/ledger"
 	"github.com/imZhuFei/zeepin/core/types"
 	"github.com/imZhuFei/zeepin/p2pserver/common"
@@ -62,6 +63,8 @@ import (
 	evtActor "github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 //P2PServer control all network activities
 type P2PServer struct {
 	network   p2pnet.P2P
@@ -117,6 +120,7 @@ func (this *P2PServer) Start() error {
 	} else {
 		return errors.New("p2p msg router invalid")
 	}
+	metrics.SetPeerSource(this.GetConnectionCnt)
 	this.tryRecentPeers()
 	go this.connectSeedService()
 	go this.syncUpRecentPeers()
@@ -158,31 +162,31 @@ func (this *P2PServer) GetNeighborAddrs() []common.PeerAddr {
 
 //Xmit called by other module to broadcast msg
 func (this *P2PServer) Xmit(message interface{}) error {
-	log.Debug()
+	logger.Debug()
 	var msg msgtypes.Message
 	isConsensus := false
 	switch message.(type) {
 	case *types.Transaction:
-		log.Debug("TX transaction message")
+		logger.Debug("TX transaction message")
 		txn := message.(*types.Transaction)
 		msg = msgpack.NewTxn(txn)
 	case *types.Block:
-		log.Debug("TX block message")
+		logger.Debug("TX block message")
 		block := message.(*types.Block)
 		msg = msgpack.NewBlock(block)
 	case *msgtypes.ConsensusPayload:
-		log.Debug("TX consensus message")
+		logger.Debug("TX consensus message")
 		consensusPayload := message.(*msgtypes.ConsensusPayload)
 		msg = msgpack.NewConsensus(consensusPayload)
 		isConsensus = true
 	case comm.Uint256:
-		log.Debug("TX block hash message")
+		logger.Debug("TX block hash message")
 		hash := message.(comm.Uint256)
 		// construct inv message
 		invPayload := msgpack.NewInvPayload(comm.BLOCK, []comm.Uint256{hash})
 		msg = msgpack.NewInv(invPayload)
 	default:
-		log.Warnf("Unknown Xmit message %v , type %v", message,
+		logger.Warnf("Unknown Xmit message %v , type %v", message,
 			reflect.TypeOf(message))
 		return errors.New("Unknown Xmit message type")
 	}
@@ -196,7 +200,7 @@ func (this *P2PServer) Send(p *peer.Peer, msg msgtypes.Message,
 	if this.network.IsPeerEstablished(p) {
 		return this.network.Send(p, msg, isConsensus)
 	}
-	log.Errorf("P2PServer send to a not ESTABLISH peer %d",
+	logger.Errorf("P2PServer send to a not ESTABLISH peer %d",
 		p.GetID())
 	return errors.New("send to a not ESTABLISH peer")
 }
@@ -226,6 +230,11 @@ func (this *P2PServer) OnBlockReceive(fromID uint64, blockSize uint32, block *ty
 	this.blockSync.OnBlockReceive(fromID, blockSize, block)
 }
 
+// GetSyncStatus returns the block sync progress
+func (this *P2PServer) GetSyncStatus() *common.SyncStatus {
+	return this.blockSync.GetSyncStatus()
+}
+
 // Todo: remove it if no use
 func (this *P2PServer) GetConnectionState() uint32 {
 	return common.INIT
@@ -274,7 +283,7 @@ func (this *P2PServer) WaitForSyncBlkFinish() {
 	for {
 		headerHeight := this.ledger.GetCurrentHeaderHeight()
 		currentBlkHeight := this.ledger.GetCurrentBlockHeight()
-		log.Info("WaitForSyncBlkFinish... current block height is ",
+		logger.Info("WaitForSyncBlkFinish... current block height is ",
 			currentBlkHeight, " ,current header height is ", headerHeight)
 
 		if this.blockSyncFinished() {
@@ -289,7 +298,7 @@ func (this *P2PServer) WaitForSyncBlkFinish() {
 func (this *P2PServer) WaitForPeersStart() {
 	periodTime := config.DEFAULT_GEN_BLOCK_TIME / common.UPDATE_RATE_PER_BLOCK
 	for {
-		log.Info("Wait for minimum connection...")
+		logger.Info("Wait for minimum connection...")
 		if this.reachMinConnection() {
 			break
 		}
@@ -305,17 +314,17 @@ func (this *P2PServer) connectSeeds() {
 	for _, n := range config.DefConfig.Genesis.SeedList {
 		ip, err := common.ParseIPAddr(n)
 		if err != nil {
-			log.Warnf("seed peer %s address format is wrong", n)
+			logger.Warnf("seed peer %s address format is wrong", n)
 			continue
 		}
 		ns, err := net.LookupHost(ip)
 		if err != nil {
-			log.Warnf("resolve err: %s", err.Error())
+			logger.Warnf("resolve err: %s", err.Error())
 			continue
 		}
 		port, err := common.ParseIPPort(n)
 		if err != nil {
-			log.Warnf("seed peer %s address format is wrong", n)
+			logger.Warnf("seed peer %s address format is wrong", n)
 			continue
 		}
 		seedNodes = append(seedNodes, ns[0]+port)
@@ -382,7 +391,7 @@ func (this *P2PServer) retryInactivePeer() {
 		nodeAddr := ip.To16().String() + ":" +
 			strconv.Itoa(int(p.GetSyncPort()))
 		if p.GetSyncState() == common.INACTIVITY {
-			log.Infof(" try reconnect %s", nodeAddr)
+			logger.Infof(" try reconnect %s", nodeAddr)
 			//add addr to retry list
 			this.addToRetryList(nodeAddr)
 			p.CloseSync()
@@ -398,9 +407,10 @@ func (this *P2PServer) retryInactivePeer() {
 	np.Unlock()
 
 	connCount := uint(this.network.GetOutConnRecordLen())
-	if connCount >= config.DefConfig.P2PNode.MaxConnOutBound {
-		log.Warnf("Connect: out connections(%d) reach the max limit(%d)", connCount,
-			config.DefConfig.P2PNode.MaxConnOutBound)
+	maxConnOutBound := config.DefConfig.GetMaxConnOutBound()
+	if connCount >= maxConnOutBound {
+		logger.Warnf("Connect: out connections(%d) reach the max limit(%d)", connCount,
+			maxConnOutBound)
 		return
 	}
 
@@ -436,9 +446,9 @@ func (this *P2PServer) retryInactivePeer() {
 		this.ReconnectAddrs.Unlock()
 		for _, addr := range addrs {
 			rand.Seed(time.Now().UnixNano())
-			log.Info("Try to reconnect peer, peer addr is ", addr)
+			logger.Info("Try to reconnect peer, peer addr is ", addr)
 			<-time.After(time.Duration(rand.Intn(common.CONN_MAX_BACK)) * time.Millisecond)
-			log.Info("Back off time`s up, start connect node")
+			logger.Info("Back off time`s up, start connect node")
 			this.network.Connect(addr, false)
 		}
 
@@ -532,7 +542,7 @@ func (this *P2PServer) timeout() {
 			t := p.GetContactTime()
 			if t.Before(time.Now().Add(-1 * time.Second *
 				time.Duration(periodTime) * common.KEEPALIVE_TIMEOUT)) {
-				log.Warnf("keep alive timeout!!!lost remote peer %d - %s from %s", p.GetID(), p.SyncLink.GetAddr(), t.String())
+				logger.Warnf("keep alive timeout!!!lost remote peer %d - %s from %s", p.GetID(), p.SyncLink.GetAddr(), t.String())
 				p.CloseSync()
 				p.CloseCons()
 			}
@@ -571,17 +581,17 @@ func (this *P2PServer) tryRecentPeers() {
 	if comm.FileExisted(common.RECENT_FILE_NAME) {
 		buf, err := ioutil.ReadFile(common.RECENT_FILE_NAME)
 		if err != nil {
-			log.Error("read %s fail:%s, connect recent peers cancel", common.RECENT_FILE_NAME, err.Error())
+			logger.Error("read %s fail:%s, connect recent peers cancel", common.RECENT_FILE_NAME, err.Error())
 			return
 		}
 
 		err = json.Unmarshal(buf, &this.recentPeers)
 		if err != nil {
-			log.Error("parse recent peer file fail: ", err)
+			logger.Error("parse recent peer file fail: ", err)
 			return
 		}
 		if len(this.recentPeers[netID]) > 0 {
-			log.Info("try to connect recent peer")
+			logger.Info("try to connect recent peer")
 		}
 		for _, v := range this.recentPeers[netID] {
 			go this.network.Connect(v, false)
@@ -656,12 +666,12 @@ func (this *P2PServer) syncPeerAddr() {
 	if changed {
 		buf, err := json.Marshal(this.recentPeers)
 		if err != nil {
-			log.Error("package recent peer fail: ", err)
+			logger.Error("package recent peer fail: ", err)
 			return
 		}
 		err = ioutil.WriteFile(common.RECENT_FILE_NAME, buf, os.ModePerm)
 		if err != nil {
-			log.Error("write recent peer fail: ", err)
+			logger.Error("write recent peer fail: ", err)
 		}
 	}
 }
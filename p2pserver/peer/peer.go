@@ -49,6 +49,8 @@ import (
 	"github.com/imZhuFei/zeepin/p2pserver/message/types"
 )
 
+var logger = log.Module(log.MODULE_P2P)
+
 // PeerCom provides the basic information of a peer
 type PeerCom struct {
 	id           uint64
@@ -168,23 +170,23 @@ func NewPeer() *Peer {
 
 //rmPeer print a debug log when peer be finalized by system
 func rmPeer(p *Peer) {
-	log.Debug(fmt.Sprintf("Remove unused peer: %d", p.GetID()))
+	logger.Debug(fmt.Sprintf("Remove unused peer: %d", p.GetID()))
 }
 
 //DumpInfo print all information of peer
 func (this *Peer) DumpInfo() {
-	log.Info("Node info:")
-	log.Info("\t syncState = ", this.syncState)
-	log.Info("\t consState = ", this.consState)
-	log.Info("\t id = ", this.GetID())
-	log.Info("\t addr = ", this.SyncLink.GetAddr())
-	log.Info("\t cap = ", this.cap)
-	log.Info("\t version = ", this.GetVersion())
-	log.Info("\t services = ", this.GetServices())
-	log.Info("\t syncPort = ", this.GetSyncPort())
-	log.Info("\t consPort = ", this.GetConsPort())
-	log.Info("\t relay = ", this.GetRelay())
-	log.Info("\t height = ", this.GetHeight())
+	logger.Info("Node info:")
+	logger.Info("\t syncState = ", this.syncState)
+	logger.Info("\t consState = ", this.consState)
+	logger.Info("\t id = ", this.GetID())
+	logger.Info("\t addr = ", this.SyncLink.GetAddr())
+	logger.Info("\t cap = ", this.cap)
+	logger.Info("\t version = ", this.GetVersion())
+	logger.Info("\t services = ", this.GetServices())
+	logger.Info("\t syncPort = ", this.GetSyncPort())
+	logger.Info("\t consPort = ", this.GetConsPort())
+	logger.Info("\t relay = ", this.GetRelay())
+	logger.Info("\t height = ", this.GetHeight())
 }
 
 //GetVersion return peer`s version
@@ -325,7 +327,7 @@ func (this *Peer) GetAddr16() ([16]byte, error) {
 	}
 	ip := net.ParseIP(addrIp).To16()
 	if ip == nil {
-		log.Error("parse ip address error\n", this.GetAddr())
+		logger.Error("parse ip address error\n", this.GetAddr())
 		return result, errors.New("parse ip address error")
 	}
 
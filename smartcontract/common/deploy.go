@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/core/payload"
+	"github.com/imZhuFei/zeepin/embed/simulator"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/wasm"
+)
+
+// VmTypeCheckEnabled reports whether the vm type of deployed contracts is
+// recorded and enforced at height
+func VmTypeCheckEnabled(height uint32) bool {
+	return height >= config.GetVmTypeCheckHeight(config.DefConfig.P2PNode.NetworkId)
+}
+
+// InferVmType guesses the vm type of code deployed without one, wasm modules
+// start with the wasm magic number
+func InferVmType(code []byte) payload.VmType {
+	if len(code) >= 4 && binary.LittleEndian.Uint32(code[:4]) == wasm.Magic {
+		return payload.WasmVm
+	}
+	return payload.EmbedVm
+}
+
+// ValidateDeployCode checks that the code of deploy can run on its vm type
+func ValidateDeployCode(deploy *payload.DeployCode) error {
+	switch deploy.VmType {
+	case payload.EmbedVm:
+		return simulator.ValidateScript(deploy.Code)
+	case payload.WasmVm:
+		return exec.VerifyWasmCode(deploy.Code)
+	default:
+		return fmt.Errorf("[ValidateDeployCode] unsupported vm type %s", deploy.VmType)
+	}
+}
+
+// EventAbiEnabled reports whether contracts may be deployed with an event abi at height
+func EventAbiEnabled(height uint32) bool {
+	return height >= config.GetEventAbiHeight(config.DefConfig.P2PNode.NetworkId)
+}
+
+// PrepareDeployCode returns the DeployCode to store for a deployment at height:
+// before the check height the payload is kept as is, afterwards the vm type is
+// inferred when missing and the code is validated against it
+func PrepareDeployCode(deploy *payload.DeployCode, height uint32) (*payload.DeployCode, error) {
+	if len(deploy.EventAbi) != 0 {
+		if !EventAbiEnabled(height) {
+			return nil, fmt.Errorf("[PrepareDeployCode] event abi is not supported before height %d",
+				config.GetEventAbiHeight(config.DefConfig.P2PNode.NetworkId))
+		}
+		if _, err := event.ParseEventAbi(deploy.EventAbi); err != nil {
+			return nil, fmt.Errorf("[PrepareDeployCode] %v", err)
+		}
+	}
+	if !VmTypeCheckEnabled(height) {
+		if deploy.VmType != payload.LegacyVm {
+			return nil, fmt.Errorf("[PrepareDeployCode] vm type is not supported before height %d",
+				config.GetVmTypeCheckHeight(config.DefConfig.P2PNode.NetworkId))
+		}
+		return deploy, nil
+	}
+	contract := *deploy
+	if contract.VmType == payload.LegacyVm {
+		contract.VmType = InferVmType(contract.Code)
+	}
+	if err := ValidateDeployCode(&contract); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
@@ -55,6 +55,7 @@ type ContextRef interface {
 	PushNotifications(notifications []*event.NotifyEventInfo)
 	NewExecuteEngine(code []byte) (Engine, error)
 	CheckUseGas(gas uint64) bool
+	GasLeft() uint64
 	CheckExecStep() bool
 }
 
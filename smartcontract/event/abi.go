@@ -0,0 +1,293 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package event
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/imZhuFei/zeepin/common"
+)
+
+// MAX_EVENT_ABI_SIZE limits the event abi a contract may be deployed with
+const MAX_EVENT_ABI_SIZE = 64 * 1024
+
+// EventFormat is how a vm encodes the states of its notifications
+type EventFormat byte
+
+const (
+	// native contracts notify plain go values
+	EVENT_FORMAT_NATIVE EventFormat = iota
+	// embed contracts notify stack items converted to hex strings
+	EVENT_FORMAT_EMBED
+	// wasm contracts notify one string, decoded when it is a json array
+	EVENT_FORMAT_WASM
+)
+
+// parameter types understood by the decoder, compared case-insensitively so
+// both the native and the embed abi spellings are accepted
+const (
+	EVENT_PARAM_TYPE_STRING    = "string"
+	EVENT_PARAM_TYPE_INT       = "int"
+	EVENT_PARAM_TYPE_INTEGER   = "integer"
+	EVENT_PARAM_TYPE_BOOL      = "bool"
+	EVENT_PARAM_TYPE_BOOLEAN   = "boolean"
+	EVENT_PARAM_TYPE_ADDRESS   = "address"
+	EVENT_PARAM_TYPE_BYTEARRAY = "bytearray"
+	EVENT_PARAM_TYPE_ARRAY     = "array"
+	EVENT_PARAM_TYPE_STRUCT    = "struct"
+)
+
+// EventAbi is the events part of a contract abi, any other member of the json
+// such as the functions is ignored
+type EventAbi struct {
+	Events []*EventDef `json:"events"`
+}
+
+type EventDef struct {
+	Name       string        `json:"name"`
+	Parameters []*EventParam `json:"parameters"`
+}
+
+type EventParam struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	SubType []*EventParam `json:"subType,omitempty"`
+}
+
+// DecodedEvent is a notification decoded against the abi of its contract
+type DecodedEvent struct {
+	Name   string
+	Fields []*DecodedField
+}
+
+type DecodedField struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// ParseEventAbi parses and checks the json abi of a contract
+func ParseEventAbi(data []byte) (*EventAbi, error) {
+	if len(data) > MAX_EVENT_ABI_SIZE {
+		return nil, fmt.Errorf("event abi size %d exceeds %d", len(data), MAX_EVENT_ABI_SIZE)
+	}
+	abi := new(EventAbi)
+	if err := json.Unmarshal(data, abi); err != nil {
+		return nil, fmt.Errorf("invalid event abi: %v", err)
+	}
+	names := make(map[string]bool, len(abi.Events))
+	for _, evt := range abi.Events {
+		if evt == nil || evt.Name == "" {
+			return nil, fmt.Errorf("invalid event abi: event without name")
+		}
+		name := strings.ToLower(evt.Name)
+		if names[name] {
+			return nil, fmt.Errorf("invalid event abi: duplicate event %s", evt.Name)
+		}
+		names[name] = true
+		for _, param := range evt.Parameters {
+			if param == nil || param.Name == "" {
+				return nil, fmt.Errorf("invalid event abi: parameter without name in event %s", evt.Name)
+			}
+		}
+	}
+	return abi, nil
+}
+
+func (this *EventAbi) GetEvent(name string) *EventDef {
+	name = strings.ToLower(name)
+	for _, evt := range this.Events {
+		if strings.ToLower(evt.Name) == name {
+			return evt
+		}
+	}
+	return nil
+}
+
+// Decode names and types the states of a notification, the first state is the
+// event name and the others are its parameters in abi order
+func (this *EventAbi) Decode(format EventFormat, states interface{}) (*DecodedEvent, error) {
+	items := stateItems(format, states)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("notification has no event name")
+	}
+	name, ok := items[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("event name is %T, not string", items[0])
+	}
+	if format == EVENT_FORMAT_EMBED {
+		buf, err := hex.DecodeString(name)
+		if err != nil {
+			return nil, fmt.Errorf("event name %s is not hex", name)
+		}
+		name = string(buf)
+	}
+	evt := this.GetEvent(name)
+	if evt == nil {
+		return nil, fmt.Errorf("event %s is not in the abi", name)
+	}
+	if len(items)-1 != len(evt.Parameters) {
+		return nil, fmt.Errorf("event %s has %d parameters, abi expects %d", name, len(items)-1, len(evt.Parameters))
+	}
+	decoded := &DecodedEvent{Name: evt.Name, Fields: make([]*DecodedField, 0, len(evt.Parameters))}
+	for i, param := range evt.Parameters {
+		value, err := decodeValue(format, param, items[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("event %s parameter %s: %v", name, param.Name, err)
+		}
+		decoded.Fields = append(decoded.Fields, &DecodedField{Name: param.Name, Type: param.Type, Value: value})
+	}
+	return decoded, nil
+}
+
+func stateItems(format EventFormat, states interface{}) []interface{} {
+	var items []interface{}
+	switch v := states.(type) {
+	case []interface{}:
+		items = v
+	case []string:
+		for _, s := range v {
+			items = append(items, s)
+		}
+	default:
+		items = []interface{}{v}
+	}
+	if format == EVENT_FORMAT_WASM && len(items) == 1 {
+		if s, ok := items[0].(string); ok {
+			var arr []interface{}
+			if json.Unmarshal([]byte(s), &arr) == nil {
+				return arr
+			}
+		}
+	}
+	return items
+}
+
+func decodeValue(format EventFormat, param *EventParam, value interface{}) (interface{}, error) {
+	typ := strings.ToLower(param.Type)
+	if typ == EVENT_PARAM_TYPE_ARRAY || typ == EVENT_PARAM_TYPE_STRUCT {
+		return decodeList(format, param, value)
+	}
+	if format != EVENT_FORMAT_EMBED {
+		return decodePlain(typ, value)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("value is %T, not hex string", value)
+	}
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("value %s is not hex", s)
+	}
+	switch typ {
+	case EVENT_PARAM_TYPE_STRING:
+		return string(buf), nil
+	case EVENT_PARAM_TYPE_INT, EVENT_PARAM_TYPE_INTEGER:
+		return common.BigIntFromEmbeddedBytes(buf), nil
+	case EVENT_PARAM_TYPE_BOOL, EVENT_PARAM_TYPE_BOOLEAN:
+		for _, b := range buf {
+			if b != 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case EVENT_PARAM_TYPE_ADDRESS:
+		addr, err := common.AddressParseFromBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+		return addr.ToBase58(), nil
+	}
+	return s, nil
+}
+
+//native and wasm values are already readable, only numbers are normalized
+func decodePlain(typ string, value interface{}) (interface{}, error) {
+	if typ != EVENT_PARAM_TYPE_INT && typ != EVENT_PARAM_TYPE_INTEGER {
+		return value, nil
+	}
+	switch v := value.(type) {
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint32:
+		return big.NewInt(int64(v)), nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case float64:
+		i, _ := big.NewFloat(v).Int(nil)
+		return i, nil
+	case string:
+		i, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("value %q is not an integer", v)
+		}
+		return i, nil
+	case *big.Int:
+		return v, nil
+	}
+	return nil, fmt.Errorf("value is %T, not integer", value)
+}
+
+//an array applies its only sub type to every element, a struct applies its sub
+//types in order, without sub types the elements are kept as notified
+func decodeList(format EventFormat, param *EventParam, value interface{}) (interface{}, error) {
+	list, ok := value.([]interface{})
+	if !ok || len(param.SubType) == 0 {
+		return value, nil
+	}
+	isArray := strings.ToLower(param.Type) == EVENT_PARAM_TYPE_ARRAY
+	if !isArray && len(param.SubType) != len(list) {
+		return nil, fmt.Errorf("struct has %d fields, abi expects %d", len(list), len(param.SubType))
+	}
+	ret := make([]interface{}, 0, len(list))
+	for i, item := range list {
+		sub := param.SubType[0]
+		if !isArray {
+			sub = param.SubType[i]
+		}
+		v, err := decodeValue(format, sub, item)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package event
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAbi = `{
+  "hash":"0900000000000000000000000000000000000000",
+  "functions":[],
+  "events":[
+    {
+      "name":"transfer",
+      "parameters":[
+        {"name":"from","type":"Address"},
+        {"name":"to","type":"Address"},
+        {"name":"amount","type":"Int"}
+      ]
+    },
+    {
+      "name":"Tagged",
+      "parameters":[
+        {"name":"tags","type":"Array","subType":[{"name":"tag","type":"String"}]},
+        {"name":"ok","type":"Boolean"}
+      ]
+    }
+  ]
+}`
+
+func hexStr(s string) string {
+	return hex.EncodeToString([]byte(s))
+}
+
+func TestParseEventAbi(t *testing.T) {
+	abi, err := ParseEventAbi([]byte(testAbi))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(abi.Events))
+	assert.NotNil(t, abi.GetEvent("TRANSFER"))
+	assert.Nil(t, abi.GetEvent("approve"))
+
+	_, err = ParseEventAbi([]byte(`{"events":[{"name":"a"},{"name":"A"}]}`))
+	assert.NotNil(t, err)
+	_, err = ParseEventAbi([]byte(`{"events":[{"name":"a","parameters":[{"type":"Int"}]}]}`))
+	assert.NotNil(t, err)
+	_, err = ParseEventAbi([]byte(`{"events":`))
+	assert.NotNil(t, err)
+	_, err = ParseEventAbi(make([]byte, MAX_EVENT_ABI_SIZE+1))
+	assert.NotNil(t, err)
+}
+
+func TestDecodeEmbedEvent(t *testing.T) {
+	abi, err := ParseEventAbi([]byte(testAbi))
+	assert.Nil(t, err)
+	from := common.Address{1}
+	to := common.Address{2}
+	states := []interface{}{hexStr("transfer"), hex.EncodeToString(from[:]), hex.EncodeToString(to[:]),
+		hex.EncodeToString(common.BigIntToEmbededBytes(big.NewInt(-300)))}
+
+	decoded, err := abi.Decode(EVENT_FORMAT_EMBED, states)
+	assert.Nil(t, err)
+	assert.Equal(t, "transfer", decoded.Name)
+	assert.Equal(t, 3, len(decoded.Fields))
+	assert.Equal(t, "from", decoded.Fields[0].Name)
+	assert.Equal(t, from.ToBase58(), decoded.Fields[0].Value)
+	assert.Equal(t, to.ToBase58(), decoded.Fields[1].Value)
+	assert.Equal(t, big.NewInt(-300), decoded.Fields[2].Value)
+
+	states = []interface{}{hexStr("tagged"), []interface{}{hexStr("a"), hexStr("b")}, "01"}
+	decoded, err = abi.Decode(EVENT_FORMAT_EMBED, states)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tagged", decoded.Name)
+	assert.Equal(t, []interface{}{"a", "b"}, decoded.Fields[0].Value)
+	assert.Equal(t, true, decoded.Fields[1].Value)
+
+	_, err = abi.Decode(EVENT_FORMAT_EMBED, []interface{}{hexStr("transfer"), "01"})
+	assert.NotNil(t, err)
+	_, err = abi.Decode(EVENT_FORMAT_EMBED, []interface{}{hexStr("unknown")})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeNativeEvent(t *testing.T) {
+	abi, err := ParseEventAbi([]byte(testAbi))
+	assert.Nil(t, err)
+	fromAddr, toAddr := common.Address{1}, common.Address{2}
+	from, to := fromAddr.ToBase58(), toAddr.ToBase58()
+
+	decoded, err := abi.Decode(EVENT_FORMAT_NATIVE, []interface{}{"transfer", from, to, uint64(1) << 60})
+	assert.Nil(t, err)
+	assert.Equal(t, from, decoded.Fields[0].Value)
+	assert.Equal(t, new(big.Int).SetUint64(1<<60), decoded.Fields[2].Value)
+
+	//states read back from the event store are json values
+	decoded, err = abi.Decode(EVENT_FORMAT_NATIVE, []interface{}{"transfer", from, to, float64(100)})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(100), decoded.Fields[2].Value)
+}
+
+func TestDecodeWasmEvent(t *testing.T) {
+	abi, err := ParseEventAbi([]byte(testAbi))
+	assert.Nil(t, err)
+	fromAddr, toAddr := common.Address{1}, common.Address{2}
+	from, to := fromAddr.ToBase58(), toAddr.ToBase58()
+
+	decoded, err := abi.Decode(EVENT_FORMAT_WASM, []string{`["transfer","` + from + `","` + to + `","12345678901234567890"]`})
+	assert.Nil(t, err)
+	amount, _ := new(big.Int).SetString("12345678901234567890", 10)
+	assert.Equal(t, amount, decoded.Fields[2].Value)
+
+	_, err = abi.Decode(EVENT_FORMAT_WASM, []string{"not json"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,437 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+/*
+ * audit indexes
+ *
+ * RoleHolders.role lists every GID which has been assigned or delegated the
+ * role, Delegates.GID lists every GID which received a delegation from GID.
+ * Both are kept in sync on grant, delegate, withdraw and revoke; the queries
+ * still check the underlying tokens so that expired entries are not reported.
+ */
+func addRoleHolder(native *native.NativeService, contractAddr common.Address, role, GID []byte) error {
+	key := concatRoleHoldersKey(native, contractAddr, role)
+	holders, err := getGIDList(native, key)
+	if err != nil {
+		return fmt.Errorf("get role holders failed: %v", err)
+	}
+	if !holders.add(GID) {
+		return nil
+	}
+	return putGIDList(native, key, holders)
+}
+
+//remove GID from the role's holders if it has neither a token nor a delegation of role
+func syncRoleHolder(native *native.NativeService, contractAddr common.Address, role, GID []byte) error {
+	tokens, err := getGIDToken(native, contractAddr, GID)
+	if err != nil {
+		return fmt.Errorf("getGIDToken failed: %v", err)
+	}
+	if tokens != nil {
+		for _, token := range tokens.tokens {
+			if bytes.Equal(token.role, role) {
+				return nil
+			}
+		}
+	}
+	status, err := getDelegateStatus(native, contractAddr, GID)
+	if err != nil {
+		return fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		for _, s := range status.status {
+			if bytes.Equal(s.role, role) {
+				return nil
+			}
+		}
+	}
+	key := concatRoleHoldersKey(native, contractAddr, role)
+	holders, err := getGIDList(native, key)
+	if err != nil {
+		return fmt.Errorf("get role holders failed: %v", err)
+	}
+	if !holders.remove(GID) {
+		return nil
+	}
+	return putGIDList(native, key, holders)
+}
+
+func addDelegate(native *native.NativeService, contractAddr common.Address, root, GID []byte) error {
+	key := concatDelegatesKey(native, contractAddr, root)
+	delegates, err := getGIDList(native, key)
+	if err != nil {
+		return fmt.Errorf("get delegates failed: %v", err)
+	}
+	if !delegates.add(GID) {
+		return nil
+	}
+	return putGIDList(native, key, delegates)
+}
+
+//remove GID from root's delegates if it holds no delegation from root
+func syncDelegate(native *native.NativeService, contractAddr common.Address, root, GID []byte) error {
+	status, err := getDelegateStatus(native, contractAddr, GID)
+	if err != nil {
+		return fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		for _, s := range status.status {
+			if bytes.Equal(s.root, root) {
+				return nil
+			}
+		}
+	}
+	key := concatDelegatesKey(native, contractAddr, root)
+	delegates, err := getGIDList(native, key)
+	if err != nil {
+		return fmt.Errorf("get delegates failed: %v", err)
+	}
+	if !delegates.remove(GID) {
+		return nil
+	}
+	return putGIDList(native, key, delegates)
+}
+
+/*
+ * revoke role from GID: drop its permanent token and any delegation of
+ * role it holds, then withdraw the delegations of role it has granted.
+ */
+func revokeFrom(native *native.NativeService, contractAddr common.Address, GID, role []byte) (bool, error) {
+	revoked := false
+
+	tokens, err := getGIDToken(native, contractAddr, GID)
+	if err != nil {
+		return false, fmt.Errorf("getGIDToken failed: %v", err)
+	}
+	if tokens != nil {
+		for i, token := range tokens.tokens {
+			if bytes.Equal(token.role, role) {
+				tokens.tokens = append(tokens.tokens[:i], tokens.tokens[i+1:]...)
+				if err = putGIDToken(native, contractAddr, GID, tokens); err != nil {
+					return false, err
+				}
+				revoked = true
+				break
+			}
+		}
+	}
+
+	status, err := getDelegateStatus(native, contractAddr, GID)
+	if err != nil {
+		return false, fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		for i, s := range status.status {
+			if bytes.Equal(s.role, role) {
+				status.status = append(status.status[:i], status.status[i+1:]...)
+				if err = putDelegateStatus(native, contractAddr, GID, status); err != nil {
+					return false, err
+				}
+				if err = syncDelegate(native, contractAddr, s.root, GID); err != nil {
+					return false, err
+				}
+				revoked = true
+				break
+			}
+		}
+	}
+
+	delegates, err := getGIDList(native, concatDelegatesKey(native, contractAddr, GID))
+	if err != nil {
+		return false, fmt.Errorf("get delegates failed: %v", err)
+	}
+	for _, d := range delegates.gids {
+		ret, err := withdrawDelegation(native, contractAddr, GID, d, role)
+		if err != nil {
+			return false, err
+		}
+		if ret {
+			pushEvent(native, []interface{}{"roleRevoked", contractAddr.ToHexString(),
+				string(d), string(role)})
+		}
+	}
+
+	if err = syncRoleHolder(native, contractAddr, role, GID); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+//drop the delegation of role from root to GID without checking root's permission
+func withdrawDelegation(native *native.NativeService, contractAddr common.Address, root, GID, role []byte) (bool, error) {
+	status, err := getDelegateStatus(native, contractAddr, GID)
+	if err != nil {
+		return false, fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status == nil {
+		return false, nil
+	}
+	for i, s := range status.status {
+		if bytes.Equal(s.role, role) && bytes.Equal(s.root, root) {
+			status.status = append(status.status[:i], status.status[i+1:]...)
+			if err = putDelegateStatus(native, contractAddr, GID, status); err != nil {
+				return false, err
+			}
+			if err = syncRoleHolder(native, contractAddr, role, GID); err != nil {
+				return false, err
+			}
+			if err = syncDelegate(native, contractAddr, root, GID); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func revokeRole(native *native.NativeService, param *GIDsToRoleParam) (bool, error) {
+	valid, err := checkAdmin(native, param.ContractAddr, param.AdminGID, param.KeyNo)
+	if err != nil || !valid {
+		return false, err
+	}
+	for _, p := range param.Persons {
+		if p == nil {
+			continue
+		}
+		ret, err := revokeFrom(native, param.ContractAddr, p, param.Role)
+		if err != nil {
+			return false, fmt.Errorf("revoke role %s from %s failed: %v", string(param.Role),
+				string(p), err)
+		}
+		if ret {
+			pushEvent(native, []interface{}{"roleRevoked", param.ContractAddr.ToHexString(),
+				string(p), string(param.Role)})
+		}
+	}
+	return true, nil
+}
+
+func RevokeRole(native *native.NativeService) ([]byte, error) {
+	//deserialize param
+	param := new(GIDsToRoleParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[revokeRole] deserialize param failed: %v", err)
+	}
+
+	if param.Role == nil {
+		return nil, fmt.Errorf("[revokeRole] invalid param: role is nil")
+	}
+	for i, GID := range param.Persons {
+		if !account.VerifyID(string(GID)) {
+			return nil, fmt.Errorf("[revokeRole] invalid param: param.Persons[%d]=%s",
+				i, string(GID))
+		}
+	}
+
+	ret, err := revokeRole(native, param)
+	if err != nil {
+		return nil, fmt.Errorf("[revokeRole] failed: %v", err)
+	}
+
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"revokeRole", contract, false}
+	sucState := []interface{}{"revokeRole", contract, true}
+	if ret {
+		pushEvent(native, sucState)
+		return utils.BYTE_TRUE, nil
+	} else {
+		pushEvent(native, failState)
+		return utils.BYTE_FALSE, nil
+	}
+}
+
+//the unexpired grants of role held by GID, either assigned or delegated
+func liveGrants(native *native.NativeService, contractAddr common.Address, GID, role []byte) ([]*RoleGrant, error) {
+	grants := make([]*RoleGrant, 0)
+	tokens, err := getGIDToken(native, contractAddr, GID)
+	if err != nil {
+		return nil, fmt.Errorf("getGIDToken failed: %v", err)
+	}
+	if tokens != nil {
+		for _, token := range tokens.tokens {
+			if token.expireTime < native.Time || (role != nil && !bytes.Equal(token.role, role)) {
+				continue
+			}
+			grants = append(grants, &RoleGrant{
+				GID:        GID,
+				Role:       token.role,
+				ExpireTime: token.expireTime,
+				Level:      token.level,
+			})
+		}
+	}
+	status, err := getDelegateStatus(native, contractAddr, GID)
+	if err != nil {
+		return nil, fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		for _, s := range status.status {
+			if s.expireTime < native.Time || (role != nil && !bytes.Equal(s.role, role)) {
+				continue
+			}
+			grants = append(grants, &RoleGrant{
+				GID:        GID,
+				Role:       s.role,
+				Root:       s.root,
+				ExpireTime: s.expireTime,
+				Level:      s.level,
+			})
+		}
+	}
+	return grants, nil
+}
+
+func serializeGrants(grants []*RoleGrant) ([]byte, error) {
+	bf := new(bytes.Buffer)
+	ret := &RoleGrants{Grants: grants}
+	if err := ret.Serialize(bf); err != nil {
+		return nil, err
+	}
+	return bf.Bytes(), nil
+}
+
+func GetRoleHolders(native *native.NativeService) ([]byte, error) {
+	param := new(RoleQueryParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getRoleHolders] deserialize param failed: %v", err)
+	}
+	if param.Role == nil {
+		return nil, fmt.Errorf("[getRoleHolders] invalid param: role is nil")
+	}
+
+	holders, err := getGIDList(native, concatRoleHoldersKey(native, param.ContractAddr, param.Role))
+	if err != nil {
+		return nil, fmt.Errorf("[getRoleHolders] get role holders failed: %v", err)
+	}
+	grants := make([]*RoleGrant, 0)
+	for _, h := range holders.gids {
+		g, err := liveGrants(native, param.ContractAddr, h, param.Role)
+		if err != nil {
+			return nil, fmt.Errorf("[getRoleHolders] %v", err)
+		}
+		grants = append(grants, g...)
+	}
+	ret, err := serializeGrants(grants)
+	if err != nil {
+		return nil, fmt.Errorf("[getRoleHolders] serialize failed: %v", err)
+	}
+	return ret, nil
+}
+
+func GetRolesOfGID(native *native.NativeService) ([]byte, error) {
+	param := new(GIDQueryParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getRolesOfGID] deserialize param failed: %v", err)
+	}
+	if !account.VerifyID(string(param.GID)) {
+		return nil, fmt.Errorf("[getRolesOfGID] invalid param: GID is %x", param.GID)
+	}
+
+	grants, err := liveGrants(native, param.ContractAddr, param.GID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("[getRolesOfGID] %v", err)
+	}
+	ret, err := serializeGrants(grants)
+	if err != nil {
+		return nil, fmt.Errorf("[getRolesOfGID] serialize failed: %v", err)
+	}
+	return ret, nil
+}
+
+/*
+ * GetDelegations returns the delegations received by GID followed by the
+ * delegations granted by GID. Expired delegations are included so that the
+ * history stays visible until they are withdrawn or overwritten.
+ */
+func GetDelegations(native *native.NativeService) ([]byte, error) {
+	param := new(GIDQueryParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getDelegations] deserialize param failed: %v", err)
+	}
+	if !account.VerifyID(string(param.GID)) {
+		return nil, fmt.Errorf("[getDelegations] invalid param: GID is %x", param.GID)
+	}
+
+	grants := make([]*RoleGrant, 0)
+	status, err := getDelegateStatus(native, param.ContractAddr, param.GID)
+	if err != nil {
+		return nil, fmt.Errorf("[getDelegations] getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		for _, s := range status.status {
+			grants = append(grants, &RoleGrant{param.GID, s.role, s.root, s.expireTime, s.level})
+		}
+	}
+
+	delegates, err := getGIDList(native, concatDelegatesKey(native, param.ContractAddr, param.GID))
+	if err != nil {
+		return nil, fmt.Errorf("[getDelegations] get delegates failed: %v", err)
+	}
+	for _, d := range delegates.gids {
+		status, err := getDelegateStatus(native, param.ContractAddr, d)
+		if err != nil {
+			return nil, fmt.Errorf("[getDelegations] getDelegateStatus failed: %v", err)
+		}
+		if status == nil {
+			continue
+		}
+		for _, s := range status.status {
+			if bytes.Equal(s.root, param.GID) {
+				grants = append(grants, &RoleGrant{d, s.role, s.root, s.expireTime, s.level})
+			}
+		}
+	}
+
+	ret, err := serializeGrants(grants)
+	if err != nil {
+		return nil, fmt.Errorf("[getDelegations] serialize failed: %v", err)
+	}
+	return ret, nil
+}
@@ -42,6 +42,7 @@ import (
 
 	"github.com/imZhuFei/zeepin/account"
 	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/common/serialization"
 	"github.com/imZhuFei/zeepin/errors"
@@ -223,29 +224,36 @@ func AssignFuncsToRole(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
-func assignToRole(native *native.NativeService, param *GIDsToRoleParam) (bool, error) {
-	//check admin's permission
-	admin, err := getContractAdmin(native, param.ContractAddr)
+func checkAdmin(native *native.NativeService, contractAddr common.Address, adminGID []byte, keyNo uint64) (bool, error) {
+	admin, err := getContractAdmin(native, contractAddr)
 	if err != nil {
 		return false, fmt.Errorf("getContractAdmin failed: %v", err)
 	}
 	if admin == nil {
-		return false, fmt.Errorf("admin of contract %s is not set", param.ContractAddr.ToHexString())
+		return false, fmt.Errorf("admin of contract %s is not set", contractAddr.ToHexString())
 	}
-	if bytes.Compare(admin, param.AdminGID) != 0 {
-		log.Debugf("param's adminGID doesn't match: %s != %s", string(param.AdminGID),
+	if bytes.Compare(admin, adminGID) != 0 {
+		log.Debugf("param's adminGID doesn't match: %s != %s", string(adminGID),
 			string(admin))
 		return false, nil
 	}
-	valid, err := verifySig(native, param.AdminGID, param.KeyNo)
+	valid, err := verifySig(native, adminGID, keyNo)
 	if err != nil {
 		return false, fmt.Errorf("verify admin's signature failed: %v", err)
 	}
 	if !valid {
-		log.Debugf("[assignGIDsToRole] verifySig return false: adminGID=%s, keyNo=%d",
-			string(admin), param.KeyNo)
+		log.Debugf("verifySig return false: adminGID=%s, keyNo=%d", string(admin), keyNo)
 		return false, nil
 	}
+	return true, nil
+}
+
+func assignToRole(native *native.NativeService, param *GIDsToRoleParam) (bool, error) {
+	//check admin's permission
+	valid, err := checkAdmin(native, param.ContractAddr, param.AdminGID, param.KeyNo)
+	if err != nil || !valid {
+		return false, err
+	}
 
 	//init a permanent auth token
 	token := new(AuthToken)
@@ -281,6 +289,12 @@ func assignToRole(native *native.NativeService, param *GIDsToRoleParam) (bool, e
 		if err != nil {
 			return false, err
 		}
+		err = addRoleHolder(native, param.ContractAddr, param.Role, p)
+		if err != nil {
+			return false, err
+		}
+		pushEvent(native, []interface{}{"roleGranted", param.ContractAddr.ToHexString(),
+			string(p), string(param.Role)})
 	}
 	return true, nil
 }
@@ -381,6 +395,7 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 	var fromHasRole, toHasRole bool
 	var fromLevel uint8
 	var fromExpireTime uint32
+	var oldRoot []byte
 
 	//check input param
 	expireTime := native.Time
@@ -460,6 +475,7 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 				newStatus.level = uint8(level)
 				status.status = append(status.status, newStatus)
 			} else {
+				oldRoot = status.status[j].root
 				status.status[j].level = uint8(level)
 				status.status[j].expireTime = expireTime
 				status.status[j].root = from
@@ -468,6 +484,18 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 			if err != nil {
 				return false, fmt.Errorf("putDelegateStatus failed: %v", err)
 			}
+			//update the audit indexes
+			if err = addRoleHolder(native, contractAddr, role, to); err != nil {
+				return false, err
+			}
+			if err = addDelegate(native, contractAddr, from, to); err != nil {
+				return false, err
+			}
+			if oldRoot != nil && !bytes.Equal(oldRoot, from) {
+				if err = syncDelegate(native, contractAddr, oldRoot, to); err != nil {
+					return false, err
+				}
+			}
 			return true, nil
 		}
 	}
@@ -489,8 +517,8 @@ func Delegate(native *native.NativeService) ([]byte, error) {
 
 	//prepare event msg
 	contract := param.ContractAddr.ToHexString()
-	failState := []interface{}{"delegate", contract, param.From, param.To, false}
-	sucState := []interface{}{"delegate", contract, param.From, param.To, true}
+	failState := []interface{}{"delegate", contract, param.From, param.To, false, param.Role}
+	sucState := []interface{}{"delegate", contract, param.From, param.To, true, param.Role}
 
 	//call the delegate func
 	ret, err := delegate(native, param.ContractAddr, param.From, param.To, param.Role,
@@ -546,6 +574,12 @@ func withdraw(native *native.NativeService, contractAddr common.Address, initiat
 			if err != nil {
 				return false, err
 			}
+			if err = syncRoleHolder(native, contractAddr, role, delegate); err != nil {
+				return false, err
+			}
+			if err = syncDelegate(native, contractAddr, initiator, delegate); err != nil {
+				return false, err
+			}
 			return true, nil
 		}
 	}
@@ -563,8 +597,8 @@ func Withdraw(native *native.NativeService) ([]byte, error) {
 
 	//prepare event msg
 	contract := param.ContractAddr.ToHexString()
-	failState := []interface{}{"withdraw", contract, param.Initiator, param.Delegate, false}
-	sucState := []interface{}{"withdraw", contract, param.Initiator, param.Delegate, true}
+	failState := []interface{}{"withdraw", contract, param.Initiator, param.Delegate, false, param.Role}
+	sucState := []interface{}{"withdraw", contract, param.Initiator, param.Delegate, true, param.Role}
 
 	//call the withdraw func
 	ret, err := withdraw(native, param.ContractAddr, param.Initiator, param.Delegate, param.Role, param.KeyNo)
@@ -685,6 +719,36 @@ func verifySig(native *native.NativeService, GID []byte, keyNo uint64) (bool, er
 	}
 }
 
+//inactive returns the handler replacing a method before its fork height
+func inactive(height uint32) native.Handler {
+	return func(native *native.NativeService) ([]byte, error) {
+		return nil, fmt.Errorf("method is not active before height %d", height)
+	}
+}
+
+//registerFrom registers the methods, the ones invoked before height fail
+//but stay registered, the ServiceMap is shared by nested native calls
+func registerFrom(native *native.NativeService, services map[string]native.Handler, height uint32) {
+	for name, handler := range services {
+		if native.Height < height {
+			handler = inactive(height)
+		}
+		native.Register(name, handler)
+	}
+}
+
+var auditServices = map[string]native.Handler{
+	"revokeRole":     RevokeRole,
+	"getRoleHolders": GetRoleHolders,
+	"getRolesOfGID":  GetRolesOfGID,
+	"getDelegations": GetDelegations,
+}
+
+var protectServices = map[string]native.Handler{
+	"setProtectedMethods": SetProtectedMethods,
+	"getProtectedMethods": GetProtectedMethods,
+}
+
 func RegisterAuthContract(native *native.NativeService) {
 	native.Register("initContractAdmin", InitContractAdmin)
 	native.Register("assignFuncsToRole", AssignFuncsToRole)
@@ -693,4 +757,6 @@ func RegisterAuthContract(native *native.NativeService) {
 	native.Register("assignGIDsToRole", AssignGIDsToRole)
 	native.Register("verifyToken", VerifyToken)
 	native.Register("transfer", Transfer)
+	registerFrom(native, auditServices, config.GetAuthAuditHeight(config.DefConfig.P2PNode.NetworkId))
+	registerFrom(native, protectServices, config.GetProtectedMethodsHeight(config.DefConfig.P2PNode.NetworkId))
 }
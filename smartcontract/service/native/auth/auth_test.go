@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterAuditHeight(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	height := config.GetAuthAuditHeight(config.NETWORK_ID_MAIN_NET)
+	if height == 0 {
+		t.Skip("audit methods are active from genesis on mainnet")
+	}
+	service := &native.NativeService{ServiceMap: make(map[string]native.Handler), Height: height - 1}
+	RegisterAuthContract(service)
+	for name := range auditServices {
+		_, err := service.ServiceMap[name](service)
+		assert.NotNil(t, err)
+		assert.True(t, strings.Contains(err.Error(), "not active"), name)
+	}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	service = &native.NativeService{ServiceMap: make(map[string]native.Handler)}
+	RegisterAuthContract(service)
+	//an empty input fails in the method itself once it is active
+	_, err := service.ServiceMap["getRolesOfGID"](service)
+	assert.NotNil(t, err)
+	assert.False(t, strings.Contains(err.Error(), "not active"))
+}
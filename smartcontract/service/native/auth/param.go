@@ -374,3 +374,109 @@ func (this *VerifyTokenParam) Deserialize(rd io.Reader) error {
 	}
 	return nil
 }
+
+type RoleQueryParam struct {
+	ContractAddr common.Address
+	Role         []byte
+}
+
+func (this *RoleQueryParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *RoleQueryParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+type GIDQueryParam struct {
+	ContractAddr common.Address
+	GID          []byte
+}
+
+func (this *GIDQueryParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.GID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *GIDQueryParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.GID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+type ProtectedMethodsParam struct {
+	ContractAddr common.Address
+	AdminGID     []byte
+	FuncNames    []string
+	KeyNo        uint64
+}
+
+func (this *ProtectedMethodsParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.AdminGID); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, uint64(len(this.FuncNames))); err != nil {
+		return err
+	}
+	for _, fn := range this.FuncNames {
+		if err := serialization.WriteString(w, fn); err != nil {
+			return err
+		}
+	}
+	if err := utils.WriteVarUint(w, this.KeyNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *ProtectedMethodsParam) Deserialize(rd io.Reader) error {
+	var err error
+	var fnLen uint64
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.AdminGID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if fnLen, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	this.FuncNames = make([]string, 0)
+	for i := uint64(0); i < fnLen; i++ {
+		fn, err := serialization.ReadString(rd)
+		if err != nil {
+			return err
+		}
+		this.FuncNames = append(this.FuncNames, fn)
+	}
+	if this.KeyNo, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
@@ -202,3 +202,51 @@ func TestSerialization_VerifyToken(t *testing.T) {
 	}
 	assert.Equal(t, param, param2)
 }
+
+func TestSerialization_Queries(t *testing.T) {
+	param := &RoleQueryParam{
+		ContractAddr: ZptContractAddr,
+		Role:         []byte(role),
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(RoleQueryParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param, param2)
+
+	param3 := &GIDQueryParam{
+		ContractAddr: ZptContractAddr,
+		GID:          p1,
+	}
+	bf.Reset()
+	if err := param3.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param4 := new(GIDQueryParam)
+	if err := param4.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param3, param4)
+}
+
+func TestSerialization_ProtectedMethods(t *testing.T) {
+	param := &ProtectedMethodsParam{
+		ContractAddr: ZptContractAddr,
+		AdminGID:     admin,
+		FuncNames:    funcs,
+		KeyNo:        1,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(ProtectedMethodsParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param, param2)
+}
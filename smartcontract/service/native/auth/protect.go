@@ -0,0 +1,205 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+/*
+ * protected methods
+ *
+ * A contract which has an admin may mark some of its methods as protected,
+ * either from its own deployment code (the calling contract is the target
+ * contract) or later by the admin. The embed and wasm engines check the
+ * list before dispatching an invocation, and require the caller's GID and
+ * key number as the first two arguments of a protected method, which are
+ * then passed to verifyToken.
+ */
+func setProtectedMethods(native *native.NativeService, param *ProtectedMethodsParam) (bool, error) {
+	cxt := native.ContextRef.CallingContext()
+	if cxt != nil && cxt.ContractAddress == param.ContractAddr {
+		admin, err := getContractAdmin(native, param.ContractAddr)
+		if err != nil {
+			return false, fmt.Errorf("getContractAdmin failed: %v", err)
+		}
+		if admin == nil {
+			return false, fmt.Errorf("admin of contract %s is not set", param.ContractAddr.ToHexString())
+		}
+	} else {
+		valid, err := checkAdmin(native, param.ContractAddr, param.AdminGID, param.KeyNo)
+		if err != nil || !valid {
+			return false, err
+		}
+	}
+
+	funcs := &roleFuncs{funcNames: stringSliceUniq(param.FuncNames)}
+	if err := putProtectedMethods(native, param.ContractAddr, funcs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func SetProtectedMethods(native *native.NativeService) ([]byte, error) {
+	param := new(ProtectedMethodsParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[setProtectedMethods] deserialize param failed: %v", err)
+	}
+
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"setProtectedMethods", contract, false}
+	sucState := []interface{}{"setProtectedMethods", contract, true}
+
+	ret, err := setProtectedMethods(native, param)
+	if err != nil {
+		return nil, fmt.Errorf("[setProtectedMethods] failed: %v", err)
+	}
+	if ret {
+		pushEvent(native, sucState)
+		return utils.BYTE_TRUE, nil
+	} else {
+		pushEvent(native, failState)
+		return utils.BYTE_FALSE, nil
+	}
+}
+
+func GetProtectedMethods(native *native.NativeService) ([]byte, error) {
+	contractAddr, err := utils.ReadAddress(bytes.NewReader(native.Input))
+	if err != nil {
+		return nil, fmt.Errorf("[getProtectedMethods] deserialize param failed: %v", err)
+	}
+	funcs, err := getProtectedMethods(native, contractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("[getProtectedMethods] %v", err)
+	}
+	if funcs == nil {
+		funcs = new(roleFuncs)
+	}
+	bf := new(bytes.Buffer)
+	if err := funcs.Serialize(bf); err != nil {
+		return nil, fmt.Errorf("[getProtectedMethods] serialize failed: %v", err)
+	}
+	return bf.Bytes(), nil
+}
+
+// IsProtected reports whether method of contractAddr must pass verifyToken before dispatch.
+func IsProtected(native *native.NativeService, contractAddr common.Address, method string) (bool, error) {
+	//no method is enforced before the fork height
+	if native.Height < config.GetProtectedMethodsHeight(config.DefConfig.P2PNode.NetworkId) {
+		return false, nil
+	}
+	funcs, err := getProtectedMethods(native, contractAddr)
+	if err != nil {
+		return false, fmt.Errorf("get protected methods of %s failed: %v", contractAddr.ToHexString(), err)
+	}
+	if funcs == nil {
+		return false, nil
+	}
+	for _, fn := range funcs.funcNames {
+		if fn == method {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CheckInvoke calls verifyToken for caller, and fails if caller is not allowed to invoke method.
+func CheckInvoke(native *native.NativeService, contractAddr common.Address, method string, caller []byte, keyNo uint64) error {
+	param := &VerifyTokenParam{
+		ContractAddr: contractAddr,
+		Caller:       caller,
+		Fn:           method,
+		KeyNo:        keyNo,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		return err
+	}
+	ret, err := native.NativeCall(utils.AuthContractAddress, "verifyToken", bf.Bytes())
+	if err != nil {
+		return fmt.Errorf("verifyToken failed: %v", err)
+	}
+	valid, ok := ret.([]byte)
+	if !ok || !bytes.Equal(valid, utils.BYTE_TRUE) {
+		log.Debugf("[CheckInvoke] %s denied: contract=%s, method=%s", string(caller),
+			contractAddr.ToHexString(), method)
+		return fmt.Errorf("%s is not authorized to invoke protected method %s of contract %s",
+			string(caller), method, contractAddr.ToHexString())
+	}
+	return nil
+}
+
+// ParseInvoker returns the caller's GID and key number, which are the first
+// two arguments of a protected method.
+func ParseInvoker(args []interface{}) ([]byte, uint64, error) {
+	if len(args) < 2 {
+		return nil, 0, fmt.Errorf("protected method requires caller GID and keyNo arguments")
+	}
+	var GID []byte
+	switch v := args[0].(type) {
+	case []byte:
+		GID = v
+	case string:
+		GID = []byte(v)
+	default:
+		return nil, 0, fmt.Errorf("invalid caller GID argument type %T", args[0])
+	}
+	var keyNo uint64
+	switch v := args[1].(type) {
+	case uint64:
+		keyNo = v
+	case int64:
+		if v < 0 {
+			return nil, 0, fmt.Errorf("invalid keyNo argument %d", v)
+		}
+		keyNo = uint64(v)
+	case int:
+		if v < 0 {
+			return nil, 0, fmt.Errorf("invalid keyNo argument %d", v)
+		}
+		keyNo = uint64(v)
+	default:
+		return nil, 0, fmt.Errorf("invalid keyNo argument type %T", args[1])
+	}
+	return GID, keyNo, nil
+}
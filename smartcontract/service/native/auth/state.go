@@ -35,6 +35,7 @@
 package auth
 
 import (
+	"bytes"
 	"io"
 
 	"github.com/imZhuFei/zeepin/common/serialization"
@@ -203,3 +204,150 @@ func (this *roleTokens) Deserialize(rd io.Reader) error {
 	}
 	return nil
 }
+
+/*
+ * index of GIDs, used to look up the holders of a role and
+ * the delegates of a GID
+ */
+type gidList struct {
+	gids [][]byte
+}
+
+func (this *gidList) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, uint32(len(this.gids))); err != nil {
+		return err
+	}
+	for _, gid := range this.gids {
+		if err := serialization.WriteVarBytes(w, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *gidList) Deserialize(rd io.Reader) error {
+	gLen, err := serialization.ReadUint32(rd)
+	if err != nil {
+		return err
+	}
+	this.gids = make([][]byte, 0)
+	for i := uint32(0); i < gLen; i++ {
+		gid, err := serialization.ReadVarBytes(rd)
+		if err != nil {
+			return err
+		}
+		this.gids = append(this.gids, gid)
+	}
+	return nil
+}
+
+func (this *gidList) contains(gid []byte) bool {
+	for _, g := range this.gids {
+		if bytes.Equal(g, gid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *gidList) add(gid []byte) bool {
+	if this.contains(gid) {
+		return false
+	}
+	this.gids = append(this.gids, gid)
+	return true
+}
+
+func (this *gidList) remove(gid []byte) bool {
+	for i, g := range this.gids {
+		if bytes.Equal(g, gid) {
+			this.gids = append(this.gids[:i], this.gids[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * RoleGrant is returned by the audit queries, it describes a role held
+ * by a GID. Root is empty for a role assigned by the admin, otherwise it
+ * is the GID which delegated the role.
+ */
+type RoleGrant struct {
+	GID        []byte
+	Role       []byte
+	Root       []byte
+	ExpireTime uint32
+	Level      uint8
+}
+
+func (this *RoleGrant) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.GID); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Root); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.ExpireTime); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint8(w, this.Level); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *RoleGrant) Deserialize(rd io.Reader) error {
+	var err error
+	if this.GID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Root, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.ExpireTime, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	if this.Level, err = serialization.ReadUint8(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+type RoleGrants struct {
+	Grants []*RoleGrant
+}
+
+func (this *RoleGrants) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, uint32(len(this.Grants))); err != nil {
+		return err
+	}
+	for _, g := range this.Grants {
+		if err := g.Serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *RoleGrants) Deserialize(rd io.Reader) error {
+	gLen, err := serialization.ReadUint32(rd)
+	if err != nil {
+		return err
+	}
+	this.Grants = make([]*RoleGrant, 0)
+	for i := uint32(0); i < gLen; i++ {
+		g := new(RoleGrant)
+		if err = g.Deserialize(rd); err != nil {
+			return err
+		}
+		this.Grants = append(this.Grants, g)
+	}
+	return nil
+}
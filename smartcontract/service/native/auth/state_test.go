@@ -114,3 +114,51 @@ func TestSerDelegateStatus(t *testing.T) {
 		t.Fatalf("failed")
 	}
 }
+
+func TestSerGIDList(t *testing.T) {
+	list := new(gidList)
+	if !list.add([]byte("a")) || !list.add([]byte("b")) || list.add([]byte("a")) {
+		t.Fatalf("add failed")
+	}
+	bf := new(bytes.Buffer)
+	if err := list.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	list2 := new(gidList)
+	if err := list2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if len(list2.gids) != 2 || !list2.contains([]byte("b")) {
+		t.Fatalf("does not match")
+	}
+	if !list2.remove([]byte("a")) || list2.remove([]byte("a")) || list2.contains([]byte("a")) {
+		t.Fatalf("remove failed")
+	}
+}
+
+func TestSerRoleGrants(t *testing.T) {
+	grants := &RoleGrants{
+		Grants: []*RoleGrant{
+			{GID: []byte("gid1"), Role: []byte("role"), Root: []byte{}, ExpireTime: 100, Level: 2},
+			{GID: []byte("gid2"), Role: []byte("role"), Root: []byte("gid1"), ExpireTime: 50, Level: 1},
+		},
+	}
+	bf := new(bytes.Buffer)
+	if err := grants.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	grants2 := new(RoleGrants)
+	if err := grants2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if len(grants2.Grants) != 2 {
+		t.Fatalf("does not match")
+	}
+	for i, g := range grants.Grants {
+		g2 := grants2.Grants[i]
+		if !bytes.Equal(g.GID, g2.GID) || !bytes.Equal(g.Role, g2.Role) || !bytes.Equal(g.Root, g2.Root) ||
+			g.ExpireTime != g2.ExpireTime || g.Level != g2.Level {
+			t.Fatalf("grant %d does not match", i)
+		}
+	}
+}
@@ -41,6 +41,7 @@ import (
 
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/serialization"
+	scommon "github.com/imZhuFei/zeepin/core/store/common"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
@@ -51,6 +52,9 @@ var (
 	PreRoleFunc       = []byte{0x02}
 	PreRoleToken      = []byte{0x03}
 	PreDelegateStatus = []byte{0x04}
+	PreRoleHolders    = []byte{0x05}
+	PreDelegates      = []byte{0x06}
+	PreProtected      = []byte{0x07}
 )
 
 //type(this.contractAddr.Admin) = []byte
@@ -197,6 +201,98 @@ func putDelegateStatus(native *native.NativeService, contractAddr common.Address
 	return nil
 }
 
+//type(this.contractAddr.RoleHolders.role) = gidList
+func concatRoleHoldersKey(native *native.NativeService, contractAddr common.Address, role []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreRoleHolders...)
+	key = append(key, role...)
+
+	return key
+}
+
+//type(this.contractAddr.Delegates.GID) = gidList
+func concatDelegatesKey(native *native.NativeService, contractAddr common.Address, GID []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreDelegates...)
+	key = append(key, GID...)
+
+	return key
+}
+
+func getGIDList(native *native.NativeService, key []byte) (*gidList, error) {
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	list := new(gidList)
+	if item == nil {
+		return list, nil
+	}
+	rd := bytes.NewReader(item.Value)
+	err = list.Deserialize(rd)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize gidList object failed. data: %x", item.Value)
+	}
+	return list, nil
+}
+
+func putGIDList(native *native.NativeService, key []byte, list *gidList) error {
+	if len(list.gids) == 0 {
+		native.CloneCache.Delete(scommon.ST_STORAGE, key)
+		return nil
+	}
+	bf := new(bytes.Buffer)
+	err := list.Serialize(bf)
+	if err != nil {
+		return fmt.Errorf("serialize gidList failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+//type(AuthContract.contractAddr.Protected) = roleFuncs, the key does not depend on
+//the current context since it is also read by the execution engines before dispatch
+func concatProtectedKey(contractAddr common.Address) []byte {
+	key := append(utils.AuthContractAddress[:], contractAddr[:]...)
+	key = append(key, PreProtected...)
+
+	return key
+}
+
+func getProtectedMethods(native *native.NativeService, contractAddr common.Address) (*roleFuncs, error) {
+	item, err := utils.GetStorageItem(native, concatProtectedKey(contractAddr))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, nil
+	}
+	rd := bytes.NewReader(item.Value)
+	funcs := new(roleFuncs)
+	err = funcs.Deserialize(rd)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize roleFuncs object failed. data: %x", item.Value)
+	}
+	return funcs, nil
+}
+
+func putProtectedMethods(native *native.NativeService, contractAddr common.Address, funcs *roleFuncs) error {
+	key := concatProtectedKey(contractAddr)
+	if len(funcs.funcNames) == 0 {
+		native.CloneCache.Delete(scommon.ST_STORAGE, key)
+		return nil
+	}
+	bf := new(bytes.Buffer)
+	err := funcs.Serialize(bf)
+	if err != nil {
+		return fmt.Errorf("serialize roleFuncs failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
 //remote duplicates in the slice of string
 func stringSliceUniq(s []string) []string {
 	smap := make(map[string]int)
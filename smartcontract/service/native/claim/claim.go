@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package claim
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+// MAX_CLAIM_HASH_LENGTH limits the size of the claim identifier kept in storage
+const MAX_CLAIM_HASH_LENGTH = 64
+
+func Init() {
+	native.Contracts[utils.ClaimContractAddress] = RegisterClaimContract
+}
+
+func Commit(native *native.NativeService) ([]byte, error) {
+	param := new(CommitParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[commit] deserialize param failed: %v", err)
+	}
+	if len(param.ClaimHash) == 0 || len(param.ClaimHash) > MAX_CLAIM_HASH_LENGTH {
+		return nil, fmt.Errorf("[commit] invalid param: claim hash length %d", len(param.ClaimHash))
+	}
+	if !account.VerifyID(string(param.IssuerGID)) {
+		return nil, fmt.Errorf("[commit] invalid param: issuerGID is %x", param.IssuerGID)
+	}
+	if !account.VerifyID(string(param.SubjectGID)) {
+		return nil, fmt.Errorf("[commit] invalid param: subjectGID is %x", param.SubjectGID)
+	}
+	if param.Expiry != 0 && param.Expiry <= native.Time {
+		return nil, fmt.Errorf("[commit] invalid param: expiry %d is not in the future", param.Expiry)
+	}
+
+	record, err := getClaim(native, param.ClaimHash)
+	if err != nil {
+		return nil, fmt.Errorf("[commit] getClaim failed: %v", err)
+	}
+	if record != nil {
+		return nil, fmt.Errorf("[commit] claim %x has already been committed", param.ClaimHash)
+	}
+
+	ret, err := verifySig(native, param.IssuerGID, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[commit] verify issuer's signature failed: %v", err)
+	}
+	if !ret {
+		log.Debugf("[commit] verifySig return false: issuerGID=%s, keyNo=%d", string(param.IssuerGID), param.KeyNo)
+		return utils.BYTE_FALSE, nil
+	}
+
+	record = &ClaimRecord{
+		Issuer:   param.IssuerGID,
+		Subject:  param.SubjectGID,
+		Expiry:   param.Expiry,
+		Status:   STATUS_COMMITTED,
+		CommitAt: native.Time,
+	}
+	if err := putClaim(native, param.ClaimHash, record); err != nil {
+		return nil, fmt.Errorf("[commit] putClaim failed: %v", err)
+	}
+	triggerCommitEvent(native, param.ClaimHash, record)
+	return utils.BYTE_TRUE, nil
+}
+
+func Revoke(native *native.NativeService) ([]byte, error) {
+	param := new(RevokeParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[revoke] deserialize param failed: %v", err)
+	}
+
+	record, err := getClaim(native, param.ClaimHash)
+	if err != nil {
+		return nil, fmt.Errorf("[revoke] getClaim failed: %v", err)
+	}
+	if record == nil {
+		return nil, fmt.Errorf("[revoke] claim %x has not been committed", param.ClaimHash)
+	}
+	if !bytes.Equal(record.Issuer, param.IssuerGID) {
+		log.Debugf("[revoke] invalid param: issuerGID doesn't match %s != %s",
+			string(param.IssuerGID), string(record.Issuer))
+		return utils.BYTE_FALSE, nil
+	}
+	if record.Status == STATUS_REVOKED {
+		return nil, fmt.Errorf("[revoke] claim %x has already been revoked", param.ClaimHash)
+	}
+
+	ret, err := verifySig(native, param.IssuerGID, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[revoke] verify issuer's signature failed: %v", err)
+	}
+	if !ret {
+		log.Debugf("[revoke] verifySig return false: issuerGID=%s, keyNo=%d", string(param.IssuerGID), param.KeyNo)
+		return utils.BYTE_FALSE, nil
+	}
+
+	record.Status = STATUS_REVOKED
+	record.RevokedAt = native.Time
+	if err := putClaim(native, param.ClaimHash, record); err != nil {
+		return nil, fmt.Errorf("[revoke] putClaim failed: %v", err)
+	}
+	triggerRevokeEvent(native, param.ClaimHash, record)
+	return utils.BYTE_TRUE, nil
+}
+
+// GetStatus returns the serialized ClaimRecord of the claim, with the status
+// reported as expired once the expiry has passed, or nil if it doesn't exist
+func GetStatus(native *native.NativeService) ([]byte, error) {
+	claimHash, err := serialization.ReadVarBytes(bytes.NewReader(native.Input))
+	if err != nil {
+		return nil, fmt.Errorf("[getStatus] deserialize param failed: %v", err)
+	}
+	record, err := getClaim(native, claimHash)
+	if err != nil {
+		return nil, fmt.Errorf("[getStatus] getClaim failed: %v", err)
+	}
+	if record == nil {
+		return nil, nil
+	}
+	if record.Status == STATUS_COMMITTED && record.expired(native.Time) {
+		record.Status = STATUS_EXPIRED
+	}
+	bf := new(bytes.Buffer)
+	if err := record.Serialize(bf); err != nil {
+		return nil, fmt.Errorf("[getStatus] serialize ClaimRecord failed: %v", err)
+	}
+	return bf.Bytes(), nil
+}
+
+// inactive replaces every method before the fork height
+func inactive(native *native.NativeService) ([]byte, error) {
+	return nil, fmt.Errorf("claim contract is not active before height %d",
+		config.GetClaimHeight(config.DefConfig.P2PNode.NetworkId))
+}
+
+var services = map[string]native.Handler{
+	"commit":    Commit,
+	"revoke":    Revoke,
+	"getStatus": GetStatus,
+}
+
+func RegisterClaimContract(native *native.NativeService) {
+	//the names stay registered before the fork height, the ServiceMap is shared
+	//by nested native calls and must not reach another contract's method
+	active := native.Height >= config.GetClaimHeight(config.DefConfig.P2PNode.NetworkId)
+	for name, handler := range services {
+		if !active {
+			handler = inactive
+		}
+		native.Register(name, handler)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package claim
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+)
+
+func TestSerCommitParam(t *testing.T) {
+	param := &CommitParam{
+		ClaimHash:  []byte{0x01, 0x02, 0x03},
+		IssuerGID:  []byte("GID:ZPT:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"),
+		SubjectGID: []byte("GID:ZPT:ZVpv1rhNb7XqjBJTyJq5rtgfqKgrhQo9Bs"),
+		Expiry:     1600000000,
+		KeyNo:      1,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(CommitParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(param.ClaimHash, param2.ClaimHash) != 0 ||
+		bytes.Compare(param.IssuerGID, param2.IssuerGID) != 0 ||
+		bytes.Compare(param.SubjectGID, param2.SubjectGID) != 0 ||
+		param.Expiry != param2.Expiry || param.KeyNo != param2.KeyNo {
+		t.Fatalf("failed")
+	}
+}
+
+func TestSerRevokeParam(t *testing.T) {
+	param := &RevokeParam{
+		ClaimHash: []byte{0x01, 0x02, 0x03},
+		IssuerGID: []byte("GID:ZPT:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"),
+		KeyNo:     2,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(RevokeParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(param.ClaimHash, param2.ClaimHash) != 0 ||
+		bytes.Compare(param.IssuerGID, param2.IssuerGID) != 0 ||
+		param.KeyNo != param2.KeyNo {
+		t.Fatalf("failed")
+	}
+}
+
+func TestSerClaimRecord(t *testing.T) {
+	record := &ClaimRecord{
+		Issuer:    []byte("GID:ZPT:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"),
+		Subject:   []byte("GID:ZPT:ZVpv1rhNb7XqjBJTyJq5rtgfqKgrhQo9Bs"),
+		Expiry:    1600000000,
+		Status:    STATUS_REVOKED,
+		CommitAt:  1500000000,
+		RevokedAt: 1550000000,
+	}
+	bf := new(bytes.Buffer)
+	if err := record.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	record2 := new(ClaimRecord)
+	if err := record2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(record.Issuer, record2.Issuer) != 0 ||
+		bytes.Compare(record.Subject, record2.Subject) != 0 ||
+		record.Expiry != record2.Expiry || record.Status != record2.Status ||
+		record.CommitAt != record2.CommitAt || record.RevokedAt != record2.RevokedAt {
+		t.Fatalf("failed")
+	}
+}
+
+func TestClaimExpired(t *testing.T) {
+	record := &ClaimRecord{Expiry: 100}
+	if record.expired(99) || !record.expired(100) {
+		t.Fatalf("failed")
+	}
+	record.Expiry = 0
+	if record.expired(1 << 31) {
+		t.Fatalf("claim without expiry should never expire")
+	}
+}
+
+func TestRegisterClaimContractHeight(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	height := config.GetClaimHeight(config.NETWORK_ID_MAIN_NET)
+	if height == 0 {
+		t.Skip("claim contract is active from genesis on mainnet")
+	}
+	service := &native.NativeService{ServiceMap: make(map[string]native.Handler), Height: height - 1}
+	RegisterClaimContract(service)
+	if len(service.ServiceMap) != len(services) {
+		t.Fatalf("registered %d methods, expect %d", len(service.ServiceMap), len(services))
+	}
+	if _, err := service.ServiceMap["commit"](service); err == nil ||
+		!strings.Contains(err.Error(), "not active") {
+		t.Fatalf("commit is invocable before the fork height: %v", err)
+	}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	service = &native.NativeService{ServiceMap: make(map[string]native.Handler)}
+	RegisterClaimContract(service)
+	//an empty input fails in getStatus itself once the contract is active
+	if _, err := service.ServiceMap["getStatus"](service); err == nil || !strings.HasPrefix(err.Error(), "[getStatus]") {
+		t.Fatalf("getStatus is not active on a private network: %v", err)
+	}
+}
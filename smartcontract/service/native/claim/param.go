@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package claim
+
+import (
+	"io"
+
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+/* **********************************************   */
+type CommitParam struct {
+	ClaimHash  []byte
+	IssuerGID  []byte
+	SubjectGID []byte
+	Expiry     uint32
+	KeyNo      uint64
+}
+
+func (this *CommitParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClaimHash); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.IssuerGID); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.SubjectGID); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, uint64(this.Expiry)); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.KeyNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *CommitParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClaimHash, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.IssuerGID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.SubjectGID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	expiry, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return err
+	}
+	this.Expiry = uint32(expiry)
+	if this.KeyNo, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+/* **********************************************   */
+type RevokeParam struct {
+	ClaimHash []byte
+	IssuerGID []byte
+	KeyNo     uint64
+}
+
+func (this *RevokeParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClaimHash); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.IssuerGID); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.KeyNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *RevokeParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClaimHash, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.IssuerGID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.KeyNo, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package claim
+
+import (
+	"io"
+
+	"github.com/imZhuFei/zeepin/common/serialization"
+)
+
+// status of a claim as stored on chain
+const (
+	STATUS_COMMITTED uint8 = 1
+	STATUS_REVOKED   uint8 = 2
+	// STATUS_EXPIRED is never stored, getStatus reports it for committed
+	// claims whose expiry has passed
+	STATUS_EXPIRED uint8 = 3
+)
+
+/*
+ * a claim anchored by its issuer, expiry 0 means the claim never expires
+ */
+type ClaimRecord struct {
+	Issuer    []byte
+	Subject   []byte
+	Expiry    uint32
+	Status    uint8
+	CommitAt  uint32
+	RevokedAt uint32
+}
+
+func (this *ClaimRecord) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.Issuer); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Subject); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.Expiry); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint8(w, this.Status); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.CommitAt); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.RevokedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *ClaimRecord) Deserialize(rd io.Reader) error {
+	var err error
+	if this.Issuer, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Subject, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Expiry, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	if this.Status, err = serialization.ReadUint8(rd); err != nil {
+		return err
+	}
+	if this.CommitAt, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	if this.RevokedAt, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *ClaimRecord) expired(now uint32) bool {
+	return this.Expiry != 0 && this.Expiry <= now
+}
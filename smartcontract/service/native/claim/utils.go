@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package claim
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/errors"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+var (
+	PreClaim = []byte{0x01}
+)
+
+// type(this.Claim.claimHash) = ClaimRecord
+func concatClaimKey(native *native.NativeService, claimHash []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], PreClaim...)
+	key = append(key, claimHash...)
+
+	return key
+}
+
+func getClaim(native *native.NativeService, claimHash []byte) (*ClaimRecord, error) {
+	key := concatClaimKey(native, claimHash)
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil { //is not committed
+		return nil, nil
+	}
+	record := new(ClaimRecord)
+	if err := record.Deserialize(bytes.NewReader(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize ClaimRecord object failed. data: %x", item.Value)
+	}
+	return record, nil
+}
+
+func putClaim(native *native.NativeService, claimHash []byte, record *ClaimRecord) error {
+	key := concatClaimKey(native, claimHash)
+	bf := new(bytes.Buffer)
+	if err := record.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize ClaimRecord failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+// verify the signature of GID's keyNo-th key, the same way as gid verifySignature
+func verifySig(native *native.NativeService, GID []byte, keyNo uint64) (bool, error) {
+	bf := new(bytes.Buffer)
+	if err := serialization.WriteVarBytes(bf, GID); err != nil {
+		return false, err
+	}
+	if err := utils.WriteVarUint(bf, keyNo); err != nil {
+		return false, err
+	}
+	ret, err := native.NativeCall(utils.GIDContractAddress, "verifySignature", bf.Bytes())
+	if err != nil {
+		return false, err
+	}
+	valid, ok := ret.([]byte)
+	if !ok {
+		return false, errors.NewErr("verifySignature return non-bool value")
+	}
+	return bytes.Equal(valid, utils.BYTE_TRUE), nil
+}
+
+func pushEvent(native *native.NativeService, s interface{}) {
+	event := new(event.NotifyEventInfo)
+	event.ContractAddress = native.ContextRef.CurrentContext().ContractAddress
+	event.States = s
+	native.Notifications = append(native.Notifications, event)
+}
+
+func triggerCommitEvent(native *native.NativeService, claimHash []byte, record *ClaimRecord) {
+	pushEvent(native, []interface{}{"Commit", hex.EncodeToString(claimHash), string(record.Issuer),
+		string(record.Subject), record.Expiry})
+}
+
+func triggerRevokeEvent(native *native.NativeService, claimHash []byte, record *ClaimRecord) {
+	pushEvent(native, []interface{}{"Revoke", hex.EncodeToString(claimHash), string(record.Issuer),
+		string(record.Subject)})
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package embed
+
+import (
+	"testing"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/core/signature"
+	vm "github.com/imZhuFei/zeepin/embed/simulator"
+	"github.com/imZhuFei/zeepin/embed/simulator/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSig(t *testing.T) {
+	acc := account.NewAccount("")
+	data := []byte("voucher")
+	sig, err := signature.Sign(acc, data)
+	assert.Nil(t, err)
+
+	service := &EmbeddedService{Engine: vm.NewExecutionEngine()}
+	vm.PushData(service.Engine, data)
+	vm.PushData(service.Engine, sig)
+	vm.PushData(service.Engine, keypair.SerializePublicKey(acc.PublicKey))
+	assert.Nil(t, service.checkSig())
+	ok, err := vm.PopBoolean(service.Engine)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	vm.PushData(service.Engine, []byte("other"))
+	vm.PushData(service.Engine, sig)
+	vm.PushData(service.Engine, keypair.SerializePublicKey(acc.PublicKey))
+	assert.Nil(t, service.checkSig())
+	ok, err = vm.PopBoolean(service.Engine)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestCheckMultiSig(t *testing.T) {
+	data := []byte("channel")
+	keys := make([]types.StackItems, 0, 3)
+	sigs := make([]types.StackItems, 0, 2)
+	for i := 0; i < 3; i++ {
+		acc := account.NewAccount("")
+		keys = append(keys, types.NewByteArray(keypair.SerializePublicKey(acc.PublicKey)))
+		if i > 0 {
+			sig, err := signature.Sign(acc, data)
+			assert.Nil(t, err)
+			sigs = append(sigs, types.NewByteArray(sig))
+		}
+	}
+
+	service := &EmbeddedService{Engine: vm.NewExecutionEngine()}
+	vm.PushData(service.Engine, data)
+	vm.PushData(service.Engine, types.NewArray(sigs))
+	vm.PushData(service.Engine, types.NewArray(keys))
+	price, err := GasPrice(service.Engine, CHECKMULTISIG_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, 3*CHECKSIG_GAS, price)
+	assert.Nil(t, service.checkMultiSig())
+	ok, err := vm.PopBoolean(service.Engine)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	vm.PushData(service.Engine, data)
+	vm.PushData(service.Engine, types.NewArray([]types.StackItems{sigs[0], sigs[0]}))
+	vm.PushData(service.Engine, types.NewArray(keys))
+	assert.Nil(t, service.checkMultiSig())
+	ok, err = vm.PopBoolean(service.Engine)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestCheckSigGasOutOfGenesis(t *testing.T) {
+	for _, name := range GAS_TABLE_KEYS {
+		assert.NotEqual(t, CHECKSIG_NAME, name)
+	}
+	_, ok := GAS_TABLE.Load(CHECKSIG_NAME)
+	assert.False(t, ok)
+	price, err := GasPrice(vm.NewExecutionEngine(), CHECKSIG_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, CHECKSIG_GAS, price)
+}
@@ -52,6 +52,8 @@ var (
 	STORAGE_PUT_GAS               uint64 = 4000
 	STORAGE_DELETE_GAS            uint64 = 100
 	RUNTIME_CHECKWITNESS_GAS      uint64 = 200
+	CHECKSIG_GAS                  uint64 = 200  // Not in GAS_TABLE, which is written into the genesis block.
+	WASM_MEMORY_PAGE_GAS          uint64 = 1000 // Not in GAS_TABLE, charged from the wasm allocator height.
 	APPCALL_GAS                   uint64 = 10
 	TAILCALL_GAS                  uint64 = 10
 	SHA1_GAS                      uint64 = 10
@@ -127,6 +129,8 @@ var (
 	SHA256_NAME               = "SHA256"
 	HASH160_NAME              = "HASH160"
 	HASH256_NAME              = "HASH256"
+	CHECKSIG_NAME             = "CHECKSIG"
+	CHECKMULTISIG_NAME        = "CHECKMULTISIG"
 	UINT_DEPLOY_CODE_LEN_NAME = "Deploy.Code.Gas"
 	UINT_INVOKE_CODE_LEN_NAME = "Invoke.Code.Gas"
 
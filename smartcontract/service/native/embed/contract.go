@@ -43,6 +43,7 @@ import (
 	"github.com/imZhuFei/zeepin/core/types"
 	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/errors"
+	sccommon "github.com/imZhuFei/zeepin/smartcontract/common"
 )
 
 // ContractCreate create a new smart contract on blockchain, and put it to vm stack
@@ -51,6 +52,10 @@ func ContractCreate(service *EmbeddedService, engine *vm.ExecutionEngine) error
 	if err != nil {
 		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractCreate] contract parameters invalid!")
 	}
+	contract, err = sccommon.PrepareDeployCode(contract, service.Height)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractCreate] contract code invalid!")
+	}
 	contractAddress := types.AddressFromVmCode(contract.Code)
 	state, err := service.CloneCache.GetOrAdd(scommon.ST_CONTRACT, contractAddress[:], contract)
 	if err != nil {
@@ -66,6 +71,10 @@ func ContractMigrate(service *EmbeddedService, engine *vm.ExecutionEngine) error
 	if err != nil {
 		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractMigrate] contract parameters invalid!")
 	}
+	contract, err = sccommon.PrepareDeployCode(contract, service.Height)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractMigrate] contract code invalid!")
+	}
 	contractAddress := types.AddressFromVmCode(contract.Code)
 
 	if err := isContractExist(service, contractAddress); err != nil {
@@ -38,6 +38,7 @@ import (
 	"fmt"
 
 	scommon "github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/log"
 	"github.com/imZhuFei/zeepin/core/payload"
 	"github.com/imZhuFei/zeepin/core/signature"
@@ -45,10 +46,14 @@ import (
 	"github.com/imZhuFei/zeepin/core/store/common"
 	"github.com/imZhuFei/zeepin/core/types"
 	vm "github.com/imZhuFei/zeepin/embed/simulator"
+	vmerrors "github.com/imZhuFei/zeepin/embed/simulator/errors"
 	ntypes "github.com/imZhuFei/zeepin/embed/simulator/types"
 	"github.com/imZhuFei/zeepin/errors"
+	sccommon "github.com/imZhuFei/zeepin/smartcontract/common"
 	"github.com/imZhuFei/zeepin/smartcontract/context"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/auth"
 	"github.com/imZhuFei/zeepin/smartcontract/storage"
 	"github.com/ontio/ontology-crypto/keypair"
 )
@@ -112,6 +117,7 @@ var (
 	CONTRACT_NOT_EXIST    = errors.NewErr("[EmbeddedService] Get contract code from db fail")
 	DEPLOYCODE_TYPE_ERROR = errors.NewErr("[EmbeddedService] DeployCode type error!")
 	VM_EXEC_FAULT         = errors.NewErr("[EmbeddedService] vm execute state fault!")
+	VM_TYPE_MISMATCH      = errors.NewErr("[EmbeddedService] can not invoke a wasm contract!")
 )
 
 type (
@@ -135,6 +141,8 @@ type EmbeddedService struct {
 	Time          uint32
 	Height        uint32
 	Engine        *vm.ExecutionEngine
+	Tracer        vm.Tracer
+	Depth         int // contracts on the call stack when the service was created
 }
 
 // Invoke a smart contract
@@ -158,82 +166,19 @@ func (this *EmbeddedService) Invoke() (interface{}, error) {
 		if err := this.Engine.ExecuteCode(); err != nil {
 			return nil, err
 		}
-		if this.Engine.Context.GetInstructionPointer() < len(this.Engine.Context.Code) {
-			if ok := checkStackSize(this.Engine); !ok {
-				return nil, ERR_CHECK_STACK_SIZE
-			}
-		}
-		if this.Engine.OpCode >= vm.PUSHBYTES1 && this.Engine.OpCode <= vm.PUSHBYTES75 {
-			if !this.ContextRef.CheckUseGas(OPCODE_GAS) {
-				return nil, ERR_GAS_INSUFFICIENT
-			}
-		} else {
-			if err := this.Engine.ValidateOp(); err != nil {
+		if this.Tracer == nil {
+			if err := this.executeOp(); err != nil {
 				return nil, err
 			}
-			price, err := GasPrice(this.Engine, this.Engine.OpExec.Name)
-			if err != nil {
-				return nil, err
-			}
-			if !this.ContextRef.CheckUseGas(price) {
-				return nil, ERR_GAS_INSUFFICIENT
-			}
+			continue
 		}
-		switch this.Engine.OpCode {
-		case vm.VERIFY:
-			if vm.EvaluationStackCount(this.Engine) < 3 {
-				return nil, errors.NewErr("[VERIFY] Too few input parameters ")
-			}
-			pubKey, err := vm.PopByteArray(this.Engine)
-			if err != nil {
-				return nil, err
-			}
-			key, err := keypair.DeserializePublicKey(pubKey)
-			if err != nil {
-				return nil, err
-			}
-			sig, err := vm.PopByteArray(this.Engine)
-			if err != nil {
-				return nil, err
-			}
-			data, err := vm.PopByteArray(this.Engine)
-			if err != nil {
-				return nil, err
-			}
-			if err := signature.Verify(key, data, sig); err != nil {
-				vm.PushData(this.Engine, false)
-			} else {
-				vm.PushData(this.Engine, true)
-			}
-		case vm.SYSCALL:
-			if err := this.SystemCall(this.Engine); err != nil {
-				return nil, errors.NewDetailErr(err, errors.ErrNoCode, "[EmbeddedService] service system call error!")
-			}
-		case vm.APPCALL, vm.TAILCALL:
-			address := this.Engine.Context.OpReader.ReadBytes(20)
-			code, err := this.getContract(address)
-			if err != nil {
-				return nil, err
-			}
-			service, err := this.ContextRef.NewExecuteEngine(code)
-			if err != nil {
-				return nil, err
-			}
-			this.Engine.EvaluationStack.CopyTo(service.(*EmbeddedService).Engine.EvaluationStack)
-			result, err := service.Invoke()
-			if err != nil {
-				return nil, err
-			}
-			if result != nil {
-				vm.PushData(this.Engine, result)
-			}
-		default:
-			if err := this.Engine.StepInto(); err != nil {
-				return nil, errors.NewDetailErr(err, errors.ErrNoCode, "[EmbeddedService] vm execute error!")
-			}
-			if this.Engine.State == vm.FAULT {
-				return nil, VM_EXEC_FAULT
-			}
+		op := this.Engine.OpCode
+		depth := this.Depth + len(this.Engine.Contexts)
+		this.Tracer.BeforeOp(op, this.Engine.EvaluationStack, depth, this.ContextRef.GasLeft())
+		err := this.executeOp()
+		this.Tracer.AfterOp(op, this.Engine.EvaluationStack, depth, this.ContextRef.GasLeft(), err)
+		if err != nil {
+			return nil, err
 		}
 	}
 	this.ContextRef.PopContext()
@@ -244,6 +189,103 @@ func (this *EmbeddedService) Invoke() (interface{}, error) {
 	return nil, nil
 }
 
+//executeOp charges and runs the opcode read by the engine
+func (this *EmbeddedService) executeOp() error {
+	if this.Engine.Context.GetInstructionPointer() < len(this.Engine.Context.Code) {
+		if ok := checkStackSize(this.Engine); !ok {
+			return ERR_CHECK_STACK_SIZE
+		}
+	}
+	if this.Engine.OpCode >= vm.PUSHBYTES1 && this.Engine.OpCode <= vm.PUSHBYTES75 {
+		if !this.ContextRef.CheckUseGas(OPCODE_GAS) {
+			return ERR_GAS_INSUFFICIENT
+		}
+	} else {
+		//the signature opcodes are unknown to blocks before the fork height
+		if (this.Engine.OpCode == vm.CHECKSIG || this.Engine.OpCode == vm.CHECKMULTISIG) && !this.checkSigEnabled() {
+			return vmerrors.ERR_NOT_SUPPORT_OPCODE
+		}
+		if err := this.Engine.ValidateOp(); err != nil {
+			return err
+		}
+		price, err := GasPrice(this.Engine, this.Engine.OpExec.Name)
+		if err != nil {
+			return err
+		}
+		if !this.ContextRef.CheckUseGas(price) {
+			return ERR_GAS_INSUFFICIENT
+		}
+	}
+	switch this.Engine.OpCode {
+	case vm.VERIFY:
+		if vm.EvaluationStackCount(this.Engine) < 3 {
+			return errors.NewErr("[VERIFY] Too few input parameters ")
+		}
+		pubKey, err := vm.PopByteArray(this.Engine)
+		if err != nil {
+			return err
+		}
+		key, err := keypair.DeserializePublicKey(pubKey)
+		if err != nil {
+			return err
+		}
+		sig, err := vm.PopByteArray(this.Engine)
+		if err != nil {
+			return err
+		}
+		data, err := vm.PopByteArray(this.Engine)
+		if err != nil {
+			return err
+		}
+		if err := signature.Verify(key, data, sig); err != nil {
+			vm.PushData(this.Engine, false)
+		} else {
+			vm.PushData(this.Engine, true)
+		}
+	case vm.CHECKSIG:
+		if err := this.checkSig(); err != nil {
+			return err
+		}
+	case vm.CHECKMULTISIG:
+		if err := this.checkMultiSig(); err != nil {
+			return err
+		}
+	case vm.SYSCALL:
+		if err := this.SystemCall(this.Engine); err != nil {
+			return errors.NewDetailErr(err, errors.ErrNoCode, "[EmbeddedService] service system call error!")
+		}
+	case vm.APPCALL, vm.TAILCALL:
+		address := this.Engine.Context.OpReader.ReadBytes(20)
+		code, err := this.getContract(address)
+		if err != nil {
+			return err
+		}
+		if err := this.checkProtected(address); err != nil {
+			return err
+		}
+		service, err := this.ContextRef.NewExecuteEngine(code)
+		if err != nil {
+			return err
+		}
+		this.Engine.EvaluationStack.CopyTo(service.(*EmbeddedService).Engine.EvaluationStack)
+		result, err := service.Invoke()
+		if err != nil {
+			return err
+		}
+		if result != nil {
+			vm.PushData(this.Engine, result)
+		}
+	default:
+		if err := this.Engine.StepInto(); err != nil {
+			return errors.NewDetailErr(err, errors.ErrNoCode, "[EmbeddedService] vm execute error!")
+		}
+		if this.Engine.State == vm.FAULT {
+			return VM_EXEC_FAULT
+		}
+	}
+	return nil
+}
+
 // SystemCall provide register service for smart contract to interaction with blockchain
 func (this *EmbeddedService) SystemCall(engine *vm.ExecutionEngine) error {
 	serviceName := engine.Context.OpReader.ReadVarString(vm.MAX_BYTEARRAY_SIZE)
@@ -270,6 +312,84 @@ func (this *EmbeddedService) SystemCall(engine *vm.ExecutionEngine) error {
 	return nil
 }
 
+func (this *EmbeddedService) checkSigEnabled() bool {
+	return this.Height >= config.GetCheckSigHeight(config.DefConfig.P2PNode.NetworkId)
+}
+
+// checkSig pops the public key, the signature and the message, then pushes
+// whether the signature is valid, malformed keys or signatures verify as false
+func (this *EmbeddedService) checkSig() error {
+	if vm.EvaluationStackCount(this.Engine) < 3 {
+		return errors.NewErr("[CHECKSIG] Too few input parameters ")
+	}
+	pubKey, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return err
+	}
+	sig, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return err
+	}
+	data, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return err
+	}
+	key, err := keypair.DeserializePublicKey(pubKey)
+	if err != nil {
+		vm.PushData(this.Engine, false)
+		return nil
+	}
+	vm.PushData(this.Engine, signature.Verify(key, data, sig) == nil)
+	return nil
+}
+
+// checkMultiSig pops an array of public keys, an array of signatures and the
+// message, then pushes whether every signature is valid for a distinct key
+func (this *EmbeddedService) checkMultiSig() error {
+	if vm.EvaluationStackCount(this.Engine) < 3 {
+		return errors.NewErr("[CHECKMULTISIG] Too few input parameters ")
+	}
+	keyItems, err := vm.PopArray(this.Engine)
+	if err != nil {
+		return err
+	}
+	sigItems, err := vm.PopArray(this.Engine)
+	if err != nil {
+		return err
+	}
+	data, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return err
+	}
+	if len(sigItems) == 0 || len(sigItems) > len(keyItems) {
+		return errors.NewErr("[CHECKMULTISIG] invalid signature count")
+	}
+
+	keys := make([]keypair.PublicKey, 0, len(keyItems))
+	for _, item := range keyItems {
+		buf, err := item.GetByteArray()
+		if err != nil {
+			return err
+		}
+		key, err := keypair.DeserializePublicKey(buf)
+		if err != nil {
+			vm.PushData(this.Engine, false)
+			return nil
+		}
+		keys = append(keys, key)
+	}
+	sigs := make([][]byte, 0, len(sigItems))
+	for _, item := range sigItems {
+		sig, err := item.GetByteArray()
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+	vm.PushData(this.Engine, signature.VerifyMultiSignature(data, keys, len(sigs), sigs) == nil)
+	return nil
+}
+
 func (this *EmbeddedService) getContract(address []byte) ([]byte, error) {
 	item, err := this.CloneCache.Store.TryGet(common.ST_CONTRACT, address)
 	if err != nil {
@@ -283,9 +403,65 @@ func (this *EmbeddedService) getContract(address []byte) ([]byte, error) {
 	if !ok {
 		return nil, DEPLOYCODE_TYPE_ERROR
 	}
+	if contract.VmType == payload.WasmVm && sccommon.VmTypeCheckEnabled(this.Height) {
+		return nil, VM_TYPE_MISMATCH
+	}
 	return contract.Code, nil
 }
 
+// checkProtected enforces the method permissions registered in the auth contract,
+// the evaluation stack holds the method name on top of the arguments array
+func (this *EmbeddedService) checkProtected(address []byte) error {
+	if this.Engine.EvaluationStack.Count() < 1 {
+		return nil
+	}
+	addr, err := scommon.AddressParseFromBytes(address)
+	if err != nil {
+		return err
+	}
+	method, err := vm.PeekNByteArray(0, this.Engine)
+	if err != nil {
+		//not a method name, cannot match a protected method
+		return nil
+	}
+	native := &native.NativeService{
+		CloneCache: this.CloneCache,
+		Tx:         this.Tx,
+		Height:     this.Height,
+		Time:       this.Time,
+		ContextRef: this.ContextRef,
+		ServiceMap: make(map[string]native.Handler),
+	}
+	protected, err := auth.IsProtected(native, addr, string(method))
+	if err != nil || !protected {
+		return err
+	}
+
+	args := make([]interface{}, 0, 2)
+	if this.Engine.EvaluationStack.Count() > 1 {
+		items, err := vm.PeekNStackItem(1, this.Engine).GetArray()
+		if err == nil && len(items) >= 2 {
+			gid, err := items[0].GetByteArray()
+			if err != nil {
+				return err
+			}
+			keyNo, err := items[1].GetBigInteger()
+			if err != nil {
+				return err
+			}
+			args = append(args, gid, keyNo.Int64())
+		}
+	}
+	caller, keyNo, err := auth.ParseInvoker(args)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[EmbeddedService] protected method "+string(method))
+	}
+	if err := auth.CheckInvoke(native, addr, string(method), caller, keyNo); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[EmbeddedService] permission denied")
+	}
+	return nil
+}
+
 func checkStackSize(engine *vm.ExecutionEngine) bool {
 	size := 0
 	if engine.OpCode < vm.PUSH16 {
@@ -55,10 +55,25 @@ func StoreGasCost(engine *vm.ExecutionEngine) (uint64, error) {
 	}
 }
 
+// MultiSigGasCost charges a CHECKSIG for every public key on top of the stack
+func MultiSigGasCost(engine *vm.ExecutionEngine) (uint64, error) {
+	n := 1
+	if vm.EvaluationStackCount(engine) > 0 {
+		if keys, err := vm.PeekArray(engine); err == nil && len(keys) > 1 {
+			n = len(keys)
+		}
+	}
+	return uint64(n) * CHECKSIG_GAS, nil
+}
+
 func GasPrice(engine *vm.ExecutionEngine, name string) (uint64, error) {
 	switch name {
 	case STORAGE_PUT_NAME:
 		return StoreGasCost(engine)
+	case CHECKSIG_NAME:
+		return CHECKSIG_GAS, nil
+	case CHECKMULTISIG_NAME:
+		return MultiSigGasCost(engine)
 	default:
 		if value, ok := GAS_TABLE.Load(name); ok {
 			return value.(uint64), nil
@@ -0,0 +1,416 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package gid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/core/states"
+	"github.com/imZhuFei/zeepin/core/store/common"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+// operation classes a controller policy sets thresholds for
+const (
+	OP_KEY  byte = 1
+	OP_ATTR byte = 2
+)
+
+const (
+	MAX_SIGNERS   = 32
+	MAX_GUARDIANS = 32
+)
+
+// controlPolicy is the M-of-N threshold of owner keys required for each
+// operation class, a GID without policy behaves as 1-of-N
+type controlPolicy struct {
+	keyThreshold  uint32
+	attrThreshold uint32
+}
+
+func (this *controlPolicy) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.keyThreshold); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.attrThreshold); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *controlPolicy) Deserialize(r io.Reader) error {
+	v1, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	v2, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	this.keyThreshold = v1
+	this.attrThreshold = v2
+	return nil
+}
+
+func (this *controlPolicy) threshold(op byte) uint32 {
+	if op == OP_ATTR {
+		return this.attrThreshold
+	}
+	return this.keyThreshold
+}
+
+// guardianSet is the set of GIDs or addresses allowed to rotate the owner
+// keys once threshold of them approved and delay seconds passed
+type guardianSet struct {
+	threshold uint32
+	delay     uint32
+	guardians [][]byte
+}
+
+func (this *guardianSet) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.threshold); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.delay); err != nil {
+		return err
+	}
+	return writeBytesList(w, this.guardians)
+}
+
+func (this *guardianSet) Deserialize(r io.Reader) error {
+	v1, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	v2, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	v3, err := readBytesList(r, MAX_GUARDIANS)
+	if err != nil {
+		return err
+	}
+	this.threshold = v1
+	this.delay = v2
+	this.guardians = v3
+	return nil
+}
+
+func (this *guardianSet) contains(guardian []byte) bool {
+	for _, v := range this.guardians {
+		if bytes.Equal(v, guardian) {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingRecovery collects the key each guardian approved, a guardian may
+// move its approval to another key until one of them reaches the threshold.
+// newKey and quorumTime are only set once a key reached it
+type pendingRecovery struct {
+	newKey     []byte
+	approvals  [][]byte
+	keys       [][]byte
+	quorumTime uint32
+}
+
+func (this *pendingRecovery) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.newKey); err != nil {
+		return err
+	}
+	if err := writeBytesList(w, this.approvals); err != nil {
+		return err
+	}
+	if err := writeBytesList(w, this.keys); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.quorumTime); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *pendingRecovery) Deserialize(r io.Reader) error {
+	v1, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return err
+	}
+	v2, err := readBytesList(r, MAX_GUARDIANS)
+	if err != nil {
+		return err
+	}
+	v3, err := readBytesList(r, MAX_GUARDIANS)
+	if err != nil {
+		return err
+	}
+	if len(v2) != len(v3) {
+		return fmt.Errorf("approvals mismatch keys, %d != %d", len(v2), len(v3))
+	}
+	v4, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	this.newKey = v1
+	this.approvals = v2
+	this.keys = v3
+	this.quorumTime = v4
+	return nil
+}
+
+// approve records the key approved by guardian, replacing its former
+// approval, and returns the number of guardians approving that key
+func (this *pendingRecovery) approve(guardian, key []byte) (uint32, error) {
+	found := false
+	for i, v := range this.approvals {
+		if bytes.Equal(v, guardian) {
+			if bytes.Equal(this.keys[i], key) {
+				return 0, errors.New("already approved")
+			}
+			this.keys[i] = key
+			found = true
+			break
+		}
+	}
+	if !found {
+		this.approvals = append(this.approvals, guardian)
+		this.keys = append(this.keys, key)
+	}
+	var n uint32 = 0
+	for _, v := range this.keys {
+		if bytes.Equal(v, key) {
+			n += 1
+		}
+	}
+	return n, nil
+}
+
+func writeBytesList(w io.Writer, list [][]byte) error {
+	if err := utils.WriteVarUint(w, uint64(len(list))); err != nil {
+		return err
+	}
+	for _, v := range list {
+		if err := serialization.WriteVarBytes(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readBytesList(r io.Reader, max int) ([][]byte, error) {
+	num, err := utils.ReadVarUint(r)
+	if err != nil {
+		return nil, err
+	}
+	if num > uint64(max) {
+		return nil, fmt.Errorf("too many items, %d > %d", num, max)
+	}
+	res := make([][]byte, 0, num)
+	for i := uint64(0); i < num; i++ {
+		v, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func fieldKey(encID []byte, field byte) []byte {
+	key := make([]byte, len(encID), len(encID)+1)
+	copy(key, encID)
+	return append(key, field)
+}
+
+func getPolicy(srvc *native.NativeService, encID []byte) (*controlPolicy, error) {
+	item, err := utils.GetStorageItem(srvc, fieldKey(encID, FIELD_POLICY))
+	if err != nil {
+		return nil, fmt.Errorf("get policy error, %s", err)
+	}
+	policy := &controlPolicy{keyThreshold: 1, attrThreshold: 1}
+	if item == nil {
+		return policy, nil
+	}
+	if err := policy.Deserialize(bytes.NewReader(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize policy error, %s", err)
+	}
+	return policy, nil
+}
+
+func putPolicy(srvc *native.NativeService, encID []byte, policy *controlPolicy) error {
+	var buf bytes.Buffer
+	if err := policy.Serialize(&buf); err != nil {
+		return fmt.Errorf("serialize policy error, %s", err)
+	}
+	srvc.CloneCache.Add(common.ST_STORAGE, fieldKey(encID, FIELD_POLICY), &states.StorageItem{Value: buf.Bytes()})
+	return nil
+}
+
+func getGuardians(srvc *native.NativeService, encID []byte) (*guardianSet, error) {
+	item, err := utils.GetStorageItem(srvc, fieldKey(encID, FIELD_GUARDIANS))
+	if err != nil {
+		return nil, fmt.Errorf("get guardians error, %s", err)
+	} else if item == nil {
+		return nil, nil
+	}
+	set := new(guardianSet)
+	if err := set.Deserialize(bytes.NewReader(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize guardians error, %s", err)
+	}
+	return set, nil
+}
+
+func putGuardians(srvc *native.NativeService, encID []byte, set *guardianSet) error {
+	var buf bytes.Buffer
+	if err := set.Serialize(&buf); err != nil {
+		return fmt.Errorf("serialize guardians error, %s", err)
+	}
+	srvc.CloneCache.Add(common.ST_STORAGE, fieldKey(encID, FIELD_GUARDIANS), &states.StorageItem{Value: buf.Bytes()})
+	return nil
+}
+
+func getPendingRecovery(srvc *native.NativeService, encID []byte) (*pendingRecovery, error) {
+	item, err := utils.GetStorageItem(srvc, fieldKey(encID, FIELD_PENDING_RECOVERY))
+	if err != nil {
+		return nil, fmt.Errorf("get pending recovery error, %s", err)
+	} else if item == nil {
+		return nil, nil
+	}
+	pending := new(pendingRecovery)
+	if err := pending.Deserialize(bytes.NewReader(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize pending recovery error, %s", err)
+	}
+	return pending, nil
+}
+
+func putPendingRecovery(srvc *native.NativeService, encID []byte, pending *pendingRecovery) error {
+	var buf bytes.Buffer
+	if err := pending.Serialize(&buf); err != nil {
+		return fmt.Errorf("serialize pending recovery error, %s", err)
+	}
+	srvc.CloneCache.Add(common.ST_STORAGE, fieldKey(encID, FIELD_PENDING_RECOVERY), &states.StorageItem{Value: buf.Bytes()})
+	return nil
+}
+
+func deletePendingRecovery(srvc *native.NativeService, encID []byte) {
+	srvc.CloneCache.Delete(common.ST_STORAGE, fieldKey(encID, FIELD_PENDING_RECOVERY))
+}
+
+// countActivePk returns the number of unrevoked owner keys
+func countActivePk(srvc *native.NativeService, encID []byte) (uint32, error) {
+	owners, err := getAllPk(srvc, fieldKey(encID, FIELD_PK))
+	if err != nil {
+		return 0, err
+	}
+	var n uint32 = 0
+	for _, v := range owners {
+		if !v.revoked {
+			n += 1
+		}
+	}
+	return n, nil
+}
+
+// checkSingleOperator rejects single key operations on GIDs whose policy
+// requires more than one signature for the operation class
+func checkSingleOperator(srvc *native.NativeService, encID []byte, op byte) error {
+	policy, err := getPolicy(srvc, encID)
+	if err != nil {
+		return err
+	}
+	if t := policy.threshold(op); t > 1 {
+		return fmt.Errorf("policy requires %d signatures", t)
+	}
+	return nil
+}
+
+// checkLegacyRecovery applies the controller rules to the single recovery
+// address, GIDs with guardians recover through them only so their threshold
+// and delay cannot be bypassed, the others need a 1-of-N key policy
+func checkLegacyRecovery(srvc *native.NativeService, encID []byte) error {
+	set, err := getGuardians(srvc, encID)
+	if err != nil {
+		return err
+	} else if set != nil {
+		return errors.New("recovery is controlled by guardians")
+	}
+	return checkSingleOperator(srvc, encID, OP_KEY)
+}
+
+// checkSigners verifies that signers are distinct unrevoked owner keys which
+// all signed the transaction, and that they reach the policy threshold of op
+func checkSigners(srvc *native.NativeService, encID []byte, signers [][]byte, op byte) error {
+	policy, err := getPolicy(srvc, encID)
+	if err != nil {
+		return err
+	}
+	threshold := policy.threshold(op)
+	if uint32(len(signers)) < threshold {
+		return fmt.Errorf("not enough signers, %d < %d", len(signers), threshold)
+	}
+	owners, err := getAllPk(srvc, fieldKey(encID, FIELD_PK))
+	if err != nil {
+		return err
+	}
+	for i, signer := range signers {
+		for _, v := range signers[:i] {
+			if bytes.Equal(v, signer) {
+				return errors.New("duplicated signer")
+			}
+		}
+		found := false
+		for _, v := range owners {
+			if !v.revoked && bytes.Equal(v.key, signer) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("signer is not an owner")
+		}
+		if err := checkWitness(srvc, signer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGuardianWitness verifies the guardian signed the transaction, either
+// with its keyNo-th key if it is a GID, or as an address
+func checkGuardianWitness(srvc *native.NativeService, guardian []byte, keyNo uint32) error {
+	if !account.VerifyID(string(guardian)) {
+		return checkWitness(srvc, guardian)
+	}
+	key, err := encodeID(guardian)
+	if err != nil {
+		return err
+	}
+	pk, err := getPk(srvc, key, keyNo)
+	if err != nil {
+		return err
+	} else if pk.revoked {
+		return errors.New("guardian key revoked")
+	}
+	return checkWitness(srvc, pk.key)
+}
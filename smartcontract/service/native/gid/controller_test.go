@@ -0,0 +1,220 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gid
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common/config"
+)
+
+func TestSerGuardianSet(t *testing.T) {
+	set := &guardianSet{
+		threshold: 2,
+		delay:     86400,
+		guardians: [][]byte{
+			[]byte("GID:ZPT:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"),
+			make([]byte, 20),
+		},
+	}
+	var buf bytes.Buffer
+	if err := set.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	set2 := new(guardianSet)
+	if err := set2.Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if set2.threshold != set.threshold || set2.delay != set.delay || len(set2.guardians) != 2 {
+		t.Fatalf("unexpected guardian set %+v", set2)
+	}
+	if !set2.contains(set.guardians[0]) || !set2.contains(set.guardians[1]) || set2.contains([]byte("other")) {
+		t.Fatal("guardian set membership mismatch")
+	}
+}
+
+func TestSerPendingRecovery(t *testing.T) {
+	pending := &pendingRecovery{
+		newKey:     []byte{0x02, 0x03, 0x04},
+		approvals:  [][]byte{make([]byte, 20)},
+		keys:       [][]byte{{0x02, 0x03, 0x04}},
+		quorumTime: 1500000000,
+	}
+	var buf bytes.Buffer
+	if err := pending.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pending2 := new(pendingRecovery)
+	if err := pending2.Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pending.newKey, pending2.newKey) || pending.quorumTime != pending2.quorumTime ||
+		len(pending2.approvals) != 1 || !bytes.Equal(pending.approvals[0], pending2.approvals[0]) ||
+		len(pending2.keys) != 1 || !bytes.Equal(pending.keys[0], pending2.keys[0]) {
+		t.Fatalf("unexpected pending recovery %+v", pending2)
+	}
+
+	//every approval names its key
+	pending.keys = nil
+	buf.Reset()
+	if err := pending.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := new(pendingRecovery).Deserialize(&buf); err == nil {
+		t.Fatal("approvals without keys should be rejected")
+	}
+}
+
+func TestPolicyThreshold(t *testing.T) {
+	policy := &controlPolicy{keyThreshold: 3, attrThreshold: 2}
+	var buf bytes.Buffer
+	if err := policy.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	policy2 := new(controlPolicy)
+	if err := policy2.Deserialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if policy2.threshold(OP_KEY) != 3 || policy2.threshold(OP_ATTR) != 2 {
+		t.Fatalf("unexpected policy %+v", policy2)
+	}
+}
+
+func TestReadBytesListLimit(t *testing.T) {
+	list := make([][]byte, MAX_SIGNERS+1)
+	var buf bytes.Buffer
+	if err := writeBytesList(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBytesList(&buf, MAX_SIGNERS); err == nil {
+		t.Fatal("list over the limit should be rejected")
+	}
+}
+
+func TestPolicySigners(t *testing.T) {
+	defer soloNet()()
+	srvc := newTestService(t)
+	a, b, c := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	id := newTestGID(t, srvc, a, b, c)
+
+	setPolicy := func(keyThreshold, attrThreshold uint64, signers ...*account.Account) error {
+		args := new(testArgs).varBytes(id).varUint(keyThreshold).varUint(attrThreshold)
+		var keys [][]byte
+		for _, acc := range signers {
+			keys = append(keys, pubKey(acc))
+		}
+		return call(srvc, "setPolicy", args.bytesList(keys...), signers...)
+	}
+	if err := setPolicy(4, 1, a); err == nil {
+		t.Fatal("a threshold above the number of keys was set")
+	}
+	if err := setPolicy(2, 1, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := setPolicy(1, 1, a); err == nil {
+		t.Fatal("a 2-of-3 policy was changed by a single key")
+	}
+
+	d := pubKey(account.NewAccount(""))
+	addKey := func(signers [][]byte, witnesses ...*account.Account) error {
+		return call(srvc, "addKeyBySigners", new(testArgs).varBytes(id).varBytes(d).bytesList(signers...), witnesses...)
+	}
+	if err := addKey([][]byte{pubKey(a)}, a); err == nil {
+		t.Fatal("key added below the threshold")
+	}
+	if err := addKey([][]byte{pubKey(a), pubKey(a)}, a); err == nil {
+		t.Fatal("a signer was counted twice")
+	}
+	if err := addKey([][]byte{pubKey(a), pubKey(b)}, a); err == nil {
+		t.Fatal("a signer without witness was counted")
+	}
+	outsider := account.NewAccount("")
+	if err := addKey([][]byte{pubKey(a), pubKey(outsider)}, a, outsider); err == nil {
+		t.Fatal("a key which is not an owner was counted")
+	}
+	if err := addKey([][]byte{pubKey(a), pubKey(b)}, a, b); err != nil {
+		t.Fatal(err)
+	}
+
+	//single key methods are closed by the policy, attributes still need 1 signer
+	if err := call(srvc, "removeKey", new(testArgs).varBytes(id).varBytes(d).varBytes(pubKey(a)), a); err == nil {
+		t.Fatal("a key was removed by a single owner")
+	}
+	attr := &attribute{key: []byte("hub"), valueType: []byte("string"), value: []byte("zeepin")}
+	args := new(testArgs).varBytes(id).varUint(1)
+	attr.Serialize(args)
+	if err := call(srvc, "addAttributesBySigners", args.bytesList(pubKey(c)), c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLegacyRecovery(t *testing.T) {
+	defer soloNet()()
+	srvc := newTestService(t)
+	owner, second, recovery := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	id := newTestGID(t, srvc, owner, second)
+	args := new(testArgs).varBytes(id).varBytes(recovery.Address[:]).varBytes(pubKey(owner))
+	if err := call(srvc, "addRecovery", args, owner); err != nil {
+		t.Fatal(err)
+	}
+
+	removeKey := func(key []byte) error {
+		args := new(testArgs).varBytes(id).varBytes(key).varBytes(recovery.Address[:])
+		return call(srvc, "removeKey", args, recovery)
+	}
+	if err := removeKey(pubKey(second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeKey(pubKey(owner)); err == nil {
+		t.Fatal("the last key was removed")
+	}
+
+	//guardians replace the recovery address
+	third := account.NewAccount("")
+	if err := call(srvc, "addKey", new(testArgs).varBytes(id).varBytes(pubKey(third)).varBytes(pubKey(owner)),
+		owner); err != nil {
+		t.Fatal(err)
+	}
+	setTestGuardians(t, srvc, id, owner, 1, 100, account.NewAccount(""))
+	if err := removeKey(pubKey(third)); err == nil || !strings.Contains(err.Error(), "guardians") {
+		t.Fatalf("the recovery address bypassed the guardians: %v", err)
+	}
+}
+
+func TestPolicyHeight(t *testing.T) {
+	height := config.GetGidPolicyHeight(config.DefConfig.P2PNode.NetworkId)
+	if height == 0 {
+		t.Skip("policies are active from genesis")
+	}
+	srvc := newTestService(t)
+	owner := account.NewAccount("")
+	id := newTestGID(t, srvc, owner)
+	args := new(testArgs).varBytes(id).varUint(1).varUint(1).bytesList(pubKey(owner))
+	if err := call(srvc, "setPolicy", args, owner); err == nil || !strings.Contains(err.Error(), "not active") {
+		t.Fatalf("setPolicy is invocable before the fork height: %v", err)
+	}
+	//the last key can still be removed before the fork height
+	args = new(testArgs).varBytes(id).varBytes(pubKey(owner)).varBytes(pubKey(owner))
+	if err := call(srvc, "removeKey", args, owner); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package gid
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	com "github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+const (
+	DID_CONTEXT = "https://www.w3.org/ns/did/v1"
+	DID_METHOD  = "did:zpt:"
+	GID_PREFIX  = "GID:ZPT:"
+)
+
+// verification method types of the key algorithms supported by account
+const (
+	KEY_TYPE_SECP256R1 = "EcdsaSecp256r1VerificationKey2019"
+	KEY_TYPE_ECDSA     = "EcdsaVerificationKey"
+	KEY_TYPE_SM2       = "SM2VerificationKey"
+	KEY_TYPE_ED25519   = "Ed25519VerificationKey2018"
+)
+
+// DDOKey is an unrevoked owner key as returned by getPublicKeys
+type DDOKey struct {
+	Index uint32
+	Key   []byte
+}
+
+// DDOAttribute is an attribute as returned by getAttributes
+type DDOAttribute struct {
+	Key       []byte
+	ValueType []byte
+	Value     []byte
+}
+
+// DDO is the decoded result of getDDO
+type DDO struct {
+	Keys       []*DDOKey
+	Attributes []*DDOAttribute
+	Recovery   []byte
+}
+
+type VerificationMethod struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyHex string `json:"publicKeyHex"`
+}
+
+type Service struct {
+	Id              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+// DIDDocument is the W3C DID document rendering of a GID
+type DIDDocument struct {
+	Context            []string              `json:"@context"`
+	Id                 string                `json:"id"`
+	VerificationMethod []*VerificationMethod `json:"verificationMethod"`
+	Authentication     []string              `json:"authentication"`
+	Service            []*Service            `json:"service,omitempty"`
+	Recovery           string                `json:"recovery,omitempty"`
+}
+
+// DIDToGID converts a did:zpt: identifier to the GID registered on chain
+func DIDToGID(did string) (string, error) {
+	if !strings.HasPrefix(did, DID_METHOD) || len(did) == len(DID_METHOD) {
+		return "", fmt.Errorf("invalid DID %s", did)
+	}
+	return GID_PREFIX + did[len(DID_METHOD):], nil
+}
+
+// GIDToDID converts a GID to its did:zpt: identifier
+func GIDToDID(gid string) (string, error) {
+	if !strings.HasPrefix(gid, GID_PREFIX) || len(gid) == len(GID_PREFIX) {
+		return "", fmt.Errorf("invalid GID %s", gid)
+	}
+	return DID_METHOD + gid[len(GID_PREFIX):], nil
+}
+
+func (this *DDO) Deserialize(data []byte) error {
+	buf := bytes.NewBuffer(data)
+	keys, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return fmt.Errorf("read public keys error, %s", err)
+	}
+	attrs, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return fmt.Errorf("read attributes error, %s", err)
+	}
+	recovery, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return fmt.Errorf("read recovery error, %s", err)
+	}
+
+	this.Keys = make([]*DDOKey, 0)
+	kb := bytes.NewBuffer(keys)
+	for kb.Len() > 0 {
+		index, err := serialization.ReadUint32(kb)
+		if err != nil {
+			return fmt.Errorf("read key index error, %s", err)
+		}
+		pk, err := serialization.ReadVarBytes(kb)
+		if err != nil {
+			return fmt.Errorf("read public key error, %s", err)
+		}
+		this.Keys = append(this.Keys, &DDOKey{Index: index, Key: pk})
+	}
+
+	this.Attributes = make([]*DDOAttribute, 0)
+	ab := bytes.NewBuffer(attrs)
+	for ab.Len() > 0 {
+		var attr attribute
+		if err := attr.Deserialize(ab); err != nil {
+			return fmt.Errorf("read attribute error, %s", err)
+		}
+		this.Attributes = append(this.Attributes, &DDOAttribute{
+			Key:       attr.key,
+			ValueType: attr.valueType,
+			Value:     attr.value,
+		})
+	}
+	this.Recovery = recovery
+	return nil
+}
+
+// NewDIDDocument renders the raw result of getDDO for the given did:zpt:
+// identifier as a DID document
+func NewDIDDocument(did string, data []byte) (*DIDDocument, error) {
+	if _, err := DIDToGID(did); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("DID not registered")
+	}
+	ddo := new(DDO)
+	if err := ddo.Deserialize(data); err != nil {
+		return nil, fmt.Errorf("deserialize DDO error, %s", err)
+	}
+
+	doc := &DIDDocument{
+		Context:            []string{DID_CONTEXT},
+		Id:                 did,
+		VerificationMethod: make([]*VerificationMethod, 0, len(ddo.Keys)),
+		Authentication:     make([]string, 0, len(ddo.Keys)),
+	}
+	for _, k := range ddo.Keys {
+		typ, err := verificationKeyType(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("public key %d error, %s", k.Index, err)
+		}
+		id := fmt.Sprintf("%s#keys-%d", did, k.Index)
+		doc.VerificationMethod = append(doc.VerificationMethod, &VerificationMethod{
+			Id:           id,
+			Type:         typ,
+			Controller:   did,
+			PublicKeyHex: hex.EncodeToString(k.Key),
+		})
+		doc.Authentication = append(doc.Authentication, id)
+	}
+	for _, attr := range ddo.Attributes {
+		doc.Service = append(doc.Service, &Service{
+			Id:              did + "#" + string(attr.Key),
+			Type:            string(attr.ValueType),
+			ServiceEndpoint: string(attr.Value),
+		})
+	}
+	if len(ddo.Recovery) > 0 {
+		addr, err := com.AddressParseFromBytes(ddo.Recovery)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recovery address, %s", err)
+		}
+		doc.Recovery = addr.ToBase58()
+	}
+	return doc, nil
+}
+
+// verificationKeyType maps a serialized public key to its verification
+// method type. P256 keys are serialized as a bare compressed point, other
+// keys are prefixed with the key type and curve label.
+func verificationKeyType(pk []byte) (string, error) {
+	if len(pk) == 0 {
+		return "", errors.New("empty public key")
+	}
+	if len(pk) == 33 && (pk[0] == 0x02 || pk[0] == 0x03) {
+		return KEY_TYPE_SECP256R1, nil
+	}
+	if len(pk) < 2 {
+		return "", errors.New("invalid public key length")
+	}
+	switch keypair.KeyType(pk[0]) {
+	case keypair.PK_ECDSA:
+		if pk[1] == keypair.P256 {
+			return KEY_TYPE_SECP256R1, nil
+		}
+		return KEY_TYPE_ECDSA, nil
+	case keypair.PK_SM2:
+		return KEY_TYPE_SM2, nil
+	case keypair.PK_EDDSA:
+		return KEY_TYPE_ED25519, nil
+	}
+	return "", fmt.Errorf("unsupported key type %d", pk[0])
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gid
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common/serialization"
+)
+
+func TestNewDIDDocument(t *testing.T) {
+	pk, _ := hex.DecodeString("03b2f2a1e2ad2d2d9b3d1c9b6c4f1c7a8fa5ab2f8b0e5d6c3c7e0ab35f7d2e1a9c")
+	var keys bytes.Buffer
+	serialization.WriteUint32(&keys, 2)
+	serialization.WriteVarBytes(&keys, pk)
+
+	attr := &attribute{
+		key:       []byte("hub"),
+		valueType: []byte("IdentityHub"),
+		value:     []byte("https://hub.example.com"),
+	}
+	var attrs bytes.Buffer
+	if err := attr.Serialize(&attrs); err != nil {
+		t.Fatal(err)
+	}
+
+	var ddo bytes.Buffer
+	serialization.WriteVarBytes(&ddo, keys.Bytes())
+	serialization.WriteVarBytes(&ddo, attrs.Bytes())
+	serialization.WriteVarBytes(&ddo, nil)
+
+	did := "did:zpt:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"
+	doc, err := NewDIDDocument(did, ddo.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Id != did || len(doc.VerificationMethod) != 1 || len(doc.Service) != 1 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	vm := doc.VerificationMethod[0]
+	if vm.Id != did+"#keys-2" || vm.Type != KEY_TYPE_SECP256R1 || vm.PublicKeyHex != hex.EncodeToString(pk) {
+		t.Fatalf("unexpected verification method %+v", vm)
+	}
+	if doc.Authentication[0] != vm.Id {
+		t.Fatalf("unexpected authentication %v", doc.Authentication)
+	}
+	svc := doc.Service[0]
+	if svc.Id != did+"#hub" || svc.Type != "IdentityHub" || svc.ServiceEndpoint != "https://hub.example.com" {
+		t.Fatalf("unexpected service %+v", svc)
+	}
+	if doc.Recovery != "" {
+		t.Fatalf("unexpected recovery %s", doc.Recovery)
+	}
+}
+
+func TestDIDToGID(t *testing.T) {
+	id, err := DIDToGID("did:zpt:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "GID:ZPT:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM" {
+		t.Fatalf("unexpected GID %s", id)
+	}
+	did, err := GIDToDID(id)
+	if err != nil || did != "did:zpt:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM" {
+		t.Fatalf("unexpected DID %s", did)
+	}
+	if _, err := DIDToGID("did:ont:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"); err == nil {
+		t.Fatal("other DID method should be rejected")
+	}
+}
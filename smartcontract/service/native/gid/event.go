@@ -75,3 +75,25 @@ func triggerRecoveryEvent(srvc *native.NativeService, op string, id []byte, addr
 	st := []string{"Recovery", op, string(id), addr.ToHexString()}
 	newEvent(srvc, st)
 }
+
+func triggerPolicyEvent(srvc *native.NativeService, id []byte, policy *controlPolicy) {
+	st := []interface{}{"Policy", "set", string(id), policy.keyThreshold, policy.attrThreshold}
+	newEvent(srvc, st)
+}
+
+func triggerGuardianEvent(srvc *native.NativeService, op string, id []byte, set *guardianSet) {
+	t := make([]string, len(set.guardians))
+	for i, v := range set.guardians {
+		t[i] = hex.EncodeToString(v)
+	}
+	st := []interface{}{"Guardian", op, string(id), t, set.threshold, set.delay}
+	newEvent(srvc, st)
+}
+
+func triggerRecoveryApprovalEvent(srvc *native.NativeService, op string, id, pub, guardian []byte) {
+	st := []interface{}{"KeyRecovery", op, string(id), hex.EncodeToString(pub)}
+	if guardian != nil {
+		st = append(st, hex.EncodeToString(guardian))
+	}
+	newEvent(srvc, st)
+}
@@ -34,6 +34,9 @@
 package gid
 
 import (
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
 )
@@ -42,6 +45,26 @@ func Init() {
 	native.Contracts[utils.GIDContractAddress] = RegisterIDContract
 }
 
+// inactive replaces the policy and guardian methods before the fork height
+func inactive(srvc *native.NativeService) ([]byte, error) {
+	return utils.BYTE_FALSE, fmt.Errorf("method is not active before height %d",
+		config.GetGidPolicyHeight(config.DefConfig.P2PNode.NetworkId))
+}
+
+// policyServices are the methods added with control policies and guardians
+var policyServices = map[string]native.Handler{
+	"setPolicy":                setPolicy,
+	"addKeyBySigners":          addKeyBySigners,
+	"removeKeyBySigners":       removeKeyBySigners,
+	"addAttributesBySigners":   addAttributesBySigners,
+	"removeAttributeBySigners": removeAttributeBySigners,
+	"setGuardians":             setGuardians,
+	"approveRecovery":          approveRecovery,
+	"executeRecovery":          executeRecovery,
+	"cancelRecovery":           cancelRecovery,
+	"getController":            GetController,
+}
+
 func RegisterIDContract(srvc *native.NativeService) {
 	srvc.Register("regIDWithPublicKey", regIdWithPublicKey)
 	srvc.Register("addKey", addKey)
@@ -56,5 +79,18 @@ func RegisterIDContract(srvc *native.NativeService) {
 	srvc.Register("getKeyState", GetKeyState)
 	srvc.Register("getAttributes", GetAttributes)
 	srvc.Register("getDDO", GetDDO)
+	active := policyActive(srvc)
+	for name, handler := range policyServices {
+		if !active {
+			handler = inactive
+		}
+		srvc.Register(name, handler)
+	}
 	return
 }
+
+// policyActive returns whether the block being executed is past the fork
+// height of control policies and guardians
+func policyActive(srvc *native.NativeService) bool {
+	return srvc.Height >= config.GetGidPolicyHeight(config.DefConfig.P2PNode.NetworkId)
+}
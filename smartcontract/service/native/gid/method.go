@@ -219,10 +219,17 @@ func addKey(srvc *native.NativeService) ([]byte, error) {
 	if len(rec) > 0 {
 		auth = bytes.Equal(rec, arg2)
 	}
-	if !auth {
+	if auth {
+		if err = checkLegacyRecovery(srvc, key); err != nil {
+			return utils.BYTE_FALSE, errors.New("add key failed: " + err.Error())
+		}
+	} else {
 		if !isOwner(srvc, key, arg2) {
 			return utils.BYTE_FALSE, errors.New("add key failed: operator has no authorization")
 		}
+		if err = checkSingleOperator(srvc, key, OP_KEY); err != nil {
+			return utils.BYTE_FALSE, errors.New("add key failed: " + err.Error())
+		}
 	}
 
 	item, err := findPk(srvc, key, arg1)
@@ -275,12 +282,25 @@ func removeKey(srvc *native.NativeService) ([]byte, error) {
 	if len(rec) > 0 {
 		auth = bytes.Equal(rec, arg2)
 	}
-	if !auth {
+	if auth {
+		if err = checkLegacyRecovery(srvc, key); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+		}
+	} else {
 		if !isOwner(srvc, key, arg2) {
 			return utils.BYTE_FALSE, errors.New("remove key failed: operator has no authorization")
 		}
+		if err = checkSingleOperator(srvc, key, OP_KEY); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+		}
 	}
 
+	// the last key could be removed before policies existed
+	if policyActive(srvc) {
+		if err = checkRemainingPk(srvc, key); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+		}
+	}
 	keyID, err := revokePk(srvc, key, arg1)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
@@ -324,6 +344,9 @@ func addRecovery(srvc *native.NativeService) ([]byte, error) {
 	if !isOwner(srvc, key, arg2) {
 		return utils.BYTE_FALSE, errors.New("add recovery failed: not authorized")
 	}
+	if err = checkSingleOperator(srvc, key, OP_KEY); err != nil {
+		return utils.BYTE_FALSE, errors.New("add recovery failed: " + err.Error())
+	}
 
 	re, err := getRecovery(srvc, key)
 	if err == nil && len(re) > 0 {
@@ -424,6 +447,9 @@ func addAttributes(srvc *native.NativeService) ([]byte, error) {
 	if !isOwner(srvc, key, arg2) {
 		return utils.BYTE_FALSE, errors.New("add attributes failed, no authorization")
 	}
+	if err = checkSingleOperator(srvc, key, OP_ATTR); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, %s", err)
+	}
 	err = checkWitness(srvc, arg2)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, %s", err)
@@ -474,6 +500,9 @@ func removeAttribute(srvc *native.NativeService) ([]byte, error) {
 	if !isOwner(srvc, key, arg2) {
 		return utils.BYTE_FALSE, errors.New("remove attribute failed: no authorization")
 	}
+	if err = checkSingleOperator(srvc, key, OP_ATTR); err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: " + err.Error())
+	}
 
 	key1 := append(key, FIELD_ATTR)
 	ok, err := utils.LinkedlistDelete(srvc, key1, arg1)
@@ -0,0 +1,562 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package gid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+func setPolicy(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: argument 0 error, %s", err)
+	}
+	// arg1: threshold of key management
+	arg1, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: argument 1 error, %s", err)
+	}
+	// arg2: threshold of attribute updates
+	arg2, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: argument 2 error, %s", err)
+	}
+	// arg3: signers' public keys
+	arg3, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: argument 3 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("set policy failed: ID not registered")
+	}
+	n, err := countActivePk(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: %s", err)
+	}
+	if arg1 == 0 || arg2 == 0 || arg1 > uint64(n) || arg2 > uint64(n) {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: invalid threshold, should be in [1, %d]", n)
+	}
+	if err = checkSigners(srvc, key, arg3, OP_KEY); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: %s", err)
+	}
+
+	policy := &controlPolicy{keyThreshold: uint32(arg1), attrThreshold: uint32(arg2)}
+	if err = putPolicy(srvc, key, policy); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set policy failed: %s", err)
+	}
+
+	triggerPolicyEvent(srvc, arg0, policy)
+	return utils.BYTE_TRUE, nil
+}
+
+func addKeyBySigners(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add key failed: argument 0 error, %s", err)
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add key failed: argument 1 error, %s", err)
+	}
+	// arg2: signers' public keys
+	arg2, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add key failed: argument 2 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add key failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("add key failed: ID not registered")
+	}
+	if _, err = keypair.DeserializePublicKey(arg1); err != nil {
+		return utils.BYTE_FALSE, errors.New("add key failed: invalid public key")
+	}
+	if err = checkSigners(srvc, key, arg2, OP_KEY); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add key failed: %s", err)
+	}
+	if item, _ := findPk(srvc, key, arg1); item != 0 {
+		return utils.BYTE_FALSE, errors.New("add key failed: already exists")
+	}
+
+	keyID, err := insertPk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add key failed: insert public key error, %s", err)
+	}
+
+	triggerPublicEvent(srvc, "add", arg0, arg1, keyID)
+	return utils.BYTE_TRUE, nil
+}
+
+func removeKeyBySigners(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: argument 0 error, %s", err)
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: argument 1 error, %s", err)
+	}
+	// arg2: signers' public keys
+	arg2, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: argument 2 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("remove key failed: ID not registered")
+	}
+	if err = checkSigners(srvc, key, arg2, OP_KEY); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+	}
+	if err = checkRemainingPk(srvc, key); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+	}
+
+	keyID, err := revokePk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+	}
+
+	triggerPublicEvent(srvc, "remove", arg0, arg1, keyID)
+	return utils.BYTE_TRUE, nil
+}
+
+func addAttributesBySigners(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, argument 0 error: %s", err)
+	}
+	// arg1: attributes
+	num, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, argument 1 error: %s", err)
+	}
+	var arg1 = make([]attribute, 0)
+	for i := 0; i < int(num); i++ {
+		var v attribute
+		err = v.Deserialize(args)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, argument 1 error: %s", err)
+		}
+		arg1 = append(arg1, v)
+	}
+	// arg2: signers' public keys
+	arg2, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, argument 2 error: %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("add attributes failed, ID not registered")
+	}
+	if err = checkSigners(srvc, key, arg2, OP_ATTR); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, %s", err)
+	}
+
+	err = batchInsertAttr(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add attributes failed, %s", err)
+	}
+
+	var paths = make([][]byte, 0)
+	for _, v := range arg1 {
+		paths = append(paths, v.key)
+	}
+	triggerAttributeEvent(srvc, "add", arg0, paths)
+	return utils.BYTE_TRUE, nil
+}
+
+func removeAttributeBySigners(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: argument 0 error")
+	}
+	// arg1: path
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: argument 1 error")
+	}
+	// arg2: signers' public keys
+	arg2, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: argument 2 error")
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: " + err.Error())
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: ID not registered")
+	}
+	if err = checkSigners(srvc, key, arg2, OP_ATTR); err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: " + err.Error())
+	}
+
+	ok, err := utils.LinkedlistDelete(srvc, fieldKey(key, FIELD_ATTR), arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: delete error, " + err.Error())
+	} else if !ok {
+		return utils.BYTE_FALSE, errors.New("remove attribute failed: attribute not exist")
+	}
+
+	triggerAttributeEvent(srvc, "remove", arg0, [][]byte{arg1})
+	return utils.BYTE_TRUE, nil
+}
+
+func setGuardians(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: argument 0 error, %s", err)
+	}
+	// arg1: guardian GIDs or addresses
+	arg1, err := readBytesList(args, MAX_GUARDIANS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: argument 1 error, %s", err)
+	}
+	// arg2: number of guardians required to recover
+	arg2, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: argument 2 error, %s", err)
+	}
+	// arg3: delay in seconds before an approved recovery takes effect
+	arg3, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: argument 3 error, %s", err)
+	}
+	// arg4: signers' public keys
+	arg4, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: argument 4 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("set guardians failed: ID not registered")
+	}
+	if arg2 == 0 || arg2 > uint64(len(arg1)) {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: invalid threshold, should be in [1, %d]", len(arg1))
+	}
+	if arg3 > 0xFFFFFFFF {
+		return utils.BYTE_FALSE, errors.New("set guardians failed: invalid delay")
+	}
+	for i, g := range arg1 {
+		if bytes.Equal(g, arg0) {
+			return utils.BYTE_FALSE, errors.New("set guardians failed: ID cannot guard itself")
+		}
+		if !account.VerifyID(string(g)) && len(g) != 20 {
+			return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: guardian %d is neither GID nor address", i)
+		}
+		for _, v := range arg1[:i] {
+			if bytes.Equal(v, g) {
+				return utils.BYTE_FALSE, errors.New("set guardians failed: duplicated guardian")
+			}
+		}
+	}
+	if err = checkSigners(srvc, key, arg4, OP_KEY); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: %s", err)
+	}
+
+	set := &guardianSet{threshold: uint32(arg2), delay: uint32(arg3), guardians: arg1}
+	if err = putGuardians(srvc, key, set); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set guardians failed: %s", err)
+	}
+	// approvals collected under the old guardian set are void
+	deletePendingRecovery(srvc, key)
+
+	triggerGuardianEvent(srvc, "set", arg0, set)
+	return utils.BYTE_TRUE, nil
+}
+
+func approveRecovery(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: argument 0 error, %s", err)
+	}
+	// arg1: new public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: argument 1 error, %s", err)
+	}
+	// arg2: guardian
+	arg2, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: argument 2 error, %s", err)
+	}
+	// arg3: key index of the guardian GID, ignored for address guardians
+	arg3, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: argument 3 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("approve recovery failed: ID not registered")
+	}
+	if _, err = keypair.DeserializePublicKey(arg1); err != nil {
+		return utils.BYTE_FALSE, errors.New("approve recovery failed: invalid public key")
+	}
+	set, err := getGuardians(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: %s", err)
+	} else if set == nil {
+		return utils.BYTE_FALSE, errors.New("approve recovery failed: guardians not set")
+	}
+	if !set.contains(arg2) {
+		return utils.BYTE_FALSE, errors.New("approve recovery failed: not a guardian")
+	}
+	if err = checkGuardianWitness(srvc, arg2, uint32(arg3)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: %s", err)
+	}
+
+	pending, err := getPendingRecovery(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: %s", err)
+	}
+	if pending == nil {
+		pending = new(pendingRecovery)
+	} else if pending.quorumTime != 0 {
+		return utils.BYTE_FALSE, errors.New("approve recovery failed: a recovery is already approved")
+	}
+	// approvals are counted per key, so a guardian approving a key of its own
+	// cannot keep the others from recovering
+	n, err := pending.approve(arg2, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: %s", err)
+	}
+	if n >= set.threshold {
+		pending.newKey = arg1
+		pending.quorumTime = srvc.Time
+	}
+	if err = putPendingRecovery(srvc, key, pending); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("approve recovery failed: %s", err)
+	}
+
+	triggerRecoveryApprovalEvent(srvc, "approve", arg0, arg1, arg2)
+	return utils.BYTE_TRUE, nil
+}
+
+func executeRecovery(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: argument 0 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("execute recovery failed: ID not registered")
+	}
+	set, err := getGuardians(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: %s", err)
+	} else if set == nil {
+		return utils.BYTE_FALSE, errors.New("execute recovery failed: guardians not set")
+	}
+	pending, err := getPendingRecovery(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: %s", err)
+	} else if pending == nil || pending.quorumTime == 0 {
+		return utils.BYTE_FALSE, errors.New("execute recovery failed: no approved recovery")
+	}
+	if uint64(srvc.Time) < uint64(pending.quorumTime)+uint64(set.delay) {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: delayed until %d", uint64(pending.quorumTime)+uint64(set.delay))
+	}
+
+	// rotate: revoke every owner key and make the approved key the only one
+	pkKey := fieldKey(key, FIELD_PK)
+	owners, err := getAllPk(srvc, pkKey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: %s", err)
+	}
+	for i, v := range owners {
+		if !v.revoked && !bytes.Equal(v.key, pending.newKey) {
+			v.revoked = true
+			triggerPublicEvent(srvc, "remove", arg0, v.key, uint32(i+1))
+		}
+	}
+	if err = putAllPk(srvc, pkKey, owners); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: %s", err)
+	}
+	if n, _ := countActivePk(srvc, key); n == 0 {
+		keyID, err := insertPk(srvc, key, pending.newKey)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: insert public key error, %s", err)
+		}
+		triggerPublicEvent(srvc, "add", arg0, pending.newKey, keyID)
+	}
+	// a single key remains, so thresholds above 1 would lock the GID
+	if err = putPolicy(srvc, key, &controlPolicy{keyThreshold: 1, attrThreshold: 1}); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("execute recovery failed: %s", err)
+	}
+	deletePendingRecovery(srvc, key)
+
+	triggerRecoveryApprovalEvent(srvc, "execute", arg0, pending.newKey, nil)
+	return utils.BYTE_TRUE, nil
+}
+
+func cancelRecovery(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel recovery failed: argument 0 error, %s", err)
+	}
+	// arg1: signers' public keys
+	arg1, err := readBytesList(args, MAX_SIGNERS)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel recovery failed: argument 1 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel recovery failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("cancel recovery failed: ID not registered")
+	}
+	pending, err := getPendingRecovery(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel recovery failed: %s", err)
+	} else if pending == nil {
+		return utils.BYTE_FALSE, errors.New("cancel recovery failed: no pending recovery")
+	}
+	if err = checkSigners(srvc, key, arg1, OP_KEY); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel recovery failed: %s", err)
+	}
+	deletePendingRecovery(srvc, key)
+
+	triggerRecoveryApprovalEvent(srvc, "cancel", arg0, pending.newKey, nil)
+	return utils.BYTE_TRUE, nil
+}
+
+// GetController returns the policy thresholds, the guardian set and the
+// pending recovery of a GID
+func GetController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	did, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return nil, fmt.Errorf("get controller error: invalid argument, %s", err)
+	}
+	key, err := encodeID(did)
+	if err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return nil, errors.New("get controller error: ID not registered")
+	}
+	policy, err := getPolicy(srvc, key)
+	if err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	}
+	set, err := getGuardians(srvc, key)
+	if err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	} else if set == nil {
+		set = new(guardianSet)
+	}
+	pending, err := getPendingRecovery(srvc, key)
+	if err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	} else if pending == nil {
+		pending = new(pendingRecovery)
+	}
+
+	var res bytes.Buffer
+	if err = policy.Serialize(&res); err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	}
+	if err = set.Serialize(&res); err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	}
+	if err = pending.Serialize(&res); err != nil {
+		return nil, fmt.Errorf("get controller error: %s", err)
+	}
+	return res.Bytes(), nil
+}
+
+// checkRemainingPk makes sure removing one more key leaves enough keys to
+// satisfy the policy
+func checkRemainingPk(srvc *native.NativeService, encID []byte) error {
+	policy, err := getPolicy(srvc, encID)
+	if err != nil {
+		return err
+	}
+	n, err := countActivePk(srvc, encID)
+	if err != nil {
+		return err
+	}
+	need := policy.keyThreshold
+	if policy.attrThreshold > need {
+		need = policy.attrThreshold
+	}
+	if n <= need {
+		return fmt.Errorf("%d keys are required by policy", need)
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/core/store/leveldbstore"
+	"github.com/imZhuFei/zeepin/core/store/statestore"
+	"github.com/imZhuFei/zeepin/smartcontract/context"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+	"github.com/imZhuFei/zeepin/smartcontract/storage"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+func init() {
+	log.InitLog(log.WarnLog, log.Stdout)
+}
+
+//testContext is the context of a transaction signed by the witness accounts
+type testContext struct {
+	contexts  []*context.Context
+	witnesses map[common.Address]bool
+}
+
+func (this *testContext) PushContext(ctx *context.Context) {
+	this.contexts = append(this.contexts, ctx)
+}
+
+func (this *testContext) CurrentContext() *context.Context {
+	return this.contexts[len(this.contexts)-1]
+}
+
+func (this *testContext) CallingContext() *context.Context {
+	if len(this.contexts) < 2 {
+		return nil
+	}
+	return this.contexts[len(this.contexts)-2]
+}
+
+func (this *testContext) EntryContext() *context.Context {
+	return this.contexts[0]
+}
+
+func (this *testContext) PopContext() {
+	this.contexts = this.contexts[:len(this.contexts)-1]
+}
+
+func (this *testContext) CheckWitness(address common.Address) bool {
+	return this.witnesses[address]
+}
+
+func (this *testContext) PushNotifications(notifications []*event.NotifyEventInfo) {}
+
+func (this *testContext) NewExecuteEngine(code []byte) (context.Engine, error) {
+	return nil, nil
+}
+
+func (this *testContext) CheckUseGas(gas uint64) bool { return true }
+
+func (this *testContext) GasLeft() uint64 { return 0 }
+
+func (this *testContext) CheckExecStep() bool { return true }
+
+//testArgs builds the input of a method
+type testArgs struct {
+	bytes.Buffer
+}
+
+func (this *testArgs) varBytes(v []byte) *testArgs {
+	serialization.WriteVarBytes(this, v)
+	return this
+}
+
+func (this *testArgs) varUint(v uint64) *testArgs {
+	utils.WriteVarUint(this, v)
+	return this
+}
+
+func (this *testArgs) bytesList(v ...[]byte) *testArgs {
+	writeBytesList(this, v)
+	return this
+}
+
+//soloNet switches to a private network, where policies and guardians are
+//active from genesis, and returns the function restoring the network id
+func soloNet() func() {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	return func() { config.DefConfig.P2PNode.NetworkId = networkId }
+}
+
+//newTestService returns a gid contract service on an empty in-memory store
+func newTestService(t *testing.T) *native.NativeService {
+	db, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &testContext{witnesses: make(map[common.Address]bool)}
+	ctx.PushContext(&context.Context{ContractAddress: utils.GIDContractAddress})
+	srvc := &native.NativeService{
+		CloneCache: storage.NewCloneCache(statestore.NewStateStoreBatch(statestore.NewMemDatabase(), db)),
+		ServiceMap: make(map[string]native.Handler),
+		ContextRef: ctx,
+		Time:       1000,
+	}
+	RegisterIDContract(srvc)
+	return srvc
+}
+
+//call invokes the method in a transaction signed by the signers
+func call(srvc *native.NativeService, method string, args *testArgs, signers ...*account.Account) error {
+	witnesses := make(map[common.Address]bool)
+	for _, acc := range signers {
+		witnesses[acc.Address] = true
+	}
+	srvc.ContextRef.(*testContext).witnesses = witnesses
+	srvc.Input = args.Bytes()
+	_, err := srvc.ServiceMap[method](srvc)
+	return err
+}
+
+func pubKey(acc *account.Account) []byte {
+	return keypair.SerializePublicKey(acc.PublicKey)
+}
+
+//newTestGID registers a gid owned by the keys of the accounts
+func newTestGID(t *testing.T, srvc *native.NativeService, owners ...*account.Account) []byte {
+	id, err := account.GenerateID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call(srvc, "regIDWithPublicKey", new(testArgs).varBytes([]byte(id)).varBytes(pubKey(owners[0])),
+		owners[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, acc := range owners[1:] {
+		if err := call(srvc, "addKey", new(testArgs).varBytes([]byte(id)).varBytes(pubKey(acc)).varBytes(pubKey(owners[0])),
+			owners[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []byte(id)
+}
+
+//activeKeys returns the unrevoked owner keys of the gid
+func activeKeys(t *testing.T, srvc *native.NativeService, id []byte) [][]byte {
+	key, _ := encodeID(id)
+	owners, err := getAllPk(srvc, fieldKey(key, FIELD_PK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys [][]byte
+	for _, v := range owners {
+		if !v.revoked {
+			keys = append(keys, v.key)
+		}
+	}
+	return keys
+}
+
+//setTestGuardians makes address guardians of the accounts recover the gid
+func setTestGuardians(t *testing.T, srvc *native.NativeService, id []byte, owner *account.Account,
+	threshold, delay uint64, guardians ...*account.Account) {
+	var list [][]byte
+	for _, acc := range guardians {
+		list = append(list, acc.Address[:])
+	}
+	args := new(testArgs).varBytes(id).bytesList(list...).varUint(threshold).varUint(delay).bytesList(pubKey(owner))
+	if err := call(srvc, "setGuardians", args, owner); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func approve(srvc *native.NativeService, id, newKey []byte, guardian *account.Account) error {
+	args := new(testArgs).varBytes(id).varBytes(newKey).varBytes(guardian.Address[:]).varUint(0)
+	return call(srvc, "approveRecovery", args, guardian)
+}
+
+func TestRecoveryFrontRunning(t *testing.T) {
+	defer soloNet()()
+	srvc := newTestService(t)
+	owner := account.NewAccount("")
+	g1, g2, g3 := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	id := newTestGID(t, srvc, owner)
+	setTestGuardians(t, srvc, id, owner, 2, 100, g1, g2, g3)
+
+	//the owner lost its key, a dishonest guardian approves a key of its own first
+	bad, good := pubKey(account.NewAccount("")), pubKey(account.NewAccount(""))
+	if err := approve(srvc, id, bad, g1); err != nil {
+		t.Fatal(err)
+	}
+	if err := approve(srvc, id, bad, g1); err == nil {
+		t.Fatal("a guardian approved the same key twice")
+	}
+	if err := approve(srvc, id, good, g2); err != nil {
+		t.Fatalf("the first approval blocks the other keys: %s", err)
+	}
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err == nil {
+		t.Fatal("executed a recovery without quorum")
+	}
+	if err := approve(srvc, id, good, g3); err != nil {
+		t.Fatal(err)
+	}
+
+	//the approved key is fixed once it reached the threshold
+	if err := approve(srvc, id, bad, g2); err == nil {
+		t.Fatal("an approval was moved after quorum")
+	}
+	srvc.Time += 100
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err != nil {
+		t.Fatal(err)
+	}
+	keys := activeKeys(t, srvc, id)
+	if len(keys) != 1 || !bytes.Equal(keys[0], good) {
+		t.Fatalf("unexpected keys after recovery %x", keys)
+	}
+}
+
+func TestRecoveryMoveApproval(t *testing.T) {
+	defer soloNet()()
+	srvc := newTestService(t)
+	owner := account.NewAccount("")
+	g1, g2, g3 := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	id := newTestGID(t, srvc, owner)
+	setTestGuardians(t, srvc, id, owner, 2, 0, g1, g2, g3)
+
+	k1, k2 := pubKey(account.NewAccount("")), pubKey(account.NewAccount(""))
+	for _, step := range []struct {
+		key      []byte
+		guardian *account.Account
+	}{{k1, g1}, {k2, g2}, {k2, g1}} {
+		if err := approve(srvc, id, step.key, step.guardian); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//g1 moved its approval to k2, which reached the threshold
+	key, _ := encodeID(id)
+	pending, err := getPendingRecovery(srvc, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pending.newKey, k2) || pending.quorumTime != srvc.Time || len(pending.approvals) != 2 {
+		t.Fatalf("unexpected pending recovery %+v", pending)
+	}
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err != nil {
+		t.Fatal(err)
+	}
+	if keys := activeKeys(t, srvc, id); len(keys) != 1 || !bytes.Equal(keys[0], k2) {
+		t.Fatalf("unexpected keys after recovery %x", keys)
+	}
+}
+
+func TestRecoveryDelayAndCancel(t *testing.T) {
+	defer soloNet()()
+	srvc := newTestService(t)
+	owner := account.NewAccount("")
+	g1, g2 := account.NewAccount(""), account.NewAccount("")
+	id := newTestGID(t, srvc, owner)
+	setTestGuardians(t, srvc, id, owner, 2, 100, g1, g2)
+
+	newKey := pubKey(account.NewAccount(""))
+	if err := approve(srvc, id, newKey, account.NewAccount("")); err == nil {
+		t.Fatal("approved by a non guardian")
+	}
+	args := new(testArgs).varBytes(id).varBytes(newKey).varBytes(g1.Address[:]).varUint(0)
+	if err := call(srvc, "approveRecovery", args, g2); err == nil {
+		t.Fatal("approved without the guardian's witness")
+	}
+
+	//the owner still holding its key cancels the recovery
+	for _, g := range []*account.Account{g1, g2} {
+		if err := approve(srvc, id, newKey, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srvc.Time += 99
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err == nil {
+		t.Fatal("executed a recovery before the delay")
+	}
+	if err := call(srvc, "cancelRecovery", new(testArgs).varBytes(id).bytesList(pubKey(owner))); err == nil {
+		t.Fatal("cancelled without the owner's witness")
+	}
+	if err := call(srvc, "cancelRecovery", new(testArgs).varBytes(id).bytesList(pubKey(owner)), owner); err != nil {
+		t.Fatal(err)
+	}
+	srvc.Time += 1
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err == nil {
+		t.Fatal("executed a cancelled recovery")
+	}
+
+	//the delay starts at quorum
+	for _, g := range []*account.Account{g1, g2} {
+		if err := approve(srvc, id, newKey, g); err != nil {
+			t.Fatal(err)
+		}
+		srvc.Time += 50
+	}
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err == nil {
+		t.Fatal("executed a recovery before the delay")
+	}
+	srvc.Time += 50
+	if err := call(srvc, "executeRecovery", new(testArgs).varBytes(id)); err != nil {
+		t.Fatal(err)
+	}
+	if keys := activeKeys(t, srvc, id); len(keys) != 1 || !bytes.Equal(keys[0], newKey) {
+		t.Fatalf("unexpected keys after recovery %x", keys)
+	}
+	key, _ := encodeID(id)
+	if pending, _ := getPendingRecovery(srvc, key); pending != nil {
+		t.Fatalf("pending recovery left after execution %+v", pending)
+	}
+}
@@ -63,6 +63,9 @@ const (
 	FIELD_PK byte = 1 + iota
 	FIELD_ATTR
 	FIELD_RECOVERY
+	FIELD_POLICY
+	FIELD_GUARDIANS
+	FIELD_PENDING_RECOVERY
 )
 
 func encodeID(id []byte) ([]byte, error) {
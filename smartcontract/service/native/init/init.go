@@ -43,11 +43,13 @@ import (
 	invoke "github.com/imZhuFei/zeepin/core/utils"
 	vm "github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/auth"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/claim"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/gala"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/gid"
 	params "github.com/imZhuFei/zeepin/smartcontract/service/native/global_params"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/governance"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/nft"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/zpt"
 )
@@ -61,6 +63,8 @@ func init() {
 	zpt.InitZpt()
 	params.InitGlobalParams()
 	gid.Init()
+	claim.Init()
+	nft.Init()
 	auth.Init()
 	governance.InitGovernance()
 }
@@ -0,0 +1,403 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nft
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/account"
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+// limits on the sizes of the values kept in storage
+const (
+	MAX_ID_LENGTH           = 64
+	MAX_NAME_LENGTH         = 64
+	MAX_URI_LENGTH          = 256
+	MAX_CONTENT_HASH_LENGTH = 64
+)
+
+func Init() {
+	native.Contracts[utils.NftContractAddress] = RegisterNftContract
+}
+
+func CreateClass(native *native.NativeService) ([]byte, error) {
+	param := new(CreateClassParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[createClass] deserialize param failed: %v", err)
+	}
+	if len(param.ClassId) == 0 || len(param.ClassId) > MAX_ID_LENGTH {
+		return nil, fmt.Errorf("[createClass] invalid param: class id length %d", len(param.ClassId))
+	}
+	if len(param.Name) > MAX_NAME_LENGTH || len(param.Symbol) > MAX_NAME_LENGTH {
+		return nil, fmt.Errorf("[createClass] invalid param: name or symbol longer than %d", MAX_NAME_LENGTH)
+	}
+	if !account.VerifyID(string(param.OwnerGID)) {
+		return nil, fmt.Errorf("[createClass] invalid param: ownerGID is %x", param.OwnerGID)
+	}
+
+	class, err := getClass(native, param.ClassId)
+	if err != nil {
+		return nil, fmt.Errorf("[createClass] getClass failed: %v", err)
+	}
+	if class != nil {
+		return nil, fmt.Errorf("[createClass] class %x has already been created", param.ClassId)
+	}
+
+	ret, err := verifySig(native, param.OwnerGID, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[createClass] verify owner's signature failed: %v", err)
+	}
+	if !ret {
+		log.Debugf("[createClass] verifySig return false: ownerGID=%s, keyNo=%d", string(param.OwnerGID), param.KeyNo)
+		return utils.BYTE_FALSE, nil
+	}
+
+	class = &TokenClass{
+		Owner:     param.OwnerGID,
+		Name:      param.Name,
+		Symbol:    param.Symbol,
+		CreatedAt: native.Time,
+	}
+	if err := putClass(native, param.ClassId, class); err != nil {
+		return nil, fmt.Errorf("[createClass] putClass failed: %v", err)
+	}
+	triggerCreateClassEvent(native, param.ClassId, class)
+	return utils.BYTE_TRUE, nil
+}
+
+func Mint(native *native.NativeService) ([]byte, error) {
+	param := new(MintParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[mint] deserialize param failed: %v", err)
+	}
+	if len(param.TokenId) == 0 || len(param.TokenId) > MAX_ID_LENGTH {
+		return nil, fmt.Errorf("[mint] invalid param: token id length %d", len(param.TokenId))
+	}
+	if len(param.URI) > MAX_URI_LENGTH {
+		return nil, fmt.Errorf("[mint] invalid param: uri longer than %d", MAX_URI_LENGTH)
+	}
+	if len(param.ContentHash) > MAX_CONTENT_HASH_LENGTH {
+		return nil, fmt.Errorf("[mint] invalid param: content hash length %d", len(param.ContentHash))
+	}
+	if param.To == common.ADDRESS_EMPTY {
+		return nil, fmt.Errorf("[mint] invalid param: to address is empty")
+	}
+
+	class, err := getClass(native, param.ClassId)
+	if err != nil {
+		return nil, fmt.Errorf("[mint] getClass failed: %v", err)
+	}
+	if class == nil {
+		return nil, fmt.Errorf("[mint] class %x has not been created", param.ClassId)
+	}
+	token, err := getToken(native, param.ClassId, param.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[mint] getToken failed: %v", err)
+	}
+	if token != nil {
+		return nil, fmt.Errorf("[mint] token %x of class %x has already been minted", param.TokenId, param.ClassId)
+	}
+	burned, err := isBurned(native, param.ClassId, param.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[mint] isBurned failed: %v", err)
+	}
+	if burned {
+		return nil, fmt.Errorf("[mint] token %x of class %x has been burned", param.TokenId, param.ClassId)
+	}
+
+	ret, err := verifySig(native, class.Owner, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[mint] verify class owner's signature failed: %v", err)
+	}
+	if !ret {
+		log.Debugf("[mint] verifySig return false: ownerGID=%s, keyNo=%d", string(class.Owner), param.KeyNo)
+		return utils.BYTE_FALSE, nil
+	}
+
+	token = &Token{
+		Owner:       param.To,
+		URI:         param.URI,
+		ContentHash: param.ContentHash,
+		MintedAt:    native.Time,
+	}
+	if err := putToken(native, param.ClassId, param.TokenId, token); err != nil {
+		return nil, fmt.Errorf("[mint] putToken failed: %v", err)
+	}
+	if err := addOwnerToken(native, param.To, param.ClassId, param.TokenId); err != nil {
+		return nil, fmt.Errorf("[mint] index token failed: %v", err)
+	}
+	class.Supply += 1
+	if err := putClass(native, param.ClassId, class); err != nil {
+		return nil, fmt.Errorf("[mint] putClass failed: %v", err)
+	}
+	triggerTransferEvent(native, common.ADDRESS_EMPTY, param.To, param.ClassId, param.TokenId)
+	return utils.BYTE_TRUE, nil
+}
+
+func Transfer(native *native.NativeService) ([]byte, error) {
+	param := new(TransferParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[transfer] deserialize param failed: %v", err)
+	}
+	if param.To == common.ADDRESS_EMPTY {
+		return nil, fmt.Errorf("[transfer] invalid param: to address is empty")
+	}
+	if !native.ContextRef.CheckWitness(param.From) {
+		return nil, fmt.Errorf("[transfer] authentication failed")
+	}
+	token, err := getToken(native, param.ClassId, param.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[transfer] getToken failed: %v", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("[transfer] token %x of class %x does not exist", param.TokenId, param.ClassId)
+	}
+	if token.Owner != param.From {
+		return nil, fmt.Errorf("[transfer] token %x of class %x is not owned by %s", param.TokenId, param.ClassId,
+			param.From.ToBase58())
+	}
+	if err := moveToken(native, param.ClassId, param.TokenId, token, param.To); err != nil {
+		return nil, fmt.Errorf("[transfer] move token failed: %v", err)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+func TransferFrom(native *native.NativeService) ([]byte, error) {
+	param := new(TransferFromParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[transferFrom] deserialize param failed: %v", err)
+	}
+	if param.To == common.ADDRESS_EMPTY {
+		return nil, fmt.Errorf("[transferFrom] invalid param: to address is empty")
+	}
+	if !native.ContextRef.CheckWitness(param.Sender) {
+		return nil, fmt.Errorf("[transferFrom] authentication failed")
+	}
+	token, err := getToken(native, param.ClassId, param.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[transferFrom] getToken failed: %v", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("[transferFrom] token %x of class %x does not exist", param.TokenId, param.ClassId)
+	}
+	if token.Owner != param.From {
+		return nil, fmt.Errorf("[transferFrom] token %x of class %x is not owned by %s", param.TokenId, param.ClassId,
+			param.From.ToBase58())
+	}
+	if token.Approved == common.ADDRESS_EMPTY || token.Approved != param.Sender {
+		return nil, fmt.Errorf("[transferFrom] %s is not approved for token %x of class %x", param.Sender.ToBase58(),
+			param.TokenId, param.ClassId)
+	}
+	if err := moveToken(native, param.ClassId, param.TokenId, token, param.To); err != nil {
+		return nil, fmt.Errorf("[transferFrom] move token failed: %v", err)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// Approve allows spender to transferFrom the token once, approving
+// common.ADDRESS_EMPTY clears the approval
+func Approve(native *native.NativeService) ([]byte, error) {
+	param := new(ApproveParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[approve] deserialize param failed: %v", err)
+	}
+	if !native.ContextRef.CheckWitness(param.Owner) {
+		return nil, fmt.Errorf("[approve] authentication failed")
+	}
+	token, err := getToken(native, param.ClassId, param.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[approve] getToken failed: %v", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("[approve] token %x of class %x does not exist", param.TokenId, param.ClassId)
+	}
+	if token.Owner != param.Owner {
+		return nil, fmt.Errorf("[approve] token %x of class %x is not owned by %s", param.TokenId, param.ClassId,
+			param.Owner.ToBase58())
+	}
+	token.Approved = param.Spender
+	if err := putToken(native, param.ClassId, param.TokenId, token); err != nil {
+		return nil, fmt.Errorf("[approve] putToken failed: %v", err)
+	}
+	triggerApproveEvent(native, param.Owner, param.Spender, param.ClassId, param.TokenId)
+	return utils.BYTE_TRUE, nil
+}
+
+func Burn(native *native.NativeService) ([]byte, error) {
+	param := new(BurnParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[burn] deserialize param failed: %v", err)
+	}
+	if !native.ContextRef.CheckWitness(param.Owner) {
+		return nil, fmt.Errorf("[burn] authentication failed")
+	}
+	token, err := getToken(native, param.ClassId, param.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[burn] getToken failed: %v", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("[burn] token %x of class %x does not exist", param.TokenId, param.ClassId)
+	}
+	if token.Owner != param.Owner {
+		return nil, fmt.Errorf("[burn] token %x of class %x is not owned by %s", param.TokenId, param.ClassId,
+			param.Owner.ToBase58())
+	}
+	class, err := getClass(native, param.ClassId)
+	if err != nil {
+		return nil, fmt.Errorf("[burn] getClass failed: %v", err)
+	}
+	if class == nil {
+		return nil, fmt.Errorf("[burn] class %x has not been created", param.ClassId)
+	}
+
+	if err := delOwnerToken(native, param.Owner, param.ClassId, param.TokenId); err != nil {
+		return nil, fmt.Errorf("[burn] unindex token failed: %v", err)
+	}
+	delToken(native, param.ClassId, param.TokenId)
+	setBurned(native, param.ClassId, param.TokenId)
+	class.Supply -= 1
+	if err := putClass(native, param.ClassId, class); err != nil {
+		return nil, fmt.Errorf("[burn] putClass failed: %v", err)
+	}
+	triggerTransferEvent(native, param.Owner, common.ADDRESS_EMPTY, param.ClassId, param.TokenId)
+	return utils.BYTE_TRUE, nil
+}
+
+// OwnerOf returns the owner address of the token, or nil if it doesn't exist
+func OwnerOf(native *native.NativeService) ([]byte, error) {
+	id := new(TokenId)
+	if err := id.Deserialize(bytes.NewReader(native.Input)); err != nil {
+		return nil, fmt.Errorf("[ownerOf] deserialize param failed: %v", err)
+	}
+	token, err := getToken(native, id.ClassId, id.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[ownerOf] getToken failed: %v", err)
+	}
+	if token == nil {
+		return nil, nil
+	}
+	return token.Owner[:], nil
+}
+
+// TokensOf returns the count of tokens held by the address followed by their
+// serialized TokenId
+func TokensOf(native *native.NativeService) ([]byte, error) {
+	owner, err := utils.ReadAddress(bytes.NewReader(native.Input))
+	if err != nil {
+		return nil, fmt.Errorf("[tokensOf] deserialize param failed: %v", err)
+	}
+	ids, err := getOwnerTokens(native, owner)
+	if err != nil {
+		return nil, fmt.Errorf("[tokensOf] get tokens failed: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(len(ids))); err != nil {
+		return nil, fmt.Errorf("[tokensOf] serialize count failed: %v", err)
+	}
+	for _, id := range ids {
+		if err := id.Serialize(bf); err != nil {
+			return nil, fmt.Errorf("[tokensOf] serialize TokenId failed: %v", err)
+		}
+	}
+	return bf.Bytes(), nil
+}
+
+// GetToken returns the serialized Token, or nil if it doesn't exist
+func GetToken(native *native.NativeService) ([]byte, error) {
+	id := new(TokenId)
+	if err := id.Deserialize(bytes.NewReader(native.Input)); err != nil {
+		return nil, fmt.Errorf("[getToken] deserialize param failed: %v", err)
+	}
+	token, err := getToken(native, id.ClassId, id.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[getToken] getToken failed: %v", err)
+	}
+	if token == nil {
+		return nil, nil
+	}
+	bf := new(bytes.Buffer)
+	if err := token.Serialize(bf); err != nil {
+		return nil, fmt.Errorf("[getToken] serialize Token failed: %v", err)
+	}
+	return bf.Bytes(), nil
+}
+
+// GetClass returns the serialized TokenClass, or nil if it doesn't exist
+func GetClass(native *native.NativeService) ([]byte, error) {
+	classId, err := serialization.ReadVarBytes(bytes.NewReader(native.Input))
+	if err != nil {
+		return nil, fmt.Errorf("[getClass] deserialize param failed: %v", err)
+	}
+	class, err := getClass(native, classId)
+	if err != nil {
+		return nil, fmt.Errorf("[getClass] getClass failed: %v", err)
+	}
+	if class == nil {
+		return nil, nil
+	}
+	bf := new(bytes.Buffer)
+	if err := class.Serialize(bf); err != nil {
+		return nil, fmt.Errorf("[getClass] serialize TokenClass failed: %v", err)
+	}
+	return bf.Bytes(), nil
+}
+
+// inactive replaces every method before the fork height
+func inactive(native *native.NativeService) ([]byte, error) {
+	return nil, fmt.Errorf("nft contract is not active before height %d",
+		config.GetNftHeight(config.DefConfig.P2PNode.NetworkId))
+}
+
+var services = map[string]native.Handler{
+	"createClass":  CreateClass,
+	"mint":         Mint,
+	"transfer":     Transfer,
+	"transferFrom": TransferFrom,
+	"approve":      Approve,
+	"burn":         Burn,
+	"ownerOf":      OwnerOf,
+	"tokensOf":     TokensOf,
+	"getToken":     GetToken,
+	"getClass":     GetClass,
+}
+
+func RegisterNftContract(native *native.NativeService) {
+	//the ServiceMap is shared by nested native calls, so before the fork height
+	//the names are still registered to keep them from reaching another contract
+	active := native.Height >= config.GetNftHeight(config.DefConfig.P2PNode.NetworkId)
+	for name, handler := range services {
+		if !active {
+			handler = inactive
+		}
+		native.Register(name, handler)
+	}
+}
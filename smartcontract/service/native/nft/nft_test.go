@@ -0,0 +1,167 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nft
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+)
+
+var (
+	testFrom = common.Address{0x01, 0x02, 0x03}
+	testTo   = common.Address{0x04, 0x05, 0x06}
+)
+
+func TestSerCreateClassParam(t *testing.T) {
+	param := &CreateClassParam{
+		ClassId:  []byte("art"),
+		Name:     "Zeepin Art",
+		Symbol:   "ZART",
+		OwnerGID: []byte("GID:ZPT:ZUW9Ch12eb36ARWUq44kKHHFsQ3CpCD4HM"),
+		KeyNo:    1,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(CreateClassParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(param.ClassId, param2.ClassId) != 0 || param.Name != param2.Name ||
+		param.Symbol != param2.Symbol || bytes.Compare(param.OwnerGID, param2.OwnerGID) != 0 ||
+		param.KeyNo != param2.KeyNo {
+		t.Fatalf("failed")
+	}
+}
+
+func TestSerMintParam(t *testing.T) {
+	param := &MintParam{
+		ClassId:     []byte("art"),
+		TokenId:     []byte{0x01},
+		To:          testTo,
+		URI:         "ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		ContentHash: []byte{0xaa, 0xbb, 0xcc},
+		KeyNo:       2,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(MintParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(param.ClassId, param2.ClassId) != 0 || bytes.Compare(param.TokenId, param2.TokenId) != 0 ||
+		param.To != param2.To || param.URI != param2.URI ||
+		bytes.Compare(param.ContentHash, param2.ContentHash) != 0 || param.KeyNo != param2.KeyNo {
+		t.Fatalf("failed")
+	}
+}
+
+func TestSerTransferFromParam(t *testing.T) {
+	param := &TransferFromParam{
+		Sender: testTo,
+		TransferParam: TransferParam{
+			ClassId: []byte("art"),
+			TokenId: []byte{0x01},
+			From:    testFrom,
+			To:      testTo,
+		},
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	param2 := new(TransferFromParam)
+	if err := param2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if param.Sender != param2.Sender || bytes.Compare(param.ClassId, param2.ClassId) != 0 ||
+		bytes.Compare(param.TokenId, param2.TokenId) != 0 || param.From != param2.From || param.To != param2.To {
+		t.Fatalf("failed")
+	}
+}
+
+func TestSerToken(t *testing.T) {
+	token := &Token{
+		Owner:       testFrom,
+		Approved:    testTo,
+		URI:         "https://example.com/1.json",
+		ContentHash: []byte{0x01, 0x02},
+		MintedAt:    1550000000,
+	}
+	bf := new(bytes.Buffer)
+	if err := token.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	token2 := new(Token)
+	if err := token2.Deserialize(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if token.Owner != token2.Owner || token.Approved != token2.Approved || token.URI != token2.URI ||
+		bytes.Compare(token.ContentHash, token2.ContentHash) != 0 || token.MintedAt != token2.MintedAt {
+		t.Fatalf("failed")
+	}
+}
+
+func TestTokenItemIsUnambiguous(t *testing.T) {
+	if bytes.Equal(tokenItem([]byte{0x01}, []byte{0x02, 0x03}), tokenItem([]byte{0x01, 0x02}, []byte{0x03})) {
+		t.Fatalf("token items of different tokens collide")
+	}
+	id := new(TokenId)
+	if err := id.Deserialize(bytes.NewReader(tokenItem([]byte("art"), []byte{0x07}))); err != nil {
+		t.Fatal(err)
+	}
+	if string(id.ClassId) != "art" || bytes.Compare(id.TokenId, []byte{0x07}) != 0 {
+		t.Fatalf("failed")
+	}
+}
+
+func TestRegisterNftContractHeight(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	height := config.GetNftHeight(config.NETWORK_ID_MAIN_NET)
+	if height == 0 {
+		t.Skip("nft contract is active from genesis on mainnet")
+	}
+	service := &native.NativeService{ServiceMap: make(map[string]native.Handler), Height: height - 1}
+	RegisterNftContract(service)
+	if len(service.ServiceMap) != len(services) {
+		t.Fatalf("registered %d methods, expect %d", len(service.ServiceMap), len(services))
+	}
+	if _, err := service.ServiceMap["transfer"](service); err == nil {
+		t.Fatalf("transfer is invocable before the fork height")
+	}
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	service = &native.NativeService{ServiceMap: make(map[string]native.Handler)}
+	RegisterNftContract(service)
+	//an empty input fails in getClass itself once the contract is active
+	if _, err := service.ServiceMap["getClass"](service); err == nil || !strings.HasPrefix(err.Error(), "[getClass]") {
+		t.Fatalf("getClass is not active on a private network: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nft
+
+import (
+	"io"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+/* **********************************************   */
+type CreateClassParam struct {
+	ClassId  []byte
+	Name     string
+	Symbol   string
+	OwnerGID []byte
+	KeyNo    uint64
+}
+
+func (this *CreateClassParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClassId); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.Name); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.Symbol); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.OwnerGID); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.KeyNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *CreateClassParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClassId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Name, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.Symbol, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.OwnerGID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.KeyNo, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+/* **********************************************   */
+type MintParam struct {
+	ClassId     []byte
+	TokenId     []byte
+	To          common.Address
+	URI         string
+	ContentHash []byte
+	KeyNo       uint64
+}
+
+func (this *MintParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClassId); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.TokenId); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.To); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.URI); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.ContentHash); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.KeyNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *MintParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClassId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.TokenId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.To, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.URI, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.ContentHash, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.KeyNo, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+/* **********************************************   */
+type TransferParam struct {
+	ClassId []byte
+	TokenId []byte
+	From    common.Address
+	To      common.Address
+}
+
+func (this *TransferParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClassId); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.TokenId); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.From); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.To); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *TransferParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClassId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.TokenId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.From, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.To, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+/* **********************************************   */
+type TransferFromParam struct {
+	Sender common.Address
+	TransferParam
+}
+
+func (this *TransferFromParam) Serialize(w io.Writer) error {
+	if err := utils.WriteAddress(w, this.Sender); err != nil {
+		return err
+	}
+	return this.TransferParam.Serialize(w)
+}
+
+func (this *TransferFromParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.Sender, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	return this.TransferParam.Deserialize(rd)
+}
+
+/* **********************************************   */
+type ApproveParam struct {
+	ClassId []byte
+	TokenId []byte
+	Owner   common.Address
+	Spender common.Address
+}
+
+func (this *ApproveParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClassId); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.TokenId); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.Owner); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.Spender); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *ApproveParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClassId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.TokenId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Owner, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.Spender, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+/* **********************************************   */
+type BurnParam struct {
+	ClassId []byte
+	TokenId []byte
+	Owner   common.Address
+}
+
+func (this *BurnParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClassId); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.TokenId); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.Owner); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *BurnParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClassId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.TokenId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Owner, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	return nil
+}
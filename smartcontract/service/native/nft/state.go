@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nft
+
+import (
+	"io"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+/*
+ * a token class created by a GID, only its owner may mint tokens of the class
+ */
+type TokenClass struct {
+	Owner     []byte
+	Name      string
+	Symbol    string
+	Supply    uint64
+	CreatedAt uint32
+}
+
+func (this *TokenClass) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.Owner); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.Name); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.Symbol); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint64(w, this.Supply); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.CreatedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *TokenClass) Deserialize(rd io.Reader) error {
+	var err error
+	if this.Owner, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Name, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.Symbol, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.Supply, err = serialization.ReadUint64(rd); err != nil {
+		return err
+	}
+	if this.CreatedAt, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+/*
+ * a minted token, Approved is common.ADDRESS_EMPTY when no one is approved
+ */
+type Token struct {
+	Owner       common.Address
+	Approved    common.Address
+	URI         string
+	ContentHash []byte
+	MintedAt    uint32
+}
+
+func (this *Token) Serialize(w io.Writer) error {
+	if err := utils.WriteAddress(w, this.Owner); err != nil {
+		return err
+	}
+	if err := utils.WriteAddress(w, this.Approved); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.URI); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.ContentHash); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.MintedAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *Token) Deserialize(rd io.Reader) error {
+	var err error
+	if this.Owner, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.Approved, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.URI, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.ContentHash, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.MintedAt, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TokenId identifies a token across classes, it is the item tokensOf returns
+type TokenId struct {
+	ClassId []byte
+	TokenId []byte
+}
+
+func (this *TokenId) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.ClassId); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.TokenId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *TokenId) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ClassId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.TokenId, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nft
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/serialization"
+	scommon "github.com/imZhuFei/zeepin/core/store/common"
+	"github.com/imZhuFei/zeepin/errors"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/utils"
+)
+
+var (
+	PreClass  = []byte{0x01}
+	PreToken  = []byte{0x02}
+	PreOwner  = []byte{0x03}
+	PreBurned = []byte{0x04}
+)
+
+// type(this.Class.classId) = TokenClass
+func concatClassKey(native *native.NativeService, classId []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], PreClass...)
+	key = append(key, classId...)
+
+	return key
+}
+
+// type(this.Token.len(classId).classId.tokenId) = Token
+func concatTokenKey(native *native.NativeService, classId, tokenId []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], PreToken...)
+	bf := new(bytes.Buffer)
+	serialization.WriteVarBytes(bf, classId)
+	key = append(key, bf.Bytes()...)
+	key = append(key, tokenId...)
+
+	return key
+}
+
+// type(this.Burned.len(classId).classId.tokenId) = BYTE_TRUE, kept so a burned token is never minted again
+func concatBurnedKey(native *native.NativeService, classId, tokenId []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], PreBurned...)
+	bf := new(bytes.Buffer)
+	serialization.WriteVarBytes(bf, classId)
+	key = append(key, bf.Bytes()...)
+	key = append(key, tokenId...)
+
+	return key
+}
+
+// linked list of the TokenId items held by owner
+func concatOwnerKey(native *native.NativeService, owner common.Address) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], PreOwner...)
+	key = append(key, owner[:]...)
+
+	return key
+}
+
+func getClass(native *native.NativeService, classId []byte) (*TokenClass, error) {
+	key := concatClassKey(native, classId)
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil { //is not created
+		return nil, nil
+	}
+	class := new(TokenClass)
+	if err := class.Deserialize(bytes.NewReader(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize TokenClass object failed. data: %x", item.Value)
+	}
+	return class, nil
+}
+
+func putClass(native *native.NativeService, classId []byte, class *TokenClass) error {
+	key := concatClassKey(native, classId)
+	bf := new(bytes.Buffer)
+	if err := class.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize TokenClass failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+func getToken(native *native.NativeService, classId, tokenId []byte) (*Token, error) {
+	key := concatTokenKey(native, classId, tokenId)
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil { //is not minted or burned
+		return nil, nil
+	}
+	token := new(Token)
+	if err := token.Deserialize(bytes.NewReader(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize Token object failed. data: %x", item.Value)
+	}
+	return token, nil
+}
+
+func putToken(native *native.NativeService, classId, tokenId []byte, token *Token) error {
+	key := concatTokenKey(native, classId, tokenId)
+	bf := new(bytes.Buffer)
+	if err := token.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize Token failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+func delToken(native *native.NativeService, classId, tokenId []byte) {
+	native.CloneCache.Delete(scommon.ST_STORAGE, concatTokenKey(native, classId, tokenId))
+}
+
+func isBurned(native *native.NativeService, classId, tokenId []byte) (bool, error) {
+	item, err := utils.GetStorageItem(native, concatBurnedKey(native, classId, tokenId))
+	if err != nil {
+		return false, err
+	}
+	return item != nil, nil
+}
+
+func setBurned(native *native.NativeService, classId, tokenId []byte) {
+	utils.PutBytes(native, concatBurnedKey(native, classId, tokenId), utils.BYTE_TRUE)
+}
+
+func tokenItem(classId, tokenId []byte) []byte {
+	bf := new(bytes.Buffer)
+	id := &TokenId{ClassId: classId, TokenId: tokenId}
+	id.Serialize(bf)
+	return bf.Bytes()
+}
+
+func addOwnerToken(native *native.NativeService, owner common.Address, classId, tokenId []byte) error {
+	return utils.LinkedlistInsert(native, concatOwnerKey(native, owner), tokenItem(classId, tokenId), nil)
+}
+
+func delOwnerToken(native *native.NativeService, owner common.Address, classId, tokenId []byte) error {
+	ok, err := utils.LinkedlistDelete(native, concatOwnerKey(native, owner), tokenItem(classId, tokenId))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("token %x of class %x is not indexed for %s", tokenId, classId, owner.ToBase58())
+	}
+	return nil
+}
+
+func getOwnerTokens(native *native.NativeService, owner common.Address) ([]*TokenId, error) {
+	key := concatOwnerKey(native, owner)
+	item, err := utils.LinkedlistGetHead(native, key)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]*TokenId, 0)
+	for len(item) > 0 {
+		node, err := utils.LinkedlistGetItem(native, key, item)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, fmt.Errorf("token index of %s is broken at %x", owner.ToBase58(), item)
+		}
+		id := new(TokenId)
+		if err := id.Deserialize(bytes.NewReader(item)); err != nil {
+			return nil, fmt.Errorf("deserialize TokenId failed, caused by %v", err)
+		}
+		ids = append(ids, id)
+		item = node.GetNext()
+	}
+	return ids, nil
+}
+
+// move the token to a new owner, clearing any approval, and emit the transfer event
+func moveToken(native *native.NativeService, classId, tokenId []byte, token *Token, to common.Address) error {
+	from := token.Owner
+	if err := delOwnerToken(native, from, classId, tokenId); err != nil {
+		return err
+	}
+	if err := addOwnerToken(native, to, classId, tokenId); err != nil {
+		return err
+	}
+	token.Owner = to
+	token.Approved = common.ADDRESS_EMPTY
+	if err := putToken(native, classId, tokenId, token); err != nil {
+		return err
+	}
+	triggerTransferEvent(native, from, to, classId, tokenId)
+	return nil
+}
+
+// verify the signature of GID's keyNo-th key, the same way as gid verifySignature
+func verifySig(native *native.NativeService, GID []byte, keyNo uint64) (bool, error) {
+	bf := new(bytes.Buffer)
+	if err := serialization.WriteVarBytes(bf, GID); err != nil {
+		return false, err
+	}
+	if err := utils.WriteVarUint(bf, keyNo); err != nil {
+		return false, err
+	}
+	ret, err := native.NativeCall(utils.GIDContractAddress, "verifySignature", bf.Bytes())
+	if err != nil {
+		return false, err
+	}
+	valid, ok := ret.([]byte)
+	if !ok {
+		return false, errors.NewErr("verifySignature return non-bool value")
+	}
+	return bytes.Equal(valid, utils.BYTE_TRUE), nil
+}
+
+func pushEvent(native *native.NativeService, s interface{}) {
+	event := new(event.NotifyEventInfo)
+	event.ContractAddress = native.ContextRef.CurrentContext().ContractAddress
+	event.States = s
+	native.Notifications = append(native.Notifications, event)
+}
+
+func triggerCreateClassEvent(native *native.NativeService, classId []byte, class *TokenClass) {
+	pushEvent(native, []interface{}{"createClass", hex.EncodeToString(classId), string(class.Owner),
+		class.Name, class.Symbol})
+}
+
+// transfer events share the layout of the zpt transfer event followed by the
+// token, a mint is sent from and a burn is sent to common.ADDRESS_EMPTY
+func triggerTransferEvent(native *native.NativeService, from, to common.Address, classId, tokenId []byte) {
+	pushEvent(native, []interface{}{"transfer", from.ToBase58(), to.ToBase58(),
+		hex.EncodeToString(classId), hex.EncodeToString(tokenId)})
+}
+
+func triggerApproveEvent(native *native.NativeService, owner, spender common.Address, classId, tokenId []byte) {
+	pushEvent(native, []interface{}{"approve", owner.ToBase58(), spender.ToBase58(),
+		hex.EncodeToString(classId), hex.EncodeToString(tokenId)})
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/core/payload"
+	"github.com/imZhuFei/zeepin/core/states"
+	scommon "github.com/imZhuFei/zeepin/core/store/common"
+	"github.com/imZhuFei/zeepin/core/types"
+	"github.com/imZhuFei/zeepin/errors"
+	sccommon "github.com/imZhuFei/zeepin/smartcontract/common"
+	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/util"
+)
+
+//======================contract apis here============================================
+
+//contractMigrate deploys the new code, moves the storage of the current contract to it
+//and destroys the current contract, the params are the hex encoded code, needStorage,
+//name, version, author, email and description. It returns the base58 address of the new contract
+func (this *WasmVmService) contractMigrate(engine *exec.ExecutionEngine) (bool, error) {
+	vm := engine.GetVM()
+	envCall := vm.GetEnvCall()
+	params := envCall.GetParams()
+	if len(params) != 7 {
+		return false, errors.NewErr("[contractMigrate] parameter count error")
+	}
+	contract, err := this.readDeployCode(vm, params)
+	if err != nil {
+		return false, err
+	}
+	contract, err = sccommon.PrepareDeployCode(contract, this.Height)
+	if err != nil {
+		return false, errors.NewDetailErr(err, errors.ErrNoCode, "[contractMigrate] contract code invalid!")
+	}
+	newAddr := types.AddressFromVmCode(contract.Code)
+	item, err := this.CloneCache.Get(scommon.ST_CONTRACT, newAddr[:])
+	if err != nil || item != nil {
+		return false, errors.NewErr("[contractMigrate] get contract error or contract exist!")
+	}
+
+	oldAddr := vm.ContractAddress
+	items, err := this.findStorage(oldAddr)
+	if err != nil {
+		return false, err
+	}
+	if err := this.useMigrateGas(items); err != nil {
+		return false, err
+	}
+
+	this.CloneCache.Add(scommon.ST_CONTRACT, newAddr[:], contract)
+	for _, v := range items {
+		key := []byte(v.Key)
+		this.CloneCache.Add(scommon.ST_STORAGE, append(newAddr[:], key[common.ADDR_LEN:]...), v.Value)
+		this.CloneCache.Delete(scommon.ST_STORAGE, key)
+	}
+	this.CloneCache.Delete(scommon.ST_CONTRACT, oldAddr[:])
+
+	this.Notifications = append(this.Notifications, &event.NotifyEventInfo{
+		ContractAddress: oldAddr,
+		States:          []string{"migrate", oldAddr.ToBase58(), newAddr.ToBase58()},
+	})
+
+	idx, err := vm.SetPointerMemory(newAddr.ToBase58())
+	if err != nil {
+		return false, err
+	}
+	vm.RestoreCtx()
+	if envCall.GetReturns() {
+		vm.PushResult(uint64(idx))
+	}
+	return true, nil
+}
+
+//contractDestroy deletes the current contract together with its storage
+func (this *WasmVmService) contractDestroy(engine *exec.ExecutionEngine) (bool, error) {
+	vm := engine.GetVM()
+	addr := vm.ContractAddress
+	item, err := this.CloneCache.Get(scommon.ST_CONTRACT, addr[:])
+	if err != nil || item == nil {
+		return false, errors.NewErr("[contractDestroy] get current contract fail!")
+	}
+	items, err := this.findStorage(addr)
+	if err != nil {
+		return false, err
+	}
+	this.CloneCache.Delete(scommon.ST_CONTRACT, addr[:])
+	for _, v := range items {
+		this.CloneCache.Delete(scommon.ST_STORAGE, []byte(v.Key))
+	}
+
+	this.Notifications = append(this.Notifications, &event.NotifyEventInfo{
+		ContractAddress: addr,
+		States:          []string{"destroy", addr.ToBase58()},
+	})
+	vm.RestoreCtx()
+	return true, nil
+}
+
+func (this *WasmVmService) readDeployCode(vm *exec.VM, params []uint64) (*payload.DeployCode, error) {
+	fields := make([]string, 0, 6)
+	for i, p := range params {
+		if i == 1 {
+			continue
+		}
+		b, err := vm.GetPointerMemory(p)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, util.TrimBuffToString(b))
+	}
+	code, err := common.HexToBytes(fields[0])
+	if err != nil {
+		return nil, errors.NewErr("[contractMigrate] code is not hex encoded")
+	}
+	if len(code) > 1024*1024 {
+		return nil, errors.NewErr("[contractMigrate] Code too long!")
+	}
+	for _, f := range fields[1:5] {
+		if len(f) > 252 {
+			return nil, errors.NewErr("[contractMigrate] contract info too long!")
+		}
+	}
+	if len(fields[5]) > 65536 {
+		return nil, errors.NewErr("[contractMigrate] Desc too long!")
+	}
+	return &payload.DeployCode{
+		Code:        code,
+		NeedStorage: params[1] != 0,
+		Name:        fields[1],
+		Version:     fields[2],
+		Author:      fields[3],
+		Email:       fields[4],
+		Description: fields[5],
+	}, nil
+}
+
+//findStorage returns the live storage items of a contract, including the
+//changes made by the current transaction
+func (this *WasmVmService) findStorage(addr common.Address) ([]*scommon.StateItem, error) {
+	stored, err := this.CloneCache.Store.Find(scommon.ST_STORAGE, addr[:])
+	if err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNoCode, "[findStorage] Find error!")
+	}
+	items := make(map[string]*scommon.StateItem, len(stored))
+	for _, v := range stored {
+		items[v.Key] = v
+	}
+	for _, v := range this.CloneCache.Memory {
+		if v.Prefix != scommon.ST_STORAGE || !bytes.HasPrefix([]byte(v.Key), addr[:]) {
+			continue
+		}
+		if v.State == scommon.Deleted {
+			delete(items, v.Key)
+		} else {
+			items[v.Key] = &scommon.StateItem{Key: v.Key, Value: v.Value, State: v.State}
+		}
+	}
+
+	//keep the migration order stable across nodes
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]*scommon.StateItem, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, items[k])
+	}
+	return result, nil
+}
+
+//useMigrateGas charges the migrate cost plus a storage put for each moved item
+func (this *WasmVmService) useMigrateGas(items []*scommon.StateItem) error {
+	migrateGas, ok := embed.GAS_TABLE.Load(embed.CONTRACT_MIGRATE_NAME)
+	if !ok {
+		return errors.NewErr("[contractMigrate] get CONTRACT_MIGRATE_NAME gas failed")
+	}
+	putGas, ok := embed.GAS_TABLE.Load(embed.STORAGE_PUT_NAME)
+	if !ok {
+		return errors.NewErr("[contractMigrate] get STORAGE_PUT_NAME gas failed")
+	}
+	gas := migrateGas.(uint64)
+	for _, v := range items {
+		size := len(v.Key)
+		if item, ok := v.Value.(*states.StorageItem); ok {
+			size += len(item.Value)
+		}
+		gas += uint64((size-1)/1024+1) * putGas.(uint64)
+	}
+	if !this.ContextRef.CheckUseGas(gas) {
+		return errors.NewErr("[contractMigrate] gas insufficient")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/core/states"
+	scommon "github.com/imZhuFei/zeepin/core/store/common"
+	"github.com/imZhuFei/zeepin/core/store/leveldbstore"
+	"github.com/imZhuFei/zeepin/core/store/statestore"
+	"github.com/imZhuFei/zeepin/smartcontract/storage"
+)
+
+func TestFindStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm-contract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := leveldbstore.NewLevelDBStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	addr := common.Address{1}
+	other := common.Address{2}
+	key := func(a common.Address, k string) []byte {
+		b, _ := serializeStorageKey(a, []byte(k))
+		return b
+	}
+	put := func(k []byte, v string) {
+		buf := new(bytes.Buffer)
+		item := &states.StorageItem{Value: []byte(v)}
+		if err := item.Serialize(buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Put(append([]byte{byte(scommon.ST_STORAGE)}, k...), buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put(key(addr, "b"), "1")
+	put(key(addr, "c"), "2")
+	put(key(other, "a"), "3")
+	batch := statestore.NewStateStoreBatch(statestore.NewMemDatabase(), db)
+
+	service := &WasmVmService{CloneCache: storage.NewCloneCache(batch)}
+	service.CloneCache.Add(scommon.ST_STORAGE, key(addr, "a"), &states.StorageItem{Value: []byte("4")})
+	service.CloneCache.Delete(scommon.ST_STORAGE, key(addr, "c"))
+
+	items, err := service.findStorage(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expect 2 items, got %d", len(items))
+	}
+	if items[0].Key != string(key(addr, "a")) || items[1].Key != string(key(addr, "b")) {
+		t.Fatal("unexpected storage items")
+	}
+	if string(items[0].Value.(*states.StorageItem).Value) != "4" {
+		t.Fatal("uncommitted value should win")
+	}
+}
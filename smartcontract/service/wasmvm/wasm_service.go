@@ -36,18 +36,26 @@ package wasmvm
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
 
 	"github.com/imZhuFei/zeepin/common"
+	"github.com/imZhuFei/zeepin/common/config"
+	"github.com/imZhuFei/zeepin/core/payload"
 	"github.com/imZhuFei/zeepin/core/store"
+	scommon "github.com/imZhuFei/zeepin/core/store/common"
 	"github.com/imZhuFei/zeepin/core/types"
 	"github.com/imZhuFei/zeepin/embed/simulator"
 	"github.com/imZhuFei/zeepin/errors"
+	sccommon "github.com/imZhuFei/zeepin/smartcontract/common"
 	"github.com/imZhuFei/zeepin/smartcontract/context"
 	"github.com/imZhuFei/zeepin/smartcontract/event"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/auth"
+	"github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
 	nstates "github.com/imZhuFei/zeepin/smartcontract/service/native/zpt"
 	"github.com/imZhuFei/zeepin/smartcontract/states"
 	"github.com/imZhuFei/zeepin/smartcontract/storage"
@@ -64,10 +72,80 @@ type WasmVmService struct {
 	Tx            *types.Transaction
 	Time          uint32
 	Height        uint32
+	Tracer        exec.Tracer
+}
+
+func init() {
+	//publish the host functions so deploy time checks accept their imports
+	stateMachine := NewWasmStateMachine()
+	new(WasmVmService).register(stateMachine)
+	for name := range stateMachine.GetServiceMap() {
+		exec.RegisterHostFunctions(name)
+	}
 }
 
 func (this *WasmVmService) Invoke() (interface{}, error) {
 	stateMachine := NewWasmStateMachine()
+	this.register(stateMachine)
+
+	engine := exec.NewExecutionEngine(
+		this.Tx,
+		new(util.ECDsaCrypto),
+		stateMachine,
+	)
+	if this.Height >= config.GetWasmAllocatorHeight(config.DefConfig.P2PNode.NetworkId) {
+		engine.SetMemoryPolicy(&exec.MemoryPolicy{MaxPages: exec.MAX_MEMORY_PAGES, UsePages: this.useMemoryPages})
+	}
+	if this.Tracer != nil {
+		engine.SetTracer(this.Tracer)
+	}
+
+	contract := &states.Contract{}
+	contract.Deserialize(bytes.NewBuffer(this.Code))
+	addr := contract.Address
+	dpcode, err := this.GetContractCodeFromAddress(addr)
+	if err != nil {
+		errStr := err.Error()
+		fmt.Printf("err %s %s\n", errStr, addr.ToHexString())
+		return nil, fmt.Errorf("get contract  error: %s", addr.ToHexString())
+	}
+	ccode := dpcode
+	if err := this.checkProtected(contract); err != nil {
+		return nil, err
+	}
+
+	var caller common.Address
+	if this.ContextRef.CallingContext() == nil {
+		caller = common.Address{}
+	} else {
+		caller = this.ContextRef.CallingContext().ContractAddress
+	}
+	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address})
+	res, err := engine.Call(caller, ccode, contract.Method, contract.Args, contract.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//get the return message
+	result, err := engine.GetVM().GetPointerMemory(uint64(binary.LittleEndian.Uint32(res)))
+	if err != nil {
+		return nil, err
+	}
+
+	this.ContextRef.PopContext()
+	this.ContextRef.PushNotifications(this.Notifications)
+	return result, nil
+}
+
+//useMemoryPages charges the gas of pages added to the contract memory, the page
+//price is a constant as a new GAS_TABLE entry would change the genesis block
+func (this *WasmVmService) useMemoryPages(pages int) bool {
+	return this.ContextRef.CheckUseGas(uint64(pages) * embed.WASM_MEMORY_PAGE_GAS)
+}
+
+//register binds the host functions exposed to wasm contracts
+func (this *WasmVmService) register(stateMachine *WasmStateMachine) {
 	//register the "CallContract" function
 	//stateMachine.Register("ZPT_CallContract", this.callContract)
 	stateMachine.Register("ZPT_MarshalNativeParams", this.marshalNativeParams)
@@ -118,45 +196,44 @@ func (this *WasmVmService) Invoke() (interface{}, error) {
 	stateMachine.Register("ZPT_Transaction_GetType", this.transactionGetType)
 	stateMachine.Register("ZPT_Transaction_GetAttributes", this.transactionGetAttributes)
 
-	engine := exec.NewExecutionEngine(
-		this.Tx,
-		new(util.ECDsaCrypto),
-		stateMachine,
-	)
+	//contract
+	stateMachine.Register("ZPT_Contract_Migrate", this.contractMigrate)
+	stateMachine.Register("ZPT_Contract_Destroy", this.contractDestroy)
+}
 
-	contract := &states.Contract{}
-	contract.Deserialize(bytes.NewBuffer(this.Code))
-	addr := contract.Address
-	dpcode, err := this.GetContractCodeFromAddress(addr)
-	if err != nil {
-		errStr := err.Error()
-		fmt.Printf("err %s %s\n", errStr, addr.ToHexString())
-		return nil, fmt.Errorf("get contract  error: %s", addr.ToHexString())
+// checkProtected enforces the method permissions registered in the auth contract,
+// the caller's GID and key number are the first two json params of a protected method
+func (this *WasmVmService) checkProtected(contract *states.Contract) error {
+	native := &native.NativeService{
+		CloneCache: this.CloneCache,
+		Tx:         this.Tx,
+		Height:     this.Height,
+		Time:       this.Time,
+		ContextRef: this.ContextRef,
+		ServiceMap: make(map[string]native.Handler),
 	}
-	ccode := dpcode
-
-	var caller common.Address
-	if this.ContextRef.CallingContext() == nil {
-		caller = common.Address{}
-	} else {
-		caller = this.ContextRef.CallingContext().ContractAddress
+	protected, err := auth.IsProtected(native, contract.Address, contract.Method)
+	if err != nil || !protected {
+		return err
 	}
-	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address})
-	res, err := engine.Call(caller, ccode, contract.Method, contract.Args, contract.Version)
 
-	if err != nil {
-		return nil, err
+	args := make([]interface{}, 0, 2)
+	params := &exec.Args{}
+	if err := json.Unmarshal(contract.Args, params); err == nil && len(params.Params) >= 2 {
+		keyNo, err := strconv.ParseInt(params.Params[1].Pval, 10, 64)
+		if err != nil {
+			return fmt.Errorf("[checkProtected] invalid keyNo %s: %v", params.Params[1].Pval, err)
+		}
+		args = append(args, params.Params[0].Pval, keyNo)
 	}
-
-	//get the return message
-	result, err := engine.GetVM().GetPointerMemory(uint64(binary.LittleEndian.Uint32(res)))
+	caller, keyNo, err := auth.ParseInvoker(args)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("[checkProtected] protected method %s: %v", contract.Method, err)
 	}
-
-	this.ContextRef.PopContext()
-	this.ContextRef.PushNotifications(this.Notifications)
-	return result, nil
+	if err := auth.CheckInvoke(native, contract.Address, contract.Method, caller, keyNo); err != nil {
+		return fmt.Errorf("[checkProtected] permission denied: %v", err)
+	}
+	return nil
 }
 
 func (this *WasmVmService) marshalEmbeddedParams(engine *exec.ExecutionEngine) (bool, error) {
@@ -428,6 +505,13 @@ func (this *WasmVmService) GetContractCodeFromAddress(address common.Address) ([
 	if dcode == nil {
 		return nil, errors.NewErr("[GetContractCodeFromAddress] deployed code is nil")
 	}
+	if dcode.VmType == payload.EmbedVm && sccommon.VmTypeCheckEnabled(this.Height) {
+		return nil, errors.NewErr("[GetContractCodeFromAddress] can not invoke an embed contract")
+	}
+	//the contract may have been migrated or destroyed earlier in this block
+	if item, err := this.CloneCache.Get(scommon.ST_CONTRACT, address[:]); err != nil || item == nil {
+		return nil, errors.NewErr("[GetContractCodeFromAddress] contract has been destroyed")
+	}
 
 	return dcode.Code, nil
 
@@ -46,6 +46,7 @@ import (
 	"github.com/imZhuFei/zeepin/smartcontract/service/native/embed"
 	"github.com/imZhuFei/zeepin/smartcontract/service/wasmvm"
 	"github.com/imZhuFei/zeepin/smartcontract/storage"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec"
 )
 
 const (
@@ -68,6 +69,10 @@ type Config struct {
 	Time   uint32              // current block timestamp
 	Height uint32              // current block height
 	Tx     *ctypes.Transaction // current transaction
+	// WasmTracer and EmbedTracer when set record the execution of every engine
+	// the transaction starts, they are only used to debug historical transactions
+	WasmTracer  exec.Tracer
+	EmbedTracer vm.Tracer
 }
 
 // PushContext push current context to smart contract
@@ -127,6 +132,11 @@ func (this *SmartContract) CheckUseGas(gas uint64) bool {
 	return true
 }
 
+// GasLeft returns the gas the transaction may still use
+func (this *SmartContract) GasLeft() uint64 {
+	return this.Gas
+}
+
 func (this *SmartContract) checkContexts() bool {
 	if len(this.Contexts) > MAX_EXECUTE_ENGINE {
 		return false
@@ -149,6 +159,8 @@ func (this *SmartContract) NewExecuteEngine(code []byte) (context.Engine, error)
 		Time:       this.Config.Time,
 		Height:     this.Config.Height,
 		Engine:     vm.NewExecutionEngine(),
+		Tracer:     this.Config.EmbedTracer,
+		Depth:      len(this.Contexts),
 	}
 	return service, nil
 }
@@ -167,6 +179,7 @@ func (this *SmartContract) NewWasmExecuteEngine(code []byte) (context.Engine, er
 		Tx:         this.Config.Tx,
 		Time:       this.Config.Time,
 		Height:     this.Config.Height,
+		Tracer:     this.Config.WasmTracer,
 	}
 	return service, nil
 }
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"testing"
+
+	"github.com/imZhuFei/zeepin/core/types"
+	"github.com/imZhuFei/zeepin/embed/simulator"
+	"github.com/imZhuFei/zeepin/smartcontract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbedTracer(t *testing.T) {
+	tracer := simulator.NewJSONLogger(0)
+	config := &smartcontract.Config{
+		Time:        10,
+		Height:      10,
+		Tx:          &types.Transaction{},
+		EmbedTracer: tracer,
+	}
+	sc := smartcontract.SmartContract{
+		Config: config,
+		Gas:    10000,
+	}
+	code := []byte{byte(simulator.PUSH2), byte(simulator.PUSH3), byte(simulator.ADD)}
+	engine, err := sc.NewExecuteEngine(code)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, len(tracer.Logs))
+	add := tracer.Logs[2]
+	assert.Equal(t, "ADD", add.Op)
+	assert.Equal(t, 1, add.Depth)
+	assert.Equal(t, []interface{}{"2", "3"}, add.Stack)
+	assert.True(t, add.GasCost > 0)
+	assert.Equal(t, sc.Gas, add.Gas-add.GasCost)
+	assert.Equal(t, "", add.Error)
+}
+
+func TestEmbedTracerFault(t *testing.T) {
+	tracer := simulator.NewJSONLogger(0)
+	config := &smartcontract.Config{
+		Time:        10,
+		Height:      10,
+		Tx:          &types.Transaction{},
+		EmbedTracer: tracer,
+	}
+	sc := smartcontract.SmartContract{
+		Config: config,
+		Gas:    10000,
+	}
+	code := []byte{byte(simulator.PUSH1), byte(simulator.THROW)}
+	engine, err := sc.NewExecuteEngine(code)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.NotNil(t, err)
+
+	assert.Equal(t, 2, len(tracer.Logs))
+	assert.Equal(t, "THROW", tracer.Logs[1].Op)
+	assert.NotEqual(t, "", tracer.Logs[1].Error)
+}
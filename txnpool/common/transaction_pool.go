@@ -47,6 +47,8 @@ import (
 	vt "github.com/imZhuFei/zeepin/validator/types"
 )
 
+var logger = log.Module(log.MODULE_TXNPOOL)
+
 type TXAttr struct {
 	Height  uint32         // The height in which tx was verified
 	Type    vt.VerifyType  // The validator flag: stateless/stateful
@@ -83,7 +85,7 @@ func (tp *TXPool) AddTxList(txEntry *TXEntry) bool {
 	defer tp.Unlock()
 	txHash := txEntry.Tx.Hash()
 	if _, ok := tp.txList[txHash]; ok {
-		log.Infof("AddTxList: transaction %x is already in the pool",
+		logger.Infof("AddTxList: transaction %x is already in the pool",
 			txHash)
 		return false
 	}
@@ -105,7 +107,7 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 		}
 	}
 
-	log.Debugf("CleanTransactionList: transaction %d requested,%d cleaned, remains %d in TxPool",
+	logger.Debugf("CleanTransactionList: transaction %d requested,%d cleaned, remains %d in TxPool",
 		txsNum, cleaned, len(tp.txList))
 	return nil
 }
@@ -186,6 +188,35 @@ func (tp *TXPool) GetTransaction(hash common.Uint256) *types.Transaction {
 	return tp.txList[hash].Tx
 }
 
+// GetTransactionsByShortId returns the transactions with the short ids under
+// key in their order, nil for the ids matching no tx or several txs.
+func (tp *TXPool) GetTransactionsByShortId(key []byte, ids []uint64) []*types.Transaction {
+	matches := make(map[uint64]*types.Transaction, len(ids))
+	for _, id := range ids {
+		matches[id] = nil
+	}
+	ambiguous := make(map[uint64]bool)
+	tp.RLock()
+	for hash, txEntry := range tp.txList {
+		id := ShortTxId(key, hash)
+		if tx, ok := matches[id]; !ok {
+			continue
+		} else if tx != nil {
+			ambiguous[id] = true
+		}
+		matches[id] = txEntry.Tx
+	}
+	tp.RUnlock()
+
+	txs := make([]*types.Transaction, len(ids))
+	for i, id := range ids {
+		if !ambiguous[id] {
+			txs[i] = matches[id]
+		}
+	}
+	return txs
+}
+
 // GetTxStatus returns a transaction status if it is contained in the pool
 // and nil otherwise.
 func (tp *TXPool) GetTxStatus(hash common.Uint256) *TxStatus {
@@ -263,6 +294,21 @@ func (tp *TXPool) RemoveTxsBelowGasPrice(gasPrice uint64) {
 	}
 }
 
+// RemoveExpiredTxs drops all transactions which can not be included in the
+// block at height anymore, and returns the number of dropped transactions
+func (tp *TXPool) RemoveExpiredTxs(height uint32) int {
+	tp.Lock()
+	defer tp.Unlock()
+	count := 0
+	for hash, txEntry := range tp.txList {
+		if txEntry.Tx.Expired(height) {
+			delete(tp.txList, hash)
+			count++
+		}
+	}
+	return count
+}
+
 // Remain returns the remaining tx list to cleanup
 func (tp *TXPool) Remain() []*types.Transaction {
 	tp.Lock()
@@ -35,6 +35,9 @@
 package common
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/core/types"
 	"github.com/imZhuFei/zeepin/errors"
@@ -152,6 +155,45 @@ type GetTxnRsp struct {
 	Txn *types.Transaction
 }
 
+// GetTxnsByShortIdReq specifies the api that how to get the transactions
+// of a compact block.
+// Input: the short id key of the block and the short tx ids
+type GetTxnsByShortIdReq struct {
+	Key      []byte
+	ShortIds []uint64
+}
+
+// GetTxnsByShortIdRsp returns the transactions for GetTxnsByShortIdReq in
+// the order of the short ids, nil for the ones matching no tx or several
+// txs in the pool.
+type GetTxnsByShortIdRsp struct {
+	Txns []*types.Transaction
+}
+
+// SHORT_TX_ID_LEN is the byte length of the tx ids of a compact block
+const SHORT_TX_ID_LEN = 6
+
+// ShortTxIdKey returns the key of the short tx ids of a compact block, the
+// nonce is picked by the relaying node so a collision is not repeated on
+// every link
+func ShortTxIdKey(blockHash common.Uint256, nonce uint64) []byte {
+	buf := make([]byte, common.UINT256_SIZE+8)
+	copy(buf, blockHash[:])
+	binary.LittleEndian.PutUint64(buf[common.UINT256_SIZE:], nonce)
+	key := sha256.Sum256(buf)
+	return key[:]
+}
+
+// ShortTxId returns the short id of the tx hash under the key
+func ShortTxId(key []byte, hash common.Uint256) uint64 {
+	buf := make([]byte, 0, len(key)+common.UINT256_SIZE)
+	buf = append(append(buf, key...), hash[:]...)
+	sum := sha256.Sum256(buf)
+	id := make([]byte, 8)
+	copy(id, sum[:SHORT_TX_ID_LEN])
+	return binary.LittleEndian.Uint64(id)
+}
+
 // CheckTxnReq specifies the api that how to check whether a
 // transaction in the pool.
 // Input: a transaction hash
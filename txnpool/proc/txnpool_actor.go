@@ -42,7 +42,7 @@ import (
 
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/config"
-	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	"github.com/imZhuFei/zeepin/core/ledger"
 	tx "github.com/imZhuFei/zeepin/core/types"
 	"github.com/imZhuFei/zeepin/errors"
@@ -80,7 +80,7 @@ func NewVerifyRspActor(s *TXPoolServer) *VerifyRspActor {
 func isBalanceEnough(address common.Address, gas uint64) bool {
 	balance, err := hComm.GetContractBalance(0, utils.GalaContractAddress, address)
 	if err != nil {
-		log.Debugf("failed to get contract balance %s err %v",
+		logger.Debugf("failed to get contract balance %s err %v",
 			address.ToHexString(), err)
 		return false
 	}
@@ -97,7 +97,7 @@ func replyTxResult(txResultCh chan *tc.TxResult, hash common.Uint256,
 	select {
 	case txResultCh <- result:
 	default:
-		log.Debugf("handleTransaction: duplicated result")
+		logger.Debugf("handleTransaction: duplicated result")
 	}
 }
 
@@ -105,24 +105,24 @@ func replyTxResult(txResultCh chan *tc.TxResult, hash common.Uint256,
 func preExecCheck(txn *tx.Transaction) (bool, string) {
 	result, err := ledger.DefLedger.PreExecuteContract(txn)
 	if err != nil {
-		log.Debugf("preExecCheck: failed to preExecuteContract tx %x err %v",
+		logger.Debugf("preExecCheck: failed to preExecuteContract tx %x err %v",
 			txn.Hash(), err)
 	}
 	if txn.GasLimit < result.Gas {
-		log.Debugf("preExecCheck: transaction's gasLimit %d is less than preExec gasLimit %d",
+		logger.Debugf("preExecCheck: transaction's gasLimit %d is less than preExec gasLimit %d",
 			txn.GasLimit, result.Gas)
 		return false, fmt.Sprintf("transaction's gasLimit %d is less than preExec gasLimit %d",
 			txn.GasLimit, result.Gas)
 	}
 	gas, overflow := common.SafeMul(txn.GasPrice, result.Gas)
 	if overflow {
-		log.Debugf("preExecCheck: gasPrice %d preExec gasLimit %d overflow",
+		logger.Debugf("preExecCheck: gasPrice %d preExec gasLimit %d overflow",
 			txn.GasPrice, result.Gas)
 		return false, fmt.Sprintf("gasPrice %d preExec gasLimit %d overflow",
 			txn.GasPrice, result.Gas)
 	}
 	if !isBalanceEnough(txn.Payer, gas) {
-		log.Debugf("preExecCheck: transactor %s has no balance enough to cover gas cost %d",
+		logger.Debugf("preExecCheck: transactor %s has no balance enough to cover gas cost %d",
 			txn.Payer.ToHexString(), gas)
 		return false, fmt.Sprintf("transactor %s has no balance enough to cover gas cost %d",
 			txn.Payer.ToHexString(), gas)
@@ -140,7 +140,8 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 	txn *tx.Transaction, txResultCh chan *tc.TxResult) {
 	ta.server.increaseStats(tc.RcvStats)
 	if len(txn.ToArray()) > tc.MAX_TX_SIZE {
-		log.Debugf("handleTransaction: reject a transaction due to size over 1M")
+		logger.Debugf("handleTransaction: reject a transaction due to size over 1M")
+		metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_OVERSIZE).Inc()
 		if sender == tc.HttpSender && txResultCh != nil {
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrUnknown, "size is over 1M")
 		}
@@ -148,27 +149,30 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 	}
 
 	if ta.server.getTransaction(txn.Hash()) != nil {
-		log.Debugf("handleTransaction: transaction %x already in the txn pool",
+		logger.Debugf("handleTransaction: transaction %x already in the txn pool",
 			txn.Hash())
 
 		ta.server.increaseStats(tc.DuplicateStats)
+		metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_DUPLICATE).Inc()
 		if sender == tc.HttpSender && txResultCh != nil {
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrDuplicateInput,
 				fmt.Sprintf("transaction %x is already in the tx pool", txn.Hash()))
 		}
 	} else if ta.server.getTransactionCount() >= tc.MAX_CAPACITY {
-		log.Debugf("handleTransaction: transaction pool is full for tx %x",
+		logger.Debugf("handleTransaction: transaction pool is full for tx %x",
 			txn.Hash())
 
 		ta.server.increaseStats(tc.FailureStats)
+		metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_POOL_FULL).Inc()
 		if sender == tc.HttpSender && txResultCh != nil {
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrTxPoolFull,
 				"transaction pool is full")
 		}
 	} else {
 		if _, overflow := common.SafeMul(txn.GasLimit, txn.GasPrice); overflow {
-			log.Debugf("handleTransaction: gasLimit %v, gasPrice %v overflow",
+			logger.Debugf("handleTransaction: gasLimit %v, gasPrice %v overflow",
 				txn.GasLimit, txn.GasPrice)
+			metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_GAS).Inc()
 			if sender == tc.HttpSender && txResultCh != nil {
 				replyTxResult(txResultCh, txn.Hash(), errors.ErrUnknown,
 					fmt.Sprintf("gasLimit %d * gasPrice %d overflow",
@@ -177,11 +181,12 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 			return
 		}
 
-		gasLimitConfig := config.DefConfig.Common.GasLimit
+		gasLimitConfig := config.DefConfig.GetGasLimit()
 		gasPriceConfig := ta.server.getGasPrice()
 		if txn.GasLimit < gasLimitConfig || txn.GasPrice < gasPriceConfig {
-			log.Debugf("handleTransaction: invalid gasLimit %v, gasPrice %v",
+			logger.Debugf("handleTransaction: invalid gasLimit %v, gasPrice %v",
 				txn.GasLimit, txn.GasPrice)
+			metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_GAS).Inc()
 			if sender == tc.HttpSender && txResultCh != nil {
 				replyTxResult(txResultCh, txn.Hash(), errors.ErrUnknown,
 					fmt.Sprintf("Please input gasLimit >= %d and gasPrice >= %d",
@@ -191,8 +196,9 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 		}
 
 		if txn.TxType == tx.Deploy && txn.GasLimit < embed.CONTRACT_CREATE_GAS {
-			log.Debugf("handleTransaction: deploy tx invalid gasLimit %v, gasPrice %v",
+			logger.Debugf("handleTransaction: deploy tx invalid gasLimit %v, gasPrice %v",
 				txn.GasLimit, txn.GasPrice)
+			metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_GAS).Inc()
 			if sender == tc.HttpSender && txResultCh != nil {
 				replyTxResult(txResultCh, txn.Hash(), errors.ErrUnknown,
 					fmt.Sprintf("Deploy tx gaslimit should >= %d",
@@ -203,13 +209,14 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 
 		if !ta.server.disablePreExec {
 			if ok, desc := preExecCheck(txn); !ok {
-				log.Debugf("handleTransaction: preExecCheck tx %x failed", txn.Hash())
+				logger.Debugf("handleTransaction: preExecCheck tx %x failed", txn.Hash())
+				metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_PREEXEC).Inc()
 				if sender == tc.HttpSender && txResultCh != nil {
 					replyTxResult(txResultCh, txn.Hash(), errors.ErrUnknown, desc)
 				}
 				return
 			}
-			log.Debugf("handleTransaction: preExecCheck tx %x passed", txn.Hash())
+			logger.Debugf("handleTransaction: preExecCheck tx %x passed", txn.Hash())
 		}
 		<-ta.server.slots
 		ta.server.assignTxToWorker(txn, sender, txResultCh)
@@ -220,25 +227,25 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 func (ta *TxActor) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Started:
-		log.Info("txpool-tx actor started and be ready to receive tx msg")
+		logger.Info("txpool-tx actor started and be ready to receive tx msg")
 
 	case *actor.Stopping:
-		log.Warn("txpool-tx actor stopping")
+		logger.Warn("txpool-tx actor stopping")
 
 	case *actor.Restarting:
-		log.Warn("txpool-tx actor restarting")
+		logger.Warn("txpool-tx actor restarting")
 
 	case *tc.TxReq:
 		sender := msg.Sender
 
-		log.Debugf("txpool-tx actor receives tx from %v ", sender.Sender())
+		logger.Debugf("txpool-tx actor receives tx from %v ", sender.Sender())
 
 		ta.handleTransaction(sender, context.Self(), msg.Tx, msg.TxResultCh)
 
 	case *tc.GetTxnReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool-tx actor receives getting tx req from %v", sender)
+		logger.Debugf("txpool-tx actor receives getting tx req from %v", sender)
 
 		res := ta.server.getTransaction(msg.Hash)
 		if sender != nil {
@@ -246,10 +253,21 @@ func (ta *TxActor) Receive(context actor.Context) {
 				context.Self())
 		}
 
+	case *tc.GetTxnsByShortIdReq:
+		sender := context.Sender()
+
+		logger.Debugf("txpool-tx actor receives getting txs by short id req from %v", sender)
+
+		res := ta.server.getTransactionsByShortId(msg.Key, msg.ShortIds)
+		if sender != nil {
+			sender.Request(&tc.GetTxnsByShortIdRsp{Txns: res},
+				context.Self())
+		}
+
 	case *tc.GetTxnStats:
 		sender := context.Sender()
 
-		log.Debugf("txpool-tx actor receives getting tx stats from %v", sender)
+		logger.Debugf("txpool-tx actor receives getting tx stats from %v", sender)
 
 		res := ta.server.getStats()
 		if sender != nil {
@@ -260,7 +278,7 @@ func (ta *TxActor) Receive(context actor.Context) {
 	case *tc.CheckTxnReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool-tx actor receives checking tx req from %v", sender)
+		logger.Debugf("txpool-tx actor receives checking tx req from %v", sender)
 
 		res := ta.server.checkTx(msg.Hash)
 		if sender != nil {
@@ -271,7 +289,7 @@ func (ta *TxActor) Receive(context actor.Context) {
 	case *tc.GetTxnStatusReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool-tx actor receives getting tx status req from %v", sender)
+		logger.Debugf("txpool-tx actor receives getting tx status req from %v", sender)
 
 		res := ta.server.getTxStatusReq(msg.Hash)
 		if sender != nil {
@@ -287,7 +305,7 @@ func (ta *TxActor) Receive(context actor.Context) {
 	case *tc.GetTxnCountReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool-tx actor receives getting tx count req from %v", sender)
+		logger.Debugf("txpool-tx actor receives getting tx count req from %v", sender)
 
 		res := ta.server.getTxCount()
 		if sender != nil {
@@ -296,7 +314,7 @@ func (ta *TxActor) Receive(context actor.Context) {
 		}
 
 	default:
-		log.Debugf("txpool-tx actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
+		logger.Debugf("txpool-tx actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
 	}
 }
 
@@ -313,18 +331,18 @@ type TxPoolActor struct {
 func (tpa *TxPoolActor) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Started:
-		log.Info("txpool actor started and be ready to receive txPool msg")
+		logger.Info("txpool actor started and be ready to receive txPool msg")
 
 	case *actor.Stopping:
-		log.Warn("txpool actor stopping")
+		logger.Warn("txpool actor stopping")
 
 	case *actor.Restarting:
-		log.Warn("txpool actor Restarting")
+		logger.Warn("txpool actor Restarting")
 
 	case *tc.GetTxnPoolReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool actor receives getting tx pool req from %v", sender)
+		logger.Debugf("txpool actor receives getting tx pool req from %v", sender)
 
 		res := tpa.server.getTxPool(msg.ByCount, msg.Height)
 		if sender != nil {
@@ -334,7 +352,7 @@ func (tpa *TxPoolActor) Receive(context actor.Context) {
 	case *tc.GetPendingTxnReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool actor receives getting pedning tx req from %v", sender)
+		logger.Debugf("txpool actor receives getting pedning tx req from %v", sender)
 
 		res := tpa.server.getPendingTxs(msg.ByCount)
 		if sender != nil {
@@ -344,21 +362,21 @@ func (tpa *TxPoolActor) Receive(context actor.Context) {
 	case *tc.VerifyBlockReq:
 		sender := context.Sender()
 
-		log.Debugf("txpool actor receives verifying block req from %v", sender)
+		logger.Debugf("txpool actor receives verifying block req from %v", sender)
 
 		tpa.server.verifyBlock(msg, sender)
 
 	case *message.SaveBlockCompleteMsg:
 		sender := context.Sender()
 
-		log.Debugf("txpool actor receives block complete event from %v", sender)
+		logger.Debugf("txpool actor receives block complete event from %v", sender)
 
 		if msg.Block != nil {
 			tpa.server.cleanTransactionList(msg.Block.Transactions, msg.Block.Header.Height)
 		}
 
 	default:
-		log.Debugf("txpool actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
+		logger.Debugf("txpool actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
 	}
 }
 
@@ -375,30 +393,30 @@ type VerifyRspActor struct {
 func (vpa *VerifyRspActor) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Started:
-		log.Info("txpool-verify actor: started and be ready to receive validator's msg")
+		logger.Info("txpool-verify actor: started and be ready to receive validator's msg")
 
 	case *actor.Stopping:
-		log.Warn("txpool-verify actor: stopping")
+		logger.Warn("txpool-verify actor: stopping")
 
 	case *actor.Restarting:
-		log.Warn("txpool-verify actor: Restarting")
+		logger.Warn("txpool-verify actor: Restarting")
 
 	case *types.RegisterValidator:
-		log.Debugf("txpool-verify actor:: validator %v connected", msg.Sender)
+		logger.Debugf("txpool-verify actor:: validator %v connected", msg.Sender)
 		vpa.server.registerValidator(msg)
 
 	case *types.UnRegisterValidator:
-		log.Debugf("txpool-verify actor:: validator %d:%v disconnected", msg.Type, msg.Id)
+		logger.Debugf("txpool-verify actor:: validator %d:%v disconnected", msg.Type, msg.Id)
 
 		vpa.server.unRegisterValidator(msg.Type, msg.Id)
 
 	case *types.CheckResponse:
-		log.Debug("txpool-verify actor:: Receives verify rsp message")
+		logger.Debug("txpool-verify actor:: Receives verify rsp message")
 
 		vpa.server.assignRspToWorker(msg)
 
 	default:
-		log.Debugf("txpool-verify actor:Unknown msg %v type %v", msg, reflect.TypeOf(msg))
+		logger.Debugf("txpool-verify actor:Unknown msg %v type %v", msg, reflect.TypeOf(msg))
 	}
 }
 
@@ -47,6 +47,7 @@ import (
 	"github.com/imZhuFei/zeepin/common"
 	"github.com/imZhuFei/zeepin/common/config"
 	"github.com/imZhuFei/zeepin/common/log"
+	"github.com/imZhuFei/zeepin/common/metrics"
 	"github.com/imZhuFei/zeepin/core/ledger"
 	tx "github.com/imZhuFei/zeepin/core/types"
 	"github.com/imZhuFei/zeepin/errors"
@@ -58,6 +59,8 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 )
 
+var logger = log.Module(log.MODULE_TXNPOOL)
+
 type txStats struct {
 	sync.RWMutex
 	count []uint64
@@ -155,12 +158,12 @@ func getGlobalGasPrice() (uint64, error) {
 func getGasPriceConfig() uint64 {
 	globalGasPrice, err := getGlobalGasPrice()
 	if err != nil {
-		log.Info(err)
+		logger.Info(err)
 		return 0
 	}
 
-	if globalGasPrice < config.DefConfig.Common.GasPrice {
-		return config.DefConfig.Common.GasPrice
+	if gasPrice := config.DefConfig.GetGasPrice(); globalGasPrice < gasPrice {
+		return gasPrice
 	}
 	return globalGasPrice
 }
@@ -172,6 +175,7 @@ func (s *TXPoolServer) init(num uint8, disablePreExec, enableBroadcastNetTx bool
 	s.txPool.Init()
 	s.allPendingTxs = make(map[common.Uint256]*serverPendingTx)
 	s.actors = make(map[tc.ActorType]*actor.PID)
+	metrics.SetTxPoolSource(s.getTxCount)
 
 	s.validators = &registerValidators{
 		entries: make(map[types.VerifyType][]*types.RegisterValidator),
@@ -193,7 +197,7 @@ func (s *TXPoolServer) init(num uint8, disablePreExec, enableBroadcastNetTx bool
 	}
 
 	s.gasPrice = getGasPriceConfig()
-	log.Infof("tx pool: the current local gas price is %d", s.gasPrice)
+	logger.Infof("tx pool: the current local gas price is %d", s.gasPrice)
 
 	s.disablePreExec = disablePreExec
 	s.enableBroadcastNetTx = enableBroadcastNetTx
@@ -294,6 +298,9 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 	if pt.sender == tc.HttpSender && pt.ch != nil {
 		replyTxResult(pt.ch, hash, err, err.Error())
 	}
+	if err != errors.ErrNoError {
+		metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_VERIFY).Inc()
+	}
 
 	delete(s.allPendingTxs, hash)
 
@@ -301,7 +308,7 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 		select {
 		case s.slots <- struct{}{}:
 		default:
-			log.Debug("removePendingTx: slots is full")
+			logger.Debug("removePendingTx: slots is full")
 		}
 	}
 
@@ -320,7 +327,7 @@ func (s *TXPoolServer) setPendingTx(tx *tx.Transaction,
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if ok := s.allPendingTxs[tx.Hash()]; ok != nil {
-		log.Infof("setPendingTx: transaction %x already in the verifying process",
+		logger.Infof("setPendingTx: transaction %x already in the verifying process",
 			tx.Hash())
 		return false
 	}
@@ -345,6 +352,7 @@ func (s *TXPoolServer) assignTxToWorker(tx *tx.Transaction,
 
 	if ok := s.setPendingTx(tx, sender, txResultCh); !ok {
 		s.increaseStats(tc.DuplicateStats)
+		metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_DUPLICATE).Inc()
 		if sender == tc.HttpSender && txResultCh != nil {
 			replyTxResult(txResultCh, tx.Hash(), errors.ErrDuplicateInput,
 				"duplicated transaction input detected")
@@ -432,7 +440,7 @@ func (s *TXPoolServer) unRegisterValidator(checkType types.VerifyType,
 
 	tmpSlice, ok := s.validators.entries[checkType]
 	if !ok {
-		log.Errorf("unRegisterValidator: validator not found with type:%d, id:%s",
+		logger.Errorf("unRegisterValidator: validator not found with type:%d, id:%s",
 			checkType, id)
 		return
 	}
@@ -509,6 +517,12 @@ func (s *TXPoolServer) getTransaction(hash common.Uint256) *tx.Transaction {
 	return s.txPool.GetTransaction(hash)
 }
 
+// getTransactionsByShortId returns the transactions with the short tx ids
+// of a compact block, nil for the ones not found in the pool.
+func (s *TXPoolServer) getTransactionsByShortId(key []byte, ids []uint64) []*tx.Transaction {
+	return s.txPool.GetTransactionsByShortId(key, ids)
+}
+
 // getTxPool returns a tx list for consensus.
 func (s *TXPoolServer) getTxPool(byCount bool, height uint32) []*tc.TXEntry {
 	s.setHeight(height)
@@ -542,33 +556,45 @@ func (s *TXPoolServer) getPendingTxs(byCount bool) []*tx.Transaction {
 	return ret
 }
 
+// RefreshGasPrice updates the gas price threshold from the global params
+// and the configured gas price, and removes the txs below it
+func (s *TXPoolServer) RefreshGasPrice() {
+	gasPrice := getGasPriceConfig()
+	s.mu.Lock()
+	oldGasPrice := s.gasPrice
+	s.gasPrice = gasPrice
+	s.mu.Unlock()
+	if oldGasPrice != gasPrice {
+		logger.Infof("Transaction pool price threshold updated from %d to %d",
+			oldGasPrice, gasPrice)
+	}
+
+	if oldGasPrice < gasPrice {
+		s.txPool.RemoveTxsBelowGasPrice(gasPrice)
+	}
+}
+
 // cleanTransactionList cleans the txs in the block from the ledger
 func (s *TXPoolServer) cleanTransactionList(txs []*tx.Transaction, height uint32) {
 	s.txPool.CleanTransactionList(txs)
 
+	// Evict txs whose validity window ends before the next block
+	if n := s.txPool.RemoveExpiredTxs(height + 1); n > 0 {
+		logger.Debugf("cleanTransactionList: removed %d expired txs", n)
+		metrics.TxPoolRejected.WithLabelValues(metrics.REJECT_EXPIRED).Add(float64(n))
+	}
+
 	// Check whether to update the gas price and remove txs below the
 	// threshold
 	if height%tc.UPDATE_FREQUENCY == 0 {
-		gasPrice := getGasPriceConfig()
-		s.mu.Lock()
-		oldGasPrice := s.gasPrice
-		s.gasPrice = gasPrice
-		s.mu.Unlock()
-		if oldGasPrice != gasPrice {
-			log.Infof("Transaction pool price threshold updated from %d to %d",
-				oldGasPrice, gasPrice)
-		}
-
-		if oldGasPrice < gasPrice {
-			s.txPool.RemoveTxsBelowGasPrice(gasPrice)
-		}
+		s.RefreshGasPrice()
 	}
 	// Cleanup tx pool
 	if !s.disablePreExec {
 		remain := s.txPool.Remain()
 		for _, t := range remain {
 			if ok, _ := preExecCheck(t); !ok {
-				log.Debugf("cleanTransactionList: preExecCheck tx %x failed", t.Hash())
+				logger.Debugf("cleanTransactionList: preExecCheck tx %x failed", t.Hash())
 				continue
 			}
 			s.reVerifyStateful(t, tc.NilSender)
@@ -39,7 +39,6 @@ import (
 	"time"
 
 	"github.com/imZhuFei/zeepin/common"
-	"github.com/imZhuFei/zeepin/common/log"
 	tx "github.com/imZhuFei/zeepin/core/types"
 	"github.com/imZhuFei/zeepin/errors"
 	tc "github.com/imZhuFei/zeepin/txnpool/common"
@@ -116,7 +115,7 @@ func (worker *txPoolWorker) handleRsp(rsp *types.CheckResponse) {
 	}
 	if rsp.ErrCode != errors.ErrNoError {
 		//Verify fail
-		log.Infof("handleRsp: validator %d transaction %x invalid: %s",
+		logger.Infof("handleRsp: validator %d transaction %x invalid: %s",
 			rsp.Type, rsp.Hash, rsp.ErrCode.Error())
 		delete(worker.pendingTxList, rsp.Hash)
 		worker.server.removePendingTx(rsp.Hash, rsp.ErrCode)
@@ -166,7 +165,7 @@ func (worker *txPoolWorker) handleTimeoutEvent() {
 				worker.reVerifyTx(k)
 				v.retries++
 			} else {
-				log.Infof("retry to verify transaction exhausted %x", k.ToArray())
+				logger.Infof("retry to verify transaction exhausted %x", k.ToArray())
 				worker.mu.Lock()
 				delete(worker.pendingTxList, k)
 				worker.mu.Unlock()
@@ -191,14 +190,14 @@ func (worker *txPoolWorker) putTxPool(pt *pendingTx) bool {
 // verifyTx prepares a check request and sends it to the validators.
 func (worker *txPoolWorker) verifyTx(tx *tx.Transaction) {
 	if tx := worker.server.getTransaction(tx.Hash()); tx != nil {
-		log.Infof("verifyTx: transaction %x already in the txn pool",
+		logger.Infof("verifyTx: transaction %x already in the txn pool",
 			tx.Hash())
 		worker.server.removePendingTx(tx.Hash(), errors.ErrDuplicateInput)
 		return
 	}
 
 	if _, ok := worker.pendingTxList[tx.Hash()]; ok {
-		log.Infof("verifyTx: transaction %x already in the verifying process",
+		logger.Infof("verifyTx: transaction %x already in the verifying process",
 			tx.Hash())
 		return
 	}
@@ -244,7 +243,7 @@ func (worker *txPoolWorker) reVerifyTx(txHash common.Uint256) {
 func (worker *txPoolWorker) sendReq2Validator(req *types.CheckTx) bool {
 	rspPid := worker.server.GetPID(tc.VerifyRspActor)
 	if rspPid == nil {
-		log.Info("sendReq2Validator: VerifyRspActor not exist")
+		logger.Info("sendReq2Validator: VerifyRspActor not exist")
 		return false
 	}
 
@@ -263,13 +262,13 @@ func (worker *txPoolWorker) sendReq2Validator(req *types.CheckTx) bool {
 func (worker *txPoolWorker) sendReq2StatefulV(req *types.CheckTx) {
 	rspPid := worker.server.GetPID(tc.VerifyRspActor)
 	if rspPid == nil {
-		log.Info("sendReq2StatefulV: VerifyRspActor not exist")
+		logger.Info("sendReq2StatefulV: VerifyRspActor not exist")
 		return
 	}
 
 	pid := worker.server.getNextValidatorPID(types.Stateful)
 	if pid == nil {
-		log.Info("sendReq2StatefulV: get stateful validator failed")
+		logger.Info("sendReq2StatefulV: get stateful validator failed")
 		return
 	}
 
@@ -104,5 +104,13 @@ func (self *IncrementValidator) Verify(tx *types.Transaction, startHeight uint32
 		}
 	}
 
+	//the tx goes into the block following the cached range
+	if len(self.blocks) != 0 {
+		_, end := self.BlockRange()
+		if err := tx.CheckValidityWindow(end); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
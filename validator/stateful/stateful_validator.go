@@ -94,6 +94,9 @@ func (self *validator) Receive(context actor.Context) {
 			errCode = errors.ErrUnknown
 		} else if exist {
 			errCode = errors.ErrDuplicatedTx
+		} else if err := msg.Tx.CheckValidityWindow(height + 1); err != nil {
+			log.Debugf("stateful-validator: tx %x %s", hash, err)
+			errCode = errors.ErrTxValidityWindow
 		}
 
 		response := &vatypes.CheckResponse{
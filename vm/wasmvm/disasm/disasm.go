@@ -56,6 +56,8 @@ import (
 // appropriate immediate value(s).
 type Instr struct {
 	Op ops.Op
+	// Offset is the position of the operator within the function body.
+	Offset int
 
 	// Immediates are arguments to an operator in the bytecode stream itself.
 	// Valid value types are:
@@ -135,6 +137,7 @@ func Disassemble(fn wasm.Function, module *wasm.Module) (*Disassembly, error) {
 	var lastOpReturn bool
 
 	for {
+		offset := len(code) - reader.Len()
 		op, err := reader.ReadByte()
 		if err == io.EOF {
 			break
@@ -149,6 +152,7 @@ func Disassemble(fn wasm.Function, module *wasm.Module) (*Disassembly, error) {
 		}
 		instr := Instr{
 			Op:         opStr,
+			Offset:     offset,
 			Immediates: [](interface{}){},
 		}
 		if op == ops.End || op == ops.Else {
@@ -83,6 +83,9 @@ func (vm *VM) doCall(compiled compiledFunction, index int64) {
 			if err != nil || !rtn {
 				log.Errorf("call method :%s failed: %s\n", compiled.name, err)
 			}
+			if vm.tracer != nil {
+				vm.traceHostCall(compiled, locals, err)
+			}
 		} else {
 			vm.ctx = prevCtxt
 			if compiled.returns {
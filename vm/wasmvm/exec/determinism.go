@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package exec
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/imZhuFei/zeepin/vm/wasmvm/disasm"
+	"github.com/imZhuFei/zeepin/vm/wasmvm/wasm"
+)
+
+const (
+	MAX_MEMORY_PAGES  = 256  //16MB of linear memory
+	MAX_TABLE_ENTRIES = 1024 //indirect call table size
+)
+
+var (
+	hostFunctions    = make(map[string]bool)
+	hostFunctionLock sync.RWMutex
+)
+
+// RegisterHostFunctions declares the env functions a hosting service provides to
+// wasm contracts, imports outside this set and the InteropService are rejected
+// by CheckDeterminism
+func RegisterHostFunctions(names ...string) {
+	hostFunctionLock.Lock()
+	defer hostFunctionLock.Unlock()
+	for _, name := range names {
+		hostFunctions[name] = true
+	}
+}
+
+// IsHostFunction returns whether a contract may import the env function name
+func IsHostFunction(name string) bool {
+	if NewInteropService().Exists(name) {
+		return true
+	}
+	hostFunctionLock.RLock()
+	defer hostFunctionLock.RUnlock()
+	return hostFunctions[name]
+}
+
+// CheckDeterminism rejects the module features whose behaviour may differ between
+// nodes: float instructions, imports the node does not provide, oversized memory
+// or tables and start functions
+func CheckDeterminism(m *wasm.Module) error {
+	if m.Start != nil {
+		return fmt.Errorf("[CheckDeterminism] start function %s is not allowed", functionName(m, m.Start.Index))
+	}
+	if err := checkImports(m); err != nil {
+		return err
+	}
+	if m.Memory != nil {
+		for _, mem := range m.Memory.Entries {
+			if err := checkLimits("memory", mem.Limits, MAX_MEMORY_PAGES); err != nil {
+				return err
+			}
+		}
+	}
+	if m.Table != nil {
+		for _, table := range m.Table.Entries {
+			if err := checkLimits("table", table.Limits, MAX_TABLE_ENTRIES); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, fn := range m.FunctionIndexSpace {
+		if fn.IsEnvFunc {
+			continue
+		}
+		disassembly, err := disasm.Disassemble(fn, m)
+		if err != nil {
+			return fmt.Errorf("[CheckDeterminism] disassemble function %s failed: %v", functionName(m, uint32(i)), err)
+		}
+		for _, instr := range disassembly.Code {
+			if isFloatOp(instr) {
+				return fmt.Errorf("[CheckDeterminism] function %s: float instruction %s at offset %d",
+					functionName(m, uint32(i)), instr.Op.Name, instr.Offset)
+			}
+		}
+	}
+	return nil
+}
+
+func checkImports(m *wasm.Module) error {
+	if m.Import == nil {
+		return nil
+	}
+	for _, entry := range m.Import.Entries {
+		if entry.ModuleName != "env" {
+			return fmt.Errorf("[CheckDeterminism] import %s.%s: unknown module", entry.ModuleName, entry.FieldName)
+		}
+		switch entry.Kind {
+		case wasm.ExternalFunction:
+			if !IsHostFunction(entry.FieldName) {
+				return fmt.Errorf("[CheckDeterminism] import env.%s: unknown host function", entry.FieldName)
+			}
+		case wasm.ExternalGlobal:
+			if entry.FieldName != "memoryBase" && entry.FieldName != "tableBase" {
+				return fmt.Errorf("[CheckDeterminism] import env.%s: unknown global", entry.FieldName)
+			}
+		case wasm.ExternalMemory:
+			if err := checkLimits("memory", entry.Type.(wasm.MemoryImport).Type.Limits, MAX_MEMORY_PAGES); err != nil {
+				return err
+			}
+		case wasm.ExternalTable:
+			if err := checkLimits("table", entry.Type.(wasm.TableImport).Type.Limits, MAX_TABLE_ENTRIES); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("[CheckDeterminism] import env.%s: unsupported kind %v", entry.FieldName, entry.Kind)
+		}
+	}
+	return nil
+}
+
+func checkLimits(kind string, limits wasm.ResizableLimits, max uint32) error {
+	if limits.Initial > max {
+		return fmt.Errorf("[CheckDeterminism] %s initial size %d exceeds %d", kind, limits.Initial, max)
+	}
+	if limits.Flags&0x1 != 0 && limits.Maximum > max {
+		return fmt.Errorf("[CheckDeterminism] %s maximum size %d exceeds %d", kind, limits.Maximum, max)
+	}
+	return nil
+}
+
+//isFloatOp reports whether the instruction takes or produces a float value,
+//which covers float arithmetic, constants, loads, stores and conversions
+func isFloatOp(instr disasm.Instr) bool {
+	if isFloatType(instr.Op.Returns) {
+		return true
+	}
+	for _, arg := range instr.Op.Args {
+		if isFloatType(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFloatType(t wasm.ValueType) bool {
+	return t == wasm.ValueTypeF32 || t == wasm.ValueTypeF64
+}
+
+//functionName prefers the exported name of a function and falls back to its index
+func functionName(m *wasm.Module, index uint32) string {
+	if int(index) < len(m.FunctionIndexSpace) && m.FunctionIndexSpace[index].IsEnvFunc {
+		return m.FunctionIndexSpace[index].Name
+	}
+	name := ""
+	if m.Export != nil {
+		for field, entry := range m.Export.Entries {
+			if entry.Kind == wasm.ExternalFunction && entry.Index == index && (name == "" || field < name) {
+				name = field
+			}
+		}
+	}
+	if name == "" {
+		name = fmt.Sprintf("#%d", index)
+	}
+	return name
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package exec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/vm/wasmvm/wasm"
+)
+
+func readTestModule(t *testing.T, file string) *wasm.Module {
+	code, err := ioutil.ReadFile("./test_data2/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := wasm.ReadModule(bytes.NewBuffer(code), importer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestCheckDeterminism(t *testing.T) {
+	if err := CheckDeterminism(readTestModule(t, "add.wasm")); err != nil {
+		t.Fatalf("add.wasm should pass: %v", err)
+	}
+
+	err := CheckDeterminism(readTestModule(t, "float.wasm"))
+	if err == nil || !strings.Contains(err.Error(), "function sum: float instruction f32.add at offset 4") {
+		t.Fatalf("float.wasm should be rejected, got %v", err)
+	}
+
+	err = CheckDeterminism(readTestModule(t, "testenv.wasm"))
+	if err == nil || !strings.Contains(err.Error(), "env.addOne") {
+		t.Fatalf("unknown import should be rejected, got %v", err)
+	}
+	RegisterHostFunctions("addOne")
+	defer delete(hostFunctions, "addOne")
+	if err := CheckDeterminism(readTestModule(t, "testenv.wasm")); err != nil {
+		t.Fatalf("registered import should pass: %v", err)
+	}
+}
+
+func TestCheckDeterminism_Limits(t *testing.T) {
+	m := readTestModule(t, "add.wasm")
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: MAX_MEMORY_PAGES + 1}}}}
+	if err := CheckDeterminism(m); err == nil {
+		t.Fatal("oversized memory should be rejected")
+	}
+
+	m = readTestModule(t, "add.wasm")
+	m.Table = &wasm.SectionTables{Entries: []wasm.Table{{Limits: wasm.ResizableLimits{Flags: 1, Maximum: MAX_TABLE_ENTRIES + 1}}}}
+	if err := CheckDeterminism(m); err == nil {
+		t.Fatal("oversized table should be rejected")
+	}
+
+	m = readTestModule(t, "add.wasm")
+	m.Start = &wasm.SectionStartFunction{Index: 0}
+	if err := CheckDeterminism(m); err == nil || !strings.Contains(err.Error(), "start function") {
+		t.Fatalf("start function should be rejected, got %v", err)
+	}
+}
@@ -80,6 +80,7 @@ func NewInteropService() *InteropService {
 	//init some system functions
 	service.Register("calloc", calloc)
 	service.Register("malloc", malloc)
+	service.Register("free", free)
 	service.Register("arrayLen", arrayLen)
 	service.Register("memcpy", memcpy)
 	service.Register("memset", memset)
@@ -195,6 +196,20 @@ func malloc(engine *ExecutionEngine) (bool, error) {
 
 }
 
+//for the c language "free" function
+func free(engine *ExecutionEngine) (bool, error) {
+	envCall := engine.vm.envCall
+	params := envCall.envParams
+	if len(params) != 1 {
+		return false, errors.New("parameter count error while call free")
+	}
+	if err := engine.vm.memory.Free(params[0]); err != nil {
+		return false, err
+	}
+	engine.vm.RestoreCtx()
+	return true, nil
+}
+
 //use arrayLen to replace 'sizeof'
 func arrayLen(engine *ExecutionEngine) (bool, error) {
 	envCall := engine.vm.envCall
@@ -109,6 +109,37 @@ type ExecutionEngine struct {
 	CodeContainer interfaces.CodeContainer
 	vm            *VM
 	backupVM      *vmstack
+	memoryPolicy  *MemoryPolicy
+	tracer        Tracer
+}
+
+// MemoryPolicy enables the free list allocator for contract memory, the memory
+// may grow up to MaxPages and UsePages pays for every page added
+type MemoryPolicy struct {
+	MaxPages int
+	UsePages func(pages int) bool
+}
+
+//SetMemoryPolicy applies policy to the vms created by later calls
+func (e *ExecutionEngine) SetMemoryPolicy(policy *MemoryPolicy) {
+	e.memoryPolicy = policy
+}
+
+//SetTracer reports the execution of the vms created by later calls to tracer
+func (e *ExecutionEngine) SetTracer(tracer Tracer) {
+	e.tracer = tracer
+}
+
+func (e *ExecutionEngine) newVM(m *wasm.Module) (*VM, error) {
+	vm, err := NewVM(m)
+	if err != nil {
+		return nil, err
+	}
+	if e.memoryPolicy != nil {
+		vm.memory.EnableAllocator(e.memoryPolicy.MaxPages, e.memoryPolicy.UsePages)
+	}
+	vm.tracer = e.tracer
+	return vm, nil
 }
 
 //GetVM return vm pointer
@@ -166,7 +197,7 @@ func (e *ExecutionEngine) CallInf(caller common.Address,
 		return nil, errors.NewErr("No export in wasm!")
 	}
 
-	vm, err := NewVM(m)
+	vm, err := e.newVM(m)
 	if err != nil {
 		return nil, err
 	}
@@ -393,7 +424,7 @@ func (e *ExecutionEngine) call(caller common.Address,
 			return nil, errors.NewErr("[Call]No export in wasm!")
 		}
 
-		vm, err := NewVM(m)
+		vm, err := e.newVM(m)
 		if err != nil {
 			return nil, err
 		}
@@ -489,7 +520,7 @@ func (e *ExecutionEngine) call(caller common.Address,
 			return nil, errors.NewErr("[Call]No export in wasm!")
 		}
 
-		vm, err := NewVM(m)
+		vm, err := e.newVM(m)
 		if err != nil {
 			return nil, err
 		}
@@ -547,6 +578,25 @@ func (e *ExecutionEngine) call(caller common.Address,
 	}
 }
 
+// VerifyWasmCode checks that code is a well-formed wasm module which passes
+// validation, exports at least one function and executes deterministically
+func VerifyWasmCode(code []byte) error {
+	m, err := wasm.ReadModule(bytes.NewBuffer(code), importer)
+	if err != nil {
+		return errors.NewErr("[VerifyWasmCode]read module failed:" + err.Error())
+	}
+	if m.Export == nil || len(m.Export.Entries) == 0 {
+		return errors.NewErr("[VerifyWasmCode]No export in wasm!")
+	}
+	if err = validate.VerifyModule(m); err != nil {
+		return errors.NewErr("[VerifyWasmCode]verify module failed:" + err.Error())
+	}
+	if err = CheckDeterminism(m); err != nil {
+		return errors.NewErr("[VerifyWasmCode]" + err.Error())
+	}
+	return nil
+}
+
 //FIXME NOT IN USE BUT DON'T DELETE IT
 //current we only support the ZPT SYSTEM module import
 //other imports will raise an error
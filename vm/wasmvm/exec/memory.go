@@ -243,6 +243,15 @@ func (vm *VM) growMemory() {
 	_ = vm.fetchInt8() // reserved (https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/BinaryEncoding.md#memory-related-operators-described-here)
 	curLen := len(vm.memory.Memory) / wasmPageSize
 	n := vm.popInt32()
+	if vm.memory.AllocatorEnabled() {
+		prev, err := vm.memory.GrowMemory(int(n))
+		if err != nil {
+			vm.pushInt32(-1)
+			return
+		}
+		vm.pushInt32(int32(prev))
+		return
+	}
 	vm.memory.Memory = append(vm.memory.Memory, make([]byte, n*wasmPageSize)...)
 	vm.pushInt32(int32(curLen))
 }
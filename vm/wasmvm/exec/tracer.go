@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package exec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/imZhuFei/zeepin/vm/wasmvm/exec/internal/compile"
+	ops "github.com/imZhuFei/zeepin/vm/wasmvm/wasm/operators"
+)
+
+const (
+	TRACE_INSTRUCTION  = "instruction"
+	TRACE_HOST_CALL    = "hostcall"
+	TRACE_MEMORY_WRITE = "memwrite"
+
+	DEFAULT_TRACE_STEPS = 100000
+)
+
+// Tracer observes a wasm execution, it is called for every instruction executed,
+// every env function invoked and every store to the linear memory
+type Tracer interface {
+	CaptureInstruction(depth int, funcIndex int64, pc int64, op byte, stack []uint64)
+	CaptureHostCall(depth int, name string, args []uint64, result []uint64, err error)
+	CaptureMemoryWrite(depth int, funcIndex int64, pc int64, addr uint32, data []byte)
+}
+
+// TraceEntry is one record of a JSONTracer
+type TraceEntry struct {
+	Type     string   `json:"type"`
+	Depth    int      `json:"depth"`
+	Func     int64    `json:"func,omitempty"`
+	Pc       int64    `json:"pc,omitempty"`
+	Op       string   `json:"op,omitempty"`
+	StackTop *uint64  `json:"stackTop,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Args     []uint64 `json:"args,omitempty"`
+	Result   []uint64 `json:"result,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Addr     *uint32  `json:"addr,omitempty"`
+	Data     string   `json:"data,omitempty"`
+}
+
+// JSONTracer collects the trace in memory, once MaxSteps entries are recorded
+// the rest of the execution is dropped and Truncated is set
+type JSONTracer struct {
+	MaxSteps  int          `json:"-"`
+	Truncated bool         `json:"truncated"`
+	Steps     []TraceEntry `json:"steps"`
+}
+
+func NewJSONTracer(maxSteps int) *JSONTracer {
+	if maxSteps <= 0 {
+		maxSteps = DEFAULT_TRACE_STEPS
+	}
+	return &JSONTracer{MaxSteps: maxSteps, Steps: make([]TraceEntry, 0)}
+}
+
+func (t *JSONTracer) add(entry TraceEntry) {
+	if len(t.Steps) >= t.MaxSteps {
+		t.Truncated = true
+		return
+	}
+	t.Steps = append(t.Steps, entry)
+}
+
+func (t *JSONTracer) CaptureInstruction(depth int, funcIndex int64, pc int64, op byte, stack []uint64) {
+	entry := TraceEntry{Type: TRACE_INSTRUCTION, Depth: depth, Func: funcIndex, Pc: pc, Op: OpName(op)}
+	if len(stack) > 0 {
+		top := stack[len(stack)-1]
+		entry.StackTop = &top
+	}
+	t.add(entry)
+}
+
+func (t *JSONTracer) CaptureHostCall(depth int, name string, args []uint64, result []uint64, err error) {
+	entry := TraceEntry{Type: TRACE_HOST_CALL, Depth: depth, Name: name,
+		Args: append([]uint64{}, args...), Result: result}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	t.add(entry)
+}
+
+func (t *JSONTracer) CaptureMemoryWrite(depth int, funcIndex int64, pc int64, addr uint32, data []byte) {
+	t.add(TraceEntry{Type: TRACE_MEMORY_WRITE, Depth: depth, Func: funcIndex, Pc: pc,
+		Addr: &addr, Data: hex.EncodeToString(data)})
+}
+
+// JSON returns the collected trace encoded as json
+func (t *JSONTracer) JSON() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// OpName returns the mnemonic of a compiled opcode, the branch rewrites of the
+// compiler reuse the opcodes of br, br_if, end and else
+func OpName(op byte) string {
+	switch op {
+	case compile.OpJmp:
+		return "jmp"
+	case compile.OpJmpZ:
+		return "jmpz"
+	case compile.OpJmpNz:
+		return "jmpnz"
+	case compile.OpDiscard:
+		return "discard"
+	case compile.OpDiscardPreserveTop:
+		return "discard_preserve_top"
+	}
+	o, err := ops.New(op)
+	if err != nil {
+		return fmt.Sprintf("0x%02x", op)
+	}
+	return o.Name
+}
+
+//storeSize returns the number of bytes written by a store opcode
+func storeSize(op byte) int {
+	switch op {
+	case ops.I32Store8, ops.I64Store8:
+		return 1
+	case ops.I32Store16, ops.I64Store16:
+		return 2
+	case ops.I32Store, ops.F32Store, ops.I64Store32:
+		return 4
+	case ops.I64Store, ops.F64Store:
+		return 8
+	}
+	return 0
+}
+
+//traceDepth is the number of contracts the engine suspended to run this vm
+func (vm *VM) traceDepth() int {
+	if vm.Engine == nil {
+		return 0
+	}
+	return vm.Engine.backupVM.top + 1
+}
+
+//traceStep reports the instruction about to run, stores are reported to the
+//tracer once they have completed
+func (vm *VM) traceStep(op byte, pc int64) func() {
+	depth := vm.traceDepth()
+	vm.tracer.CaptureInstruction(depth, vm.ctx.curFunc, pc, op, vm.ctx.stack)
+	size := storeSize(op)
+	if size == 0 || len(vm.ctx.stack) < 2 {
+		return nil
+	}
+	addr := endianess.Uint32(vm.ctx.code[vm.ctx.pc:]) + uint32(vm.ctx.stack[len(vm.ctx.stack)-2])
+	funcIndex := vm.ctx.curFunc
+	return func() {
+		end := int(addr) + size
+		if end > len(vm.memory.Memory) {
+			return
+		}
+		data := append([]byte{}, vm.memory.Memory[addr:end]...)
+		vm.tracer.CaptureMemoryWrite(depth, funcIndex, pc, addr, data)
+	}
+}
+
+//traceHostCall reports an env function once the service restored the caller
+//context and pushed its return value
+func (vm *VM) traceHostCall(compiled compiledFunction, locals []uint64, err error) {
+	var result []uint64
+	if err == nil && compiled.returns && len(vm.ctx.stack) > 0 {
+		result = []uint64{vm.ctx.stack[len(vm.ctx.stack)-1]}
+	}
+	vm.tracer.CaptureHostCall(vm.traceDepth(), compiled.name, locals[:compiled.args], result, err)
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package exec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/imZhuFei/zeepin/common"
+)
+
+func TestJSONTracer(t *testing.T) {
+	code, err := ioutil.ReadFile("./test_data2/malloc.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewExecutionEngine(nil, nil, nil)
+	tracer := NewJSONTracer(0)
+	engine.SetTracer(tracer)
+
+	res, err := engine.CallInf(common.Address{}, code, []interface{}{"initStu", 100, 90, 85}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var instructions, hostCalls, writes int
+	for _, step := range tracer.Steps {
+		switch step.Type {
+		case TRACE_INSTRUCTION:
+			instructions++
+		case TRACE_HOST_CALL:
+			hostCalls++
+			if step.Name != "malloc" || len(step.Args) != 1 || len(step.Result) != 1 {
+				t.Fatalf("unexpected host call %+v", step)
+			}
+			if uint32(step.Result[0]) != binary.LittleEndian.Uint32(res) {
+				t.Fatalf("malloc returned %d, invoke returned %d", step.Result[0], binary.LittleEndian.Uint32(res))
+			}
+		case TRACE_MEMORY_WRITE:
+			writes++
+		}
+	}
+	if instructions == 0 || hostCalls != 1 || writes < 3 {
+		t.Fatalf("got %d instructions, %d host calls, %d memory writes", instructions, hostCalls, writes)
+	}
+
+	raw, err := tracer.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &JSONTracer{}
+	if err := json.Unmarshal(raw, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Steps) != len(tracer.Steps) || decoded.Truncated {
+		t.Fatalf("json round trip lost steps")
+	}
+}
+
+func TestJSONTracerTruncate(t *testing.T) {
+	code, err := ioutil.ReadFile("./test_data2/malloc.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewExecutionEngine(nil, nil, nil)
+	tracer := NewJSONTracer(5)
+	engine.SetTracer(tracer)
+
+	if _, err := engine.CallInf(common.Address{}, code, []interface{}{"initStu", 100, 90, 85}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracer.Steps) != 5 || !tracer.Truncated {
+		t.Fatalf("tracer kept %d steps, truncated %v", len(tracer.Steps), tracer.Truncated)
+	}
+}
+
+func TestOpName(t *testing.T) {
+	if OpName(0x6a) != "i32.add" {
+		t.Fatalf("0x6a is %s", OpName(0x6a))
+	}
+	if OpName(0x0c) != "jmp" {
+		t.Fatalf("0x0c is %s", OpName(0x0c))
+	}
+}
@@ -119,6 +119,7 @@ type VM struct {
 	Caller          common.Address
 	Engine          *ExecutionEngine
 	VMCode          []byte
+	tracer          Tracer
 }
 
 // As per the WebAssembly spec: https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/Semantics.md#linear-memory
@@ -402,6 +403,10 @@ func (vm *VM) execCode(isinside bool, compiled compiledFunction) uint64 {
 outer:
 	for int(vm.ctx.pc) < len(vm.ctx.code) {
 		op := vm.ctx.code[vm.ctx.pc]
+		var traced func()
+		if vm.tracer != nil {
+			traced = vm.traceStep(op, vm.ctx.pc)
+		}
 		vm.ctx.pc++
 
 		switch op {
@@ -472,6 +477,9 @@ outer:
 		default:
 			vm.funcTable[op]()
 		}
+		if traced != nil {
+			traced()
+		}
 	}
 
 	if compiled.returns {
@@ -506,6 +514,7 @@ func (vm *VM) CallContract(caller common.Address, contractAddress common.Address
 	newvm.ContractAddress = contractAddress
 
 	newvm.Services = vm.Services
+	newvm.tracer = vm.tracer
 
 	engine := vm.Engine
 	newvm.Engine = engine
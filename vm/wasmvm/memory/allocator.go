@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	PAGE_SIZE   = 65536 //wasm page size
+	ALLOC_ALIGN = 8     //alignment of allocated blocks
+)
+
+type block struct {
+	offset int
+	size   int
+}
+
+//allocator is a first fit free list allocator over the pointer region of the
+//linear memory, freed blocks are merged with their free neighbours
+type allocator struct {
+	free     []block     //free blocks sorted by offset
+	used     map[int]int //allocated offset -> block size
+	maxPages int
+	usePages func(pages int) bool
+}
+
+// EnableAllocator replaces the bump allocation of pointer memory with a free
+// list allocator, the heap grows by whole pages up to maxPages and usePages
+// is asked to pay for every new page
+func (vm *VMmemory) EnableAllocator(maxPages int, usePages func(pages int) bool) {
+	alloc := &allocator{
+		used:     make(map[int]int),
+		maxPages: maxPages,
+		usePages: usePages,
+	}
+	start := align(vm.PointedMemIndex + 1)
+	end := len(vm.Memory) / ALLOC_ALIGN * ALLOC_ALIGN
+	if start < end {
+		alloc.release(block{offset: start, size: end - start})
+	}
+	vm.allocator = alloc
+}
+
+// AllocatorEnabled returns whether pointer memory is managed by the free list allocator
+func (vm *VMmemory) AllocatorEnabled() bool {
+	return vm.allocator != nil
+}
+
+// Free releases a block returned by MallocPointer
+func (vm *VMmemory) Free(addr uint64) error {
+	if vm.allocator == nil {
+		return errors.New("free is not supported")
+	}
+	if addr == uint64(VM_NIL_POINTER) {
+		return nil
+	}
+	size, ok := vm.allocator.used[int(addr)]
+	if !ok {
+		return fmt.Errorf("free of unallocated pointer %d", addr)
+	}
+	delete(vm.allocator.used, int(addr))
+	delete(vm.MemPoints, addr)
+	vm.allocator.release(block{offset: int(addr), size: size})
+	return nil
+}
+
+// GrowMemory appends pages for the memory.grow instruction and returns the
+// previous size in pages, the new pages belong to the contract, not the heap
+func (vm *VMmemory) GrowMemory(pages int) (int, error) {
+	prev := len(vm.Memory) / PAGE_SIZE
+	if err := vm.growPages(pages); err != nil {
+		return 0, err
+	}
+	return prev, nil
+}
+
+func (vm *VMmemory) growPages(pages int) error {
+	if pages < 0 {
+		return errors.New("negative memory growth")
+	}
+	if len(vm.Memory)/PAGE_SIZE+pages > vm.allocator.maxPages {
+		return fmt.Errorf("memory exceeds %d pages", vm.allocator.maxPages)
+	}
+	if vm.allocator.usePages != nil && !vm.allocator.usePages(pages) {
+		return errors.New("gas insufficient for memory growth")
+	}
+	vm.Memory = append(vm.Memory, make([]byte, pages*PAGE_SIZE)...)
+	return nil
+}
+
+//malloc returns a zeroed block of at least size bytes, growing the heap when no
+//free block fits
+func (vm *VMmemory) malloc(size int) (int, error) {
+	if size < 0 {
+		return 0, errors.New("negative allocation size")
+	}
+	n := align(size)
+	if n == 0 {
+		n = ALLOC_ALIGN
+	}
+	offset, ok := vm.allocator.take(n)
+	if !ok {
+		need := n
+		//the last free block can be extended by the new pages
+		if last := len(vm.allocator.free) - 1; last >= 0 {
+			b := vm.allocator.free[last]
+			if b.offset+b.size == len(vm.Memory) {
+				need -= b.size
+			}
+		}
+		start := len(vm.Memory)
+		if err := vm.growPages((need + PAGE_SIZE - 1) / PAGE_SIZE); err != nil {
+			return 0, err
+		}
+		vm.allocator.release(block{offset: start, size: len(vm.Memory) - start})
+		if offset, ok = vm.allocator.take(n); !ok {
+			return 0, errors.New("memory out of bound")
+		}
+	}
+	for i := offset; i < offset+n; i++ {
+		vm.Memory[i] = 0
+	}
+	return offset, nil
+}
+
+func (a *allocator) take(size int) (int, bool) {
+	for i, b := range a.free {
+		if b.size < size {
+			continue
+		}
+		if b.size == size {
+			a.free = append(a.free[:i], a.free[i+1:]...)
+		} else {
+			a.free[i] = block{offset: b.offset + size, size: b.size - size}
+		}
+		a.used[b.offset] = size
+		return b.offset, true
+	}
+	return 0, false
+}
+
+func (a *allocator) release(b block) {
+	i := sort.Search(len(a.free), func(i int) bool { return a.free[i].offset > b.offset })
+	a.free = append(a.free, block{})
+	copy(a.free[i+1:], a.free[i:])
+	a.free[i] = b
+
+	//merge with the next block, then with the previous one
+	if i+1 < len(a.free) && a.free[i].offset+a.free[i].size == a.free[i+1].offset {
+		a.free[i].size += a.free[i+1].size
+		a.free = append(a.free[:i+1], a.free[i+2:]...)
+	}
+	if i > 0 && a.free[i-1].offset+a.free[i-1].size == a.free[i].offset {
+		a.free[i-1].size += a.free[i].size
+		a.free = append(a.free[:i], a.free[i+1:]...)
+	}
+}
+
+func align(size int) int {
+	return (size + ALLOC_ALIGN - 1) / ALLOC_ALIGN * ALLOC_ALIGN
+}
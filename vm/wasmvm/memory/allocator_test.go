@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2018 The ZeepinChain Authors
+ * This file is part of The ZeepinChain library.
+ *
+ * The ZeepinChain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ZeepinChain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ZeepinChain.  If not, see <http://www.gnu.org/licenses/>.
+
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package memory
+
+import (
+	"testing"
+)
+
+func newAllocMemory(pages int, maxPages int, used *int) *VMmemory {
+	mem := &VMmemory{
+		Memory:          make([]byte, pages*PAGE_SIZE),
+		AllocedMemIdex:  -1,
+		PointedMemIndex: pages * PAGE_SIZE / 2,
+		MemPoints:       make(map[uint64]*TypeLength),
+	}
+	mem.EnableAllocator(maxPages, func(n int) bool {
+		*used += n
+		return true
+	})
+	return mem
+}
+
+func TestAllocator_Reuse(t *testing.T) {
+	var pages int
+	mem := newAllocMemory(1, 2, &pages)
+
+	a, err := mem.MallocPointer(100, PString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := mem.MallocPointer(10, PString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b < a+100 {
+		t.Fatal("blocks should not overlap")
+	}
+	copy(mem.Memory[a:], []byte("dirty"))
+	if err := mem.Free(uint64(a)); err != nil {
+		t.Fatal(err)
+	}
+	if mem.GetPointerMemSize(uint64(a)) != 0 {
+		t.Fatal("freed pointer should be forgotten")
+	}
+	c, err := mem.MallocPointer(50, PString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != a {
+		t.Fatalf("freed block should be reused, got %d want %d", c, a)
+	}
+	if mem.Memory[c] != 0 {
+		t.Fatal("reused block should be zeroed")
+	}
+	if err := mem.Free(uint64(a + 1)); err == nil {
+		t.Fatal("free of unallocated pointer should fail")
+	}
+	if err := mem.Free(VM_NIL_POINTER); err != nil {
+		t.Fatal("free of nil should be ignored")
+	}
+}
+
+func TestAllocator_Grow(t *testing.T) {
+	var pages int
+	mem := newAllocMemory(1, 2, &pages)
+
+	//repeated allocations and frees must not exhaust the memory
+	for i := 0; i < 1000; i++ {
+		p, err := mem.MallocPointer(PAGE_SIZE/4, PString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mem.Free(uint64(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pages != 0 {
+		t.Fatal("reused memory should not grow")
+	}
+
+	if _, err := mem.MallocPointer(PAGE_SIZE, PString); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 1 || len(mem.Memory) != 2*PAGE_SIZE {
+		t.Fatalf("memory should grow by one page, charged %d", pages)
+	}
+	if _, err := mem.MallocPointer(PAGE_SIZE, PString); err == nil {
+		t.Fatal("allocation over the page cap should fail")
+	}
+	if _, err := mem.GrowMemory(1); err == nil {
+		t.Fatal("memory.grow over the page cap should fail")
+	}
+}
@@ -72,6 +72,7 @@ type VMmemory struct {
 	PointedMemIndex int
 	ParamIndex      int //args analyze pointer
 	MemPoints       map[uint64]*TypeLength
+	allocator       *allocator
 }
 
 //Alloc memory for base types, return the address in memory
@@ -98,6 +99,14 @@ func (vm *VMmemory) MallocPointer(size int, p_type PType) (int, error) {
 	if vm.Memory == nil || len(vm.Memory) == 0 {
 		return 0, errors.New("memory is not initialized")
 	}
+	if vm.allocator != nil {
+		offset, err := vm.malloc(size)
+		if err != nil {
+			return 0, err
+		}
+		vm.MemPoints[uint64(offset)] = &TypeLength{Ptype: p_type, Length: size}
+		return offset, nil
+	}
 	if vm.PointedMemIndex+size > len(vm.Memory) {
 		return 0, errors.New("memory out of bound")
 	}